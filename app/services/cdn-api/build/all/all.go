@@ -2,9 +2,16 @@
 package all
 
 import (
+	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/apikeygrp"
+	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/auditgrp"
+	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/authgrp"
 	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/checkgrp"
+	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/delegategrp"
 	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/homegrp"
+	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/jwksgrp"
+	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/orggrp"
 	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/productgrp"
+	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/rolegrp"
 	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/trangrp"
 	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/usergrp"
 	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/vproductgrp"
@@ -22,43 +29,103 @@ type add struct{}
 
 // Add implements the RouterAdder interface.
 func (add) Add(app *web.App, cfg mux.Config) {
+	apikeygrp.Routes(app, apikeygrp.Config{
+		Log:    cfg.Log,
+		APIKey: cfg.APIKey,
+		Auth:   cfg.Auth,
+	})
+
+	auditgrp.Routes(app, auditgrp.Config{
+		Log:  cfg.Log,
+		Auth: cfg.Auth,
+		DB:   cfg.DB,
+	})
+
+	authgrp.Routes(app, authgrp.Config{
+		Log:             cfg.Log,
+		Delegate:        cfg.Delegate,
+		Auth:            cfg.Auth,
+		DB:              cfg.DB,
+		RefreshTokenTTL: cfg.RefreshTokenTTL,
+		OIDC:            cfg.OIDC,
+		Identity:        cfg.Identity,
+		RateLimit:       cfg.RateLimit,
+	})
+
 	checkgrp.Routes(app, checkgrp.Config{
 		Build: cfg.Build,
 		Log:   cfg.Log,
 		DB:    cfg.DB,
 	})
 
-	homegrp.Routes(app, homegrp.Config{
+	delegategrp.Routes(app, delegategrp.Config{
 		Log:      cfg.Log,
 		Delegate: cfg.Delegate,
 		Auth:     cfg.Auth,
-		DB:       cfg.DB,
 	})
 
-	productgrp.Routes(app, productgrp.Config{
+	homegrp.Routes(app, homegrp.Config{
+		Log:            cfg.Log,
+		Delegate:       cfg.Delegate,
+		Auth:           cfg.Auth,
+		DB:             cfg.DB,
+		RequireIfMatch: cfg.RequireIfMatch,
+		CursorKey:      cfg.CursorKey,
+		Idempotency:    cfg.Idempotency,
+	})
+
+	jwksgrp.Routes(app, jwksgrp.Config{
 		Log:      cfg.Log,
-		Delegate: cfg.Delegate,
-		Auth:     cfg.Auth,
-		DB:       cfg.DB,
+		KeyStore: cfg.KeyStore,
+	})
+
+	orggrp.Routes(app, orggrp.Config{
+		Log:  cfg.Log,
+		Auth: cfg.Auth,
+		DB:   cfg.DB,
+	})
+
+	productgrp.Routes(app, productgrp.Config{
+		Log:            cfg.Log,
+		Delegate:       cfg.Delegate,
+		Auth:           cfg.Auth,
+		DB:             cfg.DB,
+		RequireIfMatch: cfg.RequireIfMatch,
+		CursorKey:      cfg.CursorKey,
+		Idempotency:    cfg.Idempotency,
+	})
+
+	rolegrp.Routes(app, rolegrp.Config{
+		Log:  cfg.Log,
+		Role: cfg.Role,
+		Auth: cfg.Auth,
 	})
 
 	trangrp.Routes(app, trangrp.Config{
-		Log:      cfg.Log,
-		Delegate: cfg.Delegate,
-		Auth:     cfg.Auth,
-		DB:       cfg.DB,
+		Log:         cfg.Log,
+		Delegate:    cfg.Delegate,
+		Auth:        cfg.Auth,
+		DB:          cfg.DB,
+		Role:        cfg.Role,
+		Idempotency: cfg.Idempotency,
 	})
 
 	usergrp.Routes(app, usergrp.Config{
-		Log:      cfg.Log,
-		Delegate: cfg.Delegate,
-		Auth:     cfg.Auth,
-		DB:       cfg.DB,
+		Log:            cfg.Log,
+		Delegate:       cfg.Delegate,
+		Auth:           cfg.Auth,
+		DB:             cfg.DB,
+		RequireIfMatch: cfg.RequireIfMatch,
+		CursorKey:      cfg.CursorKey,
+		Role:           cfg.Role,
+		RateLimit:      cfg.RateLimit,
+		Idempotency:    cfg.Idempotency,
 	})
 
 	vproductgrp.Routes(app, vproductgrp.Config{
-		Log:  cfg.Log,
-		Auth: cfg.Auth,
-		DB:   cfg.DB,
+		Log:       cfg.Log,
+		Auth:      cfg.Auth,
+		DB:        cfg.DB,
+		CursorKey: cfg.CursorKey,
 	})
 }
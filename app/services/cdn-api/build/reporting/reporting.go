@@ -25,8 +25,9 @@ func (Add) Add(app *web.App, cfg mux.Config) {
 	})
 
 	vproductgrp.Routes(app, vproductgrp.Config{
-		Log:  cfg.Log,
-		Auth: cfg.Auth,
-		DB:   cfg.DB,
+		Log:       cfg.Log,
+		Auth:      cfg.Auth,
+		DB:        cfg.DB,
+		CursorKey: cfg.CursorKey,
 	})
 }
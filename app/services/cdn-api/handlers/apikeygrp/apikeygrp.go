@@ -0,0 +1,129 @@
+// Package apikeygrp maintains the group of handlers for the API keys used by
+// machine clients.
+package apikeygrp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/apikey"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	wb "github.com/testvergecloud/testApi/business/web"
+	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/google/uuid"
+)
+
+type handlers struct {
+	apiKey *apikey.Core
+}
+
+func new(apiKey *apikey.Core) *handlers {
+	return &handlers{
+		apiKey: apiKey,
+	}
+}
+
+// create adds a new API key to the system and returns the key.
+func (h *handlers) create(c *gin.Context) error {
+	var app AppNewAPIKey
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if err := app.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	nk, err := toCoreNewAPIKey(app, mid.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	rawKey, key, err := h.apiKey.Create(c.Request.Context(), nk)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusNotFound)
+		case errors.Is(err, apikey.ErrNoScopes),
+			errors.Is(err, apikey.ErrScopeNotHeld),
+			errors.Is(err, apikey.ErrOwnerDisabled):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusBadRequest)
+		case errors.Is(err, tenant.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusForbidden)
+		}
+		return fmt.Errorf("create: userID[%s]: %w", nk.UserID, err)
+	}
+
+	c.JSON(http.StatusCreated, AppNewAPIKeyResult{AppAPIKey: toAppAPIKey(key), Key: rawKey})
+	return nil
+}
+
+// revoke stops an API key from authenticating.
+func (h *handlers) revoke(c *gin.Context) error {
+	key, err := h.queryKey(c)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := h.apiKey.Revoke(c.Request.Context(), key)
+	if err != nil {
+		return fmt.Errorf("revoke: keyID[%s]: %w", key.ID, err)
+	}
+
+	c.JSON(http.StatusOK, toAppAPIKey(revoked))
+	return nil
+}
+
+// query returns the API keys the caller can access.
+func (h *handlers) query(c *gin.Context) error {
+	keys, err := h.apiKey.QueryAll(c.Request.Context())
+	if err != nil {
+		return fmt.Errorf("queryall: %w", err)
+	}
+
+	c.JSON(http.StatusOK, toAppAPIKeys(keys))
+	return nil
+}
+
+// queryByID returns an API key by its ID.
+func (h *handlers) queryByID(c *gin.Context) error {
+	key, err := h.queryKey(c)
+	if err != nil {
+		return err
+	}
+
+	c.JSON(http.StatusOK, toAppAPIKey(key))
+	return nil
+}
+
+// queryKey looks up the API key named by the key_id parameter and writes the
+// response when it can't be found.
+func (h *handlers) queryKey(c *gin.Context) (apikey.APIKey, error) {
+	keyID, err := uuid.Parse(c.Param("key_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return apikey.APIKey{}, validate.NewFieldsError("key_id", err)
+	}
+
+	key, err := h.apiKey.QueryByID(c.Request.Context(), keyID)
+	if err != nil {
+		if errors.Is(err, apikey.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return apikey.APIKey{}, wb.NewTrustedError(err, http.StatusNotFound)
+		}
+		return apikey.APIKey{}, fmt.Errorf("querybyid: keyID[%s]: %w", keyID, err)
+	}
+
+	return key, nil
+}
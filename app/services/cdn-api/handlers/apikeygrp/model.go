@@ -0,0 +1,112 @@
+package apikeygrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/apikey"
+	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/google/uuid"
+)
+
+// AppAPIKey represents information about an API key. The key itself is never
+// returned, only its prefix.
+type AppAPIKey struct {
+	ID           string   `json:"id"`
+	TenantID     string   `json:"tenantID"`
+	UserID       string   `json:"userID"`
+	Name         string   `json:"name"`
+	Prefix       string   `json:"prefix"`
+	Scopes       []string `json:"scopes"`
+	DateCreated  string   `json:"dateCreated"`
+	DateExpires  string   `json:"dateExpires,omitempty"`
+	DateLastUsed string   `json:"dateLastUsed,omitempty"`
+	DateRevoked  string   `json:"dateRevoked,omitempty"`
+}
+
+func toAppAPIKey(key apikey.APIKey) AppAPIKey {
+	return AppAPIKey{
+		ID:           key.ID.String(),
+		TenantID:     key.TenantID.String(),
+		UserID:       key.UserID.String(),
+		Name:         key.Name,
+		Prefix:       key.Prefix,
+		Scopes:       key.Scopes,
+		DateCreated:  key.DateCreated.Format(time.RFC3339),
+		DateExpires:  formatDate(key.DateExpires),
+		DateLastUsed: formatDate(key.DateLastUsed),
+		DateRevoked:  formatDate(key.DateRevoked),
+	}
+}
+
+func toAppAPIKeys(keys []apikey.APIKey) []AppAPIKey {
+	items := make([]AppAPIKey, len(keys))
+	for i, key := range keys {
+		items[i] = toAppAPIKey(key)
+	}
+
+	return items
+}
+
+// AppNewAPIKeyResult is returned when a key is created. It's the only time
+// the key is available.
+type AppNewAPIKeyResult struct {
+	AppAPIKey
+	Key string `json:"key"`
+}
+
+// AppNewAPIKey defines the data needed to add a new API key. The key belongs
+// to the caller unless another user is specified.
+type AppNewAPIKey struct {
+	UserID      string   `json:"userID" validate:"omitempty,uuid"`
+	Name        string   `json:"name" validate:"required"`
+	Scopes      []string `json:"scopes" validate:"required,min=1"`
+	DateExpires string   `json:"dateExpires" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+func toCoreNewAPIKey(app AppNewAPIKey, callerID uuid.UUID) (apikey.NewAPIKey, error) {
+	userID := callerID
+	if app.UserID != "" {
+		var err error
+		userID, err = uuid.Parse(app.UserID)
+		if err != nil {
+			return apikey.NewAPIKey{}, fmt.Errorf("parse: %w", err)
+		}
+	}
+
+	var dateExpires time.Time
+	if app.DateExpires != "" {
+		var err error
+		dateExpires, err = time.Parse(time.RFC3339, app.DateExpires)
+		if err != nil {
+			return apikey.NewAPIKey{}, fmt.Errorf("parse: %w", err)
+		}
+	}
+
+	nk := apikey.NewAPIKey{
+		UserID:      userID,
+		Name:        app.Name,
+		Scopes:      app.Scopes,
+		DateExpires: dateExpires,
+	}
+
+	return nk, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewAPIKey) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
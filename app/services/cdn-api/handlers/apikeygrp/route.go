@@ -0,0 +1,38 @@
+package apikeygrp
+
+import (
+	"net/http"
+
+	"github.com/testvergecloud/testApi/business/core/crud/apikey"
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/web"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log    *logger.Logger
+	APIKey *apikey.Core
+	Auth   *auth.Auth
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "/v1"
+
+	hdl := new(cfg.APIKey)
+	v1 := app.Mux.Group(version)
+	{
+		admin := v1.Group("/apikeys")
+		{
+			admin.Use(mid.Authenticate(cfg.Auth))
+			admin.Use(mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+
+			app.Handle(http.MethodPost, admin, "", hdl.create)
+			app.Handle(http.MethodGet, admin, "", hdl.query)
+			app.Handle(http.MethodGet, admin, "/:key_id", hdl.queryByID)
+			app.Handle(http.MethodDelete, admin, "/:key_id", hdl.revoke)
+		}
+	}
+}
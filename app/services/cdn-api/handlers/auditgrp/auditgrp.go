@@ -0,0 +1,57 @@
+// Package auditgrp maintains the group of handlers for audit log access.
+package auditgrp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	wb "github.com/testvergecloud/testApi/business/web"
+	"github.com/testvergecloud/testApi/business/web/page"
+)
+
+type handlers struct {
+	audit *audit.Core
+}
+
+func new(audit *audit.Core) *handlers {
+	return &handlers{
+		audit: audit,
+	}
+}
+
+// query returns a list of audit entries with paging.
+func (h *handlers) query(c *gin.Context) error {
+	page, err := page.Parse(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	filter, err := parseFilter(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	orderBy, err := parseOrder(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	ctx := c.Request.Context()
+	auds, err := h.audit.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.audit.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	c.JSON(http.StatusOK, wb.NewPageDocument(toAppAudits(auds), total, page.Number, page.RowsPerPage))
+	return nil
+}
@@ -0,0 +1,72 @@
+package auditgrp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/google/uuid"
+)
+
+func parseFilter(r *http.Request) (audit.QueryFilter, error) {
+	const (
+		filterByActorID   = "actor_id"
+		filterByEntityID  = "entity_id"
+		filterByDomain    = "domain"
+		filterByAction    = "action"
+		filterByStartDate = "start_date"
+		filterByEndDate   = "end_date"
+	)
+
+	values := r.URL.Query()
+
+	var filter audit.QueryFilter
+
+	if actorID := values.Get(filterByActorID); actorID != "" {
+		id, err := uuid.Parse(actorID)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError(filterByActorID, err)
+		}
+		filter.WithActorID(id)
+	}
+
+	if entityID := values.Get(filterByEntityID); entityID != "" {
+		id, err := uuid.Parse(entityID)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError(filterByEntityID, err)
+		}
+		filter.WithEntityID(id)
+	}
+
+	if domain := values.Get(filterByDomain); domain != "" {
+		filter.WithDomain(domain)
+	}
+
+	if action := values.Get(filterByAction); action != "" {
+		filter.WithAction(action)
+	}
+
+	if startDate := values.Get(filterByStartDate); startDate != "" {
+		t, err := time.Parse(time.RFC3339, startDate)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError(filterByStartDate, err)
+		}
+		filter.WithStartDate(t)
+	}
+
+	if endDate := values.Get(filterByEndDate); endDate != "" {
+		t, err := time.Parse(time.RFC3339, endDate)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError(filterByEndDate, err)
+		}
+		filter.WithEndDate(t)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return audit.QueryFilter{}, err
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,44 @@
+package auditgrp
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+)
+
+// AppAudit represents a single change recorded in the audit log.
+type AppAudit struct {
+	ID        string          `json:"id"`
+	TenantID  string          `json:"tenantID"`
+	ActorID   string          `json:"actorID"`
+	Domain    string          `json:"domain"`
+	Action    string          `json:"action"`
+	EntityID  string          `json:"entityID"`
+	Diff      json.RawMessage `json:"diff"`
+	TraceID   string          `json:"traceID"`
+	Timestamp string          `json:"timestamp"`
+}
+
+func toAppAudit(aud audit.Audit) AppAudit {
+	return AppAudit{
+		ID:        aud.ID.String(),
+		TenantID:  aud.TenantID.String(),
+		ActorID:   aud.ActorID.String(),
+		Domain:    aud.Domain,
+		Action:    aud.Action,
+		EntityID:  aud.EntityID.String(),
+		Diff:      aud.Diff,
+		TraceID:   aud.TraceID,
+		Timestamp: aud.Timestamp.Format(time.RFC3339),
+	}
+}
+
+func toAppAudits(auds []audit.Audit) []AppAudit {
+	items := make([]AppAudit, len(auds))
+	for i, aud := range auds {
+		items[i] = toAppAudit(aud)
+	}
+
+	return items
+}
@@ -0,0 +1,39 @@
+package auditgrp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/foundation/validate"
+)
+
+func parseOrder(r *http.Request) (order.By, error) {
+	const (
+		orderByID        = "audit_id"
+		orderByActorID   = "actor_id"
+		orderByDomain    = "domain"
+		orderByTimestamp = "timestamp"
+	)
+
+	orderByFields := map[string]string{
+		orderByID:        audit.OrderByID,
+		orderByActorID:   audit.OrderByActorID,
+		orderByDomain:    audit.OrderByDomain,
+		orderByTimestamp: audit.OrderByTimestamp,
+	}
+
+	orderBy, err := order.Parse(r, order.NewBy(orderByTimestamp, order.DESC))
+	if err != nil {
+		return order.By{}, err
+	}
+
+	for _, by := range orderBy.Fields() {
+		if _, exists := orderByFields[by.Field]; !exists {
+			return order.By{}, validate.NewFieldsError(by.Field, errors.New("order field does not exist"))
+		}
+	}
+
+	return orderBy.Map(orderByFields), nil
+}
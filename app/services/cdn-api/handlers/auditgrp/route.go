@@ -0,0 +1,36 @@
+package auditgrp
+
+import (
+	"net/http"
+
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/business/core/crud/audit/stores/auditdb"
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/web"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log  *logger.Logger
+	Auth *auth.Auth
+	DB   *sqlx.DB
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "/v1"
+
+	audCore := audit.NewCore(cfg.Log, auditdb.NewStore(cfg.Log, cfg.DB))
+
+	hdl := new(audCore)
+	v1 := app.Mux.Group(version)
+	{
+		v1.Use(mid.Authenticate(cfg.Auth))
+		v1.Use(mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+		app.Handle(http.MethodGet, v1, "/audits", hdl.query)
+	}
+}
@@ -0,0 +1,236 @@
+// Package authgrp maintains the group of handlers for logging in and out,
+// refreshing access tokens and revoking them.
+package authgrp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/identity"
+	"github.com/testvergecloud/testApi/business/core/crud/revocation"
+	"github.com/testvergecloud/testApi/business/core/crud/session"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/business/web/auth"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type handlers struct {
+	user       *user.Core
+	session    *session.Core
+	auth       *auth.Auth
+	oidc       *auth.OIDC
+	identity   *identity.Core
+	refreshTTL time.Duration
+}
+
+func new(user *user.Core, session *session.Core, auth *auth.Auth, oidc *auth.OIDC, identity *identity.Core, refreshTTL time.Duration) *handlers {
+	return &handlers{
+		user:       user,
+		session:    session,
+		auth:       auth,
+		oidc:       oidc,
+		identity:   identity,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// login authenticates the user and starts a new session.
+func (h *handlers) login(c *gin.Context) error {
+	var app AppLogin
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if err := app.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	addr, err := mail.ParseAddress(app.Email)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email format"})
+		return auth.NewAuthError("invalid email format")
+	}
+
+	ctx := c.Request.Context()
+	usr, err := h.user.Authenticate(ctx, *addr, app.Password, c.ClientIP())
+	if err != nil {
+		var lerr *user.LockedError
+		if errors.As(err, &lerr) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(lerr.Until).Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return auth.NewAuthError(err.Error())
+		}
+		if errors.Is(err, user.ErrNotFound) || errors.Is(err, user.ErrAuthenticationFailure) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": user.ErrAuthenticationFailure.Error()})
+			return auth.NewAuthError(user.ErrAuthenticationFailure.Error())
+		}
+		return fmt.Errorf("authenticate: %w", err)
+	}
+
+	if !usr.Enabled {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": user.ErrAuthenticationFailure.Error()})
+		return auth.NewAuthError("user disabled")
+	}
+
+	refreshToken, _, err := h.session.Create(ctx, usr.ID, h.refreshTTL)
+	if err != nil {
+		return fmt.Errorf("create: userID[%s]: %w", usr.ID, err)
+	}
+
+	return h.respond(c, usr, refreshToken)
+}
+
+// refresh exchanges a refresh token for a new access token and the next
+// refresh token of the session.
+func (h *handlers) refresh(c *gin.Context) error {
+	var app AppRefresh
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if err := app.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	ctx := c.Request.Context()
+	refreshToken, tkn, err := h.session.Rotate(ctx, app.RefreshToken, h.refreshTTL)
+	if err != nil {
+		if isSessionError(err) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return auth.NewAuthError(err.Error())
+		}
+		return fmt.Errorf("rotate: %w", err)
+	}
+
+	// The refresh token identifies the user, whatever tenant they belong to.
+	usr, err := h.user.QueryByID(tenant.SetAll(ctx), tkn.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return auth.NewAuthError(err.Error())
+		}
+		return fmt.Errorf("querybyid: userID[%s]: %w", tkn.UserID, err)
+	}
+
+	// A user that was disabled since logging in can't keep the session alive.
+	if !usr.Enabled {
+		if _, err := h.session.Revoke(ctx, refreshToken); err != nil {
+			return fmt.Errorf("revoke: userID[%s]: %w", usr.ID, err)
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user disabled"})
+		return auth.NewAuthError("user disabled")
+	}
+
+	return h.respond(c, usr, refreshToken)
+}
+
+// logout ends the session the refresh token belongs to. Every refresh token
+// of the session is revoked.
+func (h *handlers) logout(c *gin.Context) error {
+	var app AppRefresh
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if err := app.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if _, err := h.session.Revoke(c.Request.Context(), app.RefreshToken); err != nil {
+		if isSessionError(err) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return auth.NewAuthError(err.Error())
+		}
+		return fmt.Errorf("revoke: %w", err)
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+	return nil
+}
+
+// revoke revokes an access token so it's rejected until it expires, even by
+// the other instances of the service.
+func (h *handlers) revoke(c *gin.Context) error {
+	var app AppRevoke
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if err := app.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if _, err := h.auth.Revoke(c.Request.Context(), app.Token); err != nil {
+		if isTokenError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return err
+		}
+		return fmt.Errorf("revoke: %w", err)
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+	return nil
+}
+
+// policy returns the revision of the authorization policy in use.
+func (h *handlers) policy(c *gin.Context) error {
+	c.JSON(http.StatusOK, toAppPolicyRevision(h.auth.PolicyRevision()))
+	return nil
+}
+
+// respond writes a new access token for the user along with the refresh
+// token.
+func (h *handlers) respond(c *gin.Context, usr user.User, refreshToken string) error {
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: usr.ID.String(),
+		},
+		TenantID:   usr.TenantID,
+		Roles:      usr.Roles,
+		Department: usr.Department,
+	}
+
+	accessToken, err := h.auth.GenerateToken(claims)
+	if err != nil {
+		return fmt.Errorf("generatetoken: %w", err)
+	}
+
+	c.JSON(http.StatusOK, toAppTokens(accessToken, h.auth.AccessTokenTTL(), refreshToken))
+	return nil
+}
+
+// isSessionError reports whether the error is the result of presenting a
+// refresh token that can't be used.
+func isSessionError(err error) bool {
+	return errors.Is(err, session.ErrNotFound) ||
+		errors.Is(err, session.ErrExpired) ||
+		errors.Is(err, session.ErrRevoked) ||
+		errors.Is(err, session.ErrReused) ||
+		errors.Is(err, session.ErrInvalidToken)
+}
+
+// isTokenError reports whether the error is the result of presenting an
+// access token that can't be revoked.
+func isTokenError(err error) bool {
+	return errors.Is(err, revocation.ErrMissingID) ||
+		errors.Is(err, jwt.ErrTokenMalformed) ||
+		errors.Is(err, jwt.ErrTokenUnverifiable) ||
+		errors.Is(err, jwt.ErrTokenSignatureInvalid)
+}
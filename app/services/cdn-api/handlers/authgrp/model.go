@@ -0,0 +1,86 @@
+package authgrp
+
+import (
+	"time"
+
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/foundation/validate"
+)
+
+// AppLogin defines the credentials needed to log in.
+type AppLogin struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppLogin) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AppRefresh defines the refresh token presented to get new tokens or to log
+// out.
+type AppRefresh struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppRefresh) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AppRevoke defines the access token to revoke.
+type AppRevoke struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppRevoke) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AppTokens represents the tokens issued to a client.
+type AppTokens struct {
+	AccessToken  string `json:"accessToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int    `json:"expiresIn"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+func toAppTokens(accessToken string, ttl time.Duration, refreshToken string) AppTokens {
+	return AppTokens{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(ttl.Seconds()),
+		RefreshToken: refreshToken,
+	}
+}
+
+// AppPolicyRevision represents the authorization policy in use.
+type AppPolicyRevision struct {
+	Revision   string   `json:"revision"`
+	Source     string   `json:"source"`
+	Rules      []string `json:"rules"`
+	DateLoaded string   `json:"dateLoaded"`
+}
+
+func toAppPolicyRevision(rev auth.PolicyRevision) AppPolicyRevision {
+	return AppPolicyRevision{
+		Revision:   rev.Revision,
+		Source:     rev.Source,
+		Rules:      rev.Rules,
+		DateLoaded: rev.DateLoaded.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,107 @@
+package authgrp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/identity"
+	"github.com/testvergecloud/testApi/business/web/auth"
+)
+
+// Set of values for the cookie keeping a login with the OpenID provider
+// until the provider redirects back. A login has to be completed in time.
+const (
+	oidcCookieName = "cdn_oidc_login"
+	oidcCookiePath = "/v1/auth/oidc"
+	oidcTimeout    = 10 * time.Minute
+)
+
+// oidcLogin starts logging in with the OpenID provider. The user is sent to
+// the provider and the values needed to complete the login are kept in a
+// cookie.
+func (h *handlers) oidcLogin(c *gin.Context) error {
+	login, err := h.oidc.Begin(c.Request.Context())
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+
+	value := strings.Join([]string{login.State, login.Nonce, login.Verifier}, ".")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcCookieName, value, int(oidcTimeout.Seconds()), oidcCookiePath, "", c.Request.TLS != nil, true)
+
+	c.Redirect(http.StatusFound, login.URL)
+	return nil
+}
+
+// oidcCallback completes the login the OpenID provider redirected back with.
+// The user of the external identity is looked up, or provisioned, and a new
+// session is started for them.
+func (h *handlers) oidcCallback(c *gin.Context) error {
+	value, _ := c.Cookie(oidcCookieName)
+
+	// The login can only be completed once.
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcCookieName, "", -1, oidcCookiePath, "", c.Request.TLS != nil, true)
+
+	if reason := c.Query("error"); reason != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("provider error: %s", reason)})
+		return auth.NewAuthError("provider error: %s", reason)
+	}
+
+	var login auth.OIDCLogin
+	if parts := strings.Split(value, "."); len(parts) == 3 {
+		login = auth.OIDCLogin{
+			State:    parts[0],
+			Nonce:    parts[1],
+			Verifier: parts[2],
+		}
+	}
+
+	ctx := c.Request.Context()
+	ext, err := h.oidc.Complete(ctx, login, c.Query("state"), c.Query("code"))
+	if err != nil {
+		if errors.Is(err, auth.ErrOIDCState) || errors.Is(err, auth.ErrOIDCGrant) || errors.Is(err, auth.ErrOIDCToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return auth.NewAuthError(err.Error())
+		}
+		return fmt.Errorf("complete: %w", err)
+	}
+
+	addr, err := mail.ParseAddress(ext.Email)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "provider didn't share a valid email"})
+		return auth.NewAuthError("provider didn't share a valid email")
+	}
+
+	usr, err := h.identity.Resolve(ctx, identity.External{
+		Issuer:        ext.Issuer,
+		Subject:       ext.Subject,
+		Email:         *addr,
+		EmailVerified: ext.EmailVerified,
+		Name:          ext.Name,
+	})
+	if err != nil {
+		if errors.Is(err, identity.ErrEmailNotVerified) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return auth.NewAuthError(err.Error())
+		}
+		return fmt.Errorf("resolve: subject[%s]: %w", ext.Subject, err)
+	}
+
+	if !usr.Enabled {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user disabled"})
+		return auth.NewAuthError("user disabled")
+	}
+
+	refreshToken, _, err := h.session.Create(ctx, usr.ID, h.refreshTTL)
+	if err != nil {
+		return fmt.Errorf("create: userID[%s]: %w", usr.ID, err)
+	}
+
+	return h.respond(c, usr, refreshToken)
+}
@@ -0,0 +1,73 @@
+package authgrp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	"github.com/testvergecloud/testApi/business/core/crud/identity"
+	"github.com/testvergecloud/testApi/business/core/crud/session"
+	"github.com/testvergecloud/testApi/business/core/crud/session/stores/sessiondb"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/core/crud/user/stores/userdb"
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/business/web/ratelimit"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/web"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log             *logger.Logger
+	Delegate        *delegate.Delegate
+	Auth            *auth.Auth
+	DB              *sqlx.DB
+	RefreshTokenTTL time.Duration
+	OIDC            *auth.OIDC
+	Identity        *identity.Core
+	RateLimit       *ratelimit.Limiter
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "/v1"
+
+	// The user is read from the database, not the cache, so a disabled user
+	// can't refresh tokens. Other domains are told about lockouts through the
+	// delegate.
+	usrCore := user.NewCore(cfg.Log, cfg.Delegate, nil, userdb.NewStore(cfg.Log, cfg.DB))
+	sesCore := session.NewCore(cfg.Log, sessiondb.NewStore(cfg.Log, cfg.DB))
+
+	hdl := new(usrCore, sesCore, cfg.Auth, cfg.OIDC, cfg.Identity, cfg.RefreshTokenTTL)
+	v1 := app.Mux.Group(version)
+	{
+		// Credentials are checked on these routes, they get the tighter
+		// quota so they can't be guessed at the rate of the other routes.
+		noAuth := v1.Group("/auth")
+		{
+			noAuth.Use(mid.RateLimit(cfg.Auth, cfg.RateLimit, ratelimit.Token))
+
+			app.Handle(http.MethodPost, noAuth, "/login", hdl.login)
+			app.Handle(http.MethodPost, noAuth, "/refresh", hdl.refresh)
+			app.Handle(http.MethodPost, noAuth, "/logout", hdl.logout)
+
+			// Logging in with an OpenID provider is only offered when one is
+			// configured.
+			if cfg.OIDC != nil {
+				app.Handle(http.MethodGet, noAuth, "/oidc/login", hdl.oidcLogin)
+				app.Handle(http.MethodGet, noAuth, "/oidc/callback", hdl.oidcCallback)
+			}
+		}
+
+		admin := v1.Group("/auth")
+		{
+			admin.Use(mid.Authenticate(cfg.Auth))
+			admin.Use(mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+			app.Handle(http.MethodPost, admin, "/revoke", hdl.revoke)
+			app.Handle(http.MethodGet, admin, "/policy", hdl.policy)
+		}
+	}
+}
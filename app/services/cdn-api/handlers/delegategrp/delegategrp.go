@@ -0,0 +1,86 @@
+// Package delegategrp maintains the group of handlers for administering
+// delegate events that could not be delivered.
+package delegategrp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	wb "github.com/testvergecloud/testApi/business/web"
+	"github.com/testvergecloud/testApi/business/web/page"
+	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/google/uuid"
+)
+
+type handlers struct {
+	delegate *delegate.Delegate
+}
+
+func new(delegate *delegate.Delegate) *handlers {
+	return &handlers{
+		delegate: delegate,
+	}
+}
+
+// query returns a list of dead letters with paging.
+func (h *handlers) query(c *gin.Context) error {
+	page, err := page.Parse(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	ctx := c.Request.Context()
+	dls, err := h.delegate.QueryDeadLetters(ctx, page.Number, page.RowsPerPage)
+	if err != nil {
+		if errors.Is(err, delegate.ErrNoDeadLetter) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusNotImplemented)
+		}
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.delegate.CountDeadLetters(ctx)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	c.JSON(http.StatusOK, wb.NewPageDocument(toAppDeadLetters(dls), total, page.Number, page.RowsPerPage))
+	return nil
+}
+
+// replay executes the function recorded in a dead letter again.
+func (h *handlers) replay(c *gin.Context) error {
+	id, err := uuid.Parse(c.Param("dead_letter_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return validate.NewFieldsError("dead_letter_id", err)
+	}
+
+	dl, err := h.delegate.Replay(c.Request.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, delegate.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusNotFound)
+		case errors.Is(err, delegate.ErrAlreadyReplayed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusConflict)
+		case errors.Is(err, delegate.ErrFuncNotFound):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusUnprocessableEntity)
+		case errors.Is(err, delegate.ErrNoDeadLetter):
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusNotImplemented)
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return wb.NewTrustedError(err, http.StatusBadGateway)
+	}
+
+	c.JSON(http.StatusOK, toAppDeadLetter(dl))
+	return nil
+}
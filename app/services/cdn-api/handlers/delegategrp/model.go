@@ -0,0 +1,50 @@
+package delegategrp
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+)
+
+// AppDeadLetter represents information about an event that could not be
+// delivered.
+type AppDeadLetter struct {
+	ID           string          `json:"id"`
+	Domain       string          `json:"domain"`
+	Action       string          `json:"action"`
+	Params       json.RawMessage `json:"params"`
+	Func         string          `json:"func"`
+	Attempts     int             `json:"attempts"`
+	Error        string          `json:"error"`
+	DateCreated  string          `json:"dateCreated"`
+	DateReplayed string          `json:"dateReplayed,omitempty"`
+}
+
+func toAppDeadLetter(dl delegate.DeadLetter) AppDeadLetter {
+	app := AppDeadLetter{
+		ID:          dl.ID.String(),
+		Domain:      dl.Data.Domain,
+		Action:      dl.Data.Action,
+		Params:      json.RawMessage(dl.Data.RawParams),
+		Func:        dl.Func,
+		Attempts:    dl.Attempts,
+		Error:       dl.Error,
+		DateCreated: dl.DateCreated.Format(time.RFC3339),
+	}
+
+	if !dl.DateReplayed.IsZero() {
+		app.DateReplayed = dl.DateReplayed.Format(time.RFC3339)
+	}
+
+	return app
+}
+
+func toAppDeadLetters(dls []delegate.DeadLetter) []AppDeadLetter {
+	items := make([]AppDeadLetter, len(dls))
+	for i, dl := range dls {
+		items[i] = toAppDeadLetter(dl)
+	}
+
+	return items
+}
@@ -0,0 +1,38 @@
+package delegategrp
+
+import (
+	"net/http"
+
+	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/web"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log      *logger.Logger
+	Delegate *delegate.Delegate
+	Auth     *auth.Auth
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "/v1"
+
+	hdl := new(cfg.Delegate)
+	v1 := app.Mux.Group(version)
+	{
+		// Dead letters hold the events of every organization, so only super
+		// admins can see and replay them.
+		ruleSuperAdmin := v1.Group("/deadletters")
+		{
+			ruleSuperAdmin.Use(mid.Authenticate(cfg.Auth))
+			ruleSuperAdmin.Use(mid.Authorize(cfg.Auth, auth.RuleSuperAdmin))
+
+			app.Handle(http.MethodGet, ruleSuperAdmin, "", hdl.query)
+			app.Handle(http.MethodPost, ruleSuperAdmin, "/:dead_letter_id/replay", hdl.replay)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package homegrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/home"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	wb "github.com/testvergecloud/testApi/business/web"
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/batch"
+	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/google/uuid"
+)
+
+// batch executes a batch of home operations, every one of them authorized
+// on its own.
+func (h *handlers) batch(c *gin.Context) error {
+	var req batch.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return wb.NewTrustedError(err, http.StatusBadRequest)
+	}
+
+	items := make([]batch.Item[home.NewHome], len(req.Operations))
+	for i, op := range req.Operations {
+		items[i] = h.batchItem(c, op)
+	}
+
+	b := batch.Batch[home.NewHome]{
+		Log:        h.log,
+		Beginner:   h.bgn,
+		CreateMany: h.createMany,
+		Status:     batchStatus,
+	}
+
+	results := b.Execute(c.Request.Context(), req.Atomic, items)
+
+	c.JSON(batch.StatusCode(req.Atomic, results), batch.Response{Results: results})
+	return nil
+}
+
+// batchItem prepares the operation for execution.
+func (h *handlers) batchItem(c *gin.Context, op batch.Operation) batch.Item[home.NewHome] {
+	switch op.Op {
+	case batch.OpCreate:
+		var app AppNewHome
+		if err := batch.Decode(op, &app); err != nil {
+			return batch.Item[home.NewHome]{Err: err}
+		}
+
+		nh, err := toCoreNewHome(c, app)
+		if err != nil {
+			return batch.Item[home.NewHome]{Err: validate.NewFieldsError("type", err)}
+		}
+
+		if err := mid.AuthorizeHomeItem(c, h.auth, auth.RuleUserOnly, home.Home{}); err != nil {
+			return batch.Item[home.NewHome]{Err: wb.NewTrustedError(err, http.StatusForbidden)}
+		}
+
+		return batch.Item[home.NewHome]{Op: op.Op, New: nh, Status: http.StatusCreated}
+
+	case batch.OpUpdate:
+		homeID, err := uuid.Parse(op.ID)
+		if err != nil {
+			return batch.Item[home.NewHome]{Err: validate.NewFieldsError("id", ErrInvalidID)}
+		}
+
+		var app AppUpdateHome
+		if err := batch.Decode(op, &app); err != nil {
+			return batch.Item[home.NewHome]{Err: err}
+		}
+
+		uh, err := toCoreUpdateHome(app)
+		if err != nil {
+			return batch.Item[home.NewHome]{Err: validate.NewFieldsError("type", err)}
+		}
+
+		run := func(ctx context.Context, tx transaction.Transaction) (any, error) {
+			hmeCore, hme, err := h.batchHome(c, tx, homeID, op)
+			if err != nil {
+				return nil, err
+			}
+
+			hme, err = hmeCore.Update(ctx, hme, uh)
+			if err != nil {
+				return nil, fmt.Errorf("update: homeID[%s]: %w", homeID, err)
+			}
+
+			return toAppHome(hme), nil
+		}
+
+		return batch.Item[home.NewHome]{Op: op.Op, Run: run, Status: http.StatusOK}
+
+	case batch.OpDelete:
+		homeID, err := uuid.Parse(op.ID)
+		if err != nil {
+			return batch.Item[home.NewHome]{Err: validate.NewFieldsError("id", ErrInvalidID)}
+		}
+
+		run := func(ctx context.Context, tx transaction.Transaction) (any, error) {
+			hmeCore, hme, err := h.batchHome(c, tx, homeID, op)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := hmeCore.Delete(ctx, hme); err != nil {
+				return nil, fmt.Errorf("delete: homeID[%s]: %w", homeID, err)
+			}
+
+			return nil, nil
+		}
+
+		return batch.Item[home.NewHome]{Op: op.Op, Run: run, Status: http.StatusNoContent}
+	}
+
+	return batch.Item[home.NewHome]{Err: validate.NewFieldsError("op", batch.ErrUnknownOp)}
+}
+
+// batchHome loads the home an operation acts on under the transaction
+// and checks the caller may change it.
+func (h *handlers) batchHome(c *gin.Context, tx transaction.Transaction, homeID uuid.UUID, op batch.Operation) (*home.Core, home.Home, error) {
+	hmeCore, err := h.home.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, home.Home{}, err
+	}
+
+	hme, err := hmeCore.QueryByID(c.Request.Context(), homeID)
+	if err != nil {
+		return nil, home.Home{}, fmt.Errorf("querybyid: homeID[%s]: %w", homeID, err)
+	}
+
+	if err := mid.AuthorizeHomeItem(c, h.auth, auth.RuleAdminOrSubject, hme); err != nil {
+		return nil, home.Home{}, wb.NewTrustedError(err, http.StatusForbidden)
+	}
+
+	if err := op.CheckVersion(hme.Version, home.ErrVersionConflict); err != nil {
+		return nil, home.Home{}, err
+	}
+
+	return hmeCore, hme, nil
+}
+
+// createMany adds the new homes of a batch under the transaction.
+func (h *handlers) createMany(ctx context.Context, tx transaction.Transaction, nhs []home.NewHome) ([]any, error) {
+	hmeCore, err := h.home.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	hmes, err := hmeCore.CreateMany(ctx, nhs)
+	if err != nil {
+		return nil, fmt.Errorf("createmany: %w", err)
+	}
+
+	data := make([]any, len(hmes))
+	for i, hme := range hmes {
+		data[i] = toAppHome(hme)
+	}
+
+	return data, nil
+}
+
+// batchStatus maps the errors of a home operation to a status code.
+func batchStatus(err error) int {
+	switch {
+	case errors.Is(err, home.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, home.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, home.ErrUserDisabled),
+		errors.Is(err, user.ErrNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, tenant.ErrForbidden):
+		return http.StatusForbidden
+	}
+
+	return http.StatusInternalServerError
+}
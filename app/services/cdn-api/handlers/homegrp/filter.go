@@ -2,41 +2,57 @@ package homegrp
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/testvergecloud/testApi/business/core/crud/home"
+	"github.com/testvergecloud/testApi/business/web"
 	"github.com/testvergecloud/testApi/foundation/validate"
-
-	"github.com/google/uuid"
 )
 
 func parseFilter(r *http.Request) (home.QueryFilter, error) {
 	const (
 		filterByHomeID           = "home_id"
+		filterByNotHomeID        = "not_home_id"
 		filterByUserID           = "user_id"
 		filterByType             = "type"
-		filterByStartCreatedDate = "start_date_created"
-		filterByEndCreatedDate   = "end_date_created"
+		filterByNotType          = "not_type"
+		filterByStartCreatedDate = "start_created_date"
+		filterByEndCreatedDate   = "end_created_date"
+		filterByIncludeDeleted   = "include_deleted"
+
+		// Names used before the filters were made consistent with the
+		// other groups. They are still accepted.
+		filterByStartDateCreated = "start_date_created"
+		filterByEndDateCreated   = "end_date_created"
 	)
 
 	values := r.URL.Query()
 
 	var filter home.QueryFilter
 
-	if homeID := values.Get(filterByHomeID); homeID != "" {
-		id, err := uuid.Parse(homeID)
+	if homeIDs := values.Get(filterByHomeID); homeIDs != "" {
+		ids, err := web.ParseIDs(homeIDs)
 		if err != nil {
 			return home.QueryFilter{}, validate.NewFieldsError(filterByHomeID, err)
 		}
-		filter.WithHomeID(id)
+		filter.WithHomeIDs(ids)
+	}
+
+	if homeIDs := values.Get(filterByNotHomeID); homeIDs != "" {
+		ids, err := web.ParseIDs(homeIDs)
+		if err != nil {
+			return home.QueryFilter{}, validate.NewFieldsError(filterByNotHomeID, err)
+		}
+		filter.WithoutHomeIDs(ids)
 	}
 
-	if userID := values.Get(filterByUserID); userID != "" {
-		id, err := uuid.Parse(userID)
+	if userIDs := values.Get(filterByUserID); userIDs != "" {
+		ids, err := web.ParseIDs(userIDs)
 		if err != nil {
 			return home.QueryFilter{}, validate.NewFieldsError(filterByUserID, err)
 		}
-		filter.WithUserID(id)
+		filter.WithUserIDs(ids)
 	}
 
 	if homeType := values.Get(filterByType); homeType != "" {
@@ -47,20 +63,46 @@ func parseFilter(r *http.Request) (home.QueryFilter, error) {
 		filter.WithHomeType(typ)
 	}
 
-	if createdDate := values.Get(filterByStartCreatedDate); createdDate != "" {
-		t, err := time.Parse(time.RFC3339, createdDate)
+	if homeType := values.Get(filterByNotType); homeType != "" {
+		typ, err := home.ParseType(homeType)
 		if err != nil {
-			return home.QueryFilter{}, validate.NewFieldsError(filterByStartCreatedDate, err)
+			return home.QueryFilter{}, validate.NewFieldsError(filterByNotType, err)
 		}
-		filter.WithStartDateCreated(t)
+		filter.WithoutHomeType(typ)
 	}
 
-	if createdDate := values.Get(filterByEndCreatedDate); createdDate != "" {
-		t, err := time.Parse(time.RFC3339, createdDate)
+	for _, field := range []string{filterByStartCreatedDate, filterByStartDateCreated} {
+		if createdDate := values.Get(field); createdDate != "" {
+			t, err := time.Parse(time.RFC3339, createdDate)
+			if err != nil {
+				return home.QueryFilter{}, validate.NewFieldsError(field, err)
+			}
+			filter.WithStartDateCreated(t)
+			break
+		}
+	}
+
+	for _, field := range []string{filterByEndCreatedDate, filterByEndDateCreated} {
+		if createdDate := values.Get(field); createdDate != "" {
+			t, err := time.Parse(time.RFC3339, createdDate)
+			if err != nil {
+				return home.QueryFilter{}, validate.NewFieldsError(field, err)
+			}
+			filter.WithEndCreatedDate(t)
+			break
+		}
+	}
+
+	if includeDeleted := values.Get(filterByIncludeDeleted); includeDeleted != "" {
+		inc, err := strconv.ParseBool(includeDeleted)
 		if err != nil {
-			return home.QueryFilter{}, validate.NewFieldsError(filterByEndCreatedDate, err)
+			return home.QueryFilter{}, validate.NewFieldsError(filterByIncludeDeleted, err)
 		}
-		filter.WithEndCreatedDate(t)
+		filter.WithIncludeDeleted(inc)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return home.QueryFilter{}, err
 	}
 
 	return filter, nil
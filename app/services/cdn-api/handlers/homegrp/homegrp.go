@@ -8,9 +8,16 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/testvergecloud/testApi/business/core/crud/home"
+	"github.com/testvergecloud/testApi/business/data/transaction"
 	wb "github.com/testvergecloud/testApi/business/web"
+	"github.com/testvergecloud/testApi/business/web/auth"
 	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/business/web/order"
 	"github.com/testvergecloud/testApi/business/web/page"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/google/uuid"
 )
 
 // Set of error variables for handling home group errors.
@@ -19,12 +26,22 @@ var (
 )
 
 type handlers struct {
-	home *home.Core
+	log            *logger.Logger
+	auth           *auth.Auth
+	bgn            transaction.Beginner
+	home           *home.Core
+	requireIfMatch bool
+	cursorKey      []byte
 }
 
-func new(home *home.Core) *handlers {
+func new(log *logger.Logger, a *auth.Auth, bgn transaction.Beginner, home *home.Core, requireIfMatch bool, cursorKey []byte) *handlers {
 	return &handlers{
-		home: home,
+		log:            log,
+		auth:           a,
+		bgn:            bgn,
+		home:           home,
+		requireIfMatch: requireIfMatch,
+		cursorKey:      cursorKey,
 	}
 }
 
@@ -43,11 +60,20 @@ func (h *handlers) create(c *gin.Context) error {
 		return err
 	}
 
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
 	hme, err := h.home.Create(ctx, nh)
 	if err != nil {
+		// Recording the error rolls back the transaction.
+		c.Error(err)
 		return fmt.Errorf("create: hme[%+v]: %w", app, err)
 	}
 
+	c.Header("ETag", wb.ETag(hme.Version))
 	c.JSON(http.StatusCreated, toAppHome(hme))
 	return nil
 }
@@ -67,22 +93,50 @@ func (h *handlers) update(c *gin.Context) error {
 	}
 
 	ctx := c.Request.Context()
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
 	hme := mid.GetHome(c)
 
+	if err := wb.CheckIfMatch(c.Request, hme.Version, h.requireIfMatch); err != nil {
+		c.JSON(wb.GetTrustedError(err).Status, gin.H{"error": err.Error()})
+		return err
+	}
+
 	updHme, err := h.home.Update(ctx, hme, uh)
 	if err != nil {
+		if errors.Is(err, home.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
+
+		// Recording the error rolls back the transaction.
+		c.Error(err)
 		return fmt.Errorf("update: homeID[%s] app[%+v]: %w", hme.ID, app, err)
 	}
 
+	c.Header("ETag", wb.ETag(updHme.Version))
 	c.JSON(http.StatusOK, toAppHome(updHme))
 	return nil
 }
 
+// delete removes a home from the system.
 func (h *handlers) delete(c *gin.Context) error {
 	ctx := c.Request.Context()
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
 	hme := mid.GetHome(c)
 
 	if err := h.home.Delete(ctx, hme); err != nil {
+		// Recording the error rolls back the transaction.
+		c.Error(err)
 		return fmt.Errorf("delete: homeID[%s]: %w", hme.ID, err)
 	}
 
@@ -90,8 +144,50 @@ func (h *handlers) delete(c *gin.Context) error {
 	return nil
 }
 
+// restore brings back a home that was deleted.
+func (h *handlers) restore(c *gin.Context) error {
+	homeID, err := uuid.Parse(c.Param("home_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidID.Error()})
+		return validate.NewFieldsError("home_id", ErrInvalidID)
+	}
+
+	ctx := c.Request.Context()
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
+	hme, err := h.home.Restore(ctx, homeID)
+	if err != nil {
+		switch {
+		case errors.Is(err, home.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusNotFound)
+		case errors.Is(err, home.ErrNotDeleted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusConflict)
+		}
+
+		// Recording the error rolls back the transaction.
+		c.Error(err)
+		return fmt.Errorf("restore: homeID[%s]: %w", homeID, err)
+	}
+
+	c.Header("ETag", wb.ETag(hme.Version))
+	c.JSON(http.StatusOK, toAppHome(hme))
+	return nil
+}
+
 // query returns a list of homes with paging.
 func (h *handlers) query(c *gin.Context) error {
+	cursor, keyset, err := page.ParseCursor(c.Request, h.cursorKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
 	page, err := page.Parse(c.Request)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -110,6 +206,10 @@ func (h *handlers) query(c *gin.Context) error {
 		return err
 	}
 
+	if keyset {
+		return h.queryByCursor(c, filter, orderBy, cursor, page.RowsPerPage)
+	}
+
 	ctx := c.Request.Context()
 	homes, err := h.home.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
 	if err != nil {
@@ -125,8 +225,35 @@ func (h *handlers) query(c *gin.Context) error {
 	return nil
 }
 
+// queryByCursor returns a list of homes using keyset paging. The order of
+// a non empty cursor takes precedence over the order requested.
+func (h *handlers) queryByCursor(c *gin.Context, filter home.QueryFilter, orderBy order.By, cursor page.Cursor, rowsPerPage int) error {
+	if !cursor.IsZero() {
+		orderBy = cursor.OrderBy
+	}
+
+	ctx := c.Request.Context()
+	homes, cursors, err := h.home.QueryByCursor(ctx, filter, orderBy, cursor, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("querybycursor: %w", err)
+	}
+
+	total, err := h.home.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	next, prev := cursors.Encode(h.cursorKey)
+
+	c.JSON(http.StatusOK, wb.NewCursorDocument(toAppHomes(homes), total, rowsPerPage, next, prev))
+	return nil
+}
+
 // queryByID returns a home by its ID.
 func (h *handlers) queryByID(c *gin.Context) error {
-	c.JSON(http.StatusOK, toAppHome(mid.GetHome(c)))
+	hme := mid.GetHome(c)
+
+	c.Header("ETag", wb.ETag(hme.Version))
+	c.JSON(http.StatusOK, toAppHome(hme))
 	return nil
 }
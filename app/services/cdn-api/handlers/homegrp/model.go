@@ -23,18 +23,21 @@ type AppAddress struct {
 // AppHome represents information about an individual home.
 type AppHome struct {
 	ID          string     `json:"id"`
+	TenantID    string     `json:"tenantID"`
 	UserID      string     `json:"userID"`
 	Type        string     `json:"type"`
 	Address     AppAddress `json:"address"`
+	Version     int        `json:"version"`
 	DateCreated string     `json:"dateCreated"`
 	DateUpdated string     `json:"dateUpdated"`
 }
 
 func toAppHome(hme home.Home) AppHome {
 	return AppHome{
-		ID:     hme.ID.String(),
-		UserID: hme.UserID.String(),
-		Type:   hme.Type.Name(),
+		ID:       hme.ID.String(),
+		TenantID: hme.TenantID.String(),
+		UserID:   hme.UserID.String(),
+		Type:     hme.Type.Name(),
 		Address: AppAddress{
 			Address1: hme.Address.Address1,
 			Address2: hme.Address.Address2,
@@ -43,6 +46,7 @@ func toAppHome(hme home.Home) AppHome {
 			State:    hme.Address.State,
 			Country:  hme.Address.Country,
 		},
+		Version:     hme.Version,
 		DateCreated: hme.DateCreated.Format(time.RFC3339),
 		DateUpdated: hme.DateUpdated.Format(time.RFC3339),
 	}
@@ -27,11 +27,11 @@ func parseOrder(r *http.Request) (order.By, error) {
 		return order.By{}, err
 	}
 
-	if _, exists := orderByFields[orderBy.Field]; !exists {
-		return order.By{}, validate.NewFieldsError(orderBy.Field, errors.New("order field does not exist"))
+	for _, by := range orderBy.Fields() {
+		if _, exists := orderByFields[by.Field]; !exists {
+			return order.By{}, validate.NewFieldsError(by.Field, errors.New("order field does not exist"))
+		}
 	}
 
-	orderBy.Field = orderByFields[orderBy.Field]
-
-	return orderBy, nil
+	return orderBy.Map(orderByFields), nil
 }
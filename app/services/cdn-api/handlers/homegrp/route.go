@@ -3,13 +3,17 @@ package homegrp
 import (
 	"net/http"
 
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/business/core/crud/audit/stores/auditdb"
 	"github.com/testvergecloud/testApi/business/core/crud/delegate"
 	"github.com/testvergecloud/testApi/business/core/crud/home"
 	"github.com/testvergecloud/testApi/business/core/crud/home/stores/homedb"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/core/crud/user/stores/usercache"
 	"github.com/testvergecloud/testApi/business/core/crud/user/stores/userdb"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
 	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/idempotency"
 	"github.com/testvergecloud/testApi/business/web/mid"
 	"github.com/testvergecloud/testApi/foundation/logger"
 	"github.com/testvergecloud/testApi/foundation/web"
@@ -19,20 +23,24 @@ import (
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log      *logger.Logger
-	Delegate *delegate.Delegate
-	Auth     *auth.Auth
-	DB       *sqlx.DB
+	Log            *logger.Logger
+	Delegate       *delegate.Delegate
+	Auth           *auth.Auth
+	DB             *sqlx.DB
+	RequireIfMatch bool
+	CursorKey      []byte
+	Idempotency    *idempotency.Core
 }
 
 // Routes adds specific routes for this group.
 func Routes(app *web.App, cfg Config) {
 	const version = "/v1"
 
-	usrCore := user.NewCore(cfg.Log, cfg.Delegate, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB)))
-	hmeCore := home.NewCore(cfg.Log, usrCore, cfg.Delegate, homedb.NewStore(cfg.Log, cfg.DB))
+	audCore := audit.NewCore(cfg.Log, auditdb.NewStore(cfg.Log, cfg.DB))
+	usrCore := user.NewCore(cfg.Log, cfg.Delegate, audCore, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB)))
+	hmeCore := home.NewCore(cfg.Log, usrCore, cfg.Delegate, audCore, homedb.NewStore(cfg.Log, cfg.DB))
 
-	hdl := new(hmeCore)
+	hdl := new(cfg.Log, cfg.Auth, sqldb.NewBeginner(cfg.DB), hmeCore, cfg.RequireIfMatch, cfg.CursorKey)
 	v1 := app.Mux.Group(version)
 	{
 		v1.Use(mid.Authenticate(cfg.Auth))
@@ -43,18 +51,46 @@ func Routes(app *web.App, cfg Config) {
 			app.Handle(http.MethodGet, ruleAny, "", hdl.query)
 		}
 
+		// Changes run under a transaction so the home change and the audit
+		// entry it produces are committed together. Retried creates are
+		// replayed from the idempotency key stored in it.
 		ruleUserOnly := v1.Group("/homes")
 		{
 			ruleUserOnly.Use(mid.Authorize(cfg.Auth, auth.RuleUserOnly))
+			ruleUserOnly.Use(mid.ExecuteInTransaction(cfg.Log, sqldb.NewBeginner(cfg.DB)))
+			ruleUserOnly.Use(mid.Idempotency(cfg.Idempotency))
 			app.Handle(http.MethodPost, ruleUserOnly, "", hdl.create)
 		}
 
-		ruleAdminOrSubject := v1.Group("/homes").Group("/{home_id}")
+		// A batch authorizes every operation on its own and runs them under
+		// the transactions it asks for.
+		ruleAnyBatch := v1.Group("")
+		{
+			ruleAnyBatch.Use(mid.Authorize(cfg.Auth, auth.RuleAny))
+			app.HandleCustomMethod(http.MethodPost, ruleAnyBatch, "/homes", "batch", hdl.batch)
+		}
+
+		ruleAdminOrSubject := v1.Group("/homes").Group("/:home_id")
 		{
 			ruleAdminOrSubject.Use(mid.AuthorizeHome(cfg.Auth, auth.RuleAdminOrSubject, hmeCore))
 			app.Handle(http.MethodGet, ruleAdminOrSubject, "", hdl.queryByID)
-			app.Handle(http.MethodPut, ruleAdminOrSubject, "", hdl.update)
-			app.Handle(http.MethodDelete, ruleAdminOrSubject, "", hdl.delete)
+		}
+
+		ruleAdminOrSubjectTran := v1.Group("/homes").Group("/:home_id")
+		{
+			ruleAdminOrSubjectTran.Use(mid.AuthorizeHome(cfg.Auth, auth.RuleAdminOrSubject, hmeCore))
+			ruleAdminOrSubjectTran.Use(mid.ExecuteInTransaction(cfg.Log, sqldb.NewBeginner(cfg.DB)))
+			app.Handle(http.MethodPut, ruleAdminOrSubjectTran, "", hdl.update)
+			app.Handle(http.MethodDelete, ruleAdminOrSubjectTran, "", hdl.delete)
+		}
+
+		// Deleted homes can't be loaded by the home authorization, so only
+		// admins can restore them.
+		ruleAdmin := v1.Group("/homes").Group("/:home_id")
+		{
+			ruleAdmin.Use(mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+			ruleAdmin.Use(mid.ExecuteInTransaction(cfg.Log, sqldb.NewBeginner(cfg.DB)))
+			app.Handle(http.MethodPost, ruleAdmin, "/restore", hdl.restore)
 		}
 	}
 }
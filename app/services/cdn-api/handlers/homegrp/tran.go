@@ -0,0 +1,31 @@
+package homegrp
+
+import (
+	"context"
+
+	"github.com/testvergecloud/testApi/business/data/transaction"
+)
+
+// executeUnderTransaction constructs a new Handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *handlers) executeUnderTransaction(ctx context.Context) (*handlers, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		home, err := h.home.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		handlers := handlers{
+			log:            h.log,
+			auth:           h.auth,
+			bgn:            h.bgn,
+			home:           home,
+			requireIfMatch: h.requireIfMatch,
+			cursorKey:      h.cursorKey,
+		}
+
+		return &handlers, nil
+	}
+
+	return h, nil
+}
@@ -0,0 +1,35 @@
+// Package jwksgrp maintains the group of handlers publishing the public keys
+// used to sign tokens, so other services can verify them.
+package jwksgrp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/foundation/keystore"
+)
+
+type handlers struct {
+	keyStore *keystore.KeyStore
+}
+
+func new(keyStore *keystore.KeyStore) *handlers {
+	return &handlers{
+		keyStore: keyStore,
+	}
+}
+
+// query returns the public keys as a JSON Web Key Set.
+func (h *handlers) query(c *gin.Context) error {
+	jwks, err := h.keyStore.JWKS()
+	if err != nil {
+		return fmt.Errorf("jwks: %w", err)
+	}
+
+	// Verifiers fetch the set again when they see an unknown kid, so a short
+	// cache is enough.
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+	return nil
+}
@@ -0,0 +1,24 @@
+package jwksgrp
+
+import (
+	"net/http"
+
+	"github.com/testvergecloud/testApi/foundation/keystore"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/web"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log      *logger.Logger
+	KeyStore *keystore.KeyStore
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	hdl := new(cfg.KeyStore)
+	wellKnown := app.Mux.Group("/.well-known")
+	{
+		app.Handle(http.MethodGet, wellKnown, "/jwks.json", hdl.query)
+	}
+}
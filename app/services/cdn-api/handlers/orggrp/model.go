@@ -0,0 +1,74 @@
+package orggrp
+
+import (
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/organization"
+	"github.com/testvergecloud/testApi/foundation/validate"
+)
+
+// AppOrganization represents information about an organization.
+type AppOrganization struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DateCreated string `json:"dateCreated"`
+	DateUpdated string `json:"dateUpdated"`
+}
+
+func toAppOrganization(org organization.Organization) AppOrganization {
+	return AppOrganization{
+		ID:          org.ID.String(),
+		Name:        org.Name,
+		DateCreated: org.DateCreated.Format(time.RFC3339),
+		DateUpdated: org.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppOrganizations(orgs []organization.Organization) []AppOrganization {
+	items := make([]AppOrganization, len(orgs))
+	for i, org := range orgs {
+		items[i] = toAppOrganization(org)
+	}
+
+	return items
+}
+
+// AppNewOrganization defines the data needed to add a new organization.
+type AppNewOrganization struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func toCoreNewOrganization(app AppNewOrganization) organization.NewOrganization {
+	return organization.NewOrganization{
+		Name: app.Name,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewOrganization) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AppUpdateOrganization defines the data needed to update an organization.
+type AppUpdateOrganization struct {
+	Name *string `json:"name" validate:"omitempty,min=1"`
+}
+
+func toCoreUpdateOrganization(app AppUpdateOrganization) organization.UpdateOrganization {
+	return organization.UpdateOrganization{
+		Name: app.Name,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateOrganization) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+// Package orggrp maintains the group of handlers for the organizations served
+// by the service.
+package orggrp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/organization"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	wb "github.com/testvergecloud/testApi/business/web"
+	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/google/uuid"
+)
+
+type handlers struct {
+	org *organization.Core
+}
+
+func new(org *organization.Core) *handlers {
+	return &handlers{
+		org: org,
+	}
+}
+
+// create adds a new organization to the system.
+func (h *handlers) create(c *gin.Context) error {
+	var app AppNewOrganization
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if err := app.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	org, err := h.org.Create(c.Request.Context(), toCoreNewOrganization(app))
+	if err != nil {
+		switch {
+		case errors.Is(err, organization.ErrUniqueName):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusConflict)
+		case errors.Is(err, tenant.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusForbidden)
+		}
+		return fmt.Errorf("create: name[%s]: %w", app.Name, err)
+	}
+
+	c.JSON(http.StatusCreated, toAppOrganization(org))
+	return nil
+}
+
+// update modifies the name of an organization.
+func (h *handlers) update(c *gin.Context) error {
+	var app AppUpdateOrganization
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if err := app.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	orgID, err := uuid.Parse(c.Param("organization_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return validate.NewFieldsError("organization_id", err)
+	}
+
+	ctx := c.Request.Context()
+	org, err := h.org.QueryByID(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, organization.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusNotFound)
+		}
+		return fmt.Errorf("querybyid: orgID[%s]: %w", orgID, err)
+	}
+
+	org, err = h.org.Update(ctx, org, toCoreUpdateOrganization(app))
+	if err != nil {
+		if errors.Is(err, organization.ErrUniqueName) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("update: orgID[%s]: %w", orgID, err)
+	}
+
+	c.JSON(http.StatusOK, toAppOrganization(org))
+	return nil
+}
+
+// query returns the organizations the caller can access.
+func (h *handlers) query(c *gin.Context) error {
+	orgs, err := h.org.QueryAll(c.Request.Context())
+	if err != nil {
+		return fmt.Errorf("queryall: %w", err)
+	}
+
+	c.JSON(http.StatusOK, toAppOrganizations(orgs))
+	return nil
+}
+
+// queryByID returns an organization by its ID.
+func (h *handlers) queryByID(c *gin.Context) error {
+	orgID, err := uuid.Parse(c.Param("organization_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return validate.NewFieldsError("organization_id", err)
+	}
+
+	org, err := h.org.QueryByID(c.Request.Context(), orgID)
+	if err != nil {
+		if errors.Is(err, organization.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusNotFound)
+		}
+		return fmt.Errorf("querybyid: orgID[%s]: %w", orgID, err)
+	}
+
+	c.JSON(http.StatusOK, toAppOrganization(org))
+	return nil
+}
@@ -0,0 +1,50 @@
+package orggrp
+
+import (
+	"net/http"
+
+	"github.com/testvergecloud/testApi/business/core/crud/organization"
+	"github.com/testvergecloud/testApi/business/core/crud/organization/stores/organizationdb"
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/web"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log  *logger.Logger
+	Auth *auth.Auth
+	DB   *sqlx.DB
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "/v1"
+
+	orgCore := organization.NewCore(cfg.Log, organizationdb.NewStore(cfg.Log, cfg.DB))
+
+	hdl := new(orgCore)
+	v1 := app.Mux.Group(version)
+	{
+		read := v1.Group("/organizations")
+		{
+			read.Use(mid.Authenticate(cfg.Auth))
+			read.Use(mid.Authorize(cfg.Auth, auth.RuleAny))
+
+			app.Handle(http.MethodGet, read, "", hdl.query)
+			app.Handle(http.MethodGet, read, "/:organization_id", hdl.queryByID)
+		}
+
+		write := v1.Group("/organizations")
+		{
+			write.Use(mid.Authenticate(cfg.Auth))
+			write.Use(mid.Authorize(cfg.Auth, auth.RuleSuperAdmin))
+
+			app.Handle(http.MethodPost, write, "", hdl.create)
+			app.Handle(http.MethodPut, write, "/:organization_id", hdl.update)
+		}
+	}
+}
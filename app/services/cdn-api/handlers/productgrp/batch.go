@@ -0,0 +1,182 @@
+package productgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/product"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	wb "github.com/testvergecloud/testApi/business/web"
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/batch"
+	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/google/uuid"
+)
+
+// batch executes a batch of product operations, every one of them authorized
+// on its own.
+func (h *handlers) batch(c *gin.Context) error {
+	var req batch.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return wb.NewTrustedError(err, http.StatusBadRequest)
+	}
+
+	items := make([]batch.Item[product.NewProduct], len(req.Operations))
+	for i, op := range req.Operations {
+		items[i] = h.batchItem(c, op)
+	}
+
+	b := batch.Batch[product.NewProduct]{
+		Log:        h.log,
+		Beginner:   h.bgn,
+		CreateMany: h.createMany,
+		Status:     batchStatus,
+	}
+
+	results := b.Execute(c.Request.Context(), req.Atomic, items)
+
+	c.JSON(batch.StatusCode(req.Atomic, results), batch.Response{Results: results})
+	return nil
+}
+
+// batchItem prepares the operation for execution.
+func (h *handlers) batchItem(c *gin.Context, op batch.Operation) batch.Item[product.NewProduct] {
+	switch op.Op {
+	case batch.OpCreate:
+		var app AppNewProduct
+		if err := batch.Decode(op, &app); err != nil {
+			return batch.Item[product.NewProduct]{Err: err}
+		}
+
+		if err := mid.AuthorizeProductItem(c, h.auth, auth.RuleUserOnly, product.Product{}); err != nil {
+			return batch.Item[product.NewProduct]{Err: wb.NewTrustedError(err, http.StatusForbidden)}
+		}
+
+		return batch.Item[product.NewProduct]{Op: op.Op, New: toCoreNewProduct(c, app), Status: http.StatusCreated}
+
+	case batch.OpUpdate:
+		productID, err := uuid.Parse(op.ID)
+		if err != nil {
+			return batch.Item[product.NewProduct]{Err: validate.NewFieldsError("id", ErrInvalidID)}
+		}
+
+		var app AppUpdateProduct
+		if err := batch.Decode(op, &app); err != nil {
+			return batch.Item[product.NewProduct]{Err: err}
+		}
+
+		run := func(ctx context.Context, tx transaction.Transaction) (any, error) {
+			prdCore, prd, err := h.batchProduct(c, tx, productID, op)
+			if err != nil {
+				return nil, err
+			}
+
+			prd, err = prdCore.Update(ctx, prd, toCoreUpdateProduct(app))
+			if err != nil {
+				return nil, fmt.Errorf("update: productID[%s]: %w", productID, err)
+			}
+
+			return toAppProduct(prd), nil
+		}
+
+		return batch.Item[product.NewProduct]{Op: op.Op, Run: run, Status: http.StatusOK}
+
+	case batch.OpDelete:
+		productID, err := uuid.Parse(op.ID)
+		if err != nil {
+			return batch.Item[product.NewProduct]{Err: validate.NewFieldsError("id", ErrInvalidID)}
+		}
+
+		run := func(ctx context.Context, tx transaction.Transaction) (any, error) {
+			prdCore, prd, err := h.batchProduct(c, tx, productID, op)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := prdCore.Delete(ctx, prd); err != nil {
+				return nil, fmt.Errorf("delete: productID[%s]: %w", productID, err)
+			}
+
+			return nil, nil
+		}
+
+		return batch.Item[product.NewProduct]{Op: op.Op, Run: run, Status: http.StatusNoContent}
+	}
+
+	return batch.Item[product.NewProduct]{Err: validate.NewFieldsError("op", batch.ErrUnknownOp)}
+}
+
+// batchProduct loads the product an operation acts on under the transaction
+// and checks the caller may change it.
+func (h *handlers) batchProduct(c *gin.Context, tx transaction.Transaction, productID uuid.UUID, op batch.Operation) (*product.Core, product.Product, error) {
+	prdCore, err := h.product.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, product.Product{}, err
+	}
+
+	prd, err := prdCore.QueryByID(c.Request.Context(), productID)
+	if err != nil {
+		return nil, product.Product{}, fmt.Errorf("querybyid: productID[%s]: %w", productID, err)
+	}
+
+	if err := mid.AuthorizeProductItem(c, h.auth, auth.RuleAdminOrSubject, prd); err != nil {
+		return nil, product.Product{}, wb.NewTrustedError(err, http.StatusForbidden)
+	}
+
+	if err := op.CheckVersion(prd.Version, product.ErrVersionConflict); err != nil {
+		return nil, product.Product{}, err
+	}
+
+	return prdCore, prd, nil
+}
+
+// createMany adds the new products of a batch under the transaction.
+func (h *handlers) createMany(ctx context.Context, tx transaction.Transaction, nps []product.NewProduct) ([]any, error) {
+	prdCore, err := h.product.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	prds, err := prdCore.CreateMany(ctx, nps)
+	if err != nil {
+		return nil, fmt.Errorf("createmany: %w", err)
+	}
+
+	data := make([]any, len(prds))
+	for i, prd := range prds {
+		data[i] = toAppProduct(prd)
+	}
+
+	return data, nil
+}
+
+// batchStatus maps the errors of a product operation to a status code.
+func batchStatus(err error) int {
+	switch {
+	case errors.Is(err, product.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, product.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, product.ErrInvalidCost),
+		errors.Is(err, product.ErrUserDisabled),
+		errors.Is(err, user.ErrNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, tenant.ErrForbidden):
+		return http.StatusForbidden
+	}
+
+	return http.StatusInternalServerError
+}
@@ -3,31 +3,47 @@ package productgrp
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/testvergecloud/testApi/business/core/crud/product"
+	"github.com/testvergecloud/testApi/business/web"
 	"github.com/testvergecloud/testApi/foundation/validate"
-
-	"github.com/google/uuid"
 )
 
 func parseFilter(r *http.Request) (product.QueryFilter, error) {
 	const (
-		filterByProdID   = "product_id"
-		filterByCost     = "cost"
-		filterByQuantity = "quantity"
-		filterByName     = "name"
+		filterByProdID           = "product_id"
+		filterByNotProdID        = "not_product_id"
+		filterByCost             = "cost"
+		filterByStartCost        = "start_cost"
+		filterByEndCost          = "end_cost"
+		filterByQuantity         = "quantity"
+		filterByName             = "name"
+		filterByNamePrefix       = "name_prefix"
+		filterByNotName          = "not_name"
+		filterByStartCreatedDate = "start_created_date"
+		filterByEndCreatedDate   = "end_created_date"
+		filterByIncludeDeleted   = "include_deleted"
 	)
 
 	values := r.URL.Query()
 
 	var filter product.QueryFilter
 
-	if productID := values.Get(filterByProdID); productID != "" {
-		id, err := uuid.Parse(productID)
+	if productIDs := values.Get(filterByProdID); productIDs != "" {
+		ids, err := web.ParseIDs(productIDs)
 		if err != nil {
 			return product.QueryFilter{}, validate.NewFieldsError(filterByProdID, err)
 		}
-		filter.WithProductID(id)
+		filter.WithProductIDs(ids)
+	}
+
+	if productIDs := values.Get(filterByNotProdID); productIDs != "" {
+		ids, err := web.ParseIDs(productIDs)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError(filterByNotProdID, err)
+		}
+		filter.WithoutProductIDs(ids)
 	}
 
 	if cost := values.Get(filterByCost); cost != "" {
@@ -38,6 +54,22 @@ func parseFilter(r *http.Request) (product.QueryFilter, error) {
 		filter.WithCost(cst)
 	}
 
+	if cost := values.Get(filterByStartCost); cost != "" {
+		cst, err := strconv.ParseFloat(cost, 64)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError(filterByStartCost, err)
+		}
+		filter.WithStartCost(cst)
+	}
+
+	if cost := values.Get(filterByEndCost); cost != "" {
+		cst, err := strconv.ParseFloat(cost, 64)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError(filterByEndCost, err)
+		}
+		filter.WithEndCost(cst)
+	}
+
 	if quantity := values.Get(filterByQuantity); quantity != "" {
 		qua, err := strconv.ParseInt(quantity, 10, 64)
 		if err != nil {
@@ -50,5 +82,41 @@ func parseFilter(r *http.Request) (product.QueryFilter, error) {
 		filter.WithName(name)
 	}
 
+	if prefix := values.Get(filterByNamePrefix); prefix != "" {
+		filter.WithNamePrefix(prefix)
+	}
+
+	if name := values.Get(filterByNotName); name != "" {
+		filter.WithoutName(name)
+	}
+
+	if createdDate := values.Get(filterByStartCreatedDate); createdDate != "" {
+		t, err := time.Parse(time.RFC3339, createdDate)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError(filterByStartCreatedDate, err)
+		}
+		filter.WithStartDateCreated(t)
+	}
+
+	if createdDate := values.Get(filterByEndCreatedDate); createdDate != "" {
+		t, err := time.Parse(time.RFC3339, createdDate)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError(filterByEndCreatedDate, err)
+		}
+		filter.WithEndCreatedDate(t)
+	}
+
+	if includeDeleted := values.Get(filterByIncludeDeleted); includeDeleted != "" {
+		inc, err := strconv.ParseBool(includeDeleted)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError(filterByIncludeDeleted, err)
+		}
+		filter.WithIncludeDeleted(inc)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return product.QueryFilter{}, err
+	}
+
 	return filter, nil
 }
@@ -12,10 +12,12 @@ import (
 // AppProduct represents information about an individual product.
 type AppProduct struct {
 	ID          string  `json:"id"`
+	TenantID    string  `json:"tenantID"`
 	UserID      string  `json:"userID"`
 	Name        string  `json:"name"`
 	Cost        float64 `json:"cost"`
 	Quantity    int     `json:"quantity"`
+	Version     int     `json:"version"`
 	DateCreated string  `json:"dateCreated"`
 	DateUpdated string  `json:"dateUpdated"`
 }
@@ -23,10 +25,12 @@ type AppProduct struct {
 func toAppProduct(prd product.Product) AppProduct {
 	return AppProduct{
 		ID:          prd.ID.String(),
+		TenantID:    prd.TenantID.String(),
 		UserID:      prd.UserID.String(),
 		Name:        prd.Name,
 		Cost:        prd.Cost,
 		Quantity:    prd.Quantity,
+		Version:     prd.Version,
 		DateCreated: prd.DateCreated.Format(time.RFC3339),
 		DateUpdated: prd.DateUpdated.Format(time.RFC3339),
 	}
@@ -9,9 +9,16 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/testvergecloud/testApi/business/core/crud/product"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/transaction"
 	wb "github.com/testvergecloud/testApi/business/web"
+	"github.com/testvergecloud/testApi/business/web/auth"
 	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/business/web/order"
 	"github.com/testvergecloud/testApi/business/web/page"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/google/uuid"
 )
 
 // Set of error variables for handling product group errors.
@@ -20,14 +27,24 @@ var (
 )
 
 type handlers struct {
-	product *product.Core
-	user    *user.Core
+	log            *logger.Logger
+	auth           *auth.Auth
+	bgn            transaction.Beginner
+	product        *product.Core
+	user           *user.Core
+	requireIfMatch bool
+	cursorKey      []byte
 }
 
-func new(product *product.Core, user *user.Core) *handlers {
+func new(log *logger.Logger, a *auth.Auth, bgn transaction.Beginner, product *product.Core, user *user.Core, requireIfMatch bool, cursorKey []byte) *handlers {
 	return &handlers{
-		product: product,
-		user:    user,
+		log:            log,
+		auth:           a,
+		bgn:            bgn,
+		product:        product,
+		user:           user,
+		requireIfMatch: requireIfMatch,
+		cursorKey:      cursorKey,
 	}
 }
 
@@ -40,11 +57,20 @@ func (h *handlers) create(c *gin.Context) error {
 	}
 
 	ctx := c.Request.Context()
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
 	prd, err := h.product.Create(ctx, toCoreNewProduct(c, app))
 	if err != nil {
+		// Recording the error rolls back the transaction.
+		c.Error(err)
 		return fmt.Errorf("create: app[%+v]: %w", app, err)
 	}
 
+	c.Header("ETag", wb.ETag(prd.Version))
 	c.JSON(http.StatusCreated, toAppProduct(prd))
 	return nil
 }
@@ -58,13 +84,32 @@ func (h *handlers) update(c *gin.Context) error {
 	}
 
 	ctx := c.Request.Context()
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
 	prd := mid.GetProduct(ctx)
 
+	if err := wb.CheckIfMatch(c.Request, prd.Version, h.requireIfMatch); err != nil {
+		c.JSON(wb.GetTrustedError(err).Status, gin.H{"error": err.Error()})
+		return err
+	}
+
 	updPrd, err := h.product.Update(ctx, prd, toCoreUpdateProduct(app))
 	if err != nil {
+		if errors.Is(err, product.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusPreconditionFailed)
+		}
+
+		// Recording the error rolls back the transaction.
+		c.Error(err)
 		return fmt.Errorf("update: productID[%s] app[%+v]: %w", prd.ID, app, err)
 	}
 
+	c.Header("ETag", wb.ETag(updPrd.Version))
 	c.JSON(http.StatusOK, toAppProduct(updPrd))
 	return nil
 }
@@ -72,9 +117,17 @@ func (h *handlers) update(c *gin.Context) error {
 // delete removes a product from the system.
 func (h *handlers) delete(c *gin.Context) error {
 	ctx := c.Request.Context()
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
 	prd := mid.GetProduct(ctx)
 
 	if err := h.product.Delete(ctx, prd); err != nil {
+		// Recording the error rolls back the transaction.
+		c.Error(err)
 		return fmt.Errorf("delete: productID[%s]: %w", prd.ID, err)
 	}
 
@@ -82,8 +135,50 @@ func (h *handlers) delete(c *gin.Context) error {
 	return nil
 }
 
+// restore brings back a product that was deleted.
+func (h *handlers) restore(c *gin.Context) error {
+	productID, err := uuid.Parse(c.Param("product_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidID.Error()})
+		return validate.NewFieldsError("product_id", ErrInvalidID)
+	}
+
+	ctx := c.Request.Context()
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
+	prd, err := h.product.Restore(ctx, productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusNotFound)
+		case errors.Is(err, product.ErrNotDeleted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusConflict)
+		}
+
+		// Recording the error rolls back the transaction.
+		c.Error(err)
+		return fmt.Errorf("restore: productID[%s]: %w", productID, err)
+	}
+
+	c.Header("ETag", wb.ETag(prd.Version))
+	c.JSON(http.StatusOK, toAppProduct(prd))
+	return nil
+}
+
 // query returns a list of products with paging.
 func (h *handlers) query(c *gin.Context) error {
+	cursor, keyset, err := page.ParseCursor(c.Request, h.cursorKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
 	page, err := page.Parse(c.Request)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -102,6 +197,10 @@ func (h *handlers) query(c *gin.Context) error {
 		return err
 	}
 
+	if keyset {
+		return h.queryByCursor(c, filter, orderBy, cursor, page.RowsPerPage)
+	}
+
 	ctx := c.Request.Context()
 	prds, err := h.product.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
 	if err != nil {
@@ -117,8 +216,35 @@ func (h *handlers) query(c *gin.Context) error {
 	return nil
 }
 
+// queryByCursor returns a list of products using keyset paging. The order of
+// a non empty cursor takes precedence over the order requested.
+func (h *handlers) queryByCursor(c *gin.Context, filter product.QueryFilter, orderBy order.By, cursor page.Cursor, rowsPerPage int) error {
+	if !cursor.IsZero() {
+		orderBy = cursor.OrderBy
+	}
+
+	ctx := c.Request.Context()
+	prds, cursors, err := h.product.QueryByCursor(ctx, filter, orderBy, cursor, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("querybycursor: %w", err)
+	}
+
+	total, err := h.product.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	next, prev := cursors.Encode(h.cursorKey)
+
+	c.JSON(http.StatusOK, wb.NewCursorDocument(toAppProducts(prds), total, rowsPerPage, next, prev))
+	return nil
+}
+
 // queryByID returns a product by its ID.
 func (h *handlers) queryByID(c *gin.Context) error {
-	c.JSON(http.StatusOK, toAppProduct(mid.GetProduct(c.Request.Context())))
+	prd := mid.GetProduct(c.Request.Context())
+
+	c.Header("ETag", wb.ETag(prd.Version))
+	c.JSON(http.StatusOK, toAppProduct(prd))
 	return nil
 }
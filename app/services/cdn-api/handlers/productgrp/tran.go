@@ -0,0 +1,37 @@
+package productgrp
+
+import (
+	"context"
+
+	"github.com/testvergecloud/testApi/business/data/transaction"
+)
+
+// executeUnderTransaction constructs a new Handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *handlers) executeUnderTransaction(ctx context.Context) (*handlers, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		product, err := h.product.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		user, err := h.user.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		handlers := handlers{
+			log:            h.log,
+			auth:           h.auth,
+			bgn:            h.bgn,
+			product:        product,
+			user:           user,
+			requireIfMatch: h.requireIfMatch,
+			cursorKey:      h.cursorKey,
+		}
+
+		return &handlers, nil
+	}
+
+	return h, nil
+}
@@ -0,0 +1,89 @@
+package rolegrp
+
+import (
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/role"
+	"github.com/testvergecloud/testApi/foundation/validate"
+)
+
+// AppRole represents information about a role.
+type AppRole struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	BuiltIn     bool     `json:"builtIn"`
+	DateCreated string   `json:"dateCreated"`
+	DateUpdated string   `json:"dateUpdated"`
+}
+
+func toAppRole(r role.Role) AppRole {
+	perms := r.Permissions
+	if perms == nil {
+		perms = []string{}
+	}
+
+	return AppRole{
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: perms,
+		BuiltIn:     r.BuiltIn,
+		DateCreated: r.DateCreated.Format(time.RFC3339),
+		DateUpdated: r.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppRoles(roles []role.Role) []AppRole {
+	items := make([]AppRole, len(roles))
+	for i, r := range roles {
+		items[i] = toAppRole(r)
+	}
+
+	return items
+}
+
+// AppNewRole defines the data needed to add a new role.
+type AppNewRole struct {
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+func toCoreNewRole(app AppNewRole) role.NewRole {
+	return role.NewRole{
+		Name:        app.Name,
+		Description: app.Description,
+		Permissions: app.Permissions,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewRole) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AppUpdateRole defines the data needed to update a role.
+type AppUpdateRole struct {
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+func toCoreUpdateRole(app AppUpdateRole) role.UpdateRole {
+	return role.UpdateRole{
+		Description: app.Description,
+		Permissions: app.Permissions,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateRole) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
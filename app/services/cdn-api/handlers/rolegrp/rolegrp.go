@@ -0,0 +1,146 @@
+// Package rolegrp maintains the group of handlers for administering the roles
+// users are assigned and the permissions they grant.
+package rolegrp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/role"
+	wb "github.com/testvergecloud/testApi/business/web"
+)
+
+type handlers struct {
+	role *role.Core
+}
+
+func new(role *role.Core) *handlers {
+	return &handlers{
+		role: role,
+	}
+}
+
+// create adds a new role to the system.
+func (h *handlers) create(c *gin.Context) error {
+	var app AppNewRole
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if err := app.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	r, err := h.role.Create(c.Request.Context(), toCoreNewRole(app))
+	if err != nil {
+		if isRoleError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusBadRequest)
+		}
+		if errors.Is(err, role.ErrUniqueName) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("create: name[%s]: %w", app.Name, err)
+	}
+
+	c.JSON(http.StatusCreated, toAppRole(r))
+	return nil
+}
+
+// update modifies the description and permissions of a role.
+func (h *handlers) update(c *gin.Context) error {
+	var app AppUpdateRole
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if err := app.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	ctx := c.Request.Context()
+	r, err := h.role.QueryByName(ctx, c.Param("role_name"))
+	if err != nil {
+		if errors.Is(err, role.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusNotFound)
+		}
+		return fmt.Errorf("querybyname: %w", err)
+	}
+
+	r, err = h.role.Update(ctx, r, toCoreUpdateRole(app))
+	if err != nil {
+		if isRoleError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusBadRequest)
+		}
+		return fmt.Errorf("update: name[%s]: %w", r.Name, err)
+	}
+
+	c.JSON(http.StatusOK, toAppRole(r))
+	return nil
+}
+
+// delete removes a role from the system and from the users assigned to it.
+func (h *handlers) delete(c *gin.Context) error {
+	ctx := c.Request.Context()
+	r, err := h.role.QueryByName(ctx, c.Param("role_name"))
+	if err != nil {
+		if errors.Is(err, role.ErrNotFound) {
+			c.JSON(http.StatusNoContent, nil)
+			return nil
+		}
+		return fmt.Errorf("querybyname: %w", err)
+	}
+
+	if err := h.role.Delete(ctx, r); err != nil {
+		if errors.Is(err, role.ErrBuiltIn) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("delete: name[%s]: %w", r.Name, err)
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+	return nil
+}
+
+// query returns all the roles.
+func (h *handlers) query(c *gin.Context) error {
+	roles, err := h.role.QueryAll(c.Request.Context())
+	if err != nil {
+		return fmt.Errorf("queryall: %w", err)
+	}
+
+	c.JSON(http.StatusOK, toAppRoles(roles))
+	return nil
+}
+
+// queryByName returns a role by its name.
+func (h *handlers) queryByName(c *gin.Context) error {
+	r, err := h.role.QueryByName(c.Request.Context(), c.Param("role_name"))
+	if err != nil {
+		if errors.Is(err, role.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusNotFound)
+		}
+		return fmt.Errorf("querybyname: %w", err)
+	}
+
+	c.JSON(http.StatusOK, toAppRole(r))
+	return nil
+}
+
+// isRoleError reports whether the error is the result of presenting a role
+// that isn't valid.
+func isRoleError(err error) bool {
+	return errors.Is(err, role.ErrInvalidName) ||
+		errors.Is(err, role.ErrInvalidPermission)
+}
@@ -0,0 +1,51 @@
+package rolegrp
+
+import (
+	"net/http"
+
+	"github.com/testvergecloud/testApi/business/core/crud/role"
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/web"
+)
+
+// PermissionRead is the permission required to read the roles.
+const PermissionRead = "roles:read"
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Log  *logger.Logger
+	Role *role.Core
+	Auth *auth.Auth
+}
+
+// Routes adds specific routes for this group.
+func Routes(app *web.App, cfg Config) {
+	const version = "/v1"
+
+	hdl := new(cfg.Role)
+	v1 := app.Mux.Group(version)
+	{
+		read := v1.Group("/roles")
+		{
+			read.Use(mid.Authenticate(cfg.Auth))
+			read.Use(mid.AuthorizePermission(cfg.Auth, PermissionRead))
+
+			app.Handle(http.MethodGet, read, "", hdl.query)
+			app.Handle(http.MethodGet, read, "/:role_name", hdl.queryByName)
+		}
+
+		// Roles are shared by every organization, so only super admins can
+		// change them.
+		write := v1.Group("/roles")
+		{
+			write.Use(mid.Authenticate(cfg.Auth))
+			write.Use(mid.Authorize(cfg.Auth, auth.RuleSuperAdmin))
+
+			app.Handle(http.MethodPost, write, "", hdl.create)
+			app.Handle(http.MethodPut, write, "/:role_name", hdl.update)
+			app.Handle(http.MethodDelete, write, "/:role_name", hdl.delete)
+		}
+	}
+}
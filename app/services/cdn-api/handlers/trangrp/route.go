@@ -3,14 +3,18 @@ package trangrp
 import (
 	"net/http"
 
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/business/core/crud/audit/stores/auditdb"
 	"github.com/testvergecloud/testApi/business/core/crud/delegate"
 	"github.com/testvergecloud/testApi/business/core/crud/product"
 	"github.com/testvergecloud/testApi/business/core/crud/product/stores/productdb"
+	"github.com/testvergecloud/testApi/business/core/crud/role"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/core/crud/user/stores/usercache"
 	"github.com/testvergecloud/testApi/business/core/crud/user/stores/userdb"
 	"github.com/testvergecloud/testApi/business/data/sqldb"
 	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/idempotency"
 	"github.com/testvergecloud/testApi/business/web/mid"
 	"github.com/testvergecloud/testApi/foundation/logger"
 	"github.com/testvergecloud/testApi/foundation/web"
@@ -20,24 +24,32 @@ import (
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log      *logger.Logger
-	Delegate *delegate.Delegate
-	Auth     *auth.Auth
-	DB       *sqlx.DB
+	Log         *logger.Logger
+	Delegate    *delegate.Delegate
+	Auth        *auth.Auth
+	DB          *sqlx.DB
+	Role        *role.Core
+	Idempotency *idempotency.Core
 }
 
 // Routes adds specific routes for this group.
 func Routes(app *web.App, cfg Config) {
 	const version = "/v1"
 
-	usrCore := user.NewCore(cfg.Log, cfg.Delegate, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB)))
-	prdCore := product.NewCore(cfg.Log, usrCore, cfg.Delegate, productdb.NewStore(cfg.Log, cfg.DB))
+	audCore := audit.NewCore(cfg.Log, auditdb.NewStore(cfg.Log, cfg.DB))
+	usrCore := user.NewCore(cfg.Log, cfg.Delegate, audCore, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB)))
+	usrCore.Roles = cfg.Role
+
+	// The product functions are registered with the delegate by the product
+	// group, registering them again would deliver every event to them twice.
+	prdCore := product.NewCore(cfg.Log, usrCore, nil, audCore, productdb.NewStore(cfg.Log, cfg.DB))
 
 	hdl := new(usrCore, prdCore)
 	v1 := app.Mux.Group(version)
 	{
 		v1.Use(mid.Authenticate(cfg.Auth))
 		v1.Use(mid.ExecuteInTransaction(cfg.Log, sqldb.NewBeginner(cfg.DB)))
+		v1.Use(mid.Idempotency(cfg.Idempotency))
 		app.Handle(http.MethodPost, v1, "/tranexample", hdl.create)
 	}
 }
@@ -0,0 +1,179 @@
+package usergrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	wb "github.com/testvergecloud/testApi/business/web"
+	"github.com/testvergecloud/testApi/business/web/batch"
+	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/google/uuid"
+)
+
+// batch executes a batch of user operations.
+func (h *handlers) batch(c *gin.Context) error {
+	var req batch.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return wb.NewTrustedError(err, http.StatusBadRequest)
+	}
+
+	items := make([]batch.Item[user.NewUser], len(req.Operations))
+	for i, op := range req.Operations {
+		items[i] = h.batchItem(op)
+	}
+
+	b := batch.Batch[user.NewUser]{
+		Log:        h.log,
+		Beginner:   h.bgn,
+		CreateMany: h.createMany,
+		Status:     batchStatus,
+	}
+
+	results := b.Execute(c.Request.Context(), req.Atomic, items)
+
+	c.JSON(batch.StatusCode(req.Atomic, results), batch.Response{Results: results})
+	return nil
+}
+
+// batchItem prepares the operation for execution.
+func (h *handlers) batchItem(op batch.Operation) batch.Item[user.NewUser] {
+	switch op.Op {
+	case batch.OpCreate:
+		var app AppNewUser
+		if err := batch.Decode(op, &app); err != nil {
+			return batch.Item[user.NewUser]{Err: err}
+		}
+
+		nu, err := toCoreNewUser(app)
+		if err != nil {
+			return batch.Item[user.NewUser]{Err: wb.NewTrustedError(err, http.StatusBadRequest)}
+		}
+
+		return batch.Item[user.NewUser]{Op: op.Op, New: nu, Status: http.StatusCreated}
+
+	case batch.OpUpdate:
+		userID, err := uuid.Parse(op.ID)
+		if err != nil {
+			return batch.Item[user.NewUser]{Err: validate.NewFieldsError("id", err)}
+		}
+
+		var app AppUpdateUser
+		if err := batch.Decode(op, &app); err != nil {
+			return batch.Item[user.NewUser]{Err: err}
+		}
+
+		uu, err := toCoreUpdateUser(app)
+		if err != nil {
+			return batch.Item[user.NewUser]{Err: wb.NewTrustedError(err, http.StatusBadRequest)}
+		}
+
+		run := func(ctx context.Context, tx transaction.Transaction) (any, error) {
+			usrCore, usr, err := h.batchUser(ctx, tx, userID, op)
+			if err != nil {
+				return nil, err
+			}
+
+			usr, err = usrCore.Update(ctx, usr, uu)
+			if err != nil {
+				return nil, fmt.Errorf("update: userID[%s]: %w", userID, err)
+			}
+
+			return toAppUser(usr), nil
+		}
+
+		return batch.Item[user.NewUser]{Op: op.Op, Run: run, Status: http.StatusOK}
+
+	case batch.OpDelete:
+		userID, err := uuid.Parse(op.ID)
+		if err != nil {
+			return batch.Item[user.NewUser]{Err: validate.NewFieldsError("id", err)}
+		}
+
+		run := func(ctx context.Context, tx transaction.Transaction) (any, error) {
+			usrCore, usr, err := h.batchUser(ctx, tx, userID, op)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := usrCore.Delete(ctx, usr); err != nil {
+				return nil, fmt.Errorf("delete: userID[%s]: %w", userID, err)
+			}
+
+			return nil, nil
+		}
+
+		return batch.Item[user.NewUser]{Op: op.Op, Run: run, Status: http.StatusNoContent}
+	}
+
+	return batch.Item[user.NewUser]{Err: validate.NewFieldsError("op", batch.ErrUnknownOp)}
+}
+
+// batchUser loads the user an operation acts on under the transaction.
+func (h *handlers) batchUser(ctx context.Context, tx transaction.Transaction, userID uuid.UUID, op batch.Operation) (*user.Core, user.User, error) {
+	usrCore, err := h.user.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, user.User{}, err
+	}
+
+	usr, err := usrCore.QueryByID(ctx, userID)
+	if err != nil {
+		return nil, user.User{}, fmt.Errorf("querybyid: userID[%s]: %w", userID, err)
+	}
+
+	if err := op.CheckVersion(usr.Version, user.ErrVersionConflict); err != nil {
+		return nil, user.User{}, err
+	}
+
+	return usrCore, usr, nil
+}
+
+// createMany adds the new users of a batch under the transaction.
+func (h *handlers) createMany(ctx context.Context, tx transaction.Transaction, nus []user.NewUser) ([]any, error) {
+	usrCore, err := h.user.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	usrs, err := usrCore.CreateMany(ctx, nus)
+	if err != nil {
+		return nil, fmt.Errorf("createmany: %w", err)
+	}
+
+	data := make([]any, len(usrs))
+	for i, usr := range usrs {
+		data[i] = toAppUser(usr)
+	}
+
+	return data, nil
+}
+
+// batchStatus maps the errors of a user operation to a status code.
+func batchStatus(err error) int {
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, user.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, user.ErrUniqueEmail):
+		return http.StatusConflict
+	case errors.Is(err, user.ErrUnknownRole):
+		return http.StatusBadRequest
+	case errors.Is(err, tenant.ErrForbidden), errors.Is(err, user.ErrSuperAdminRole):
+		return http.StatusForbidden
+	}
+
+	return http.StatusInternalServerError
+}
@@ -3,33 +3,45 @@ package usergrp
 import (
 	"net/http"
 	"net/mail"
+	"strconv"
 	"time"
 
 	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/web"
 	"github.com/testvergecloud/testApi/foundation/validate"
-
-	"github.com/google/uuid"
 )
 
 func parseFilter(r *http.Request) (user.QueryFilter, error) {
 	const (
 		filterByUserID           = "user_id"
+		filterByNotUserID        = "not_user_id"
 		filterByEmail            = "email"
 		filterByStartCreatedDate = "start_created_date"
 		filterByEndCreatedDate   = "end_created_date"
 		filterByName             = "name"
+		filterByNamePrefix       = "name_prefix"
+		filterByNotName          = "not_name"
+		filterByIncludeDeleted   = "include_deleted"
 	)
 
 	values := r.URL.Query()
 
 	var filter user.QueryFilter
 
-	if userID := values.Get(filterByUserID); userID != "" {
-		id, err := uuid.Parse(userID)
+	if userIDs := values.Get(filterByUserID); userIDs != "" {
+		ids, err := web.ParseIDs(userIDs)
 		if err != nil {
 			return user.QueryFilter{}, validate.NewFieldsError(filterByUserID, err)
 		}
-		filter.WithUserID(id)
+		filter.WithUserIDs(ids)
+	}
+
+	if userIDs := values.Get(filterByNotUserID); userIDs != "" {
+		ids, err := web.ParseIDs(userIDs)
+		if err != nil {
+			return user.QueryFilter{}, validate.NewFieldsError(filterByNotUserID, err)
+		}
+		filter.WithoutUserIDs(ids)
 	}
 
 	if email := values.Get(filterByEmail); email != "" {
@@ -60,5 +72,25 @@ func parseFilter(r *http.Request) (user.QueryFilter, error) {
 		filter.WithName(name)
 	}
 
+	if prefix := values.Get(filterByNamePrefix); prefix != "" {
+		filter.WithNamePrefix(prefix)
+	}
+
+	if name := values.Get(filterByNotName); name != "" {
+		filter.WithoutName(name)
+	}
+
+	if includeDeleted := values.Get(filterByIncludeDeleted); includeDeleted != "" {
+		inc, err := strconv.ParseBool(includeDeleted)
+		if err != nil {
+			return user.QueryFilter{}, validate.NewFieldsError(filterByIncludeDeleted, err)
+		}
+		filter.WithIncludeDeleted(inc)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return user.QueryFilter{}, err
+	}
+
 	return filter, nil
 }
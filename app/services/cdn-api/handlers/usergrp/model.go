@@ -7,17 +7,23 @@ import (
 
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/google/uuid"
 )
 
 // AppUser represents information about an individual user.
 type AppUser struct {
 	ID           string   `json:"id"`
+	TenantID     string   `json:"tenantID"`
 	Name         string   `json:"name"`
 	Email        string   `json:"email"`
 	Roles        []string `json:"roles"`
 	PasswordHash []byte   `json:"-"`
 	Department   string   `json:"department"`
 	Enabled      bool     `json:"enabled"`
+	FailedLogins int      `json:"failedLogins"`
+	LockedUntil  string   `json:"lockedUntil,omitempty"`
+	Version      int      `json:"version"`
 	DateCreated  string   `json:"dateCreated"`
 	DateUpdated  string   `json:"dateUpdated"`
 }
@@ -28,14 +34,23 @@ func toAppUser(usr user.User) AppUser {
 		roles[i] = role.Name()
 	}
 
+	var lockedUntil string
+	if usr.IsLocked(time.Now()) {
+		lockedUntil = usr.LockedUntil.Format(time.RFC3339)
+	}
+
 	return AppUser{
 		ID:           usr.ID.String(),
+		TenantID:     usr.TenantID.String(),
 		Name:         usr.Name,
 		Email:        usr.Email.Address,
 		Roles:        roles,
 		PasswordHash: usr.PasswordHash,
 		Department:   usr.Department,
 		Enabled:      usr.Enabled,
+		FailedLogins: usr.FailedLogins,
+		LockedUntil:  lockedUntil,
+		Version:      usr.Version,
 		DateCreated:  usr.DateCreated.Format(time.RFC3339),
 		DateUpdated:  usr.DateUpdated.Format(time.RFC3339),
 	}
@@ -50,8 +65,10 @@ func toAppUsers(users []user.User) []AppUser {
 	return items
 }
 
-// AppNewUser defines the data needed to add a new user.
+// AppNewUser defines the data needed to add a new user. The user joins the
+// tenant of the caller unless another tenant is requested.
 type AppNewUser struct {
+	TenantID        string   `json:"tenantID" validate:"omitempty,uuid"`
 	Name            string   `json:"name" validate:"required"`
 	Email           string   `json:"email" validate:"required,email"`
 	Roles           []string `json:"roles" validate:"required"`
@@ -75,7 +92,16 @@ func toCoreNewUser(app AppNewUser) (user.NewUser, error) {
 		return user.NewUser{}, fmt.Errorf("parse: %w", err)
 	}
 
+	var tenantID uuid.UUID
+	if app.TenantID != "" {
+		tenantID, err = uuid.Parse(app.TenantID)
+		if err != nil {
+			return user.NewUser{}, fmt.Errorf("parse: %w", err)
+		}
+	}
+
 	usr := user.NewUser{
+		TenantID:        tenantID,
 		Name:            app.Name,
 		Email:           *addr,
 		Roles:           roles,
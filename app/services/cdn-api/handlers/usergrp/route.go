@@ -3,12 +3,18 @@ package usergrp
 import (
 	"net/http"
 
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/business/core/crud/audit/stores/auditdb"
 	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	"github.com/testvergecloud/testApi/business/core/crud/role"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/core/crud/user/stores/usercache"
 	"github.com/testvergecloud/testApi/business/core/crud/user/stores/userdb"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
 	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/idempotency"
 	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/business/web/ratelimit"
 	"github.com/testvergecloud/testApi/foundation/logger"
 	"github.com/testvergecloud/testApi/foundation/web"
 
@@ -17,24 +23,38 @@ import (
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log      *logger.Logger
-	Delegate *delegate.Delegate
-	Auth     *auth.Auth
-	DB       *sqlx.DB
+	Log            *logger.Logger
+	Delegate       *delegate.Delegate
+	Auth           *auth.Auth
+	DB             *sqlx.DB
+	RequireIfMatch bool
+	CursorKey      []byte
+	Role           *role.Core
+	RateLimit      *ratelimit.Limiter
+	Idempotency    *idempotency.Core
 }
 
 // Routes adds specific routes for this group.
 func Routes(app *web.App, cfg Config) {
 	const version = "/v1"
 
-	usrCore := user.NewCore(cfg.Log, cfg.Delegate, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB)))
+	audCore := audit.NewCore(cfg.Log, auditdb.NewStore(cfg.Log, cfg.DB))
+	usrCore := user.NewCore(cfg.Log, cfg.Delegate, audCore, usercache.NewStore(cfg.Log, userdb.NewStore(cfg.Log, cfg.DB)))
+	usrCore.Roles = cfg.Role
 
-	hdl := new(usrCore, cfg.Auth)
+	hdl := new(cfg.Log, sqldb.NewBeginner(cfg.DB), usrCore, cfg.Auth, cfg.RequireIfMatch, cfg.CursorKey)
 	v1 := app.Mux.Group(version)
 	{
+		// Tokens are issued for a password, the tighter quota keeps the
+		// passwords from being guessed.
 		noAuth := v1.Group("/users")
 		{
-			app.Handle(http.MethodGet, noAuth, "/token/{kid}", hdl.token)
+			noAuth.Use(mid.RateLimit(cfg.Auth, cfg.RateLimit, ratelimit.Token))
+
+			app.Handle(http.MethodGet, noAuth, "/token", hdl.token)
+
+			// Kept for the clients that still pick the kid.
+			app.Handle(http.MethodGet, noAuth, "/token/:kid", hdl.token)
 		}
 
 		ruleAdmin := v1.Group("/users")
@@ -43,17 +63,56 @@ func Routes(app *web.App, cfg Config) {
 			ruleAdmin.Use(mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
 
 			app.Handle(http.MethodGet, ruleAdmin, "", hdl.query)
-			app.Handle(http.MethodPost, ruleAdmin, "", hdl.create)
 		}
 
-		ruleAdminOrSubject := v1.Group("/users").Group("/{user_id}")
+		// Changes run under a transaction so the user change, the events and
+		// the audit entry it produces are committed together. Retried posts
+		// are replayed from the idempotency key stored in it.
+		ruleAdminTran := v1.Group("/users")
+		{
+			ruleAdminTran.Use(mid.Authenticate(cfg.Auth))
+			ruleAdminTran.Use(mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+			ruleAdminTran.Use(mid.ExecuteInTransaction(cfg.Log, sqldb.NewBeginner(cfg.DB)))
+			ruleAdminTran.Use(mid.Idempotency(cfg.Idempotency))
+
+			app.Handle(http.MethodPost, ruleAdminTran, "", hdl.create)
+			app.Handle(http.MethodPost, ruleAdminTran, "/:user_id/restore", hdl.restore)
+			app.Handle(http.MethodPost, ruleAdminTran, "/:user_id/unlock", hdl.unlock)
+		}
+
+		// A batch runs its operations under the transactions it asks for.
+		ruleAdminBatch := v1.Group("")
+		{
+			ruleAdminBatch.Use(mid.Authenticate(cfg.Auth))
+			ruleAdminBatch.Use(mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+
+			app.HandleCustomMethod(http.MethodPost, ruleAdminBatch, "/users", "batch", hdl.batch)
+		}
+
+		ruleManager := v1.Group("/users").Group("/:user_id")
+		{
+			ruleManager.Use(mid.Authenticate(cfg.Auth))
+			ruleManager.Use(mid.AuthorizeUser(cfg.Auth, auth.RuleAdminSubjectOrManager, usrCore))
+
+			app.Handle(http.MethodGet, ruleManager, "", hdl.queryByID)
+		}
+
+		ruleManagerTran := v1.Group("/users").Group("/:user_id")
+		{
+			ruleManagerTran.Use(mid.Authenticate(cfg.Auth))
+			ruleManagerTran.Use(mid.AuthorizeUser(cfg.Auth, auth.RuleAdminSubjectOrManager, usrCore))
+			ruleManagerTran.Use(mid.ExecuteInTransaction(cfg.Log, sqldb.NewBeginner(cfg.DB)))
+
+			app.Handle(http.MethodPut, ruleManagerTran, "", hdl.update)
+		}
+
+		ruleAdminOrSubjectTran := v1.Group("/users").Group("/:user_id")
 		{
-			ruleAdminOrSubject.Use(mid.Authenticate(cfg.Auth))
-			ruleAdminOrSubject.Use(mid.AuthorizeUser(cfg.Auth, auth.RuleAdminOrSubject, usrCore))
+			ruleAdminOrSubjectTran.Use(mid.Authenticate(cfg.Auth))
+			ruleAdminOrSubjectTran.Use(mid.AuthorizeUser(cfg.Auth, auth.RuleAdminOrSubject, usrCore))
+			ruleAdminOrSubjectTran.Use(mid.ExecuteInTransaction(cfg.Log, sqldb.NewBeginner(cfg.DB)))
 
-			app.Handle(http.MethodGet, ruleAdminOrSubject, "", hdl.queryByID)
-			app.Handle(http.MethodPut, ruleAdminOrSubject, "", hdl.update)
-			app.Handle(http.MethodDelete, ruleAdminOrSubject, "", hdl.delete)
+			app.Handle(http.MethodDelete, ruleAdminOrSubjectTran, "", hdl.delete)
 		}
 	}
 }
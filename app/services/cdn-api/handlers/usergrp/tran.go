@@ -0,0 +1,31 @@
+package usergrp
+
+import (
+	"context"
+
+	"github.com/testvergecloud/testApi/business/data/transaction"
+)
+
+// executeUnderTransaction constructs a new Handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *handlers) executeUnderTransaction(ctx context.Context) (*handlers, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		user, err := h.user.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		handlers := handlers{
+			log:            h.log,
+			bgn:            h.bgn,
+			user:           user,
+			auth:           h.auth,
+			requireIfMatch: h.requireIfMatch,
+			cursorKey:      h.cursorKey,
+		}
+
+		return &handlers, nil
+	}
+
+	return h, nil
+}
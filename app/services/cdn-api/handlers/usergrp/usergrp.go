@@ -4,30 +4,45 @@ package usergrp
 import (
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"net/mail"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/business/data/transaction"
 	wb "github.com/testvergecloud/testApi/business/web"
 	"github.com/testvergecloud/testApi/business/web/auth"
 	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/business/web/order"
 	"github.com/testvergecloud/testApi/business/web/page"
+	"github.com/testvergecloud/testApi/foundation/logger"
 	"github.com/testvergecloud/testApi/foundation/validate"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
 type handlers struct {
-	user *user.Core
-	auth *auth.Auth
+	log            *logger.Logger
+	bgn            transaction.Beginner
+	user           *user.Core
+	auth           *auth.Auth
+	requireIfMatch bool
+	cursorKey      []byte
 }
 
-func new(user *user.Core, auth *auth.Auth) *handlers {
+func new(log *logger.Logger, bgn transaction.Beginner, user *user.Core, auth *auth.Auth, requireIfMatch bool, cursorKey []byte) *handlers {
 	return &handlers{
-		user: user,
-		auth: auth,
+		log:            log,
+		bgn:            bgn,
+		user:           user,
+		auth:           auth,
+		requireIfMatch: requireIfMatch,
+		cursorKey:      cursorKey,
 	}
 }
 
@@ -45,15 +60,33 @@ func (h *handlers) create(c *gin.Context) error {
 		return err
 	}
 
-	usr, err := h.user.Create(c.Request.Context(), nc)
+	ctx := c.Request.Context()
+	h, err = h.executeUnderTransaction(ctx)
 	if err != nil {
-		if errors.Is(err, user.ErrUniqueEmail) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
+	usr, err := h.user.Create(ctx, nc)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrUniqueEmail):
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			return err
+		case errors.Is(err, user.ErrUnknownRole):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusBadRequest)
+		case errors.Is(err, user.ErrSuperAdminRole), errors.Is(err, tenant.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusForbidden)
 		}
+
+		// Recording the error rolls back the transaction.
+		c.Error(err)
 		return fmt.Errorf("create: usr[%+v]: %w", usr, err)
 	}
 
+	c.Header("ETag", wb.ETag(usr.Version))
 	c.JSON(http.StatusCreated, toAppUser(usr))
 	return nil
 }
@@ -72,14 +105,57 @@ func (h *handlers) update(c *gin.Context) error {
 		return err
 	}
 
+	// Managers and the users themselves may edit the profile, only admins
+	// can change what a user is allowed to do. A password is changed by the
+	// user or an admin, never by the manager of the user.
+	if uu.Roles != nil || uu.Department != nil || uu.Enabled != nil {
+		if err := mid.AuthorizeRule(c, h.auth, auth.RuleAdminOnly); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusForbidden)
+		}
+	}
+
+	if uu.Password != nil {
+		if err := mid.AuthorizeRule(c, h.auth, auth.RuleAdminOrSubject); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusForbidden)
+		}
+	}
+
 	ctx := c.Request.Context()
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
 	usr := mid.GetUser(c)
 
+	if err := wb.CheckIfMatch(c.Request, usr.Version, h.requireIfMatch); err != nil {
+		c.JSON(wb.GetTrustedError(err).Status, gin.H{"error": err.Error()})
+		return err
+	}
+
 	updUsr, err := h.user.Update(ctx, usr, uu)
 	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrVersionConflict):
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusPreconditionFailed)
+		case errors.Is(err, user.ErrUnknownRole):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusBadRequest)
+		case errors.Is(err, user.ErrSuperAdminRole):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusForbidden)
+		}
+
+		// Recording the error rolls back the transaction.
+		c.Error(err)
 		return fmt.Errorf("update: userID[%s] uu[%+v]: %w", usr.ID, uu, err)
 	}
 
+	c.Header("ETag", wb.ETag(updUsr.Version))
 	c.JSON(http.StatusOK, toAppUser(updUsr))
 	return nil
 }
@@ -87,9 +163,17 @@ func (h *handlers) update(c *gin.Context) error {
 // delete removes a user from the system.
 func (h *handlers) delete(c *gin.Context) error {
 	ctx := c.Request.Context()
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
 	usr := mid.GetUser(c)
 
-	if err := h.user.Delete(ctx, mid.GetUser(c)); err != nil {
+	if err := h.user.Delete(ctx, usr); err != nil {
+		// Recording the error rolls back the transaction.
+		c.Error(err)
 		return fmt.Errorf("delete: userID[%s]: %w", usr.ID, err)
 	}
 
@@ -97,8 +181,89 @@ func (h *handlers) delete(c *gin.Context) error {
 	return nil
 }
 
+// restore brings back a user that was deleted.
+func (h *handlers) restore(c *gin.Context) error {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return validate.NewFieldsError("user_id", err)
+	}
+
+	ctx := c.Request.Context()
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
+	usr, err := h.user.Restore(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusNotFound)
+		case errors.Is(err, user.ErrNotDeleted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusConflict)
+		case errors.Is(err, user.ErrUniqueEmail):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusConflict)
+		}
+
+		// Recording the error rolls back the transaction.
+		c.Error(err)
+		return fmt.Errorf("restore: userID[%s]: %w", userID, err)
+	}
+
+	c.Header("ETag", wb.ETag(usr.Version))
+	c.JSON(http.StatusOK, toAppUser(usr))
+	return nil
+}
+
+// unlock clears the failed logins of a user so they can log in right away.
+func (h *handlers) unlock(c *gin.Context) error {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return validate.NewFieldsError("user_id", err)
+	}
+
+	ctx := c.Request.Context()
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return err
+	}
+
+	usr, err := h.user.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusNotFound)
+		}
+		return fmt.Errorf("querybyid: userID[%s]: %w", userID, err)
+	}
+
+	usr, err = h.user.Unlock(ctx, usr)
+	if err != nil {
+		// Recording the error rolls back the transaction.
+		c.Error(err)
+		return fmt.Errorf("unlock: userID[%s]: %w", userID, err)
+	}
+
+	c.Header("ETag", wb.ETag(usr.Version))
+	c.JSON(http.StatusOK, toAppUser(usr))
+	return nil
+}
+
 // query returns a list of users with paging.
 func (h *handlers) query(c *gin.Context) error {
+	cursor, keyset, err := page.ParseCursor(c.Request, h.cursorKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
 	page, err := page.Parse(c.Request)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -117,6 +282,10 @@ func (h *handlers) query(c *gin.Context) error {
 		return err
 	}
 
+	if keyset {
+		return h.queryByCursor(c, filter, orderBy, cursor, page.RowsPerPage)
+	}
+
 	ctx := c.Request.Context()
 	users, err := h.user.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
 	if err != nil {
@@ -132,20 +301,42 @@ func (h *handlers) query(c *gin.Context) error {
 	return nil
 }
 
+// queryByCursor returns a list of users using keyset paging. The order of
+// a non empty cursor takes precedence over the order requested.
+func (h *handlers) queryByCursor(c *gin.Context, filter user.QueryFilter, orderBy order.By, cursor page.Cursor, rowsPerPage int) error {
+	if !cursor.IsZero() {
+		orderBy = cursor.OrderBy
+	}
+
+	ctx := c.Request.Context()
+	users, cursors, err := h.user.QueryByCursor(ctx, filter, orderBy, cursor, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("querybycursor: %w", err)
+	}
+
+	total, err := h.user.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	next, prev := cursors.Encode(h.cursorKey)
+
+	c.JSON(http.StatusOK, wb.NewCursorDocument(toAppUsers(users), total, rowsPerPage, next, prev))
+	return nil
+}
+
 // queryByID returns a user by its ID.
 func (h *handlers) queryByID(c *gin.Context) error {
-	c.JSON(http.StatusOK, toAppUser(mid.GetUser(c)))
+	usr := mid.GetUser(c)
+
+	c.Header("ETag", wb.ETag(usr.Version))
+	c.JSON(http.StatusOK, toAppUser(usr))
 	return nil
 }
 
-// token provides an API token for the authenticated user.
+// token provides an API token for the authenticated user. The token is
+// signed with the newest active key, a kid in the path is ignored.
 func (h *handlers) token(c *gin.Context) error {
-	kid := c.Param("kid")
-	if kid == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing kid"})
-		return validate.NewFieldsError("kid", errors.New("missing kid"))
-	}
-
 	email, pass, ok := c.Request.BasicAuth()
 	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "must provide email and password in Basic auth"})
@@ -158,9 +349,14 @@ func (h *handlers) token(c *gin.Context) error {
 		return auth.NewAuthError("invalid email format")
 	}
 
-	usr, err := h.user.Authenticate(c.Request.Context(), *addr, pass)
+	usr, err := h.user.Authenticate(c.Request.Context(), *addr, pass, c.ClientIP())
 	if err != nil {
+		var lerr *user.LockedError
 		switch {
+		case errors.As(err, &lerr):
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(lerr.Until).Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return wb.NewTrustedError(err, http.StatusTooManyRequests)
 		case errors.Is(err, user.ErrNotFound):
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return wb.NewTrustedError(err, http.StatusNotFound)
@@ -174,15 +370,14 @@ func (h *handlers) token(c *gin.Context) error {
 
 	claims := auth.Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			Subject:   usr.ID.String(),
-			Issuer:    "service project",
-			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			Subject: usr.ID.String(),
 		},
-		Roles: usr.Roles,
+		TenantID:   usr.TenantID,
+		Roles:      usr.Roles,
+		Department: usr.Department,
 	}
 
-	token, err := h.auth.GenerateToken(kid, claims)
+	token, err := h.auth.GenerateToken(claims)
 	if err != nil {
 		return fmt.Errorf("generatetoken: %w", err)
 	}
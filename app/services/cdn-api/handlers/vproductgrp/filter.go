@@ -3,32 +3,47 @@ package vproductgrp
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/testvergecloud/testApi/business/core/views/vproduct"
+	"github.com/testvergecloud/testApi/business/web"
 	"github.com/testvergecloud/testApi/foundation/validate"
-
-	"github.com/google/uuid"
 )
 
 func parseFilter(r *http.Request) (vproduct.QueryFilter, error) {
 	const (
-		filterByProdID   = "product_id"
-		filterByCost     = "cost"
-		filterByQuantity = "quantity"
-		filterByName     = "name"
-		filterByUserName = "user_name"
+		filterByProdID           = "product_id"
+		filterByNotProdID        = "not_product_id"
+		filterByCost             = "cost"
+		filterByStartCost        = "start_cost"
+		filterByEndCost          = "end_cost"
+		filterByQuantity         = "quantity"
+		filterByName             = "name"
+		filterByNamePrefix       = "name_prefix"
+		filterByNotName          = "not_name"
+		filterByStartCreatedDate = "start_created_date"
+		filterByEndCreatedDate   = "end_created_date"
+		filterByUserName         = "user_name"
 	)
 
 	values := r.URL.Query()
 
 	var filter vproduct.QueryFilter
 
-	if productID := values.Get(filterByProdID); productID != "" {
-		id, err := uuid.Parse(productID)
+	if productIDs := values.Get(filterByProdID); productIDs != "" {
+		ids, err := web.ParseIDs(productIDs)
 		if err != nil {
 			return vproduct.QueryFilter{}, validate.NewFieldsError(filterByProdID, err)
 		}
-		filter.WithID(id)
+		filter.WithIDs(ids)
+	}
+
+	if productIDs := values.Get(filterByNotProdID); productIDs != "" {
+		ids, err := web.ParseIDs(productIDs)
+		if err != nil {
+			return vproduct.QueryFilter{}, validate.NewFieldsError(filterByNotProdID, err)
+		}
+		filter.WithoutIDs(ids)
 	}
 
 	if cost := values.Get(filterByCost); cost != "" {
@@ -39,6 +54,22 @@ func parseFilter(r *http.Request) (vproduct.QueryFilter, error) {
 		filter.WithCost(cst)
 	}
 
+	if cost := values.Get(filterByStartCost); cost != "" {
+		cst, err := strconv.ParseFloat(cost, 64)
+		if err != nil {
+			return vproduct.QueryFilter{}, validate.NewFieldsError(filterByStartCost, err)
+		}
+		filter.WithStartCost(cst)
+	}
+
+	if cost := values.Get(filterByEndCost); cost != "" {
+		cst, err := strconv.ParseFloat(cost, 64)
+		if err != nil {
+			return vproduct.QueryFilter{}, validate.NewFieldsError(filterByEndCost, err)
+		}
+		filter.WithEndCost(cst)
+	}
+
 	if quantity := values.Get(filterByQuantity); quantity != "" {
 		qua, err := strconv.ParseInt(quantity, 10, 64)
 		if err != nil {
@@ -51,5 +82,37 @@ func parseFilter(r *http.Request) (vproduct.QueryFilter, error) {
 		filter.WithName(name)
 	}
 
+	if prefix := values.Get(filterByNamePrefix); prefix != "" {
+		filter.WithNamePrefix(prefix)
+	}
+
+	if name := values.Get(filterByNotName); name != "" {
+		filter.WithoutName(name)
+	}
+
+	if createdDate := values.Get(filterByStartCreatedDate); createdDate != "" {
+		t, err := time.Parse(time.RFC3339, createdDate)
+		if err != nil {
+			return vproduct.QueryFilter{}, validate.NewFieldsError(filterByStartCreatedDate, err)
+		}
+		filter.WithStartDateCreated(t)
+	}
+
+	if createdDate := values.Get(filterByEndCreatedDate); createdDate != "" {
+		t, err := time.Parse(time.RFC3339, createdDate)
+		if err != nil {
+			return vproduct.QueryFilter{}, validate.NewFieldsError(filterByEndCreatedDate, err)
+		}
+		filter.WithEndCreatedDate(t)
+	}
+
+	if userName := values.Get(filterByUserName); userName != "" {
+		filter.WithUserName(userName)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return vproduct.QueryFilter{}, err
+	}
+
 	return filter, nil
 }
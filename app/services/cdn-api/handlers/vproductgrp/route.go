@@ -15,9 +15,10 @@ import (
 
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Log  *logger.Logger
-	Auth *auth.Auth
-	DB   *sqlx.DB
+	Log       *logger.Logger
+	Auth      *auth.Auth
+	DB        *sqlx.DB
+	CursorKey []byte
 }
 
 // Routes adds specific routes for this group.
@@ -26,7 +27,7 @@ func Routes(app *web.App, cfg Config) {
 
 	vPrdCore := vproduct.NewCore(vproductdb.NewStore(cfg.Log, cfg.DB))
 
-	hdl := new(vPrdCore)
+	hdl := new(cfg.Log, vPrdCore, cfg.CursorKey)
 	v1 := app.Mux.Group(version)
 	{
 		v1.Use(mid.Authenticate(cfg.Auth))
@@ -4,25 +4,40 @@ package vproductgrp
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/testvergecloud/testApi/business/core/views/vproduct"
 	wb "github.com/testvergecloud/testApi/business/web"
+	"github.com/testvergecloud/testApi/business/web/export"
+	"github.com/testvergecloud/testApi/business/web/order"
 	"github.com/testvergecloud/testApi/business/web/page"
+	"github.com/testvergecloud/testApi/foundation/logger"
 )
 
 type handlers struct {
-	vProduct *vproduct.Core
+	log       *logger.Logger
+	vProduct  *vproduct.Core
+	cursorKey []byte
 }
 
-func new(vProduct *vproduct.Core) *handlers {
+func new(log *logger.Logger, vProduct *vproduct.Core, cursorKey []byte) *handlers {
 	return &handlers{
-		vProduct: vProduct,
+		log:       log,
+		vProduct:  vProduct,
+		cursorKey: cursorKey,
 	}
 }
 
-// Query returns a list of products with paging.
+// Query returns a list of products with paging, or every product as a file
+// when an export format is requested.
 func (h *handlers) Query(c *gin.Context) error {
+	cursor, keyset, err := page.ParseCursor(c.Request, h.cursorKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
 	page, err := page.Parse(c.Request)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -41,6 +56,20 @@ func (h *handlers) Query(c *gin.Context) error {
 		return err
 	}
 
+	format, err := export.ParseFormat(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return err
+	}
+
+	if format != export.FormatNone {
+		return h.export(c, format, filter, orderBy)
+	}
+
+	if keyset {
+		return h.queryByCursor(c, filter, orderBy, cursor, page.RowsPerPage)
+	}
+
 	ctx := c.Request.Context()
 	prds, err := h.vProduct.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
 	if err != nil {
@@ -55,3 +84,73 @@ func (h *handlers) Query(c *gin.Context) error {
 	c.JSON(http.StatusOK, wb.NewPageDocument(toAppProducts(prds), total, page.Number, page.RowsPerPage))
 	return nil
 }
+
+// queryByCursor returns a list of products using keyset paging. The order of
+// a non empty cursor takes precedence over the order requested.
+func (h *handlers) queryByCursor(c *gin.Context, filter vproduct.QueryFilter, orderBy order.By, cursor page.Cursor, rowsPerPage int) error {
+	if !cursor.IsZero() {
+		orderBy = cursor.OrderBy
+	}
+
+	ctx := c.Request.Context()
+	prds, cursors, err := h.vProduct.QueryByCursor(ctx, filter, orderBy, cursor, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("querybycursor: %w", err)
+	}
+
+	total, err := h.vProduct.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	next, prev := cursors.Encode(h.cursorKey)
+
+	c.JSON(http.StatusOK, wb.NewCursorDocument(toAppProducts(prds), total, rowsPerPage, next, prev))
+	return nil
+}
+
+// export streams every product matching the filter in the format without
+// paging. The export stops once the client goes away.
+func (h *handlers) export(c *gin.Context, format export.Format, filter vproduct.QueryFilter, orderBy order.By) error {
+	ctx := c.Request.Context()
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", format.Filename("vproducts")))
+	c.Status(http.StatusOK)
+
+	// An export can take longer than the write timeout of the server.
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.log.Info(ctx, "export", "msg", "unable to clear the write deadline", "ERROR", err)
+	}
+
+	w, err := export.NewWriter[AppProduct](c.Writer, format)
+	if err != nil {
+		return fmt.Errorf("newwriter: %w", err)
+	}
+
+	err = h.vProduct.QueryEach(ctx, filter, orderBy, func(prd vproduct.Product) error {
+		return w.Write(toAppProduct(prd))
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+
+	switch {
+	case err == nil:
+		return nil
+
+	case ctx.Err() != nil:
+		// There is no one left to tell.
+		return nil
+
+	case !c.Writer.Written():
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": http.StatusText(http.StatusInternalServerError)})
+		return fmt.Errorf("queryeach: %w", err)
+	}
+
+	// The status went out with the first rows, the export is cut short.
+	h.log.Error(ctx, "export", "msg", err)
+	return fmt.Errorf("queryeach: %w", err)
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
 	"expvar"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,16 +18,37 @@ import (
 	"github.com/testvergecloud/testApi/app/services/cdn-api/build/all"
 	"github.com/testvergecloud/testApi/app/services/cdn-api/build/crud"
 	"github.com/testvergecloud/testApi/app/services/cdn-api/build/reporting"
+	"github.com/testvergecloud/testApi/business/core/crud/apikey"
+	"github.com/testvergecloud/testApi/business/core/crud/apikey/stores/apikeydb"
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/business/core/crud/audit/stores/auditdb"
 	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	"github.com/testvergecloud/testApi/business/core/crud/delegate/stores/deadletterdb"
+	"github.com/testvergecloud/testApi/business/core/crud/delegate/stores/outboxdb"
+	"github.com/testvergecloud/testApi/business/core/crud/identity"
+	"github.com/testvergecloud/testApi/business/core/crud/identity/stores/identitydb"
+	"github.com/testvergecloud/testApi/business/core/crud/revocation"
+	"github.com/testvergecloud/testApi/business/core/crud/revocation/stores/revocationdb"
+	"github.com/testvergecloud/testApi/business/core/crud/role"
+	"github.com/testvergecloud/testApi/business/core/crud/role/stores/roledb"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/core/crud/user/stores/userdb"
 	"github.com/testvergecloud/testApi/business/data/sqldb"
 	"github.com/testvergecloud/testApi/business/web/auth"
 	"github.com/testvergecloud/testApi/business/web/debug"
+	"github.com/testvergecloud/testApi/business/web/idempotency"
+	"github.com/testvergecloud/testApi/business/web/idempotency/stores/idempotencydb"
 	"github.com/testvergecloud/testApi/business/web/mux"
+	"github.com/testvergecloud/testApi/business/web/ratelimit"
+	"github.com/testvergecloud/testApi/business/web/ratelimit/stores/ratelimitdb"
+	"github.com/testvergecloud/testApi/business/web/ratelimit/stores/ratelimitmem"
 	"github.com/testvergecloud/testApi/foundation/config"
 	"github.com/testvergecloud/testApi/foundation/keystore"
 	"github.com/testvergecloud/testApi/foundation/logger"
 	"github.com/testvergecloud/testApi/foundation/web"
+	"github.com/testvergecloud/testApi/foundation/worker"
 
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
@@ -53,7 +77,15 @@ func main() {
 		fx.Provide(initializeLogger),
 		fx.Provide(startTracing),
 		fx.Provide(loadKeyStore),
+		fx.Provide(keyLookup),
 		fx.Provide(sqldb.Open),
+		fx.Provide(initializeDelegate),
+		fx.Provide(initializeRevocation),
+		fx.Provide(initializeRoles),
+		fx.Provide(initializeAPIKeys),
+		fx.Provide(initializeOIDC),
+		fx.Provide(initializeRateLimit),
+		fx.Provide(initializeIdempotency),
 		fx.Provide(auth.New),
 		fx.Invoke(run), // Run the application logic
 	)
@@ -61,6 +93,7 @@ func main() {
 	// Start the application
 	if err := app.Start(context.Background()); err != nil {
 		fmt.Printf("Error starting application: %v", err)
+		os.Exit(1)
 	}
 
 	// Application has stopped, exit with success status code
@@ -75,7 +108,7 @@ func main() {
 // DB       *sqlx.DB
 // Tracer   trace.Tracer
 
-func run(cfg *config.Config, log *logger.Logger, ctx context.Context, tp *trace.TracerProvider, db *sqlx.DB, server *http.Server, shutdown chan os.Signal) {
+func run(cfg *config.Config, log *logger.Logger, ctx context.Context, tp *trace.TracerProvider, db *sqlx.DB, dlg *delegate.Delegate, revCore *revocation.Core, roleCore *role.Core, rl *ratelimit.Limiter, idmCore *idempotency.Core, ks *keystore.KeyStore, a *auth.Auth, server *http.Server, shutdown chan os.Signal) error {
 	// -------------------------------------------------------------------------
 	// GOMAXPROCS
 	log.Info(ctx, "startup", "GOMAXPROCS", runtime.GOMAXPROCS(0))
@@ -93,6 +126,130 @@ func run(cfg *config.Config, log *logger.Logger, ctx context.Context, tp *trace.
 
 	defer tp.Shutdown(context.Background())
 
+	// -------------------------------------------------------------------------
+	// Start Outbox Relay
+
+	log.Info(ctx, "startup", "status", "initializing outbox relay")
+
+	wrk, err := worker.New(runtime.GOMAXPROCS(0))
+	if err != nil {
+		return fmt.Errorf("constructing worker: %w", err)
+	}
+
+	relay := delegate.NewRelay(log, dlg, outboxdb.NewStore(log, db), wrk)
+	relay.Start(ctx)
+
+	defer func() {
+		log.Info(ctx, "shutdown", "status", "stopping outbox relay")
+
+		ctx, cancel := context.WithTimeout(ctx, cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		if err := relay.Shutdown(ctx); err != nil {
+			log.Error(ctx, "shutdown", "status", "outbox relay shutdown", "msg", err)
+		}
+	}()
+
+	// -------------------------------------------------------------------------
+	// Start Token Revocation
+
+	log.Info(ctx, "startup", "status", "initializing token revocation")
+
+	revCore.Start(ctx)
+
+	defer func() {
+		log.Info(ctx, "shutdown", "status", "stopping token revocation")
+
+		ctx, cancel := context.WithTimeout(ctx, cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		if err := revCore.Shutdown(ctx); err != nil {
+			log.Error(ctx, "shutdown", "status", "token revocation shutdown", "msg", err)
+		}
+	}()
+
+	// -------------------------------------------------------------------------
+	// Start Roles
+
+	log.Info(ctx, "startup", "status", "initializing roles")
+
+	roleCore.Start(ctx)
+
+	defer func() {
+		log.Info(ctx, "shutdown", "status", "stopping roles")
+
+		ctx, cancel := context.WithTimeout(ctx, cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		if err := roleCore.Shutdown(ctx); err != nil {
+			log.Error(ctx, "shutdown", "status", "roles shutdown", "msg", err)
+		}
+	}()
+
+	// -------------------------------------------------------------------------
+	// Start Rate Limiting
+
+	if rl != nil {
+		log.Info(ctx, "startup", "status", "initializing rate limiting", "backend", cfg.RateLimitBackend)
+
+		rl.Start(ctx)
+
+		defer func() {
+			log.Info(ctx, "shutdown", "status", "stopping rate limiting")
+
+			ctx, cancel := context.WithTimeout(ctx, cfg.Web.ShutdownTimeout)
+			defer cancel()
+
+			if err := rl.Shutdown(ctx); err != nil {
+				log.Error(ctx, "shutdown", "status", "rate limiting shutdown", "msg", err)
+			}
+		}()
+	}
+
+	// -------------------------------------------------------------------------
+	// Start Idempotency Key Eviction
+
+	log.Info(ctx, "startup", "status", "initializing idempotency keys", "ttl", idmCore.TTL)
+
+	idmCore.Start(ctx)
+
+	defer func() {
+		log.Info(ctx, "shutdown", "status", "stopping idempotency keys")
+
+		ctx, cancel := context.WithTimeout(ctx, cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		if err := idmCore.Shutdown(ctx); err != nil {
+			log.Error(ctx, "shutdown", "status", "idempotency keys shutdown", "msg", err)
+		}
+	}()
+
+	// -------------------------------------------------------------------------
+	// Start Key Reloading
+
+	log.Info(ctx, "startup", "status", "watching keys folder", "folder", cfg.KeysFolder)
+
+	keysCtx, cancelKeys := context.WithCancel(ctx)
+	defer cancelKeys()
+
+	go ks.Watch(keysCtx, os.DirFS(cfg.KeysFolder), cfg.KeysReloadInterval, func(err error) {
+		log.Error(ctx, "keystore", "status", "reloading keys", "msg", err)
+	})
+
+	// -------------------------------------------------------------------------
+	// Start Policy Reloading
+
+	log.Info(ctx, "startup", "status", "authorization policy loaded", "revision", a.PolicyRevision().Revision, "source", a.PolicyRevision().Source)
+
+	if cfg.PolicyFolder != "" {
+		log.Info(ctx, "startup", "status", "watching policy folder", "folder", cfg.PolicyFolder)
+
+		policyCtx, cancelPolicy := context.WithCancel(ctx)
+		defer cancelPolicy()
+
+		go a.WatchPolicy(policyCtx, os.DirFS(cfg.PolicyFolder), cfg.PolicyReloadInterval)
+	}
+
 	// -------------------------------------------------------------------------
 	// Start Debug Service
 
@@ -119,6 +276,8 @@ func run(cfg *config.Config, log *logger.Logger, ctx context.Context, tp *trace.
 
 	// Handle graceful shutdown
 	handleShutdown(server, log, ctx, cfg.Web.ShutdownTimeout, shutdown, serverErrors)
+
+	return nil
 }
 
 // Handle graceful shutdown
@@ -217,7 +376,7 @@ func startTracing(cfg *config.Config, log *logger.Logger, ctx context.Context) (
 }
 
 func loadConfig(log *logger.Logger, ctx context.Context) (*config.Config, error) {
-	c, err := config.LoadConfig("./foundation/env/cdn/", "web", "auth", "db", "tempo")
+	c, err := config.LoadConfig("./foundation/env/cdn/", "web", "auth", "oidc", "ratelimit", "idempotency", "db", "tempo")
 	if err != nil {
 		return nil, err
 	}
@@ -243,38 +402,223 @@ func initializeLogger() *logger.Logger {
 	return log
 }
 
-func loadKeyStore(cfg *config.Config) (auth.KeyLookup, error) {
+func loadKeyStore(cfg *config.Config) (*keystore.KeyStore, error) {
 	ks := keystore.New()
-	if err := ks.LoadRSAKeys(os.DirFS(cfg.KeysFolder)); err != nil {
+	if err := ks.LoadKeys(os.DirFS(cfg.KeysFolder)); err != nil {
 		return nil, fmt.Errorf("reading keys: %w", err)
 	}
 	return ks, nil
 }
 
+// keyLookup provides the key store as the key lookup used by auth.
+func keyLookup(ks *keystore.KeyStore) auth.KeyLookup {
+	return ks
+}
+
 func initializeContext() context.Context {
 	return context.Background()
 }
 
-func initializeMux(cfg *config.Config, log *logger.Logger, db *sqlx.DB, tp *trace.TracerProvider, a *auth.Auth) (*http.Server, chan os.Signal) {
+// initializeDelegate constructs the delegate used by the core packages. Events
+// are written to the outbox and delivered by the relay started in run.
+// Events that keep failing are recorded as dead letters.
+func initializeDelegate(log *logger.Logger, db *sqlx.DB) *delegate.Delegate {
+	return delegate.New(log,
+		delegate.WithOutbox(outboxdb.NewStore(log, db)),
+		delegate.WithDeadLetter(deadletterdb.NewStore(log, db)),
+	)
+}
+
+// initializeRevocation constructs the core holding the revoked tokens checked
+// by auth. It's kept in sync with the other instances once started in run.
+func initializeRevocation(log *logger.Logger, db *sqlx.DB) *revocation.Core {
+	return revocation.NewCore(log, revocationdb.NewStore(log, db))
+}
+
+// initializeRoles constructs the core holding the roles and the permissions
+// they grant. It's kept in sync with the other instances once started in run.
+func initializeRoles(log *logger.Logger, db *sqlx.DB) *role.Core {
+	return role.NewCore(log, roledb.NewStore(log, db))
+}
+
+// initializeAPIKeys constructs the core checking the API keys of machine
+// clients. The configured API key secret is used to hash the keys.
+func initializeAPIKeys(cfg *config.Config, log *logger.Logger, db *sqlx.DB, roleCore *role.Core) *apikey.Core {
+	usrCore := user.NewCore(log, nil, nil, userdb.NewStore(log, db))
+	return apikey.NewCore(log, usrCore, roleCore, apikeydb.NewStore(log, db), []byte(cfg.CDNApiKey))
+}
+
+// initializeOIDC constructs what's needed to log in with an OpenID provider.
+// Nothing is constructed when no provider is configured, which disables it.
+func initializeOIDC(cfg *config.Config, log *logger.Logger, db *sqlx.DB, dlg *delegate.Delegate, roleCore *role.Core) (*auth.OIDC, *identity.Core, error) {
+	if cfg.OIDC == nil || cfg.OIDC.Issuer == "" {
+		return nil, nil, nil
+	}
+
+	tenantID, err := uuid.Parse(cfg.OIDC.TenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing oidc tenant: %w", err)
+	}
+
+	roles := make([]user.Role, len(cfg.OIDC.DefaultRoles))
+	for i, name := range cfg.OIDC.DefaultRoles {
+		if roles[i], err = user.ParseRole(name); err != nil {
+			return nil, nil, fmt.Errorf("parsing oidc default roles: %w", err)
+		}
+	}
+
+	oidc := auth.NewOIDC(auth.OIDCConfig{
+		Issuer:       cfg.OIDC.Issuer,
+		ClientID:     cfg.OIDC.ClientID,
+		ClientSecret: cfg.OIDC.ClientSecret,
+		RedirectURL:  cfg.OIDC.RedirectURL,
+		Scopes:       cfg.OIDC.Scopes,
+	})
+
+	// Users provisioned on their first login are audited like any other.
+	audCore := audit.NewCore(log, auditdb.NewStore(log, db))
+	usrCore := user.NewCore(log, dlg, audCore, userdb.NewStore(log, db))
+	usrCore.Roles = roleCore
+
+	prov := identity.Provisioning{
+		TenantID: tenantID,
+		Roles:    roles,
+	}
+
+	return oidc, identity.NewCore(log, usrCore, identitydb.NewStore(log, db), prov), nil
+}
+
+// initializeRateLimit constructs the limiter holding the quotas of the route
+// groups. Nothing is constructed when no backend is configured, which
+// disables rate limiting.
+func initializeRateLimit(cfg *config.Config, log *logger.Logger, db *sqlx.DB) (*ratelimit.Limiter, error) {
+	if cfg.RateLimit == nil {
+		return nil, nil
+	}
+
+	var storer ratelimit.Storer
+	switch cfg.RateLimitBackend {
+	case "":
+		return nil, nil
+	case "memory":
+		storer = ratelimitmem.NewStore()
+	case "postgres":
+		storer = ratelimitdb.NewStore(log, db)
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.RateLimitBackend)
+	}
+
+	user, err := ratelimit.ParseLimit(cfg.UserLimit)
+	if err != nil {
+		return nil, fmt.Errorf("parsing user limit: %w", err)
+	}
+
+	ip, err := ratelimit.ParseLimit(cfg.IPLimit)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ip limit: %w", err)
+	}
+
+	token, err := ratelimit.ParseLimit(cfg.TokenLimit)
+	if err != nil {
+		return nil, fmt.Errorf("parsing token limit: %w", err)
+	}
+
+	quotas := []ratelimit.Quota{
+		{Name: ratelimit.Default, User: user, IP: ip},
+		{Name: ratelimit.Token, User: token, IP: token},
+	}
+
+	return ratelimit.NewLimiter(log, storer, quotas...), nil
+}
+
+// initializeIdempotency constructs the core storing the responses of requests
+// sent with an idempotency key.
+func initializeIdempotency(cfg *config.Config, log *logger.Logger, db *sqlx.DB) *idempotency.Core {
+	idmCore := idempotency.NewCore(log, idempotencydb.NewStore(log, db))
+	if cfg.Idempotency != nil && cfg.IdempotencyTTL > 0 {
+		idmCore.TTL = cfg.IdempotencyTTL
+	}
+
+	return idmCore
+}
+
+func initializeMux(cfg *config.Config, log *logger.Logger, db *sqlx.DB, tp *trace.TracerProvider, a *auth.Auth, ks *keystore.KeyStore, dlg *delegate.Delegate, roleCore *role.Core, keyCore *apikey.Core, oidc *auth.OIDC, idnCore *identity.Core, rl *ratelimit.Limiter, idmCore *idempotency.Core) (*http.Server, chan os.Signal, error) {
+	cursorKey, err := loadCursorKey(log, cfg.Web.CursorKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkTrustedProxies(cfg.Web.TrustedProxies); err != nil {
+		return nil, nil, err
+	}
+
 	shutdown := make(chan os.Signal, 1)
 	cfgMux := mux.Config{
-		Build:    build,
-		Shutdown: shutdown,
-		Log:      log,
-		Delegate: delegate.New(log),
-		Auth:     a,
-		DB:       db,
-		Tracer:   tp.Tracer("service"),
+		Build:           build,
+		Shutdown:        shutdown,
+		Log:             log,
+		Delegate:        dlg,
+		Auth:            a,
+		DB:              db,
+		Tracer:          tp.Tracer("service"),
+		RequireIfMatch:  cfg.Web.RequireIfMatch,
+		CursorKey:       cursorKey,
+		RefreshTokenTTL: cfg.Auth.RefreshTokenTTL,
+		KeyStore:        ks,
+		Role:            roleCore,
+		APIKey:          keyCore,
+		OIDC:            oidc,
+		Identity:        idnCore,
+		RateLimit:       rl,
+		Idempotency:     idmCore,
 	}
 
 	api := http.Server{
 		Addr:         cfg.APIHost,
-		Handler:      mux.WebAPI(cfgMux, buildRoutes(), mux.WithCORS(cfg.CORSAllowedOrigins)),
+		Handler:      mux.WebAPI(cfgMux, buildRoutes(), mux.WithCORS(cfg.CORSAllowedOrigins), mux.WithTrustedProxies(cfg.Web.TrustedProxies)),
 		ReadTimeout:  cfg.Web.ReadTimeout,
 		WriteTimeout: cfg.Web.WriteTimeout,
 		IdleTimeout:  cfg.Web.IdleTimeout,
 		ErrorLog:     logger.NewStdLogger(log, logger.LevelError),
 	}
 
-	return &api, shutdown
+	return &api, shutdown, nil
+}
+
+// checkTrustedProxies makes sure every trusted proxy is an IP address or a
+// CIDR range, a typo would otherwise make every client look like the proxy.
+func checkTrustedProxies(proxies []string) error {
+	for _, proxy := range proxies {
+		if _, _, err := net.ParseCIDR(proxy); err == nil {
+			continue
+		}
+
+		if net.ParseIP(proxy) == nil {
+			return fmt.Errorf("trusted proxies: CDN_WEB_TRUSTED_PROXIES has an invalid address %q", proxy)
+		}
+	}
+
+	return nil
+}
+
+// loadCursorKey returns the key cursors are signed with so clients can't
+// forge positions. A random key is only allowed in development, since the
+// cursors it signs are rejected after a restart and by every other replica.
+func loadCursorKey(log *logger.Logger, key string) ([]byte, error) {
+	if key != "" {
+		return []byte(key), nil
+	}
+
+	if build != "develop" {
+		return nil, errors.New("cursor key: CDN_WEB_CURSOR_KEY is required outside of development")
+	}
+
+	cursorKey := make([]byte, 32)
+	if _, err := rand.Read(cursorKey); err != nil {
+		return nil, fmt.Errorf("cursor key: generating random key: %w", err)
+	}
+
+	log.Warn(context.Background(), "startup", "msg", "CDN_WEB_CURSOR_KEY not set, cursors are signed with a random key and won't be accepted after a restart or by other replicas")
+
+	return cursorKey, nil
 }
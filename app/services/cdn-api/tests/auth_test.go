@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime/debug"
+	"testing"
+
+	"github.com/testvergecloud/testApi/app/services/cdn-api/build/all"
+	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/authgrp"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/web/mux"
+
+	"github.com/go-json-experiment/json"
+)
+
+func Test_Auth(t *testing.T) {
+	t.Parallel()
+
+	dbTest := dbtest.NewTest(t, c, "Test_Auth")
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		dbTest.Teardown()
+	}()
+
+	handler := mux.WebAPI(mux.Config{
+		Shutdown: make(chan os.Signal, 1),
+		Delegate: dbTest.CoreAPIs.Delegate,
+		Auth:     dbTest.V1.Auth,
+		DB:       dbTest.DB,
+	}, all.Routes())
+
+	// -------------------------------------------------------------------------
+
+	t.Run("source-lockout-forwarded-for", func(t *testing.T) {
+		login := func(i int) int {
+			var b bytes.Buffer
+			app := authgrp.AppLogin{
+				Email:    fmt.Sprintf("unknown%d@example.com", i),
+				Password: "gophers",
+			}
+			if err := json.MarshalWrite(&b, app); err != nil {
+				t.Fatalf("Should be able to marshal the model : %s", err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/auth/login", &b)
+			r.RemoteAddr = "192.0.2.1:1234"
+			r.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			return w.Code
+		}
+
+		// Every failed login claims another client, the source is still the
+		// address the requests come from.
+		threshold := user.DefaultLockoutPolicy.SourceThreshold
+		for i := range threshold {
+			if code := login(i); code != http.StatusUnauthorized {
+				t.Fatalf("Should receive a status code of %d for the login %d : %d", http.StatusUnauthorized, i, code)
+			}
+		}
+
+		if code := login(threshold); code != http.StatusTooManyRequests {
+			t.Fatalf("Should lock out the source despite a spoofed X-Forwarded-For : %d", code)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/homegrp"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	wb "github.com/testvergecloud/testApi/business/web"
 
 	"github.com/google/go-cmp/cmp"
@@ -30,8 +31,9 @@ func homeCreate200(sd seedData) []tableData {
 			},
 			resp: &homegrp.AppHome{},
 			expResp: &homegrp.AppHome{
-				UserID: sd.users[0].ID.String(),
-				Type:   "SINGLE FAMILY",
+				TenantID: tenant.DefaultID.String(),
+				UserID:   sd.users[0].ID.String(),
+				Type:     "SINGLE FAMILY",
 				Address: homegrp.AppAddress{
 					Address1: "123 Mocking Bird Lane",
 					ZipCode:  "35810",
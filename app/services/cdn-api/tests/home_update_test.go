@@ -6,6 +6,7 @@ import (
 
 	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/homegrp"
 	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/web"
 
 	"github.com/google/go-cmp/cmp"
@@ -33,8 +34,9 @@ func homeUpdate200(sd seedData) []tableData {
 			},
 			resp: &homegrp.AppHome{},
 			expResp: &homegrp.AppHome{
-				UserID: sd.users[0].ID.String(),
-				Type:   "SINGLE FAMILY",
+				TenantID: tenant.DefaultID.String(),
+				UserID:   sd.users[0].ID.String(),
+				Type:     "SINGLE FAMILY",
 				Address: homegrp.AppAddress{
 					Address1: "123 Mocking Bird Lane",
 					Address2: "apt 105",
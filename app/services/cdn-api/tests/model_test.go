@@ -32,8 +32,9 @@ type testUser struct {
 }
 
 type seedData struct {
-	users  []testUser
-	admins []testUser
+	users    []testUser
+	admins   []testUser
+	managers []testUser
 }
 
 func toAppUser(usr user.User) usergrp.AppUser {
@@ -44,6 +45,7 @@ func toAppUser(usr user.User) usergrp.AppUser {
 
 	return usergrp.AppUser{
 		ID:           usr.ID.String(),
+		TenantID:     usr.TenantID.String(),
 		Name:         usr.Name,
 		Email:        usr.Email.Address,
 		Roles:        roles,
@@ -72,6 +74,7 @@ func toAppUserPtr(usr user.User) *usergrp.AppUser {
 func toAppProduct(prd product.Product) productgrp.AppProduct {
 	return productgrp.AppProduct{
 		ID:          prd.ID.String(),
+		TenantID:    prd.TenantID.String(),
 		UserID:      prd.UserID.String(),
 		Name:        prd.Name,
 		Cost:        prd.Cost,
@@ -97,9 +100,10 @@ func toAppProducts(prds []product.Product) []productgrp.AppProduct {
 
 func toAppHome(hme home.Home) homegrp.AppHome {
 	return homegrp.AppHome{
-		ID:     hme.ID.String(),
-		UserID: hme.UserID.String(),
-		Type:   hme.Type.Name(),
+		ID:       hme.ID.String(),
+		TenantID: hme.TenantID.String(),
+		UserID:   hme.UserID.String(),
+		Type:     hme.Type.Name(),
 		Address: homegrp.AppAddress{
 			Address1: hme.Address.Address1,
 			Address2: hme.Address.Address2,
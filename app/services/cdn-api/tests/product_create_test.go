@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/productgrp"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/web"
 
 	"github.com/google/go-cmp/cmp"
@@ -25,6 +26,7 @@ func productCreate200(sd seedData) []tableData {
 			},
 			resp: &productgrp.AppProduct{},
 			expResp: &productgrp.AppProduct{
+				TenantID: tenant.DefaultID.String(),
 				Name:     "Guitar",
 				UserID:   sd.users[0].ID.String(),
 				Cost:     10.34,
@@ -7,11 +7,12 @@ import (
 	"github.com/testvergecloud/testApi/business/core/crud/product"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/web/order"
 )
 
 func createProductSeed(dbTest *dbtest.Test) (seedData, error) {
-	usrs, err := dbTest.CoreAPIs.User.Query(context.Background(), user.QueryFilter{}, order.By{Field: user.OrderByName, Direction: order.ASC}, 1, 2)
+	usrs, err := dbTest.CoreAPIs.User.Query(tenant.Set(context.Background(), tenant.DefaultID), user.QueryFilter{}, order.By{Field: user.OrderByName, Direction: order.ASC}, 1, 2)
 	if err != nil {
 		return seedData{}, fmt.Errorf("seeding users : %w", err)
 	}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/productgrp"
 	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/web"
 
 	"github.com/google/go-cmp/cmp"
@@ -27,6 +28,7 @@ func productUpdate200(sd seedData) []tableData {
 			},
 			resp: &productgrp.AppProduct{},
 			expResp: &productgrp.AppProduct{
+				TenantID: tenant.DefaultID.String(),
 				Name:     "Guitar",
 				UserID:   sd.users[1].ID.String(),
 				Cost:     10.34,
@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/usergrp"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	v1 "github.com/testvergecloud/testApi/business/web"
 
 	"github.com/google/go-cmp/cmp"
@@ -28,6 +30,7 @@ func userCreate200(sd seedData) []tableData {
 			},
 			resp: &usergrp.AppUser{},
 			expResp: &usergrp.AppUser{
+				TenantID:   tenant.DefaultID.String(),
 				Name:       "Bill Kennedy",
 				Email:      "bill@ardanlabs.com",
 				Roles:      []string{"ADMIN"},
@@ -107,6 +110,35 @@ func userCreate400(sd seedData) []tableData {
 	return table
 }
 
+func userCreate403(sd seedData) []tableData {
+	table := []tableData{
+		{
+			name:       "super-admin",
+			url:        "/v1/users",
+			token:      sd.admins[0].token,
+			method:     http.MethodPost,
+			statusCode: http.StatusForbidden,
+			model: &usergrp.AppNewUser{
+				Name:            "Jill Kennedy",
+				Email:           "jill@ardanlabs.com",
+				Roles:           []string{"SUPER_ADMIN"},
+				Department:      "IT",
+				Password:        "123",
+				PasswordConfirm: "123",
+			},
+			resp: &v1.ErrorResponse{},
+			expResp: &v1.ErrorResponse{
+				Error: user.ErrSuperAdminRole.Error(),
+			},
+			cmpFunc: func(x interface{}, y interface{}) string {
+				return cmp.Diff(x, y)
+			},
+		},
+	}
+
+	return table
+}
+
 func userCreate401(sd seedData) []tableData {
 	table := []tableData{
 		{
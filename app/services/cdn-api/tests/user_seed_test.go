@@ -1,10 +1,12 @@
 package tests
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 )
 
 func createUserSeed(dbTest *dbtest.Test) (seedData, error) {
@@ -42,9 +44,26 @@ func createUserSeed(dbTest *dbtest.Test) (seedData, error) {
 
 	// -------------------------------------------------------------------------
 
+	// The manager runs the department of the second user.
+	nu := user.TestGenerateNewUsers(1, user.RoleManager)[0]
+	nu.Department = tu4.Department
+
+	mgr, err := dbTest.CoreAPIs.User.Create(tenant.Set(context.Background(), tenant.DefaultID), nu)
+	if err != nil {
+		return seedData{}, fmt.Errorf("seeding manager : %w", err)
+	}
+
+	tu5 := testUser{
+		User:  mgr,
+		token: dbTest.TokenV1(mgr.Email.Address, nu.Password),
+	}
+
+	// -------------------------------------------------------------------------
+
 	sd := seedData{
-		users:  []testUser{tu3, tu4},
-		admins: []testUser{tu1, tu2},
+		users:    []testUser{tu3, tu4},
+		admins:   []testUser{tu1, tu2},
+		managers: []testUser{tu5},
 	}
 
 	return sd, nil
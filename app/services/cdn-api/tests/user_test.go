@@ -49,9 +49,11 @@ func Test_User(t *testing.T) {
 	app.test(t, userCreate200(sd), "user-create-200")
 	app.test(t, userCreate401(sd), "user-create-401")
 	app.test(t, userCreate400(sd), "user-create-400")
+	app.test(t, userCreate403(sd), "user-create-403")
 
 	app.test(t, userUpdate200(sd), "user-update-200")
 	app.test(t, userUpdate401(sd), "user-update-401")
+	app.test(t, userUpdate403(sd), "user-update-403")
 	app.test(t, userUpdate400(sd), "user-update-400")
 
 	app.test(t, userDelete200(sd), "user-delete-200")
@@ -5,7 +5,9 @@ import (
 	"net/http"
 
 	"github.com/testvergecloud/testApi/app/services/cdn-api/handlers/usergrp"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/web"
 
 	"github.com/google/go-cmp/cmp"
@@ -23,13 +25,46 @@ func userUpdate200(sd seedData) []tableData {
 			model: &usergrp.AppUpdateUser{
 				Name:            dbtest.StringPointer("Jack Kennedy"),
 				Email:           dbtest.StringPointer("jack@ardanlabs.com"),
-				Roles:           []string{"ADMIN"},
-				Department:      dbtest.StringPointer("IT"),
 				Password:        dbtest.StringPointer("123"),
 				PasswordConfirm: dbtest.StringPointer("123"),
 			},
+			resp:    &usergrp.AppUser{},
+			expResp: toAppUserPtr(sd.users[0].User),
+			cmpFunc: func(x interface{}, y interface{}) string {
+				resp := x.(*usergrp.AppUser)
+				expResp := y.(*usergrp.AppUser)
+
+				if resp.Name != "Jack Kennedy" {
+					return "name not updated"
+				}
+
+				if resp.Email != "jack@ardanlabs.com" {
+					return "email not updated"
+				}
+
+				expResp.Name = resp.Name
+				expResp.Email = resp.Email
+				expResp.Version = resp.Version
+				expResp.DateUpdated = resp.DateUpdated
+
+				return cmp.Diff(x, y)
+			},
+		},
+		{
+			name:       "admin",
+			url:        fmt.Sprintf("/v1/users/%s", sd.users[0].ID),
+			token:      sd.admins[0].token,
+			method:     http.MethodPut,
+			statusCode: http.StatusOK,
+			model: &usergrp.AppUpdateUser{
+				Roles:           []string{"ADMIN"},
+				Department:      dbtest.StringPointer("IT"),
+				Password:        dbtest.StringPointer("1234"),
+				PasswordConfirm: dbtest.StringPointer("1234"),
+			},
 			resp: &usergrp.AppUser{},
 			expResp: &usergrp.AppUser{
+				TenantID:   tenant.DefaultID.String(),
 				Name:       "Jack Kennedy",
 				Email:      "jack@ardanlabs.com",
 				Roles:      []string{"ADMIN"},
@@ -53,9 +88,36 @@ func userUpdate200(sd seedData) []tableData {
 				}
 
 				expResp.ID = resp.ID
+				expResp.Version = resp.Version
 				expResp.DateCreated = resp.DateCreated
 				expResp.DateUpdated = resp.DateUpdated
 
+				return cmp.Diff(x, y)
+			},
+		},
+		{
+			name:       "manager",
+			url:        fmt.Sprintf("/v1/users/%s", sd.users[1].ID),
+			token:      sd.managers[0].token,
+			method:     http.MethodPut,
+			statusCode: http.StatusOK,
+			model: &usergrp.AppUpdateUser{
+				Name: dbtest.StringPointer("Jill Kennedy"),
+			},
+			resp:    &usergrp.AppUser{},
+			expResp: toAppUserPtr(sd.users[1].User),
+			cmpFunc: func(x interface{}, y interface{}) string {
+				resp := x.(*usergrp.AppUser)
+				expResp := y.(*usergrp.AppUser)
+
+				if resp.Name != "Jill Kennedy" {
+					return "name not updated"
+				}
+
+				expResp.Name = resp.Name
+				expResp.Version = resp.Version
+				expResp.DateUpdated = resp.DateUpdated
+
 				return cmp.Diff(x, y)
 			},
 		},
@@ -107,6 +169,82 @@ func userUpdate400(sd seedData) []tableData {
 	return table
 }
 
+func userUpdate403(sd seedData) []tableData {
+	table := []tableData{
+		{
+			name:       "subject-roles",
+			url:        fmt.Sprintf("/v1/users/%s", sd.users[1].ID),
+			token:      sd.users[1].token,
+			method:     http.MethodPut,
+			statusCode: http.StatusForbidden,
+			model: &usergrp.AppUpdateUser{
+				Roles: []string{"ADMIN"},
+			},
+			resp:    &web.ErrorResponse{},
+			expResp: &web.ErrorResponse{},
+			cmpFunc: func(x interface{}, y interface{}) string {
+				if x.(*web.ErrorResponse).Error == "" {
+					return "missing error"
+				}
+				return ""
+			},
+		},
+	}
+
+	// Only a super admin can grant the super admin role, the admins of a
+	// tenant can't.
+	table = append(table, tableData{
+		name:       "super-admin",
+		url:        fmt.Sprintf("/v1/users/%s", sd.admins[1].ID),
+		token:      sd.admins[0].token,
+		method:     http.MethodPut,
+		statusCode: http.StatusForbidden,
+		model: &usergrp.AppUpdateUser{
+			Roles: []string{"ADMIN", "SUPER_ADMIN"},
+		},
+		resp: &web.ErrorResponse{},
+		expResp: &web.ErrorResponse{
+			Error: user.ErrSuperAdminRole.Error(),
+		},
+		cmpFunc: func(x interface{}, y interface{}) string {
+			return cmp.Diff(x, y)
+		},
+	})
+
+	// A manager can edit the profile of the users of their department, but
+	// not what they are allowed to do or how they sign in.
+	models := []struct {
+		name  string
+		model *usergrp.AppUpdateUser
+	}{
+		{name: "manager-roles", model: &usergrp.AppUpdateUser{Roles: []string{"ADMIN"}}},
+		{name: "manager-department", model: &usergrp.AppUpdateUser{Department: dbtest.StringPointer("IT")}},
+		{name: "manager-password", model: &usergrp.AppUpdateUser{Password: dbtest.StringPointer("123"), PasswordConfirm: dbtest.StringPointer("123")}},
+		{name: "manager-enabled", model: &usergrp.AppUpdateUser{Enabled: dbtest.BoolPointer(false)}},
+	}
+
+	for _, m := range models {
+		table = append(table, tableData{
+			name:       m.name,
+			url:        fmt.Sprintf("/v1/users/%s", sd.users[1].ID),
+			token:      sd.managers[0].token,
+			method:     http.MethodPut,
+			statusCode: http.StatusForbidden,
+			model:      m.model,
+			resp:       &web.ErrorResponse{},
+			expResp:    &web.ErrorResponse{},
+			cmpFunc: func(x interface{}, y interface{}) string {
+				if x.(*web.ErrorResponse).Error == "" {
+					return "missing error"
+				}
+				return ""
+			},
+		})
+	}
+
+	return table
+}
+
 func userUpdate401(sd seedData) []tableData {
 	table := []tableData{
 		{
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-json-experiment/json"
+	"github.com/testvergecloud/testApi/business/core/crud/apikey"
+	"github.com/testvergecloud/testApi/business/core/crud/apikey/stores/apikeydb"
+	"github.com/testvergecloud/testApi/business/core/crud/role"
+	"github.com/testvergecloud/testApi/business/core/crud/role/stores/roledb"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/core/crud/user/stores/userdb"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/foundation/config"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/google/uuid"
+)
+
+// APIKey manages the API keys of machine clients. Keys are created for a user
+// and scoped to a comma separated list of roles the user holds.
+func APIKey(log *logger.Logger, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Println("help: apikey create <user_id> <name> <scopes> [ttl]")
+		fmt.Println("      apikey list")
+		fmt.Println("      apikey revoke <key_id>")
+		return ErrHelp
+	}
+
+	db, err := sqldb.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The roles created by admins have to be known for the permissions the
+	// owners of the keys are granted.
+	roleCore := role.NewCore(log, roledb.NewStore(log, db))
+	if err := roleCore.Load(ctx); err != nil {
+		return fmt.Errorf("load roles: %w", err)
+	}
+
+	usrCore := user.NewCore(log, nil, nil, userdb.NewStore(log, db))
+	usrCore.Roles = roleCore
+	core := apikey.NewCore(log, usrCore, roleCore, apikeydb.NewStore(log, db), []byte(cfg.CDNApiKey))
+
+	// The admin tooling manages the keys of every tenant.
+	ctx = tenant.SetAll(ctx)
+
+	switch args[0] {
+	case "create":
+		return createAPIKey(ctx, core, args[1:])
+
+	case "list":
+		keys, err := core.QueryAll(ctx)
+		if err != nil {
+			return fmt.Errorf("retrieve api keys: %w", err)
+		}
+
+		return json.MarshalWrite(os.Stdout, keys, json.FormatNilSliceAsNull(true))
+
+	case "revoke":
+		if len(args) < 2 {
+			fmt.Println("help: apikey revoke <key_id>")
+			return ErrHelp
+		}
+
+		keyID, err := uuid.Parse(args[1])
+		if err != nil {
+			return fmt.Errorf("parsing key id: %w", err)
+		}
+
+		key, err := core.QueryByID(ctx, keyID)
+		if err != nil {
+			return fmt.Errorf("retrieve api key: %w", err)
+		}
+
+		if _, err := core.Revoke(ctx, key); err != nil {
+			return fmt.Errorf("revoking api key: %w", err)
+		}
+
+		fmt.Printf("api key revoked: id[%s] prefix[%s]\n", key.ID, key.Prefix)
+		return nil
+	}
+
+	fmt.Println("help: apikey create|list|revoke")
+	return ErrHelp
+}
+
+func createAPIKey(ctx context.Context, core *apikey.Core, args []string) error {
+	if len(args) < 3 {
+		fmt.Println("help: apikey create <user_id> <name> <scopes> [ttl]")
+		return ErrHelp
+	}
+
+	userID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("parsing user id: %w", err)
+	}
+
+	var scopes []string
+	for _, scope := range strings.Split(args[2], ",") {
+		scopes = append(scopes, strings.TrimSpace(scope))
+	}
+
+	nk := apikey.NewAPIKey{
+		UserID: userID,
+		Name:   args[1],
+		Scopes: scopes,
+	}
+
+	if len(args) > 3 {
+		ttl, err := time.ParseDuration(args[3])
+		if err != nil {
+			return fmt.Errorf("parsing ttl: %w", err)
+		}
+		nk.DateExpires = time.Now().Add(ttl)
+	}
+
+	rawKey, key, err := core.Create(ctx, nk)
+	if err != nil {
+		return fmt.Errorf("creating api key: %w", err)
+	}
+
+	fmt.Printf("api key created: id[%s] prefix[%s]\n", key.ID, key.Prefix)
+	fmt.Println("the key is only shown once:")
+	fmt.Println(rawKey)
+	return nil
+}
@@ -1,6 +1,10 @@
 package commands
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -9,13 +13,21 @@ import (
 	"os"
 )
 
-// GenKey creates an x509 private/public key for auth tokens.
-func GenKey() error {
+// Set of key types that can be generated.
+const (
+	KeyTypeRSA     = "rsa"
+	KeyTypeECDSA   = "ecdsa"
+	KeyTypeEd25519 = "ed25519"
+)
+
+// GenKey creates an x509 private/public key for auth tokens. The type of the
+// key decides the signing method of the tokens, rsa is used by default.
+func GenKey(keyType string) error {
 
-	// Generate a new private key.
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	// Generate a new private key in PEM form.
+	privateBlock, publicKey, err := generateKey(keyType)
 	if err != nil {
-		return fmt.Errorf("generating key: %w", err)
+		return err
 	}
 
 	// Create a file for the private key information in PEM form.
@@ -25,12 +37,6 @@ func GenKey() error {
 	}
 	defer privateFile.Close()
 
-	// Construct a PEM block for the private key.
-	privateBlock := pem.Block{
-		Type:  "PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
-	}
-
 	// Write the private key to the private key file.
 	if err := pem.Encode(privateFile, &privateBlock); err != nil {
 		return fmt.Errorf("encoding to private file: %w", err)
@@ -44,7 +50,7 @@ func GenKey() error {
 	defer publicFile.Close()
 
 	// Marshal the public key from the private key to PKIX.
-	asn1Bytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	asn1Bytes, err := x509.MarshalPKIXPublicKey(publicKey)
 	if err != nil {
 		return fmt.Errorf("marshaling public key: %w", err)
 	}
@@ -60,6 +66,54 @@ func GenKey() error {
 		return fmt.Errorf("encoding to public file: %w", err)
 	}
 
-	fmt.Println("private and public key files generated")
+	fmt.Printf("%s private and public key files generated\n", keyType)
 	return nil
 }
+
+// generateKey generates a new private key of the specified type and returns
+// it as a PEM block along with its public key. ECDSA keys use the P-256
+// curve.
+func generateKey(keyType string) (pem.Block, crypto.PublicKey, error) {
+	var privateKey crypto.Signer
+	var err error
+
+	switch keyType {
+	case "", KeyTypeRSA:
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return pem.Block{}, nil, fmt.Errorf("generating key: %w", err)
+		}
+
+		block := pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+		}
+
+		return block, rsaKey.Public(), nil
+
+	case KeyTypeECDSA:
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	case KeyTypeEd25519:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+
+	default:
+		return pem.Block{}, nil, fmt.Errorf("unknown key type %q, expected %s, %s or %s", keyType, KeyTypeRSA, KeyTypeECDSA, KeyTypeEd25519)
+	}
+
+	if err != nil {
+		return pem.Block{}, nil, fmt.Errorf("generating key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return pem.Block{}, nil, fmt.Errorf("marshaling private key: %w", err)
+	}
+
+	block := pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: der,
+	}
+
+	return block, privateKey.Public(), nil
+}
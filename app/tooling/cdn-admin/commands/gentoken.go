@@ -9,6 +9,7 @@ import (
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/core/crud/user/stores/userdb"
 	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/web/auth"
 	"github.com/testvergecloud/testApi/foundation/config"
 	"github.com/testvergecloud/testApi/foundation/keystore"
@@ -18,13 +19,9 @@ import (
 	"github.com/google/uuid"
 )
 
-// GenToken generates a JWT for the specified user.
-func GenToken(log *logger.Logger, cfg *config.Config, keyPath string, userID uuid.UUID, kid string) error {
-	if kid == "" {
-		fmt.Println("help: gentoken <user_id> <kid>")
-		return ErrHelp
-	}
-
+// GenToken generates a JWT for the specified user. The token is signed with
+// the newest active key.
+func GenToken(log *logger.Logger, cfg *config.Config, keyPath string, userID uuid.UUID) error {
 	db, err := sqldb.Open(cfg)
 	if err != nil {
 		return fmt.Errorf("connect database: %w", err)
@@ -34,15 +31,17 @@ func GenToken(log *logger.Logger, cfg *config.Config, keyPath string, userID uui
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	core := user.NewCore(log, nil, userdb.NewStore(log, db))
+	core := user.NewCore(log, nil, nil, userdb.NewStore(log, db))
 
-	usr, err := core.QueryByID(ctx, userID)
+	// The admin tooling isn't confined to a tenant, the token carries the
+	// tenant of the user.
+	usr, err := core.QueryByID(tenant.SetAll(ctx), userID)
 	if err != nil {
 		return fmt.Errorf("retrieve user: %w", err)
 	}
 
 	ks := keystore.New()
-	if err := ks.LoadRSAKeys(os.DirFS(keyPath)); err != nil {
+	if err := ks.LoadKeys(os.DirFS(keyPath)); err != nil {
 		return fmt.Errorf("reading keys: %w", err)
 	}
 
@@ -52,7 +51,7 @@ func GenToken(log *logger.Logger, cfg *config.Config, keyPath string, userID uui
 	// 	KeyLookup: ks,
 	// }
 
-	a, err := auth.New(cfg, db, ks, log)
+	a, err := auth.New(cfg, db, ks, log, nil, nil)
 	if err != nil {
 		return fmt.Errorf("constructing auth: %w", err)
 	}
@@ -75,14 +74,16 @@ func GenToken(log *logger.Logger, cfg *config.Config, keyPath string, userID uui
 			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(8760 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 		},
-		Roles: usr.Roles,
+		TenantID:   usr.TenantID,
+		Roles:      usr.Roles,
+		Department: usr.Department,
 	}
 
 	// This will generate a JWT with the claims embedded in them. The database
 	// with need to be configured with the information found in the public key
 	// file to validate these claims. Dgraph does not support key rotate at
 	// this time.
-	token, err := a.GenerateToken(kid, claims)
+	token, err := a.GenerateToken(claims)
 	if err != nil {
 		return fmt.Errorf("generating token: %w", err)
 	}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/revocation"
+	"github.com/testvergecloud/testApi/business/core/crud/revocation/stores/revocationdb"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/foundation/config"
+	"github.com/testvergecloud/testApi/foundation/keystore"
+	"github.com/testvergecloud/testApi/foundation/logger"
+)
+
+// RevokeToken revokes the specified JWT so the running services reject it
+// until it expires.
+func RevokeToken(log *logger.Logger, cfg *config.Config, keyPath string, token string) error {
+	if token == "" {
+		fmt.Println("help: revoke-token <token>")
+		return ErrHelp
+	}
+
+	db, err := sqldb.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ks := keystore.New()
+	if err := ks.LoadKeys(os.DirFS(keyPath)); err != nil {
+		return fmt.Errorf("reading keys: %w", err)
+	}
+
+	revCore := revocation.NewCore(log, revocationdb.NewStore(log, db))
+
+	a, err := auth.New(cfg, db, ks, log, revCore, nil)
+	if err != nil {
+		return fmt.Errorf("constructing auth: %w", err)
+	}
+
+	claims, err := a.Revoke(ctx, token)
+	if err != nil {
+		return fmt.Errorf("revoking token: %w", err)
+	}
+
+	fmt.Printf("token revoked: jti[%s] sub[%s]\n", claims.ID, claims.Subject)
+	return nil
+}
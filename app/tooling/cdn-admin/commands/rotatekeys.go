@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/testvergecloud/testApi/foundation/keystore"
+
+	"github.com/google/uuid"
+)
+
+// RotateKeys adds a new signing key to the keys folder and makes the keys
+// that were active verify only. They are retired once the grace period has
+// passed, which should be longer than the tokens they signed are valid for.
+// The running services pick up the change when they reload the folder.
+func RotateKeys(keyPath string, grace time.Duration, keyType string) error {
+	ks := keystore.New()
+	if err := ks.LoadKeys(os.DirFS(keyPath)); err != nil {
+		return fmt.Errorf("reading keys: %w", err)
+	}
+
+	privateBlock, _, err := generateKey(keyType)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	kid := uuid.NewString()
+
+	// The metadata is written first so the key is never seen without it.
+	md := keystore.Metadata{
+		Status:  keystore.StatusActive,
+		Created: now,
+	}
+
+	if err := writeMetadata(keyPath, kid, md); err != nil {
+		return err
+	}
+
+	if err := replaceFile(keyPath, kid+".pem", pem.EncodeToMemory(&privateBlock)); err != nil {
+		return fmt.Errorf("writing key: %w", err)
+	}
+
+	fmt.Printf("key generated: kid[%s]\n", kid)
+
+	retireAt := now.Add(grace)
+
+	for oldKID, md := range ks.Metadata() {
+		if md.Status != keystore.StatusActive {
+			continue
+		}
+
+		md.Status = keystore.StatusVerify
+		md.RetireAt = &retireAt
+
+		if err := writeMetadata(keyPath, oldKID, md); err != nil {
+			return err
+		}
+
+		fmt.Printf("key verify only: kid[%s] retired[%s]\n", oldKID, retireAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func writeMetadata(keyPath string, kid string, md keystore.Metadata) error {
+	data, err := json.MarshalIndent(md, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling metadata: kid[%s]: %w", kid, err)
+	}
+
+	if err := replaceFile(keyPath, kid+".json", data); err != nil {
+		return fmt.Errorf("writing metadata: kid[%s]: %w", kid, err)
+	}
+
+	return nil
+}
+
+// replaceFile replaces the file in one step so a service reloading the folder
+// never reads a partial file.
+func replaceFile(dir string, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
@@ -6,9 +6,12 @@ import (
 	"net/mail"
 	"time"
 
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/business/core/crud/audit/stores/auditdb"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/core/crud/user/stores/userdb"
 	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/foundation/config"
 	"github.com/testvergecloud/testApi/foundation/logger"
 )
@@ -29,7 +32,9 @@ func UserAdd(log *logger.Logger, cfg *config.Config, name, email, password strin
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	core := user.NewCore(log, nil, userdb.NewStore(log, db))
+	// Users added from the command line are recorded without an actor.
+	audCore := audit.NewCore(log, auditdb.NewStore(log, db))
+	core := user.NewCore(log, nil, audCore, userdb.NewStore(log, db))
 
 	addr, err := mail.ParseAddress(email)
 	if err != nil {
@@ -44,7 +49,8 @@ func UserAdd(log *logger.Logger, cfg *config.Config, name, email, password strin
 		Roles:           []user.Role{user.RoleAdmin, user.RoleUser},
 	}
 
-	usr, err := core.Create(ctx, nu)
+	// Users added from the command line join the default organization.
+	usr, err := core.Create(tenant.Set(ctx, tenant.DefaultID), nu)
 	if err != nil {
 		return fmt.Errorf("create user: %w", err)
 	}
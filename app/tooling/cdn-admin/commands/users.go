@@ -11,6 +11,7 @@ import (
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/core/crud/user/stores/userdb"
 	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/foundation/config"
 	"github.com/testvergecloud/testApi/foundation/logger"
 )
@@ -36,9 +37,10 @@ func Users(log *logger.Logger, cfg *config.Config, pageNumber string, rowsPerPag
 		return fmt.Errorf("converting rows per page: %w", err)
 	}
 
-	core := user.NewCore(log, nil, userdb.NewStore(log, db))
+	core := user.NewCore(log, nil, nil, userdb.NewStore(log, db))
 
-	users, err := core.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, page, rows)
+	// The admin tooling lists the users of every tenant.
+	users, err := core.Query(tenant.SetAll(ctx), user.QueryFilter{}, user.DefaultOrderBy, page, rows)
 	if err != nil {
 		return fmt.Errorf("retrieve users: %w", err)
 	}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/business/core/crud/audit/stores/auditdb"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/core/crud/user/stores/userdb"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/foundation/config"
+	"github.com/testvergecloud/testApi/foundation/logger"
+)
+
+// UserUnlock clears the failed logins of the user with the specified email so
+// they can log in right away.
+func UserUnlock(log *logger.Logger, cfg *config.Config, email string) error {
+	if email == "" {
+		fmt.Println("help: userunlock <email>")
+		return ErrHelp
+	}
+
+	db, err := sqldb.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Users unlocked from the command line are recorded without an actor.
+	audCore := audit.NewCore(log, auditdb.NewStore(log, db))
+	core := user.NewCore(log, nil, audCore, userdb.NewStore(log, db))
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return fmt.Errorf("parsing email: %w", err)
+	}
+
+	// Emails are unique across tenants.
+	ctx = tenant.SetAll(ctx)
+
+	usr, err := core.QueryByEmail(ctx, *addr)
+	if err != nil {
+		return fmt.Errorf("retrieve user: %w", err)
+	}
+
+	if _, err := core.Unlock(ctx, usr); err != nil {
+		return fmt.Errorf("unlock user: %w", err)
+	}
+
+	fmt.Println("user unlocked:", usr.ID)
+	return nil
+}
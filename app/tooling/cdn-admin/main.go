@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/testvergecloud/testApi/app/tooling/cdn-admin/commands"
 	"github.com/testvergecloud/testApi/foundation/config"
@@ -81,6 +82,14 @@ func run(cfg *config.Config, log *logger.Logger) {
 			return
 		}
 
+	case "userunlock":
+		email := os.Args[2]
+		if err := commands.UserUnlock(log, cfg, email); err != nil {
+			log.Error(ctx, "unlocking user: ", err)
+			fmt.Println(ctx, "unlocking user: ", err)
+			return
+		}
+
 	case "users":
 		pageNumber := os.Args[2]
 		rowsPerPage := os.Args[3]
@@ -91,7 +100,11 @@ func run(cfg *config.Config, log *logger.Logger) {
 		}
 
 	case "genkey":
-		if err := commands.GenKey(); err != nil {
+		var keyType string
+		if len(os.Args) > 2 {
+			keyType = os.Args[2]
+		}
+		if err := commands.GenKey(keyType); err != nil {
 			log.Error(ctx, "key generation: ", err)
 			fmt.Println(ctx, "key generation: ", err)
 			return
@@ -104,24 +117,60 @@ func run(cfg *config.Config, log *logger.Logger) {
 			fmt.Println(ctx, "generating token: ", err)
 			return
 		}
-		kid := os.Args[3]
-		if kid == "" {
-			kid = cfg.DefaultKID
-		}
-		if err := commands.GenToken(log, cfg, cfg.KeysFolder, userID, kid); err != nil {
+		if err := commands.GenToken(log, cfg, cfg.KeysFolder, userID); err != nil {
 			log.Error(ctx, "generating token: ", err)
 			fmt.Println(ctx, "generating token: ", err)
 			return
 		}
 
+	case "rotate-keys":
+		grace := cfg.KeyGracePeriod
+		if len(os.Args) > 2 {
+			d, err := time.ParseDuration(os.Args[2])
+			if err != nil {
+				log.Error(ctx, "rotating keys: ", err)
+				fmt.Println(ctx, "rotating keys: ", err)
+				return
+			}
+			grace = d
+		}
+		var keyType string
+		if len(os.Args) > 3 {
+			keyType = os.Args[3]
+		}
+		if err := commands.RotateKeys(cfg.KeysFolder, grace, keyType); err != nil {
+			log.Error(ctx, "rotating keys: ", err)
+			fmt.Println(ctx, "rotating keys: ", err)
+			return
+		}
+
+	case "revoke-token":
+		token := os.Args[2]
+		if err := commands.RevokeToken(log, cfg, cfg.KeysFolder, token); err != nil {
+			log.Error(ctx, "revoking token: ", err)
+			fmt.Println(ctx, "revoking token: ", err)
+			return
+		}
+
+	case "apikey":
+		if err := commands.APIKey(log, cfg, os.Args[2:]); err != nil {
+			log.Error(ctx, "managing api keys: ", err)
+			fmt.Println(ctx, "managing api keys: ", err)
+			return
+		}
+
 	default:
 		fmt.Println("domain:     add a new domain to the project")
 		fmt.Println("migrate:    create the schema in the database")
 		fmt.Println("seed:       add data to the database")
 		fmt.Println("useradd:    add a new user to the database")
+		fmt.Println("userunlock: let a user locked out by failed logins log in again")
 		fmt.Println("users:      get a list of users from the database")
-		fmt.Println("genkey:     generate a set of private/public key files")
+		fmt.Println("genkey:     generate a set of private/public key files (rsa, ecdsa or ed25519)")
 		fmt.Println("gentoken:   generate a JWT for a user with claims")
+		fmt.Println("rotate-keys: add a signing key and retire the old ones")
+		fmt.Println("revoke-token: revoke a JWT until it expires")
+		fmt.Println("apikey:     create, list or revoke the API keys of machine clients")
 		fmt.Println("provide a command to get more help.")
 		log.Error(ctx, "commands.ErrHelp: ", commands.ErrHelp)
 		return
@@ -0,0 +1,250 @@
+// Package apikey provides support for the API keys machine clients
+// authenticate with. A key acts for the user owning it, limited to the
+// permissions it was scoped to and its owner is still granted.
+package apikey
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/role"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/google/uuid"
+)
+
+// Set of error variables for API key operations.
+var (
+	ErrNotFound      = errors.New("api key not found")
+	ErrInvalidKey    = errors.New("api key not valid")
+	ErrExpired       = errors.New("api key expired")
+	ErrRevoked       = errors.New("api key revoked")
+	ErrOwnerDisabled = errors.New("api key owner disabled")
+	ErrNoScopes      = errors.New("api key needs at least one scope")
+	ErrScopeNotHeld  = errors.New("api key scope not held by the owner")
+)
+
+// Keys look like cdn_<prefix>_<secret>. The prefix identifies the key and is
+// shown in listings, the secret is what makes the key hard to guess.
+const (
+	keyTag       = "cdn_"
+	prefixBytes  = 6
+	prefixLength = prefixBytes * 2
+	secretBytes  = 32
+)
+
+// lastUsedResolution limits how often using a key is written to the
+// database, a key used on every request would otherwise cost a write each.
+const lastUsedResolution = time.Minute
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, key APIKey) error
+	Update(ctx context.Context, key APIKey) error
+	QueryAll(ctx context.Context) ([]APIKey, error)
+	QueryByID(ctx context.Context, keyID uuid.UUID) (APIKey, error)
+	QueryByPrefix(ctx context.Context, prefix string) (APIKey, error)
+}
+
+// Core manages the set of APIs for API key access.
+type Core struct {
+	log      *logger.Logger
+	usrCore  *user.Core
+	roleCore *role.Core
+	storer   Storer
+	secret   []byte
+}
+
+// NewCore constructs an API key core API for use. When a secret is provided
+// the keys are hashed with it, so a leaked table can't be checked against
+// guessed keys without it as well.
+func NewCore(log *logger.Logger, usrCore *user.Core, roleCore *role.Core, storer Storer, secret []byte) *Core {
+	return &Core{
+		log:      log,
+		usrCore:  usrCore,
+		roleCore: roleCore,
+		storer:   storer,
+		secret:   secret,
+	}
+}
+
+// Create adds a new API key for the user and returns the key along with what
+// is stored about it. The key is only available now, it can't be recovered
+// later. The roles of the owner have to grant every scope of the key.
+func (c *Core) Create(ctx context.Context, nk NewAPIKey) (string, APIKey, error) {
+	if len(nk.Scopes) == 0 {
+		return "", APIKey{}, ErrNoScopes
+	}
+
+	owner, err := c.usrCore.QueryByID(ctx, nk.UserID)
+	if err != nil {
+		return "", APIKey{}, fmt.Errorf("query owner: %w", err)
+	}
+
+	if !owner.Enabled {
+		return "", APIKey{}, ErrOwnerDisabled
+	}
+
+	perms := c.roleCore.Permissions(owner.Roles)
+	for _, scope := range nk.Scopes {
+		if !slices.Contains(perms, scope) {
+			return "", APIKey{}, fmt.Errorf("scope[%s]: %w", scope, ErrScopeNotHeld)
+		}
+	}
+
+	b := make([]byte, prefixBytes+secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", APIKey{}, fmt.Errorf("generating key: %w", err)
+	}
+
+	prefix := hex.EncodeToString(b[:prefixBytes])
+	rawKey := keyTag + prefix + "_" + base64.RawURLEncoding.EncodeToString(b[prefixBytes:])
+
+	now := time.Now()
+
+	key := APIKey{
+		ID:          uuid.New(),
+		TenantID:    owner.TenantID,
+		UserID:      owner.ID,
+		Name:        nk.Name,
+		Prefix:      prefix,
+		Hash:        c.hash(rawKey),
+		Scopes:      nk.Scopes,
+		DateCreated: now,
+		DateExpires: nk.DateExpires,
+	}
+
+	if err := c.storer.Create(ctx, key); err != nil {
+		return "", APIKey{}, fmt.Errorf("create: %w", err)
+	}
+
+	return rawKey, key, nil
+}
+
+// Revoke stops the key from authenticating. Revoking a key twice keeps the
+// original date.
+func (c *Core) Revoke(ctx context.Context, key APIKey) (APIKey, error) {
+	if !key.DateRevoked.IsZero() {
+		return key, nil
+	}
+
+	key.DateRevoked = time.Now()
+
+	if err := c.storer.Update(ctx, key); err != nil {
+		return APIKey{}, fmt.Errorf("update: %w", err)
+	}
+
+	return key, nil
+}
+
+// QueryAll retrieves the API keys the context can access ordered by the date
+// they were created.
+func (c *Core) QueryAll(ctx context.Context) ([]APIKey, error) {
+	keys, err := c.storer.QueryAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("queryall: %w", err)
+	}
+
+	return keys, nil
+}
+
+// QueryByID finds the API key by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, keyID uuid.UUID) (APIKey, error) {
+	key, err := c.storer.QueryByID(ctx, keyID)
+	if err != nil {
+		return APIKey{}, fmt.Errorf("query: keyID[%s]: %w", keyID, err)
+	}
+
+	return key, nil
+}
+
+// Authenticate checks the key is valid and returns it along with its owner.
+// The key has to be active and its owner enabled. The permissions the key
+// acts with are given by Permissions.
+func (c *Core) Authenticate(ctx context.Context, rawKey string) (APIKey, user.User, error) {
+	prefix, ok := parsePrefix(rawKey)
+	if !ok {
+		return APIKey{}, user.User{}, ErrInvalidKey
+	}
+
+	// The tenant isn't known until the key is found.
+	key, err := c.storer.QueryByPrefix(tenant.SetAll(ctx), prefix)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return APIKey{}, user.User{}, ErrInvalidKey
+		}
+		return APIKey{}, user.User{}, fmt.Errorf("querybyprefix: %w", err)
+	}
+
+	if !hmac.Equal(key.Hash, c.hash(rawKey)) {
+		return APIKey{}, user.User{}, ErrInvalidKey
+	}
+
+	now := time.Now()
+
+	switch {
+	case !key.DateRevoked.IsZero():
+		return APIKey{}, user.User{}, ErrRevoked
+
+	case !key.DateExpires.IsZero() && now.After(key.DateExpires):
+		return APIKey{}, user.User{}, ErrExpired
+	}
+
+	ctx = tenant.Set(ctx, key.TenantID)
+
+	owner, err := c.usrCore.QueryByID(ctx, key.UserID)
+	if err != nil {
+		return APIKey{}, user.User{}, fmt.Errorf("query owner: %w", err)
+	}
+
+	if !owner.Enabled {
+		return APIKey{}, user.User{}, ErrOwnerDisabled
+	}
+
+	// Failing to record the use doesn't fail the request.
+	if now.Sub(key.DateLastUsed) >= lastUsedResolution {
+		key.DateLastUsed = now
+		if err := c.storer.Update(ctx, key); err != nil {
+			c.log.Error(ctx, "apikey: last used", "keyID", key.ID, "ERROR", err)
+		}
+	}
+
+	return key, owner, nil
+}
+
+// =============================================================================
+
+// hash returns the value stored for a key. The keys are random so a fast
+// hash is enough to keep them useless if the table leaks.
+func (c *Core) hash(rawKey string) []byte {
+	if len(c.secret) == 0 {
+		sum := sha256.Sum256([]byte(rawKey))
+		return sum[:]
+	}
+
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(rawKey))
+	return mac.Sum(nil)
+}
+
+// parsePrefix returns the prefix of a key that is in the expected form.
+func parsePrefix(rawKey string) (string, bool) {
+	rest, found := strings.CutPrefix(rawKey, keyTag)
+	if !found || len(rest) <= prefixLength+1 || rest[prefixLength] != '_' {
+		return "", false
+	}
+
+	return rest[:prefixLength], true
+}
@@ -0,0 +1,174 @@
+package apikey_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/apikey"
+	"github.com/testvergecloud/testApi/business/core/crud/role"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/foundation/docker"
+
+	"github.com/google/uuid"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	code, err := run(m)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	os.Exit(code)
+}
+
+func run(m *testing.M) (int, error) {
+	var err error
+
+	c, err = dbtest.StartDB()
+	if err != nil {
+		return 1, err
+	}
+	defer dbtest.StopDB(c)
+
+	return m.Run(), nil
+}
+
+func Test_APIKey(t *testing.T) {
+	t.Run("authenticate", authenticate)
+}
+
+func authenticate(t *testing.T) {
+	test := dbtest.NewTest(t, c, "Test_APIKey/authenticate")
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.Set(context.Background(), tenant.DefaultID), 10*time.Second)
+	defer cancel()
+
+	// The owner is granted the permissions of the role, the scopes of a key
+	// are checked against them.
+	billing, err := api.Role.Create(ctx, role.NewRole{
+		Name:        "BILLING",
+		Description: "Reads the invoices",
+		Permissions: []string{"invoices:read"},
+	})
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	billingRole, err := user.ParseRole(billing.Name)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	usrs, err := user.TestGenerateSeedUsers(1, billingRole, api.User)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	nk := apikey.NewAPIKey{
+		UserID: usrs[0].ID,
+		Name:   "Billing",
+		Scopes: []string{"invoices:write"},
+	}
+
+	if _, _, err := api.APIKey.Create(ctx, nk); !errors.Is(err, apikey.ErrScopeNotHeld) {
+		t.Fatalf("Should NOT be able to scope a key to a permission the owner isn't granted : %v", err)
+	}
+
+	nk.Scopes = []string{"invoices:read"}
+
+	rawKey, key, err := api.APIKey.Create(ctx, nk)
+	if err != nil {
+		t.Fatalf("Should be able to create a key : %s", err)
+	}
+
+	if key.TenantID != usrs[0].TenantID {
+		t.Fatalf("Should create the key in the tenant of the owner : got %s", key.TenantID)
+	}
+
+	// The key is authenticated before the tenant of the request is known.
+	got, owner, err := api.APIKey.Authenticate(context.Background(), rawKey)
+	if err != nil {
+		t.Fatalf("Should be able to authenticate with the key : %s", err)
+	}
+
+	if owner.ID != usrs[0].ID || got.ID != key.ID {
+		t.Fatalf("Should get back the key and its owner : key %s owner %s", got.ID, owner.ID)
+	}
+
+	if perms := got.Permissions(api.Role.Permissions(owner.Roles)); len(perms) != 1 || perms[0] != "invoices:read" {
+		t.Fatalf("Should act with the scopes of the key : %v", perms)
+	}
+
+	// The key loses the scopes its owner is no longer granted.
+	if _, err := api.Role.Update(ctx, billing, role.UpdateRole{Permissions: []string{}}); err != nil {
+		t.Fatalf("Should be able to update the role : %s", err)
+	}
+
+	if perms := got.Permissions(api.Role.Permissions(owner.Roles)); len(perms) != 0 {
+		t.Fatalf("Should NOT act with the scopes the owner is no longer granted : %v", perms)
+	}
+
+	saved, err := api.APIKey.QueryByID(ctx, key.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the key by ID : %s", err)
+	}
+
+	if saved.DateLastUsed.IsZero() {
+		t.Fatal("Should record when the key was last used")
+	}
+
+	if _, _, err := api.APIKey.Authenticate(ctx, rawKey+"x"); !errors.Is(err, apikey.ErrInvalidKey) {
+		t.Fatalf("Should NOT be able to authenticate with a tampered key : %v", err)
+	}
+
+	if _, _, err := api.APIKey.Authenticate(ctx, "unknown"); !errors.Is(err, apikey.ErrInvalidKey) {
+		t.Fatalf("Should NOT be able to authenticate with an unknown key : %v", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := api.APIKey.QueryByID(tenant.Set(ctx, uuid.New()), key.ID); !errors.Is(err, apikey.ErrNotFound) {
+		t.Fatalf("Should NOT be able to retrieve the key from another tenant : %v", err)
+	}
+
+	if _, err := api.APIKey.Revoke(ctx, saved); err != nil {
+		t.Fatalf("Should be able to revoke the key : %s", err)
+	}
+
+	if _, _, err := api.APIKey.Authenticate(ctx, rawKey); !errors.Is(err, apikey.ErrRevoked) {
+		t.Fatalf("Should NOT be able to authenticate with a revoked key : %v", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	nk.DateExpires = time.Now().Add(-time.Minute)
+
+	expired, _, err := api.APIKey.Create(ctx, nk)
+	if err != nil {
+		t.Fatalf("Should be able to create a key : %s", err)
+	}
+
+	if _, _, err := api.APIKey.Authenticate(ctx, expired); !errors.Is(err, apikey.ErrExpired) {
+		t.Fatalf("Should NOT be able to authenticate with an expired key : %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package apikey
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey represents a key a machine client authenticates with on behalf of
+// the user owning it. The key itself is never stored, only its hash. The
+// prefix is stored in the clear so the key can be found and recognized.
+type APIKey struct {
+	ID           uuid.UUID
+	TenantID     uuid.UUID
+	UserID       uuid.UUID
+	Name         string
+	Prefix       string
+	Hash         []byte
+	Scopes       []string
+	DateCreated  time.Time
+	DateExpires  time.Time
+	DateLastUsed time.Time
+	DateRevoked  time.Time
+}
+
+// Permissions returns the scopes of the key the owner is still granted by
+// the specified permissions. The key never grants more than its owner has.
+func (k APIKey) Permissions(ownerPermissions []string) []string {
+	perms := make([]string, 0, len(k.Scopes))
+	for _, scope := range k.Scopes {
+		if slices.Contains(ownerPermissions, scope) {
+			perms = append(perms, scope)
+		}
+	}
+
+	return perms
+}
+
+// NewAPIKey contains information needed to create a new API key. A zero
+// DateExpires creates a key that never expires.
+type NewAPIKey struct {
+	UserID      uuid.UUID
+	Name        string
+	Scopes      []string
+	DateExpires time.Time
+}
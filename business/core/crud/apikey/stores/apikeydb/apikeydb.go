@@ -0,0 +1,181 @@
+// Package apikeydb contains API key related CRUD functionality.
+package apikeydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/testvergecloud/testApi/business/core/crud/apikey"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for API key database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new API key into the database.
+func (s *Store) Create(ctx context.Context, key apikey.APIKey) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !scope.Allows(key.TenantID) {
+		return tenant.ErrForbidden
+	}
+
+	const q = `
+	INSERT INTO api_keys
+		(api_key_id, tenant_id, user_id, name, prefix, key_hash, scopes, date_created, date_expires)
+	VALUES
+		(:api_key_id, :tenant_id, :user_id, :name, :prefix, :key_hash, :scopes, :date_created, :date_expires)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBAPIKey(key)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update records the use and the revocation of an API key in the database.
+func (s *Store) Update(ctx context.Context, key apikey.APIKey) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	const q = `
+	UPDATE
+		api_keys
+	SET
+		"date_last_used" = :date_last_used,
+		"date_revoked" = :date_revoked
+	WHERE
+		api_key_id = :api_key_id AND ` + tenant.Clause
+
+	affected, err := sqldb.NamedExecContextAffected(ctx, s.log, s.db, q, scoped{toDBAPIKey(key), scope})
+	if err != nil {
+		return fmt.Errorf("namedexeccontextaffected: %w", err)
+	}
+
+	if affected == 0 {
+		return apikey.ErrNotFound
+	}
+
+	return nil
+}
+
+// QueryAll retrieves the API keys of the scope from the database ordered by
+// the date they were created.
+func (s *Store) QueryAll(ctx context.Context) ([]apikey.APIKey, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	const q = `
+	SELECT
+		api_key_id, tenant_id, user_id, name, prefix, key_hash, scopes, date_created, date_expires, date_last_used, date_revoked
+	FROM
+		api_keys
+	WHERE ` + tenant.Clause + `
+	ORDER BY
+		date_created`
+
+	var dbKeys []dbAPIKey
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, scope, &dbKeys); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreAPIKeySlice(dbKeys)
+}
+
+// QueryByID gets the specified API key from the database.
+func (s *Store) QueryByID(ctx context.Context, keyID uuid.UUID) (apikey.APIKey, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return apikey.APIKey{}, err
+	}
+
+	data := struct {
+		ID string `db:"api_key_id"`
+		tenant.Scope
+	}{
+		ID:    keyID.String(),
+		Scope: scope,
+	}
+
+	const q = `
+	SELECT
+		api_key_id, tenant_id, user_id, name, prefix, key_hash, scopes, date_created, date_expires, date_last_used, date_revoked
+	FROM
+		api_keys
+	WHERE
+		api_key_id = :api_key_id AND ` + tenant.Clause
+
+	var dbKey dbAPIKey
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbKey); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return apikey.APIKey{}, fmt.Errorf("namedquerystruct: %w", apikey.ErrNotFound)
+		}
+		return apikey.APIKey{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreAPIKey(dbKey)
+}
+
+// QueryByPrefix gets the API key with the specified prefix from the database.
+func (s *Store) QueryByPrefix(ctx context.Context, prefix string) (apikey.APIKey, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return apikey.APIKey{}, err
+	}
+
+	data := struct {
+		Prefix string `db:"prefix"`
+		tenant.Scope
+	}{
+		Prefix: prefix,
+		Scope:  scope,
+	}
+
+	const q = `
+	SELECT
+		api_key_id, tenant_id, user_id, name, prefix, key_hash, scopes, date_created, date_expires, date_last_used, date_revoked
+	FROM
+		api_keys
+	WHERE
+		prefix = :prefix AND ` + tenant.Clause
+
+	var dbKey dbAPIKey
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbKey); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return apikey.APIKey{}, fmt.Errorf("namedquerystruct: %w", apikey.ErrNotFound)
+		}
+		return apikey.APIKey{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreAPIKey(dbKey)
+}
+
+// scoped binds the tenant scope of the request along with the API key.
+type scoped struct {
+	dbAPIKey
+	tenant.Scope
+}
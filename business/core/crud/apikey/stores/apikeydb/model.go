@@ -0,0 +1,91 @@
+package apikeydb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/apikey"
+	"github.com/testvergecloud/testApi/business/data/sqldb/dbarray"
+
+	"github.com/google/uuid"
+)
+
+type dbAPIKey struct {
+	ID           uuid.UUID      `db:"api_key_id"`
+	TenantID     uuid.UUID      `db:"tenant_id"`
+	UserID       uuid.UUID      `db:"user_id"`
+	Name         string         `db:"name"`
+	Prefix       string         `db:"prefix"`
+	Hash         []byte         `db:"key_hash"`
+	Scopes       dbarray.String `db:"scopes"`
+	DateCreated  time.Time      `db:"date_created"`
+	DateExpires  sql.NullTime   `db:"date_expires"`
+	DateLastUsed sql.NullTime   `db:"date_last_used"`
+	DateRevoked  sql.NullTime   `db:"date_revoked"`
+}
+
+func toDBAPIKey(key apikey.APIKey) dbAPIKey {
+	return dbAPIKey{
+		ID:          key.ID,
+		TenantID:    key.TenantID,
+		UserID:      key.UserID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Hash:        key.Hash,
+		Scopes:      key.Scopes,
+		DateCreated: key.DateCreated.UTC(),
+		DateExpires: sql.NullTime{
+			Time:  key.DateExpires.UTC(),
+			Valid: !key.DateExpires.IsZero(),
+		},
+		DateLastUsed: sql.NullTime{
+			Time:  key.DateLastUsed.UTC(),
+			Valid: !key.DateLastUsed.IsZero(),
+		},
+		DateRevoked: sql.NullTime{
+			Time:  key.DateRevoked.UTC(),
+			Valid: !key.DateRevoked.IsZero(),
+		},
+	}
+}
+
+func toCoreAPIKey(dbKey dbAPIKey) (apikey.APIKey, error) {
+	key := apikey.APIKey{
+		ID:          dbKey.ID,
+		TenantID:    dbKey.TenantID,
+		UserID:      dbKey.UserID,
+		Name:        dbKey.Name,
+		Prefix:      dbKey.Prefix,
+		Hash:        dbKey.Hash,
+		Scopes:      dbKey.Scopes,
+		DateCreated: dbKey.DateCreated.In(time.Local),
+	}
+
+	if dbKey.DateExpires.Valid {
+		key.DateExpires = dbKey.DateExpires.Time.In(time.Local)
+	}
+
+	if dbKey.DateLastUsed.Valid {
+		key.DateLastUsed = dbKey.DateLastUsed.Time.In(time.Local)
+	}
+
+	if dbKey.DateRevoked.Valid {
+		key.DateRevoked = dbKey.DateRevoked.Time.In(time.Local)
+	}
+
+	return key, nil
+}
+
+func toCoreAPIKeySlice(dbKeys []dbAPIKey) ([]apikey.APIKey, error) {
+	keys := make([]apikey.APIKey, len(dbKeys))
+
+	for i, dbKey := range dbKeys {
+		var err error
+		keys[i], err = toCoreAPIKey(dbKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,160 @@
+// Package audit provides a core business API for recording who changed what
+// in the system. The other cores record their mutations here so the changes
+// can be reviewed later.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/web"
+
+	"github.com/google/uuid"
+)
+
+// Set of actions that are recorded for an entity.
+const (
+	ActionCreated  = "created"
+	ActionUpdated  = "updated"
+	ActionDeleted  = "deleted"
+	ActionRestored = "restored"
+)
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+	Create(ctx context.Context, aud Audit) error
+	CreateMany(ctx context.Context, auds []Audit) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Audit, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+}
+
+// Core manages the set of APIs for audit access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewCore constructs an audit core API for use.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	storer, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	core := Core{
+		log:    c.log,
+		storer: storer,
+	}
+
+	return &core, nil
+}
+
+// Record adds an audit entry for a change made to an entity. The before and
+// after values are compared to store only the fields that changed, a nil
+// value represents an entity that doesn't exist on that side of the change.
+// The actor and trace ID are taken from the context. A nil Core records
+// nothing so cores constructed for query only don't need an audit log.
+func (c *Core) Record(ctx context.Context, domain string, action string, tenantID uuid.UUID, entityID uuid.UUID, before any, after any) error {
+	if c == nil {
+		return nil
+	}
+
+	diff, err := Diff(before, after)
+	if err != nil {
+		return fmt.Errorf("diff: %w", err)
+	}
+
+	aud := Audit{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		ActorID:   GetActorID(ctx),
+		Domain:    domain,
+		Action:    action,
+		EntityID:  entityID,
+		Diff:      diff,
+		TraceID:   web.GetTraceID(ctx),
+		Timestamp: time.Now(),
+	}
+
+	if err := c.storer.Create(ctx, aud); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	return nil
+}
+
+// RecordMany adds an audit entry for each of the mutations made by the same
+// action, storing them together. A nil Core records nothing.
+func (c *Core) RecordMany(ctx context.Context, domain string, action string, muts []Mutation) error {
+	if c == nil || len(muts) == 0 {
+		return nil
+	}
+
+	actorID := GetActorID(ctx)
+	traceID := web.GetTraceID(ctx)
+	now := time.Now()
+
+	auds := make([]Audit, len(muts))
+	for i, mut := range muts {
+		diff, err := Diff(mut.Before, mut.After)
+		if err != nil {
+			return fmt.Errorf("diff: entityID[%s]: %w", mut.EntityID, err)
+		}
+
+		auds[i] = Audit{
+			ID:        uuid.New(),
+			TenantID:  mut.TenantID,
+			ActorID:   actorID,
+			Domain:    domain,
+			Action:    action,
+			EntityID:  mut.EntityID,
+			Diff:      diff,
+			TraceID:   traceID,
+			Timestamp: now,
+		}
+	}
+
+	if err := c.storer.CreateMany(ctx, auds); err != nil {
+		return fmt.Errorf("createmany: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing audit entries.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Audit, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	auds, err := c.storer.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return auds, nil
+}
+
+// Count returns the total number of audit entries.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	if err := filter.Validate(); err != nil {
+		return 0, err
+	}
+
+	return c.storer.Count(ctx, filter)
+}
@@ -0,0 +1,65 @@
+package audit_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Diff(t *testing.T) {
+	type entity struct {
+		Name string
+		Cost float64
+	}
+
+	tests := []struct {
+		name   string
+		before any
+		after  any
+		exp    map[string]audit.Change
+	}{
+		{
+			name:  "created",
+			after: entity{Name: "Comics", Cost: 10},
+			exp: map[string]audit.Change{
+				"Name": {After: json.RawMessage(`"Comics"`)},
+				"Cost": {After: json.RawMessage(`10`)},
+			},
+		},
+		{
+			name:   "updated",
+			before: entity{Name: "Comics", Cost: 10},
+			after:  entity{Name: "Comics", Cost: 20},
+			exp: map[string]audit.Change{
+				"Cost": {Before: json.RawMessage(`10`), After: json.RawMessage(`20`)},
+			},
+		},
+		{
+			name:   "unchanged",
+			before: entity{Name: "Comics", Cost: 10},
+			after:  entity{Name: "Comics", Cost: 10},
+			exp:    map[string]audit.Change{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := audit.Diff(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("Should be able to diff the values: %s", err)
+			}
+
+			var got map[string]audit.Change
+			if err := json.Unmarshal(diff, &got); err != nil {
+				t.Fatalf("Should be able to unmarshal the diff: %s", err)
+			}
+
+			if d := cmp.Diff(tt.exp, got); d != "" {
+				t.Fatalf("Should get the expected changes, dif:\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type ctxKey int
+
+const actorKey ctxKey = 1
+
+// SetActorID stores the ID of the user making the request in the context so
+// changes can be attributed to them.
+func SetActorID(ctx context.Context, actorID uuid.UUID) context.Context {
+	return context.WithValue(ctx, actorKey, actorID)
+}
+
+// GetActorID returns the ID of the user making the request from the context.
+// The zero ID is returned for changes made outside of a request.
+func GetActorID(ctx context.Context) uuid.UUID {
+	v, ok := ctx.Value(actorKey).(uuid.UUID)
+	if !ok {
+		return uuid.UUID{}
+	}
+	return v
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/google/uuid"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+// We are using pointer semantics because the With API mutates the value.
+type QueryFilter struct {
+	ActorID   *uuid.UUID
+	EntityID  *uuid.UUID
+	Domain    *string
+	Action    *string
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+// Validate can perform a check of the data against the validate tags.
+func (qf *QueryFilter) Validate() error {
+	if err := validate.Check(qf); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	if qf.StartDate != nil && qf.EndDate != nil && qf.EndDate.Before(*qf.StartDate) {
+		return fmt.Errorf("validate: %w", validate.NewFieldsError("end_date", errors.New("end_date is before start_date")))
+	}
+
+	return nil
+}
+
+// WithActorID sets the ActorID field of the QueryFilter value.
+func (qf *QueryFilter) WithActorID(actorID uuid.UUID) {
+	qf.ActorID = &actorID
+}
+
+// WithEntityID sets the EntityID field of the QueryFilter value.
+func (qf *QueryFilter) WithEntityID(entityID uuid.UUID) {
+	qf.EntityID = &entityID
+}
+
+// WithDomain sets the Domain field of the QueryFilter value.
+func (qf *QueryFilter) WithDomain(domain string) {
+	qf.Domain = &domain
+}
+
+// WithAction sets the Action field of the QueryFilter value.
+func (qf *QueryFilter) WithAction(action string) {
+	qf.Action = &action
+}
+
+// WithStartDate sets the StartDate field of the QueryFilter value.
+func (qf *QueryFilter) WithStartDate(startDate time.Time) {
+	d := startDate.UTC()
+	qf.StartDate = &d
+}
+
+// WithEndDate sets the EndDate field of the QueryFilter value.
+func (qf *QueryFilter) WithEndDate(endDate time.Time) {
+	d := endDate.UTC()
+	qf.EndDate = &d
+}
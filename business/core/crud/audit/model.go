@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit represents a single change made to an entity. The entry belongs to the
+// tenant of the entity.
+type Audit struct {
+	ID        uuid.UUID
+	TenantID  uuid.UUID
+	ActorID   uuid.UUID
+	Domain    string
+	Action    string
+	EntityID  uuid.UUID
+	Diff      json.RawMessage
+	TraceID   string
+	Timestamp time.Time
+}
+
+// Mutation represents a change made to an entity, recorded by RecordMany. A
+// nil value represents an entity that doesn't exist on that side of the
+// change.
+type Mutation struct {
+	TenantID uuid.UUID
+	EntityID uuid.UUID
+	Before   any
+	After    any
+}
+
+// Change represents the before and after value of a single field. A missing
+// value means the field didn't exist on that side of the change.
+type Change struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// Diff compares the JSON representation of the before and after values and
+// returns the set of fields that changed, keyed by field name.
+func Diff(before any, after any) (json.RawMessage, error) {
+	b, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+
+	for field, value := range b {
+		if string(a[field]) != string(value) {
+			changes[field] = Change{Before: value, After: a[field]}
+		}
+	}
+
+	for field, value := range a {
+		if _, exists := b[field]; !exists {
+			changes[field] = Change{After: value}
+		}
+	}
+
+	return json.Marshal(changes)
+}
+
+func toFields(v any) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,14 @@
+package audit
+
+import "github.com/testvergecloud/testApi/business/web/order"
+
+// DefaultOrderBy represents the default way we sort.
+var DefaultOrderBy = order.NewBy(OrderByTimestamp, order.DESC)
+
+// Set of fields that the results can be ordered by.
+const (
+	OrderByID        = "audit_id"
+	OrderByActorID   = "actor_id"
+	OrderByDomain    = "domain"
+	OrderByTimestamp = "timestamp"
+)
@@ -0,0 +1,169 @@
+// Package auditdb contains audit related CRUD functionality.
+package auditdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for audit database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (audit.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new audit entry into the database.
+func (s *Store) Create(ctx context.Context, aud audit.Audit) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !scope.Allows(aud.TenantID) {
+		return tenant.ErrForbidden
+	}
+
+	const q = `
+	INSERT INTO audits
+		(audit_id, tenant_id, actor_id, domain, action, entity_id, diff, trace_id, timestamp)
+	VALUES
+		(:audit_id, :tenant_id, :actor_id, :domain, :action, :entity_id, :diff, :trace_id, :timestamp)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBAudit(aud)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// CreateMany inserts the audit entries into the database in a single
+// statement.
+func (s *Store) CreateMany(ctx context.Context, auds []audit.Audit) error {
+	if len(auds) == 0 {
+		return nil
+	}
+
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, aud := range auds {
+		if !scope.Allows(aud.TenantID) {
+			return tenant.ErrForbidden
+		}
+	}
+
+	const q = `
+	INSERT INTO audits
+		(audit_id, tenant_id, actor_id, domain, action, entity_id, diff, trace_id, timestamp)
+	VALUES
+		(:audit_id, :tenant_id, :actor_id, :domain, :action, :entity_id, :diff, :trace_id, :timestamp)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBAudits(auds)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing audit entries from the database.
+func (s *Store) Query(ctx context.Context, filter audit.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]audit.Audit, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+	scope.Bind(data)
+
+	const q = `
+	SELECT
+		audit_id, tenant_id, actor_id, domain, action, entity_id, diff, trace_id, timestamp
+	FROM
+		audits`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf, tenant.Clause)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbAuds []dbAudit
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbAuds); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreAudits(dbAuds), nil
+}
+
+// Count returns the total number of audit entries in the DB.
+func (s *Store) Count(ctx context.Context, filter audit.QueryFilter) (int, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	data := map[string]interface{}{}
+	scope.Bind(data)
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		audits`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf, tenant.Clause)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
@@ -0,0 +1,47 @@
+package auditdb
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+)
+
+// applyFilter writes the WHERE clause for the filter along with any
+// additional clauses provided by the caller.
+func (s *Store) applyFilter(filter audit.QueryFilter, data map[string]interface{}, buf *bytes.Buffer, wc ...string) {
+	if filter.ActorID != nil {
+		data["actor_id"] = *filter.ActorID
+		wc = append(wc, "actor_id = :actor_id")
+	}
+
+	if filter.EntityID != nil {
+		data["entity_id"] = *filter.EntityID
+		wc = append(wc, "entity_id = :entity_id")
+	}
+
+	if filter.Domain != nil {
+		data["domain"] = *filter.Domain
+		wc = append(wc, "domain = :domain")
+	}
+
+	if filter.Action != nil {
+		data["action"] = *filter.Action
+		wc = append(wc, "action = :action")
+	}
+
+	if filter.StartDate != nil {
+		data["start_date"] = *filter.StartDate
+		wc = append(wc, "timestamp >= :start_date")
+	}
+
+	if filter.EndDate != nil {
+		data["end_date"] = *filter.EndDate
+		wc = append(wc, "timestamp <= :end_date")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
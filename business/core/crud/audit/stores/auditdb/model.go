@@ -0,0 +1,74 @@
+package auditdb
+
+import (
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+
+	"github.com/google/uuid"
+)
+
+type dbAudit struct {
+	ID        uuid.UUID `db:"audit_id"`
+	TenantID  uuid.UUID `db:"tenant_id"`
+	ActorID   uuid.UUID `db:"actor_id"`
+	Domain    string    `db:"domain"`
+	Action    string    `db:"action"`
+	EntityID  uuid.UUID `db:"entity_id"`
+	Diff      string    `db:"diff"`
+	TraceID   string    `db:"trace_id"`
+	Timestamp time.Time `db:"timestamp"`
+}
+
+func toDBAudit(aud audit.Audit) dbAudit {
+	diff := string(aud.Diff)
+	if diff == "" {
+		diff = "{}"
+	}
+
+	return dbAudit{
+		ID:        aud.ID,
+		TenantID:  aud.TenantID,
+		ActorID:   aud.ActorID,
+		Domain:    aud.Domain,
+		Action:    aud.Action,
+		EntityID:  aud.EntityID,
+		Diff:      diff,
+		TraceID:   aud.TraceID,
+		Timestamp: aud.Timestamp.UTC(),
+	}
+}
+
+func toDBAudits(auds []audit.Audit) []dbAudit {
+	dbAuds := make([]dbAudit, len(auds))
+
+	for i, aud := range auds {
+		dbAuds[i] = toDBAudit(aud)
+	}
+
+	return dbAuds
+}
+
+func toCoreAudit(dbAud dbAudit) audit.Audit {
+	return audit.Audit{
+		ID:        dbAud.ID,
+		TenantID:  dbAud.TenantID,
+		ActorID:   dbAud.ActorID,
+		Domain:    dbAud.Domain,
+		Action:    dbAud.Action,
+		EntityID:  dbAud.EntityID,
+		Diff:      []byte(dbAud.Diff),
+		TraceID:   dbAud.TraceID,
+		Timestamp: dbAud.Timestamp.In(time.Local),
+	}
+}
+
+func toCoreAudits(dbAuds []dbAudit) []audit.Audit {
+	auds := make([]audit.Audit, len(dbAuds))
+
+	for i, dbAud := range dbAuds {
+		auds[i] = toCoreAudit(dbAud)
+	}
+
+	return auds
+}
@@ -0,0 +1,43 @@
+package auditdb
+
+import (
+	"fmt"
+
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/business/web/order"
+)
+
+var orderByFields = map[string]string{
+	audit.OrderByID:        "audit_id",
+	audit.OrderByActorID:   "actor_id",
+	audit.OrderByDomain:    "domain",
+	audit.OrderByTimestamp: "timestamp",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	clause := " ORDER BY "
+	var hasKey bool
+
+	for i, by := range orderBy.Fields() {
+		column, exists := orderByFields[by.Field]
+		if !exists {
+			return "", fmt.Errorf("field %q does not exist", by.Field)
+		}
+
+		if i > 0 {
+			clause += ", "
+		}
+		clause += column + " " + by.Direction
+
+		if column == "audit_id" {
+			hasKey = true
+		}
+	}
+
+	// The ID breaks ties so the order is stable across pages.
+	if !hasKey {
+		clause += ", audit_id ASC"
+	}
+
+	return clause, nil
+}
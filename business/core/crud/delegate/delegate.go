@@ -4,10 +4,45 @@ package delegate
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/business/data/transaction"
 	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/worker"
+
+	"github.com/google/uuid"
+)
+
+// Set of error variables for delegate operations.
+var (
+	ErrNotFound        = errors.New("dead letter not found")
+	ErrNoDeadLetter    = errors.New("dead letter store not configured")
+	ErrAlreadyReplayed = errors.New("dead letter already replayed")
+	ErrFuncNotFound    = errors.New("registered function not found")
 )
 
+// Storer interface declares the behavior this package needs to persist and
+// retrieve outbox events.
+type Storer interface {
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+	Create(ctx context.Context, evt Event) error
+	Update(ctx context.Context, evt Event) error
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Event, error)
+}
+
+// DeadLetterStorer interface declares the behavior this package needs to
+// persist and retrieve events that could not be delivered.
+type DeadLetterStorer interface {
+	Create(ctx context.Context, dl DeadLetter) error
+	Update(ctx context.Context, dl DeadLetter) error
+	Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]DeadLetter, error)
+	Count(ctx context.Context) (int, error)
+	QueryByID(ctx context.Context, deadLetterID uuid.UUID) (DeadLetter, error)
+}
+
 // These types are just for documentation so we know what keys go
 // where in the map.
 type (
@@ -18,48 +53,313 @@ type (
 // Delegate manages the set of functions to be called by core
 // packages when an import is not possible.
 type Delegate struct {
-	log   *logger.Logger
-	funcs map[domain]map[action][]Func
+	log        *logger.Logger
+	storer     Storer
+	worker     *worker.Worker
+	deadLetter DeadLetterStorer
+	failFast   bool
+	funcs      map[domain]map[action][]handler
 }
 
-// New constructs a delegate for indirect api access.
-func New(log *logger.Logger) *Delegate {
+// New constructs a delegate for indirect api access. By default events are
+// delivered synchronously on the G making the call.
+func New(log *logger.Logger, options ...func(opts *Options)) *Delegate {
+	var opts Options
+	for _, option := range options {
+		option(&opts)
+	}
+
 	return &Delegate{
-		log:   log,
-		funcs: make(map[domain]map[action][]Func),
+		log:        log,
+		storer:     opts.storer,
+		worker:     opts.worker,
+		deadLetter: opts.deadLetter,
+		failFast:   opts.failFast,
+		funcs:      make(map[domain]map[action][]handler),
 	}
 }
 
+// ExecuteUnderTransaction constructs a new Delegate value that will write
+// outbox events using the specified transaction. The registered functions
+// are shared with the original value. Dead letters are never written under
+// the transaction so they survive a rollback.
+func (d *Delegate) ExecuteUnderTransaction(tx transaction.Transaction) (*Delegate, error) {
+	if d.storer == nil {
+		return d, nil
+	}
+
+	storer, err := d.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	dlg := *d
+	dlg.storer = storer
+
+	return &dlg, nil
+}
+
 // Register adds a function to be called for a specified domain and action.
-func (d *Delegate) Register(domainType string, actionType string, fn Func) {
+//
+// Dead letters find their function again by name, so a delegate with a dead
+// letter store requires every function to be given a stable name using
+// WithName. Registering a function without one, or under a name already
+// registered for the domain and action, is a programming error and panics.
+func (d *Delegate) Register(domainType string, actionType string, fn Func, options ...func(opts *FuncOptions)) {
+	h := newHandler(fn, options...)
+
+	if d.deadLetter != nil && !h.named {
+		panic(fmt.Sprintf("delegate: register %s.%s: function requires a name when dead letters are recorded", domainType, actionType))
+	}
+
 	aMap, ok := d.funcs[domain(domainType)]
 	if !ok {
-		aMap = make(map[action][]Func)
+		aMap = make(map[action][]handler)
 		d.funcs[domain(domainType)] = aMap
 	}
 
-	funcs := aMap[action(actionType)]
-	funcs = append(funcs, fn)
-	aMap[action(actionType)] = funcs
+	handlers := aMap[action(actionType)]
+	for _, registered := range handlers {
+		if registered.name == h.name {
+			panic(fmt.Sprintf("delegate: register %s.%s: function %q already registered", domainType, actionType, h.name))
+		}
+	}
+
+	aMap[action(actionType)] = append(handlers, h)
 }
 
 // Call executes all functions registered for the specified domain and
-// action. These functions are executed synchronously on the G making the call.
+// action.
+//
+// If the delegate was constructed with an outbox, the event is written to the
+// outbox and the error from that write is returned. If the delegate was
+// constructed with a worker, each function is executed on its own G and only
+// an error starting the work is returned. Otherwise the functions are
+// executed synchronously on the G making the call. In fail fast mode the
+// first error stops the call and is returned, else errors are logged and the
+// event is written to the dead letter store.
 func (d *Delegate) Call(ctx context.Context, data Data) error {
+	switch {
+	case d.storer != nil:
+		return d.publish(ctx, data)
+
+	case d.worker != nil:
+		return d.callAsync(ctx, data)
+
+	case d.failFast:
+		return d.CallFailFast(ctx, data)
+	}
+
 	d.log.Info(ctx, "delegate call", "status", "started", "domain", data.Domain, "action", data.Action, "params", data.RawParams)
 	defer d.log.Info(ctx, "delegate call", "status", "completed")
 
-	if dMap, ok := d.funcs[domain(data.Domain)]; ok {
-		if funcs, ok := dMap[action(data.Action)]; ok {
-			for _, fn := range funcs {
-				d.log.Info(ctx, "delegate call", "status", "sending")
+	for _, h := range d.handlers(data) {
+		attempts, err := h.execute(ctx, data)
+		if err != nil {
+			d.log.Error(ctx, "delegate call", "func", h.name, "attempts", attempts, "msg", err)
+			d.bury(ctx, data, h, attempts, err)
+		}
+	}
+
+	return nil
+}
+
+// CallFailFast executes all functions registered for the specified domain
+// and action synchronously on the G making the call, regardless of how the
+// delegate was constructed. The first function to fail stops the call and
+// its error is returned.
+func (d *Delegate) CallFailFast(ctx context.Context, data Data) error {
+	d.log.Info(ctx, "delegate call", "status", "started", "mode", "fail fast", "domain", data.Domain, "action", data.Action, "params", data.RawParams)
+	defer d.log.Info(ctx, "delegate call", "status", "completed")
+
+	for _, h := range d.handlers(data) {
+		if _, err := h.execute(ctx, data); err != nil {
+			return fmt.Errorf("func[%s]: %w", h.name, err)
+		}
+	}
+
+	return nil
+}
+
+// QueryDeadLetters retrieves a list of dead letters.
+func (d *Delegate) QueryDeadLetters(ctx context.Context, pageNumber int, rowsPerPage int) ([]DeadLetter, error) {
+	if d.deadLetter == nil {
+		return nil, ErrNoDeadLetter
+	}
+
+	dls, err := d.deadLetter.Query(ctx, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return dls, nil
+}
+
+// CountDeadLetters returns the total number of dead letters.
+func (d *Delegate) CountDeadLetters(ctx context.Context) (int, error) {
+	if d.deadLetter == nil {
+		return 0, ErrNoDeadLetter
+	}
+
+	return d.deadLetter.Count(ctx)
+}
+
+// Replay executes the function recorded in the dead letter again. The dead
+// letter is marked as replayed when the function succeeds.
+func (d *Delegate) Replay(ctx context.Context, deadLetterID uuid.UUID) (DeadLetter, error) {
+	if d.deadLetter == nil {
+		return DeadLetter{}, ErrNoDeadLetter
+	}
+
+	dl, err := d.deadLetter.QueryByID(ctx, deadLetterID)
+	if err != nil {
+		return DeadLetter{}, fmt.Errorf("query: deadLetterID[%s]: %w", deadLetterID, err)
+	}
+
+	if !dl.DateReplayed.IsZero() {
+		return DeadLetter{}, ErrAlreadyReplayed
+	}
+
+	h, ok := d.handler(dl.Data, dl.Func)
+	if !ok {
+		return DeadLetter{}, fmt.Errorf("func[%s]: %w", dl.Func, ErrFuncNotFound)
+	}
+
+	// The function is executed again outside of the request that failed so
+	// it can reach the data of every tenant, as it would from the relay.
+	attempts, execErr := h.execute(tenant.SetAll(ctx), dl.Data)
+
+	dl.Attempts += attempts
+	switch execErr {
+	case nil:
+		dl.DateReplayed = time.Now()
+	default:
+		dl.Error = execErr.Error()
+	}
+
+	if err := d.deadLetter.Update(ctx, dl); err != nil {
+		return DeadLetter{}, fmt.Errorf("update: %w", err)
+	}
+
+	if execErr != nil {
+		return DeadLetter{}, fmt.Errorf("replay: func[%s]: %w", dl.Func, execErr)
+	}
+
+	return dl, nil
+}
 
-				if err := fn(ctx, data); err != nil {
-					d.log.Error(ctx, "delegate call", "msg", err)
-				}
+// publish writes the event into the outbox so it can be delivered by the relay.
+func (d *Delegate) publish(ctx context.Context, data Data) error {
+	now := time.Now()
+
+	evt := Event{
+		ID:            uuid.New(),
+		Data:          data,
+		DateCreated:   now,
+		DateAvailable: now,
+	}
+
+	if err := d.storer.Create(ctx, evt); err != nil {
+		return fmt.Errorf("outbox create: %w", err)
+	}
+
+	d.log.Info(ctx, "delegate call", "status", "published", "outbox_id", evt.ID, "domain", data.Domain, "action", data.Action)
+
+	return nil
+}
+
+// callAsync hands each registered function to the worker. The work is not
+// tied to the caller's context so it continues after the caller returns.
+func (d *Delegate) callAsync(ctx context.Context, data Data) error {
+	for _, h := range d.handlers(data) {
+		h := h
+
+		jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.budget())
+
+		_, err := d.worker.Start(jobCtx, func(ctx context.Context) {
+			attempts, err := h.execute(ctx, data)
+			if err != nil {
+				d.log.Error(ctx, "delegate call", "mode", "async", "func", h.name, "attempts", attempts, "msg", err)
+				d.bury(ctx, data, h, attempts, err)
 			}
+		})
+		cancel()
+
+		if err != nil {
+			return fmt.Errorf("start: func[%s]: %w", h.name, err)
 		}
 	}
 
 	return nil
 }
+
+// failure records a registered function that failed to process an event.
+type failure struct {
+	handler  handler
+	attempts int
+	err      error
+}
+
+// dispatch executes all functions registered for the specified domain and
+// action and returns the functions that failed.
+func (d *Delegate) dispatch(ctx context.Context, data Data) []failure {
+	var failures []failure
+	for _, h := range d.handlers(data) {
+		d.log.Info(ctx, "delegate call", "status", "sending", "func", h.name)
+
+		if attempts, err := h.execute(ctx, data); err != nil {
+			failures = append(failures, failure{handler: h, attempts: attempts, err: err})
+		}
+	}
+
+	return failures
+}
+
+// bury writes a failed event into the dead letter store if one is configured.
+func (d *Delegate) bury(ctx context.Context, data Data, h handler, attempts int, cause error) {
+	if d.deadLetter == nil {
+		return
+	}
+
+	dl := DeadLetter{
+		ID:          uuid.New(),
+		Data:        data,
+		Func:        h.name,
+		Attempts:    attempts,
+		Error:       cause.Error(),
+		DateCreated: time.Now(),
+	}
+
+	// The caller's context may already be done, but the dead letter still
+	// has to be recorded.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	if err := d.deadLetter.Create(ctx, dl); err != nil {
+		d.log.Error(ctx, "delegate call", "status", "dead letter failed", "func", h.name, "msg", err)
+		return
+	}
+
+	d.log.Info(ctx, "delegate call", "status", "dead letter", "dead_letter_id", dl.ID, "func", h.name)
+}
+
+// handlers returns the functions registered for the event.
+func (d *Delegate) handlers(data Data) []handler {
+	dMap, ok := d.funcs[domain(data.Domain)]
+	if !ok {
+		return nil
+	}
+
+	return dMap[action(data.Action)]
+}
+
+// handler finds the function with the specified name registered for the event.
+func (d *Delegate) handler(data Data, name string) (handler, bool) {
+	for _, h := range d.handlers(data) {
+		if h.name == name {
+			return h, true
+		}
+	}
+
+	return handler{}, false
+}
@@ -0,0 +1,292 @@
+package delegate_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/worker"
+
+	"github.com/google/uuid"
+)
+
+const (
+	testDomain = "test"
+	testAction = "action"
+)
+
+func Test_Retries(t *testing.T) {
+	dl := newDeadLetterStore()
+	dlg := delegate.New(newLogger(), delegate.WithDeadLetter(dl))
+
+	var calls int
+	dlg.Register(testDomain, testAction, func(ctx context.Context, data delegate.Data) error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}, delegate.WithName("fn"), delegate.WithRetries(2), delegate.WithBackoff(time.Millisecond))
+
+	if err := dlg.Call(context.Background(), delegate.Data{Domain: testDomain, Action: testAction}); err != nil {
+		t.Fatalf("Should be able to call the delegate : %s", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("Exp: 3")
+		t.Errorf("Got: %d", calls)
+		t.Error("Should retry the function until it succeeds")
+	}
+
+	if n := len(dl.all()); n != 0 {
+		t.Errorf("Exp: 0")
+		t.Errorf("Got: %d", n)
+		t.Error("Should not record a dead letter for a function that succeeded")
+	}
+}
+
+func Test_DeadLetterReplay(t *testing.T) {
+	dl := newDeadLetterStore()
+	dlg := delegate.New(newLogger(), delegate.WithDeadLetter(dl))
+
+	fail := true
+	dlg.Register(testDomain, testAction, func(ctx context.Context, data delegate.Data) error {
+		if fail {
+			return errors.New("downstream failed")
+		}
+		return nil
+	}, delegate.WithName("fn"), delegate.WithRetries(1), delegate.WithBackoff(time.Millisecond))
+
+	if err := dlg.Call(context.Background(), delegate.Data{Domain: testDomain, Action: testAction}); err != nil {
+		t.Fatalf("Should be able to call the delegate : %s", err)
+	}
+
+	dls := dl.all()
+	if len(dls) != 1 {
+		t.Fatalf("Should record one dead letter, got %d", len(dls))
+	}
+
+	if dls[0].Func != "fn" || dls[0].Attempts != 2 {
+		t.Errorf("Exp: fn 2")
+		t.Errorf("Got: %s %d", dls[0].Func, dls[0].Attempts)
+		t.Error("Should record the function name and attempts")
+	}
+
+	if _, err := dlg.Replay(context.Background(), dls[0].ID); err == nil {
+		t.Fatal("Should not be able to replay while the function still fails")
+	}
+
+	fail = false
+
+	replayed, err := dlg.Replay(context.Background(), dls[0].ID)
+	if err != nil {
+		t.Fatalf("Should be able to replay the dead letter : %s", err)
+	}
+
+	if replayed.DateReplayed.IsZero() {
+		t.Error("Should mark the dead letter as replayed")
+	}
+
+	if _, err := dlg.Replay(context.Background(), dls[0].ID); !errors.Is(err, delegate.ErrAlreadyReplayed) {
+		t.Errorf("Exp: %v", delegate.ErrAlreadyReplayed)
+		t.Errorf("Got: %v", err)
+		t.Error("Should not replay a dead letter twice")
+	}
+}
+
+func Test_Register(t *testing.T) {
+	fn := func(ctx context.Context, data delegate.Data) error { return nil }
+
+	panics := func(register func()) (panicked bool) {
+		defer func() {
+			panicked = recover() != nil
+		}()
+
+		register()
+		return false
+	}
+
+	dlg := delegate.New(newLogger(), delegate.WithDeadLetter(newDeadLetterStore()))
+
+	if !panics(func() { dlg.Register(testDomain, testAction, fn) }) {
+		t.Error("Should NOT register a function without a name when dead letters are recorded")
+	}
+
+	if panics(func() { dlg.Register(testDomain, testAction, fn, delegate.WithName("fn")) }) {
+		t.Fatal("Should be able to register a named function")
+	}
+
+	if !panics(func() { dlg.Register(testDomain, testAction, fn, delegate.WithName("fn")) }) {
+		t.Error("Should NOT register a function under a name already registered")
+	}
+
+	if panics(func() { dlg.Register(testDomain, "other", fn, delegate.WithName("fn")) }) {
+		t.Error("Should be able to register the same name for another action")
+	}
+}
+
+func Test_Timeout(t *testing.T) {
+	dlg := delegate.New(newLogger(), delegate.WithFailFast())
+
+	dlg.Register(testDomain, testAction, func(ctx context.Context, data delegate.Data) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	}, delegate.WithTimeout(10*time.Millisecond))
+
+	start := time.Now()
+	err := dlg.Call(context.Background(), delegate.Data{Domain: testDomain, Action: testAction})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Exp: %v", context.DeadlineExceeded)
+		t.Errorf("Got: %v", err)
+		t.Error("Should return the timeout error")
+	}
+
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("Should stop the function after its timeout, took %s", d)
+	}
+}
+
+func Test_TimeoutOverrun(t *testing.T) {
+	dlg := delegate.New(newLogger(), delegate.WithFailFast())
+
+	var running atomic.Bool
+	dlg.Register(testDomain, testAction, func(ctx context.Context, data delegate.Data) error {
+		running.Store(true)
+		defer running.Store(false)
+
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}, delegate.WithTimeout(10*time.Millisecond))
+
+	err := dlg.Call(context.Background(), delegate.Data{Domain: testDomain, Action: testAction})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Exp: %v", context.DeadlineExceeded)
+		t.Errorf("Got: %v", err)
+		t.Error("Should fail a function that overran its timeout")
+	}
+
+	if running.Load() {
+		t.Error("Should wait for a function that overran its timeout before returning")
+	}
+}
+
+func Test_FailFast(t *testing.T) {
+	dlg := delegate.New(newLogger())
+
+	var second bool
+	dlg.Register(testDomain, testAction, func(ctx context.Context, data delegate.Data) error {
+		return errors.New("first failed")
+	})
+	dlg.Register(testDomain, testAction, func(ctx context.Context, data delegate.Data) error {
+		second = true
+		return nil
+	})
+
+	if err := dlg.CallFailFast(context.Background(), delegate.Data{Domain: testDomain, Action: testAction}); err == nil {
+		t.Error("Should return the error of the first function")
+	}
+
+	if second {
+		t.Error("Should not execute the functions after the one that failed")
+	}
+}
+
+func Test_Async(t *testing.T) {
+	w, err := worker.New(2)
+	if err != nil {
+		t.Fatalf("Should be able to create a worker : %s", err)
+	}
+
+	dl := newDeadLetterStore()
+	dlg := delegate.New(newLogger(), delegate.WithWorker(w), delegate.WithDeadLetter(dl))
+
+	dlg.Register(testDomain, testAction, func(ctx context.Context, data delegate.Data) error {
+		return errors.New("downstream failed")
+	}, delegate.WithName("fn"), delegate.WithBackoff(time.Millisecond))
+
+	if err := dlg.Call(context.Background(), delegate.Data{Domain: testDomain, Action: testAction}); err != nil {
+		t.Fatalf("Should be able to call the delegate : %s", err)
+	}
+
+	if err := w.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Should be able to shutdown the worker : %s", err)
+	}
+
+	if n := len(dl.all()); n != 1 {
+		t.Errorf("Exp: 1")
+		t.Errorf("Got: %d", n)
+		t.Error("Should record a dead letter for the failed async call")
+	}
+}
+
+// =============================================================================
+
+func newLogger() *logger.Logger {
+	var buf bytes.Buffer
+	return logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+}
+
+type deadLetterStore struct {
+	mu  sync.Mutex
+	dls map[uuid.UUID]delegate.DeadLetter
+}
+
+func newDeadLetterStore() *deadLetterStore {
+	return &deadLetterStore{
+		dls: make(map[uuid.UUID]delegate.DeadLetter),
+	}
+}
+
+func (s *deadLetterStore) all() []delegate.DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var dls []delegate.DeadLetter
+	for _, dl := range s.dls {
+		dls = append(dls, dl)
+	}
+
+	return dls
+}
+
+func (s *deadLetterStore) Create(ctx context.Context, dl delegate.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dls[dl.ID] = dl
+	return nil
+}
+
+func (s *deadLetterStore) Update(ctx context.Context, dl delegate.DeadLetter) error {
+	return s.Create(ctx, dl)
+}
+
+func (s *deadLetterStore) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]delegate.DeadLetter, error) {
+	return s.all(), nil
+}
+
+func (s *deadLetterStore) Count(ctx context.Context) (int, error) {
+	return len(s.all()), nil
+}
+
+func (s *deadLetterStore) QueryByID(ctx context.Context, deadLetterID uuid.UUID) (delegate.DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dl, ok := s.dls[deadLetterID]
+	if !ok {
+		return delegate.DeadLetter{}, delegate.ErrNotFound
+	}
+
+	return dl, nil
+}
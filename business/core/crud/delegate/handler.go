@@ -0,0 +1,107 @@
+package delegate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"time"
+)
+
+// handler represents a registered function and how it is executed.
+type handler struct {
+	fn      Func
+	name    string
+	named   bool
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+func newHandler(fn Func, options ...func(opts *FuncOptions)) handler {
+	opts := FuncOptions{
+		timeout: defaultFuncTimeout,
+		backoff: defaultFuncBackoff,
+	}
+
+	for _, option := range options {
+		option(&opts)
+	}
+
+	// The name of the Go function changes whenever the function is renamed
+	// or moved, so it only identifies the function in logs.
+	named := opts.name != ""
+	if !named {
+		opts.name = runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	}
+
+	return handler{
+		fn:      fn,
+		name:    opts.name,
+		named:   named,
+		timeout: opts.timeout,
+		retries: opts.retries,
+		backoff: opts.backoff,
+	}
+}
+
+// execute calls the function until it succeeds or the retries are used up.
+// Each attempt runs under its own timeout. It returns the number of attempts
+// made and the last error.
+func (h handler) execute(ctx context.Context, data Data) (int, error) {
+	var err error
+	attempts := 0
+	backoff := h.backoff
+
+	for {
+		attempts++
+
+		if err = h.call(ctx, data); err == nil {
+			return attempts, nil
+		}
+
+		if attempts > h.retries {
+			return attempts, err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return attempts, err
+		case <-t.C:
+		}
+
+		backoff *= 2
+	}
+}
+
+// call executes the function once under the handler timeout. The function
+// runs on the calling G so it never outlives the call and whatever it was
+// handed, like a transaction in the context. A function is expected to
+// return once its context is done; one that ignores it and runs past the
+// timeout fails with the deadline error, even if it did its work.
+func (h handler) call(ctx context.Context, data Data) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	err := h.fn(ctx, data)
+	if err == nil && ctx.Err() != nil {
+		return fmt.Errorf("overran: %w", ctx.Err())
+	}
+
+	return err
+}
+
+// budget calculates the longest time all attempts of the function can take.
+func (h handler) budget() time.Duration {
+	d := h.timeout * time.Duration(h.retries+1)
+
+	backoff := h.backoff
+	for i := 0; i < h.retries; i++ {
+		d += backoff
+		backoff *= 2
+	}
+
+	return d
+}
@@ -3,6 +3,9 @@ package delegate
 import (
 	"context"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // Func represents a function that is registered and called by the system.
@@ -22,3 +25,27 @@ func (d Data) String() string {
 		d.Domain, d.Action, string(d.RawParams),
 	)
 }
+
+// Event represents a delegate call that has been written to the outbox and
+// is waiting to be delivered.
+type Event struct {
+	ID            uuid.UUID
+	Data          Data
+	Attempts      int
+	LastError     string
+	DateCreated   time.Time
+	DateAvailable time.Time
+	DateDelivered time.Time
+}
+
+// DeadLetter represents an event that a registered function failed to
+// process after all of its attempts.
+type DeadLetter struct {
+	ID           uuid.UUID
+	Data         Data
+	Func         string
+	Attempts     int
+	Error        string
+	DateCreated  time.Time
+	DateReplayed time.Time
+}
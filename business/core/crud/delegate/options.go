@@ -0,0 +1,99 @@
+package delegate
+
+import (
+	"time"
+
+	"github.com/testvergecloud/testApi/foundation/worker"
+)
+
+// Options represent optional parameters for the delegate.
+type Options struct {
+	storer     Storer
+	worker     *worker.Worker
+	deadLetter DeadLetterStorer
+	failFast   bool
+}
+
+// WithOutbox writes events into the outbox instead of executing the
+// registered functions. A Relay is required to deliver the events.
+func WithOutbox(storer Storer) func(opts *Options) {
+	return func(opts *Options) {
+		opts.storer = storer
+	}
+}
+
+// WithWorker executes the registered functions asynchronously using the
+// specified worker.
+func WithWorker(w *worker.Worker) func(opts *Options) {
+	return func(opts *Options) {
+		opts.worker = w
+	}
+}
+
+// WithDeadLetter records events whose functions keep failing so they can be
+// inspected and replayed.
+func WithDeadLetter(storer DeadLetterStorer) func(opts *Options) {
+	return func(opts *Options) {
+		opts.deadLetter = storer
+	}
+}
+
+// WithFailFast makes synchronous calls stop and return the first error
+// produced by a registered function.
+func WithFailFast() func(opts *Options) {
+	return func(opts *Options) {
+		opts.failFast = true
+	}
+}
+
+// =============================================================================
+
+// Set of default values used when executing a registered function.
+const (
+	defaultFuncTimeout = 5 * time.Second
+	defaultFuncBackoff = 100 * time.Millisecond
+)
+
+// FuncOptions represent optional parameters for a registered function.
+type FuncOptions struct {
+	name    string
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+// WithName sets the name used to identify the function in logs and dead
+// letters. The name is stored with dead letters to replay them, so it must
+// not change between releases. Without a name the function is identified by
+// the name of the Go function, which is only allowed when no dead letters
+// are recorded.
+func WithName(name string) func(opts *FuncOptions) {
+	return func(opts *FuncOptions) {
+		opts.name = name
+	}
+}
+
+// WithTimeout sets how long a single execution of the function may take.
+// The function has to honour the deadline of its context, the call waits
+// for it to return.
+func WithTimeout(timeout time.Duration) func(opts *FuncOptions) {
+	return func(opts *FuncOptions) {
+		opts.timeout = timeout
+	}
+}
+
+// WithRetries sets how many times the function is executed again after
+// failing.
+func WithRetries(retries int) func(opts *FuncOptions) {
+	return func(opts *FuncOptions) {
+		opts.retries = retries
+	}
+}
+
+// WithBackoff sets the delay before the first retry. The delay doubles
+// for every retry after that.
+func WithBackoff(backoff time.Duration) func(opts *FuncOptions) {
+	return func(opts *FuncOptions) {
+		opts.backoff = backoff
+	}
+}
@@ -0,0 +1,192 @@
+package delegate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/worker"
+)
+
+// Set of default values used by the relay.
+const (
+	defaultInterval    = time.Second
+	defaultBatch       = 50
+	defaultLease       = 30 * time.Second
+	defaultMaxBackoff  = 5 * time.Minute
+	defaultMaxAttempts = 10
+)
+
+// Relay polls the outbox for pending events and delivers them to the
+// functions registered with the delegate. Events are delivered at least
+// once, so registered functions must be safe to execute more than once for
+// the same event. Events that still fail after MaxAttempts deliveries are
+// written to the dead letter store of the delegate and are not retried.
+type Relay struct {
+	log         *logger.Logger
+	delegate    *Delegate
+	storer      Storer
+	worker      *worker.Worker
+	Interval    time.Duration
+	Batch       int
+	Lease       time.Duration
+	MaxBackoff  time.Duration
+	MaxAttempts int
+	shutdown    chan struct{}
+	wg          sync.WaitGroup
+}
+
+// NewRelay constructs a relay that delivers the events found in the outbox
+// using the functions registered with the specified delegate. The worker
+// bounds the number of events being delivered at the same time.
+func NewRelay(log *logger.Logger, delegate *Delegate, storer Storer, w *worker.Worker) *Relay {
+	return &Relay{
+		log:         log,
+		delegate:    delegate,
+		storer:      storer,
+		worker:      w,
+		Interval:    defaultInterval,
+		Batch:       defaultBatch,
+		Lease:       defaultLease,
+		MaxBackoff:  defaultMaxBackoff,
+		MaxAttempts: defaultMaxAttempts,
+		shutdown:    make(chan struct{}),
+	}
+}
+
+// Start launches a goroutine that polls the outbox until Shutdown is called.
+func (r *Relay) Start(ctx context.Context) {
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.Interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := r.Poll(ctx); err != nil {
+				r.log.Error(ctx, "outbox relay", "status", "poll failed", "msg", err)
+			}
+
+			select {
+			case <-r.shutdown:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown stops the polling goroutine and waits for the events being
+// delivered to complete.
+func (r *Relay) Shutdown(ctx context.Context) error {
+	close(r.shutdown)
+	r.wg.Wait()
+
+	return r.worker.Shutdown(ctx)
+}
+
+// Poll claims the next batch of available events and hands them to the worker
+// for delivery. It returns the number of events claimed.
+func (r *Relay) Poll(ctx context.Context) (int, error) {
+	evts, err := r.storer.Claim(ctx, time.Now(), r.Lease, r.Batch)
+	if err != nil {
+		return 0, fmt.Errorf("claim: %w", err)
+	}
+
+	for _, evt := range evts {
+		evt := evt
+
+		// The delivery must complete before the lease expires, otherwise
+		// another relay can claim the same event.
+		jobCtx, cancel := context.WithTimeout(ctx, r.Lease)
+
+		// The event is delivered outside of the request that published it
+		// so the functions can reach the data of every tenant.
+		jobCtx = tenant.SetAll(jobCtx)
+
+		_, err := r.worker.Start(jobCtx, func(ctx context.Context) {
+			r.deliver(ctx, evt)
+		})
+		cancel()
+
+		if err != nil {
+			return 0, fmt.Errorf("start: outbox_id[%s]: %w", evt.ID, err)
+		}
+	}
+
+	return len(evts), nil
+}
+
+// deliver executes the registered functions for the event and records the
+// outcome in the outbox. Failed events are scheduled for another attempt.
+func (r *Relay) deliver(ctx context.Context, evt Event) {
+	r.log.Info(ctx, "outbox relay", "status", "delivering", "outbox_id", evt.ID, "domain", evt.Data.Domain, "action", evt.Data.Action, "attempts", evt.Attempts)
+
+	failures := r.delegate.dispatch(ctx, evt.Data)
+
+	now := time.Now()
+	evt.Attempts++
+
+	switch {
+	case len(failures) == 0:
+		evt.LastError = ""
+		evt.DateDelivered = now
+
+	case evt.Attempts >= r.MaxAttempts:
+		evt.LastError = joinFailures(failures).Error()
+		evt.DateDelivered = now
+
+		r.log.Error(ctx, "outbox relay", "status", "delivery abandoned", "outbox_id", evt.ID, "attempts", evt.Attempts, "msg", evt.LastError)
+
+		for _, f := range failures {
+			r.delegate.bury(ctx, evt.Data, f.handler, f.attempts, f.err)
+		}
+
+	default:
+		evt.LastError = joinFailures(failures).Error()
+		evt.DateAvailable = now.Add(r.backoff(evt.Attempts))
+
+		r.log.Error(ctx, "outbox relay", "status", "delivery failed", "outbox_id", evt.ID, "attempts", evt.Attempts, "retry", evt.DateAvailable, "msg", evt.LastError)
+	}
+
+	// The delivery context may already be done, but the outcome still has
+	// to be recorded.
+	updCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := r.storer.Update(updCtx, evt); err != nil {
+		r.log.Error(ctx, "outbox relay", "status", "update failed", "outbox_id", evt.ID, "msg", err)
+	}
+}
+
+// backoff calculates an exponential delay for the specified attempt that is
+// capped by the maximum backoff.
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.Interval
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+
+	return d
+}
+
+// joinFailures combines the errors of the failed functions into one error.
+func joinFailures(failures []failure) error {
+	errs := make([]error, len(failures))
+	for i, f := range failures {
+		errs[i] = fmt.Errorf("func[%s]: %w", f.handler.name, f.err)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,362 @@
+package delegate_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	"github.com/testvergecloud/testApi/foundation/worker"
+
+	"github.com/google/uuid"
+)
+
+func Test_Relay(t *testing.T) {
+	t.Run("publish", publish)
+	t.Run("deliver", deliver)
+	t.Run("abandon", abandon)
+}
+
+func publish(t *testing.T) {
+	outbox := newOutboxStore()
+	dlg := delegate.New(newLogger(), delegate.WithOutbox(outbox))
+
+	var called bool
+	dlg.Register(testDomain, testAction, func(ctx context.Context, data delegate.Data) error {
+		called = true
+		return nil
+	})
+
+	data := delegate.Data{Domain: testDomain, Action: testAction, RawParams: []byte(`{"id":1}`)}
+
+	// -------------------------------------------------------------------------
+
+	tx := outbox.begin()
+
+	txDlg, err := dlg.ExecuteUnderTransaction(tx)
+	if err != nil {
+		t.Fatalf("Should be able to execute under a transaction : %s", err)
+	}
+
+	if err := txDlg.Call(context.Background(), data); err != nil {
+		t.Fatalf("Should be able to publish the event : %s", err)
+	}
+
+	if called {
+		t.Fatal("Should NOT execute the functions when publishing to the outbox")
+	}
+
+	if n := len(outbox.all()); n != 0 {
+		t.Fatalf("Should NOT write the event before the transaction commits, got %d", n)
+	}
+
+	tx.Rollback()
+
+	if n := len(outbox.all()); n != 0 {
+		t.Fatalf("Should drop the event when the transaction rolls back, got %d", n)
+	}
+
+	// -------------------------------------------------------------------------
+
+	tx = outbox.begin()
+
+	txDlg, err = dlg.ExecuteUnderTransaction(tx)
+	if err != nil {
+		t.Fatalf("Should be able to execute under a transaction : %s", err)
+	}
+
+	if err := txDlg.Call(context.Background(), data); err != nil {
+		t.Fatalf("Should be able to publish the event : %s", err)
+	}
+
+	tx.Commit()
+
+	evts := outbox.all()
+	if len(evts) != 1 {
+		t.Fatalf("Should write the event once the transaction commits, got %d", len(evts))
+	}
+
+	if string(evts[0].Data.RawParams) != string(data.RawParams) || !evts[0].DateDelivered.IsZero() {
+		t.Errorf("Got: %+v", evts[0])
+		t.Error("Should write the event as undelivered")
+	}
+}
+
+func deliver(t *testing.T) {
+	outbox := newOutboxStore()
+	dlg := delegate.New(newLogger(), delegate.WithOutbox(outbox))
+
+	var mu sync.Mutex
+	var calls int
+	var scopes []tenant.Scope
+	dlg.Register(testDomain, testAction, func(ctx context.Context, data delegate.Data) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		scope, _ := tenant.GetScope(ctx)
+		scopes = append(scopes, scope)
+
+		calls++
+		if calls < 4 {
+			return errors.New("downstream failed")
+		}
+		return nil
+	}, delegate.WithBackoff(time.Millisecond))
+
+	relay := newRelay(t, dlg, outbox)
+	relay.Interval = time.Minute
+	relay.MaxBackoff = 3 * time.Minute
+
+	if err := dlg.Call(context.Background(), delegate.Data{Domain: testDomain, Action: testAction}); err != nil {
+		t.Fatalf("Should be able to publish the event : %s", err)
+	}
+
+	// Every failed delivery pushes the event back, twice as long each time
+	// until the maximum backoff.
+	backoffs := []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute}
+
+	for i, backoff := range backoffs {
+		evt := pollOne(t, relay, outbox)
+
+		if evt.Attempts != i+1 || evt.LastError == "" || !evt.DateDelivered.IsZero() {
+			t.Fatalf("Should record the failed delivery %d : %+v", i+1, evt)
+		}
+
+		if d := time.Until(evt.DateAvailable); d > backoff || d < backoff-time.Second {
+			t.Errorf("Exp: %s", backoff)
+			t.Errorf("Got: %s", d)
+			t.Fatalf("Should schedule delivery %d after the backoff", i+2)
+		}
+
+		if n, err := relay.Poll(context.Background()); err != nil || n != 0 {
+			t.Fatalf("Should NOT claim an event before it is available again : %d : %v", n, err)
+		}
+
+		outbox.makeAvailable()
+	}
+
+	evt := pollOne(t, relay, outbox)
+
+	if evt.Attempts != 4 || evt.LastError != "" || evt.DateDelivered.IsZero() {
+		t.Fatalf("Should record the successful delivery : %+v", evt)
+	}
+
+	// The functions run outside of the request that published the event, so
+	// they can reach the data of every tenant.
+	mu.Lock()
+	for _, scope := range scopes {
+		if !scope.All {
+			t.Fatalf("Should deliver the event with access to every tenant : %+v", scopes)
+		}
+	}
+	mu.Unlock()
+
+	outbox.makeAvailable()
+
+	if n, err := relay.Poll(context.Background()); err != nil || n != 0 {
+		t.Fatalf("Should NOT claim a delivered event : %d : %v", n, err)
+	}
+}
+
+func abandon(t *testing.T) {
+	outbox := newOutboxStore()
+	dl := newDeadLetterStore()
+	dlg := delegate.New(newLogger(), delegate.WithOutbox(outbox), delegate.WithDeadLetter(dl))
+
+	dlg.Register(testDomain, testAction, func(ctx context.Context, data delegate.Data) error {
+		return errors.New("downstream failed")
+	}, delegate.WithName("fn"), delegate.WithBackoff(time.Millisecond))
+
+	relay := newRelay(t, dlg, outbox)
+	relay.MaxAttempts = 2
+
+	if err := dlg.Call(context.Background(), delegate.Data{Domain: testDomain, Action: testAction}); err != nil {
+		t.Fatalf("Should be able to publish the event : %s", err)
+	}
+
+	pollOne(t, relay, outbox)
+	outbox.makeAvailable()
+
+	if n := len(dl.all()); n != 0 {
+		t.Fatalf("Should NOT record a dead letter while attempts remain, got %d", n)
+	}
+
+	evt := pollOne(t, relay, outbox)
+
+	if evt.Attempts != 2 || evt.DateDelivered.IsZero() {
+		t.Fatalf("Should stop delivering the event after the last attempt : %+v", evt)
+	}
+
+	dls := dl.all()
+	if len(dls) != 1 || dls[0].Func != "fn" {
+		t.Fatalf("Should record a dead letter for the abandoned event : %+v", dls)
+	}
+
+	outbox.makeAvailable()
+
+	if n, err := relay.Poll(context.Background()); err != nil || n != 0 {
+		t.Fatalf("Should NOT claim an abandoned event : %d : %v", n, err)
+	}
+}
+
+// =============================================================================
+
+func newRelay(t *testing.T, dlg *delegate.Delegate, outbox *outboxStore) *delegate.Relay {
+	w, err := worker.New(2)
+	if err != nil {
+		t.Fatalf("Should be able to create a worker : %s", err)
+	}
+
+	relay := delegate.NewRelay(newLogger(), dlg, outbox, w)
+	t.Cleanup(func() {
+		relay.Shutdown(context.Background())
+	})
+
+	return relay
+}
+
+// pollOne polls the outbox expecting a single event and waits for its
+// delivery to be recorded.
+func pollOne(t *testing.T, relay *delegate.Relay, outbox *outboxStore) delegate.Event {
+	t.Helper()
+
+	n, err := relay.Poll(context.Background())
+	if err != nil {
+		t.Fatalf("Should be able to poll the outbox : %s", err)
+	}
+
+	if n != 1 {
+		t.Fatalf("Should claim the available event, got %d", n)
+	}
+
+	select {
+	case evt := <-outbox.updated:
+		return evt
+	case <-time.After(5 * time.Second):
+		t.Fatal("Should record the outcome of the delivery")
+	}
+
+	return delegate.Event{}
+}
+
+type outboxStore struct {
+	mu      sync.Mutex
+	evts    map[uuid.UUID]delegate.Event
+	updated chan delegate.Event
+}
+
+func newOutboxStore() *outboxStore {
+	return &outboxStore{
+		evts:    make(map[uuid.UUID]delegate.Event),
+		updated: make(chan delegate.Event, 10),
+	}
+}
+
+func (s *outboxStore) all() []delegate.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var evts []delegate.Event
+	for _, evt := range s.evts {
+		evts = append(evts, evt)
+	}
+
+	return evts
+}
+
+// makeAvailable makes every event available right away, as if their
+// backoff or lease had passed.
+func (s *outboxStore) makeAvailable() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, evt := range s.evts {
+		evt.DateAvailable = time.Now().Add(-time.Second)
+		s.evts[id] = evt
+	}
+}
+
+func (s *outboxStore) begin() *outboxTx {
+	return &outboxTx{store: s}
+}
+
+func (s *outboxStore) ExecuteUnderTransaction(tx transaction.Transaction) (delegate.Storer, error) {
+	otx, ok := tx.(*outboxTx)
+	if !ok {
+		return nil, errors.New("unknown transaction")
+	}
+
+	return &txOutboxStore{outboxStore: s, tx: otx}, nil
+}
+
+func (s *outboxStore) Create(ctx context.Context, evt delegate.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evts[evt.ID] = evt
+	return nil
+}
+
+func (s *outboxStore) Update(ctx context.Context, evt delegate.Event) error {
+	s.mu.Lock()
+	s.evts[evt.ID] = evt
+	s.mu.Unlock()
+
+	s.updated <- evt
+	return nil
+}
+
+func (s *outboxStore) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]delegate.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var evts []delegate.Event
+	for id, evt := range s.evts {
+		if len(evts) == limit {
+			break
+		}
+
+		if !evt.DateDelivered.IsZero() || evt.DateAvailable.After(now) {
+			continue
+		}
+
+		evt.DateAvailable = now.Add(lease)
+		s.evts[id] = evt
+		evts = append(evts, evt)
+	}
+
+	return evts, nil
+}
+
+// txOutboxStore writes events only once its transaction commits.
+type txOutboxStore struct {
+	*outboxStore
+	tx *outboxTx
+}
+
+func (s *txOutboxStore) Create(ctx context.Context, evt delegate.Event) error {
+	s.tx.pending = append(s.tx.pending, evt)
+	return nil
+}
+
+type outboxTx struct {
+	store   *outboxStore
+	pending []delegate.Event
+}
+
+func (tx *outboxTx) Commit() error {
+	for _, evt := range tx.pending {
+		tx.store.Create(context.Background(), evt)
+	}
+	tx.pending = nil
+	return nil
+}
+
+func (tx *outboxTx) Rollback() error {
+	tx.pending = nil
+	return nil
+}
@@ -0,0 +1,134 @@
+// Package deadletterdb contains dead letter related CRUD functionality.
+package deadletterdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for dead letter database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new dead letter into the database.
+func (s *Store) Create(ctx context.Context, dl delegate.DeadLetter) error {
+	const q = `
+	INSERT INTO dead_letters
+		(dead_letter_id, domain, action, params, func, attempts, error, date_created, date_replayed)
+	VALUES
+		(:dead_letter_id, :domain, :action, :params, :func, :attempts, :error, :date_created, :date_replayed)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBDeadLetter(dl)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update records the outcome of a replay.
+func (s *Store) Update(ctx context.Context, dl delegate.DeadLetter) error {
+	const q = `
+	UPDATE
+		dead_letters
+	SET
+		"attempts" = :attempts,
+		"error" = :error,
+		"date_replayed" = :date_replayed
+	WHERE
+		dead_letter_id = :dead_letter_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBDeadLetter(dl)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of dead letters from the database, newest first.
+func (s *Store) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]delegate.DeadLetter, error) {
+	data := map[string]interface{}{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		dead_letter_id, domain, action, params, func, attempts, error, date_created, date_replayed
+	FROM
+		dead_letters
+	ORDER BY
+		date_created DESC
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var dbDLs []dbDeadLetter
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbDLs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreDeadLetterSlice(dbDLs), nil
+}
+
+// Count returns the total number of dead letters in the DB.
+func (s *Store) Count(ctx context.Context) (int, error) {
+	data := map[string]interface{}{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		dead_letters`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID gets the specified dead letter from the database.
+func (s *Store) QueryByID(ctx context.Context, deadLetterID uuid.UUID) (delegate.DeadLetter, error) {
+	data := struct {
+		ID string `db:"dead_letter_id"`
+	}{
+		ID: deadLetterID.String(),
+	}
+
+	const q = `
+	SELECT
+		dead_letter_id, domain, action, params, func, attempts, error, date_created, date_replayed
+	FROM
+		dead_letters
+	WHERE
+		dead_letter_id = :dead_letter_id`
+
+	var dbDL dbDeadLetter
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbDL); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return delegate.DeadLetter{}, fmt.Errorf("namedquerystruct: %w", delegate.ErrNotFound)
+		}
+		return delegate.DeadLetter{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreDeadLetter(dbDL), nil
+}
@@ -0,0 +1,75 @@
+package deadletterdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+
+	"github.com/google/uuid"
+)
+
+type dbDeadLetter struct {
+	ID           uuid.UUID    `db:"dead_letter_id"`
+	Domain       string       `db:"domain"`
+	Action       string       `db:"action"`
+	Params       string       `db:"params"`
+	Func         string       `db:"func"`
+	Attempts     int          `db:"attempts"`
+	Error        string       `db:"error"`
+	DateCreated  time.Time    `db:"date_created"`
+	DateReplayed sql.NullTime `db:"date_replayed"`
+}
+
+func toDBDeadLetter(dl delegate.DeadLetter) dbDeadLetter {
+	params := string(dl.Data.RawParams)
+	if params == "" {
+		params = "null"
+	}
+
+	return dbDeadLetter{
+		ID:          dl.ID,
+		Domain:      dl.Data.Domain,
+		Action:      dl.Data.Action,
+		Params:      params,
+		Func:        dl.Func,
+		Attempts:    dl.Attempts,
+		Error:       dl.Error,
+		DateCreated: dl.DateCreated.UTC(),
+		DateReplayed: sql.NullTime{
+			Time:  dl.DateReplayed.UTC(),
+			Valid: !dl.DateReplayed.IsZero(),
+		},
+	}
+}
+
+func toCoreDeadLetter(dbDL dbDeadLetter) delegate.DeadLetter {
+	dl := delegate.DeadLetter{
+		ID: dbDL.ID,
+		Data: delegate.Data{
+			Domain:    dbDL.Domain,
+			Action:    dbDL.Action,
+			RawParams: []byte(dbDL.Params),
+		},
+		Func:        dbDL.Func,
+		Attempts:    dbDL.Attempts,
+		Error:       dbDL.Error,
+		DateCreated: dbDL.DateCreated.In(time.Local),
+	}
+
+	if dbDL.DateReplayed.Valid {
+		dl.DateReplayed = dbDL.DateReplayed.Time.In(time.Local)
+	}
+
+	return dl
+}
+
+func toCoreDeadLetterSlice(dbDLs []dbDeadLetter) []delegate.DeadLetter {
+	dls := make([]delegate.DeadLetter, len(dbDLs))
+
+	for i, dbDL := range dbDLs {
+		dls[i] = toCoreDeadLetter(dbDL)
+	}
+
+	return dls
+}
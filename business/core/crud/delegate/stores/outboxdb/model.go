@@ -0,0 +1,78 @@
+package outboxdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+
+	"github.com/google/uuid"
+)
+
+type dbEvent struct {
+	ID            uuid.UUID      `db:"outbox_id"`
+	Domain        string         `db:"domain"`
+	Action        string         `db:"action"`
+	Params        string         `db:"params"`
+	Attempts      int            `db:"attempts"`
+	LastError     sql.NullString `db:"last_error"`
+	DateCreated   time.Time      `db:"date_created"`
+	DateAvailable time.Time      `db:"date_available"`
+	DateDelivered sql.NullTime   `db:"date_delivered"`
+}
+
+func toDBEvent(evt delegate.Event) dbEvent {
+	params := string(evt.Data.RawParams)
+	if params == "" {
+		params = "null"
+	}
+
+	return dbEvent{
+		ID:       evt.ID,
+		Domain:   evt.Data.Domain,
+		Action:   evt.Data.Action,
+		Params:   params,
+		Attempts: evt.Attempts,
+		LastError: sql.NullString{
+			String: evt.LastError,
+			Valid:  evt.LastError != "",
+		},
+		DateCreated:   evt.DateCreated.UTC(),
+		DateAvailable: evt.DateAvailable.UTC(),
+		DateDelivered: sql.NullTime{
+			Time:  evt.DateDelivered.UTC(),
+			Valid: !evt.DateDelivered.IsZero(),
+		},
+	}
+}
+
+func toCoreEvent(dbEvt dbEvent) delegate.Event {
+	evt := delegate.Event{
+		ID: dbEvt.ID,
+		Data: delegate.Data{
+			Domain:    dbEvt.Domain,
+			Action:    dbEvt.Action,
+			RawParams: []byte(dbEvt.Params),
+		},
+		Attempts:      dbEvt.Attempts,
+		LastError:     dbEvt.LastError.String,
+		DateCreated:   dbEvt.DateCreated.In(time.Local),
+		DateAvailable: dbEvt.DateAvailable.In(time.Local),
+	}
+
+	if dbEvt.DateDelivered.Valid {
+		evt.DateDelivered = dbEvt.DateDelivered.Time.In(time.Local)
+	}
+
+	return evt
+}
+
+func toCoreEvents(dbEvts []dbEvent) []delegate.Event {
+	evts := make([]delegate.Event, len(dbEvts))
+
+	for i, dbEvt := range dbEvts {
+		evts[i] = toCoreEvent(dbEvt)
+	}
+
+	return evts
+}
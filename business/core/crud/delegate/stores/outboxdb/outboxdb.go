@@ -0,0 +1,124 @@
+// Package outboxdb contains outbox related CRUD functionality.
+package outboxdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for outbox database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (delegate.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new event into the outbox.
+func (s *Store) Create(ctx context.Context, evt delegate.Event) error {
+	const q = `
+	INSERT INTO outbox
+		(outbox_id, domain, action, params, attempts, last_error, date_created, date_available, date_delivered)
+	VALUES
+		(:outbox_id, :domain, :action, :params, :attempts, :last_error, :date_created, :date_available, :date_delivered)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBEvent(evt)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update records the outcome of a delivery attempt.
+func (s *Store) Update(ctx context.Context, evt delegate.Event) error {
+	const q = `
+	UPDATE
+		outbox
+	SET
+		"attempts" = :attempts,
+		"last_error" = :last_error,
+		"date_available" = :date_available,
+		"date_delivered" = :date_delivered
+	WHERE
+		outbox_id = :outbox_id`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBEvent(evt)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Claim locks the next set of undelivered events that are available at the
+// specified time. The claimed events are hidden from other callers until the
+// lease expires, which allows multiple relays to share the same outbox.
+func (s *Store) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]delegate.Event, error) {
+	data := struct {
+		Now        time.Time `db:"now"`
+		LeaseUntil time.Time `db:"lease_until"`
+		Limit      int       `db:"limit"`
+	}{
+		Now:        now.UTC(),
+		LeaseUntil: now.Add(lease).UTC(),
+		Limit:      limit,
+	}
+
+	const q = `
+	UPDATE
+		outbox
+	SET
+		"date_available" = :lease_until
+	WHERE
+		outbox_id IN (
+			SELECT
+				outbox_id
+			FROM
+				outbox
+			WHERE
+				date_delivered IS NULL AND
+				date_available <= :now
+			ORDER BY
+				date_created
+			LIMIT :limit
+			FOR UPDATE SKIP LOCKED
+		)
+	RETURNING
+		outbox_id, domain, action, params, attempts, last_error, date_created, date_available, date_delivered`
+
+	var dbEvts []dbEvent
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbEvts); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreEvents(dbEvts), nil
+}
@@ -0,0 +1,197 @@
+package outboxdb_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	"github.com/testvergecloud/testApi/business/core/crud/delegate/stores/outboxdb"
+	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/foundation/docker"
+
+	"github.com/google/uuid"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	code, err := run(m)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	os.Exit(code)
+}
+
+func run(m *testing.M) (int, error) {
+	var err error
+
+	c, err = dbtest.StartDB()
+	if err != nil {
+		return 1, err
+	}
+	defer dbtest.StopDB(c)
+
+	return m.Run(), nil
+}
+
+func Test_Outbox(t *testing.T) {
+	t.Run("claim", claim)
+}
+
+func claim(t *testing.T) {
+	test := dbtest.NewTest(t, c, "Test_Outbox/claim")
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	store := outboxdb.NewStore(test.Log, test.DB)
+
+	const total = 20
+
+	now := time.Now().Add(-time.Minute)
+	for i := range total {
+		evt := delegate.Event{
+			ID:            uuid.New(),
+			Data:          delegate.Data{Domain: "test", Action: "action", RawParams: []byte(`{}`)},
+			DateCreated:   now.Add(time.Duration(i) * time.Millisecond),
+			DateAvailable: now,
+		}
+
+		if err := store.Create(ctx, evt); err != nil {
+			t.Fatalf("Should be able to create an event : %s", err)
+		}
+	}
+
+	// -------------------------------------------------------------------------
+	// A claimer still holding the rows it claimed makes the other one skip
+	// them instead of waiting or taking them too.
+
+	bgn := sqldb.NewBeginner(test.DB)
+
+	tx1, err := bgn.Begin()
+	if err != nil {
+		t.Fatalf("Should be able to begin a transaction : %s", err)
+	}
+	defer tx1.Rollback()
+
+	tx2, err := bgn.Begin()
+	if err != nil {
+		t.Fatalf("Should be able to begin a transaction : %s", err)
+	}
+	defer tx2.Rollback()
+
+	store1, err := store.ExecuteUnderTransaction(tx1)
+	if err != nil {
+		t.Fatalf("Should be able to execute under a transaction : %s", err)
+	}
+
+	store2, err := store.ExecuteUnderTransaction(tx2)
+	if err != nil {
+		t.Fatalf("Should be able to execute under a transaction : %s", err)
+	}
+
+	evts1, err := store1.Claim(ctx, time.Now(), time.Minute, 5)
+	if err != nil {
+		t.Fatalf("Should be able to claim events : %s", err)
+	}
+
+	evts2, err := store2.Claim(ctx, time.Now(), time.Minute, 5)
+	if err != nil {
+		t.Fatalf("Should be able to claim events : %s", err)
+	}
+
+	if len(evts1) != 5 || len(evts2) != 5 {
+		t.Fatalf("Should claim the events available to each claimer : %d %d", len(evts1), len(evts2))
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, evt := range append(evts1, evts2...) {
+		if seen[evt.ID] {
+			t.Fatalf("Should NOT claim the same event twice : %s", evt.ID)
+		}
+		seen[evt.ID] = true
+	}
+
+	if err := tx1.Commit(); err != nil {
+		t.Fatalf("Should be able to commit : %s", err)
+	}
+
+	if err := tx2.Commit(); err != nil {
+		t.Fatalf("Should be able to commit : %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+	// Claimers racing each other share the rest of the outbox and the leased
+	// events stay hidden from them.
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var claimErr error
+
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				evts, err := store.Claim(ctx, time.Now(), time.Minute, 3)
+
+				mu.Lock()
+				if err != nil {
+					claimErr = err
+				}
+				for _, evt := range evts {
+					if seen[evt.ID] && claimErr == nil {
+						claimErr = fmt.Errorf("event %s claimed twice", evt.ID)
+					}
+					seen[evt.ID] = true
+				}
+				mu.Unlock()
+
+				if err != nil || len(evts) == 0 {
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if claimErr != nil {
+		t.Fatalf("Should NOT claim the same event twice : %s", claimErr)
+	}
+
+	if len(seen) != total {
+		t.Errorf("Exp: %d", total)
+		t.Errorf("Got: %d", len(seen))
+		t.Fatal("Should claim every event once")
+	}
+
+	// -------------------------------------------------------------------------
+	// Once the lease expires the events can be claimed again.
+
+	evts, err := store.Claim(ctx, time.Now().Add(2*time.Minute), time.Minute, total)
+	if err != nil {
+		t.Fatalf("Should be able to claim events : %s", err)
+	}
+
+	if len(evts) != total {
+		t.Errorf("Exp: %d", total)
+		t.Errorf("Got: %d", len(evts))
+		t.Fatal("Should claim the events again once their lease expired")
+	}
+}
@@ -1 +1,45 @@
 package home
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+
+	"github.com/go-json-experiment/json"
+)
+
+// Domain represents the name of this domain.
+const Domain = "home"
+
+// registerDelegateFunctions will register action functions with the delegate
+// system. If the core was constructed for query only, there won't be a
+// delegate provided.
+func (c *Core) registerDelegateFunctions() {
+	if c.delegate != nil {
+		c.delegate.Register(user.Domain, user.ActionUpdated, c.actionUserUpdated, delegate.WithName(Domain+".userupdated"))
+	}
+}
+
+// actionUserUpdated is executed by the user domain indirectly when a user is updated.
+func (c *Core) actionUserUpdated(ctx context.Context, data delegate.Data) error {
+	var params user.ActionUpdatedParms
+	err := json.Unmarshal(data.RawParams, &params)
+	if err != nil {
+		return fmt.Errorf("expected an encoded %T: %w", params, err)
+	}
+
+	c.log.Info(ctx, "action-userupdate", "user_id", params.UserID, "enabled", params.Enabled)
+
+	// If the user has been disabled, all of their homes are marked as deleted.
+	if params.Enabled == nil || *params.Enabled {
+		return nil
+	}
+
+	if err := c.DeleteByUserID(ctx, params.UserID); err != nil {
+		return fmt.Errorf("deletebyuserid: %w", err)
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package home
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -13,10 +14,15 @@ import (
 // We are using pointer semantics because the With API mutates the value.
 type QueryFilter struct {
 	ID               *uuid.UUID
+	IDs              []uuid.UUID `validate:"omitempty,max=100"`
+	NotIDs           []uuid.UUID `validate:"omitempty,max=100"`
 	UserID           *uuid.UUID
+	UserIDs          []uuid.UUID `validate:"omitempty,max=100"`
 	Type             *Type
+	NotType          *Type
 	StartCreatedDate *time.Time
 	EndCreatedDate   *time.Time
+	IncludeDeleted   *bool
 }
 
 // Validate can perform a check of the data against the validate tags.
@@ -25,6 +31,10 @@ func (qf *QueryFilter) Validate() error {
 		return fmt.Errorf("validate: %w", err)
 	}
 
+	if qf.StartCreatedDate != nil && qf.EndCreatedDate != nil && qf.EndCreatedDate.Before(*qf.StartCreatedDate) {
+		return fmt.Errorf("validate: %w", validate.NewFieldsError("end_created_date", errors.New("end_created_date is before start_created_date")))
+	}
+
 	return nil
 }
 
@@ -33,16 +43,40 @@ func (qf *QueryFilter) WithHomeID(homeID uuid.UUID) {
 	qf.ID = &homeID
 }
 
+// WithHomeIDs sets the IDs field of the QueryFilter value. Homes matching any
+// of the IDs are returned.
+func (qf *QueryFilter) WithHomeIDs(homeIDs []uuid.UUID) {
+	qf.IDs = homeIDs
+}
+
+// WithoutHomeIDs sets the NotIDs field of the QueryFilter value. Homes
+// matching any of the IDs are excluded.
+func (qf *QueryFilter) WithoutHomeIDs(homeIDs []uuid.UUID) {
+	qf.NotIDs = homeIDs
+}
+
 // WithUserID sets the ID field of the QueryFilter value.
 func (qf *QueryFilter) WithUserID(userID uuid.UUID) {
 	qf.UserID = &userID
 }
 
+// WithUserIDs sets the UserIDs field of the QueryFilter value. Homes owned by
+// any of the users are returned.
+func (qf *QueryFilter) WithUserIDs(userIDs []uuid.UUID) {
+	qf.UserIDs = userIDs
+}
+
 // WithHomeType sets the Type field of the QueryFilter value.
 func (qf *QueryFilter) WithHomeType(homeType Type) {
 	qf.Type = &homeType
 }
 
+// WithoutHomeType sets the NotType field of the QueryFilter value. Homes of
+// the type are excluded.
+func (qf *QueryFilter) WithoutHomeType(homeType Type) {
+	qf.NotType = &homeType
+}
+
 // WithStartDateCreated sets the DateCreated field of the QueryFilter value.
 func (qf *QueryFilter) WithStartDateCreated(startDate time.Time) {
 	d := startDate.UTC()
@@ -54,3 +88,9 @@ func (qf *QueryFilter) WithEndCreatedDate(endDate time.Time) {
 	d := endDate.UTC()
 	qf.EndCreatedDate = &d
 }
+
+// WithIncludeDeleted sets the IncludeDeleted field of the QueryFilter value.
+// Deleted rows are excluded from queries unless this is set to true.
+func (qf *QueryFilter) WithIncludeDeleted(includeDeleted bool) {
+	qf.IncludeDeleted = &includeDeleted
+}
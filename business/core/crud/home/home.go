@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
 	"github.com/testvergecloud/testApi/business/core/crud/delegate"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/data/transaction"
 	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/business/web/page"
 	"github.com/testvergecloud/testApi/foundation/logger"
 
 	"github.com/google/uuid"
@@ -18,8 +20,10 @@ import (
 
 // Set of error variables for CRUD operations.
 var (
-	ErrNotFound     = errors.New("home not found")
-	ErrUserDisabled = errors.New("user disabled")
+	ErrNotFound        = errors.New("home not found")
+	ErrUserDisabled    = errors.New("user disabled")
+	ErrNotDeleted      = errors.New("home not deleted")
+	ErrVersionConflict = errors.New("home version conflict")
 )
 
 // Storer interface declares the behaviour this package needs to persist and
@@ -27,9 +31,13 @@ var (
 type Storer interface {
 	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
 	Create(ctx context.Context, hme Home) error
+	CreateMany(ctx context.Context, hmes []Home) error
 	Update(ctx context.Context, hme Home) error
 	Delete(ctx context.Context, hme Home) error
+	DeleteByUserID(ctx context.Context, userID uuid.UUID, dateDeleted time.Time) error
+	Restore(ctx context.Context, hme Home) error
 	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Home, error)
+	QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, limit int) ([]Home, error)
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 	QueryByID(ctx context.Context, homeID uuid.UUID) (Home, error)
 	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Home, error)
@@ -40,17 +48,24 @@ type Core struct {
 	log      *logger.Logger
 	usrCore  *user.Core
 	delegate *delegate.Delegate
+	audit    *audit.Core
 	storer   Storer
 }
 
-// NewCore constructs a home core API for use.
-func NewCore(log *logger.Logger, usrCore *user.Core, delegate *delegate.Delegate, storer Storer) *Core {
-	return &Core{
+// NewCore constructs a home core API for use. The audit core can be nil when
+// the core is only used for queries.
+func NewCore(log *logger.Logger, usrCore *user.Core, delegate *delegate.Delegate, audCore *audit.Core, storer Storer) *Core {
+	c := Core{
 		log:      log,
 		usrCore:  usrCore,
 		delegate: delegate,
+		audit:    audCore,
 		storer:   storer,
 	}
+
+	c.registerDelegateFunctions()
+
+	return &c
 }
 
 // ExecuteUnderTransaction constructs a new Core value that will use the
@@ -66,17 +81,35 @@ func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error
 		return nil, err
 	}
 
+	dlg := c.delegate
+	if dlg != nil {
+		dlg, err = dlg.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	audCore := c.audit
+	if audCore != nil {
+		audCore, err = audCore.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	core := Core{
 		log:      c.log,
 		usrCore:  usrCore,
-		delegate: c.delegate,
+		delegate: dlg,
+		audit:    audCore,
 		storer:   storer,
 	}
 
 	return &core, nil
 }
 
-// Create adds a new home to the system.
+// Create adds a new home to the system. The home belongs to the tenant of the
+// user it's created for.
 func (c *Core) Create(ctx context.Context, nh NewHome) (Home, error) {
 	usr, err := c.usrCore.QueryByID(ctx, nh.UserID)
 	if err != nil {
@@ -90,8 +123,9 @@ func (c *Core) Create(ctx context.Context, nh NewHome) (Home, error) {
 	now := time.Now()
 
 	hme := Home{
-		ID:   uuid.New(),
-		Type: nh.Type,
+		ID:       uuid.New(),
+		TenantID: usr.TenantID,
+		Type:     nh.Type,
 		Address: Address{
 			Address1: nh.Address.Address1,
 			Address2: nh.Address.Address2,
@@ -101,6 +135,7 @@ func (c *Core) Create(ctx context.Context, nh NewHome) (Home, error) {
 			Country:  nh.Address.Country,
 		},
 		UserID:      nh.UserID,
+		Version:     1,
 		DateCreated: now,
 		DateUpdated: now,
 	}
@@ -109,11 +144,76 @@ func (c *Core) Create(ctx context.Context, nh NewHome) (Home, error) {
 		return Home{}, fmt.Errorf("create: %w", err)
 	}
 
+	if err := c.audit.Record(ctx, Domain, audit.ActionCreated, hme.TenantID, hme.ID, nil, hme); err != nil {
+		return Home{}, fmt.Errorf("audit: %w", err)
+	}
+
 	return hme, nil
 }
 
-// Update modifies information about a home.
+// CreateMany adds the new homes to the system with a single insert. Either all
+// of them are added or none are.
+func (c *Core) CreateMany(ctx context.Context, nhs []NewHome) ([]Home, error) {
+	usrs := make(map[uuid.UUID]user.User)
+	for _, nh := range nhs {
+		if _, exists := usrs[nh.UserID]; exists {
+			continue
+		}
+
+		usr, err := c.usrCore.QueryByID(ctx, nh.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("user.querybyid: %s: %w", nh.UserID, err)
+		}
+		usrs[nh.UserID] = usr
+	}
+
+	now := time.Now()
+
+	hmes := make([]Home, len(nhs))
+	muts := make([]audit.Mutation, len(nhs))
+	for i, nh := range nhs {
+		usr := usrs[nh.UserID]
+		if !usr.Enabled {
+			return nil, ErrUserDisabled
+		}
+
+		hmes[i] = Home{
+			ID:       uuid.New(),
+			TenantID: usr.TenantID,
+			Type:     nh.Type,
+			Address: Address{
+				Address1: nh.Address.Address1,
+				Address2: nh.Address.Address2,
+				ZipCode:  nh.Address.ZipCode,
+				City:     nh.Address.City,
+				State:    nh.Address.State,
+				Country:  nh.Address.Country,
+			},
+			UserID:      nh.UserID,
+			Version:     1,
+			DateCreated: now,
+			DateUpdated: now,
+		}
+		muts[i] = audit.Mutation{TenantID: hmes[i].TenantID, EntityID: hmes[i].ID, After: hmes[i]}
+	}
+
+	if err := c.storer.CreateMany(ctx, hmes); err != nil {
+		return nil, fmt.Errorf("createmany: %w", err)
+	}
+
+	if err := c.audit.RecordMany(ctx, Domain, audit.ActionCreated, muts); err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+
+	return hmes, nil
+}
+
+// Update modifies information about a home. The update only succeeds if the
+// home still has the version it was read with, otherwise ErrVersionConflict
+// is returned.
 func (c *Core) Update(ctx context.Context, hme Home, uh UpdateHome) (Home, error) {
+	before := hme
+
 	if uh.Type != nil {
 		hme.Type = *uh.Type
 	}
@@ -149,19 +249,77 @@ func (c *Core) Update(ctx context.Context, hme Home, uh UpdateHome) (Home, error
 	if err := c.storer.Update(ctx, hme); err != nil {
 		return Home{}, fmt.Errorf("update: %w", err)
 	}
+	hme.Version++
+
+	if err := c.audit.Record(ctx, Domain, audit.ActionUpdated, hme.TenantID, hme.ID, before, hme); err != nil {
+		return Home{}, fmt.Errorf("audit: %w", err)
+	}
 
 	return hme, nil
 }
 
-// Delete removes the specified home.
+// Delete marks the specified home as deleted. The home is no longer returned
+// by the query apis unless deleted homes are asked for.
 func (c *Core) Delete(ctx context.Context, hme Home) error {
+	before := hme
+	hme.DateDeleted = time.Now()
+
 	if err := c.storer.Delete(ctx, hme); err != nil {
 		return fmt.Errorf("delete: %w", err)
 	}
 
+	if err := c.audit.Record(ctx, Domain, audit.ActionDeleted, hme.TenantID, hme.ID, before, hme); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
 	return nil
 }
 
+// DeleteByUserID marks all the homes owned by the specified user as deleted.
+func (c *Core) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	if err := c.storer.DeleteByUserID(ctx, userID, time.Now()); err != nil {
+		return fmt.Errorf("deletebyuserid: userID[%s]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// Restore brings back the specified home that was previously deleted.
+func (c *Core) Restore(ctx context.Context, homeID uuid.UUID) (Home, error) {
+	var filter QueryFilter
+	filter.WithHomeID(homeID)
+	filter.WithIncludeDeleted(true)
+
+	hmes, err := c.storer.Query(ctx, filter, DefaultOrderBy, 1, 1)
+	if err != nil {
+		return Home{}, fmt.Errorf("query: homeID[%s]: %w", homeID, err)
+	}
+
+	if len(hmes) == 0 {
+		return Home{}, fmt.Errorf("query: homeID[%s]: %w", homeID, ErrNotFound)
+	}
+
+	hme := hmes[0]
+	if hme.DateDeleted.IsZero() {
+		return Home{}, ErrNotDeleted
+	}
+
+	before := hme
+	hme.DateDeleted = time.Time{}
+	hme.DateUpdated = time.Now()
+
+	if err := c.storer.Restore(ctx, hme); err != nil {
+		return Home{}, fmt.Errorf("restore: %w", err)
+	}
+	hme.Version++
+
+	if err := c.audit.Record(ctx, Domain, audit.ActionRestored, hme.TenantID, hme.ID, before, hme); err != nil {
+		return Home{}, fmt.Errorf("audit: %w", err)
+	}
+
+	return hme, nil
+}
+
 // Query retrieves a list of existing homes.
 func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Home, error) {
 	if err := filter.Validate(); err != nil {
@@ -176,6 +334,23 @@ func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By,
 	return hmes, nil
 }
 
+// QueryByCursor retrieves a list of existing homes positioned after, or
+// before for a backward cursor, the specified cursor.
+func (c *Core) QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, rowsPerPage int) ([]Home, page.Cursors, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, page.Cursors{}, err
+	}
+
+	hmes, err := c.storer.QueryByCursor(ctx, filter, orderBy, cursor, rowsPerPage+1)
+	if err != nil {
+		return nil, page.Cursors{}, fmt.Errorf("querybycursor: %w", err)
+	}
+
+	hmes, cursors := page.Window(hmes, orderBy, cursor, rowsPerPage, cursorPosition(orderBy))
+
+	return hmes, cursors, nil
+}
+
 // Count returns the total number of homes.
 func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
 	if err := filter.Validate(); err != nil {
@@ -12,6 +12,7 @@ import (
 	"github.com/testvergecloud/testApi/business/core/crud/home"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/foundation/docker"
 
 	"github.com/google/go-cmp/cmp"
@@ -77,7 +78,7 @@ func crud(t *testing.T) {
 
 	api := test.CoreAPIs
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(tenant.Set(context.Background(), tenant.DefaultID), 10*time.Second)
 	defer cancel()
 
 	t.Log("Go seeding ...")
@@ -201,6 +202,14 @@ func crud(t *testing.T) {
 	if !errors.Is(err, home.ErrNotFound) {
 		t.Fatalf("Should NOT be able to retrieve deleted home : %s", err)
 	}
+
+	if _, err := api.Home.Restore(ctx, hmes[0].ID); err != nil {
+		t.Fatalf("Should be able to restore deleted home : %s", err)
+	}
+
+	if _, err := api.Home.QueryByID(ctx, hmes[0].ID); err != nil {
+		t.Fatalf("Should be able to retrieve restored home : %s", err)
+	}
 }
 
 func paging(t *testing.T) {
@@ -234,7 +243,7 @@ func paging(t *testing.T) {
 
 	api := test.CoreAPIs
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(tenant.Set(context.Background(), tenant.DefaultID), 10*time.Second)
 	defer cancel()
 
 	t.Log("Go seeding ...")
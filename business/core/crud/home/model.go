@@ -19,11 +19,14 @@ type Address struct {
 // Home represents an individual home.
 type Home struct {
 	ID          uuid.UUID
+	TenantID    uuid.UUID
 	UserID      uuid.UUID
 	Type        Type
 	Address     Address
+	Version     int
 	DateCreated time.Time
 	DateUpdated time.Time
+	DateDeleted time.Time
 }
 
 // NewHome is what we require from clients when adding a Home.
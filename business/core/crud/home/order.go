@@ -11,3 +11,31 @@ const (
 	OrderByType   = "type"
 	OrderByUserID = "user_id"
 )
+
+// cursorPosition returns the values of the order by fields and the ID of the
+// home for use in a keyset paging cursor.
+func cursorPosition(orderBy order.By) func(hme Home) ([]string, string) {
+	return func(hme Home) ([]string, string) {
+		fields := orderBy.Fields()
+
+		values := make([]string, len(fields))
+		for i, by := range fields {
+			values[i] = cursorValue(hme, by.Field)
+		}
+
+		return values, hme.ID.String()
+	}
+}
+
+// cursorValue returns the value of the specified order by field for the
+// home.
+func cursorValue(hme Home, field string) string {
+	switch field {
+	case OrderByType:
+		return hme.Type.Name()
+	case OrderByUserID:
+		return hme.UserID.String()
+	default:
+		return hme.ID.String()
+	}
+}
@@ -5,26 +5,49 @@ import (
 	"strings"
 
 	"github.com/testvergecloud/testApi/business/core/crud/home"
-)
+	"github.com/testvergecloud/testApi/business/data/sqldb/dbarray"
 
-func (s *Store) applyFilter(filter home.QueryFilter, data map[string]interface{}, buf *bytes.Buffer) {
-	var wc []string
+	"github.com/google/uuid"
+)
 
+// applyFilter writes the WHERE clause for the filter along with any
+// additional clauses provided by the caller.
+func (s *Store) applyFilter(filter home.QueryFilter, data map[string]interface{}, buf *bytes.Buffer, wc ...string) {
 	if filter.ID != nil {
 		data["home_id"] = *filter.ID
 		wc = append(wc, "home_id = :home_id")
 	}
 
+	if len(filter.IDs) > 0 {
+		data["home_ids"] = toDBIDs(filter.IDs)
+		wc = append(wc, "home_id = ANY(:home_ids)")
+	}
+
+	if len(filter.NotIDs) > 0 {
+		data["not_home_ids"] = toDBIDs(filter.NotIDs)
+		wc = append(wc, "home_id <> ALL(:not_home_ids)")
+	}
+
 	if filter.UserID != nil {
 		data["user_id"] = *filter.UserID
 		wc = append(wc, "user_id = :user_id")
 	}
 
+	if len(filter.UserIDs) > 0 {
+		data["user_ids"] = toDBIDs(filter.UserIDs)
+		wc = append(wc, "user_id = ANY(:user_ids)")
+	}
+
 	if filter.Type != nil {
 		data["type"] = filter.Type.Name()
 		wc = append(wc, "type = :type")
 	}
 
+	if filter.NotType != nil {
+		data["not_type"] = filter.NotType.Name()
+		wc = append(wc, "type <> :not_type")
+	}
+
 	if filter.StartCreatedDate != nil {
 		data["start_date_created"] = *filter.StartCreatedDate
 		wc = append(wc, "date_created >= :start_date_created")
@@ -35,8 +58,23 @@ func (s *Store) applyFilter(filter home.QueryFilter, data map[string]interface{}
 		wc = append(wc, "date_created <= :end_date_created")
 	}
 
+	if filter.IncludeDeleted == nil || !*filter.IncludeDeleted {
+		wc = append(wc, "date_deleted IS NULL")
+	}
+
 	if len(wc) > 0 {
 		buf.WriteString(" WHERE ")
 		buf.WriteString(strings.Join(wc, " AND "))
 	}
 }
+
+// toDBIDs converts the set of IDs into an array value the database can
+// compare a column against.
+func toDBIDs(ids []uuid.UUID) any {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+
+	return dbarray.Array(values)
+}
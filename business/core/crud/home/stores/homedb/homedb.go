@@ -6,11 +6,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/testvergecloud/testApi/business/core/crud/home"
 	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/data/transaction"
 	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/business/web/page"
 	"github.com/testvergecloud/testApi/foundation/logger"
 
 	"github.com/google/uuid"
@@ -49,11 +53,20 @@ func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (home.Storer
 
 // Create inserts a new home into the database.
 func (s *Store) Create(ctx context.Context, hme home.Home) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !scope.Allows(hme.TenantID) {
+		return tenant.ErrForbidden
+	}
+
 	const q = `
     INSERT INTO homes
-        (home_id, user_id, type, address_1, address_2, zip_code, city, state, country, date_created, date_updated)
+        (home_id, tenant_id, user_id, type, address_1, address_2, zip_code, city, state, country, version, date_created, date_updated)
     VALUES
-        (:home_id, :user_id, :type, :address_1, :address_2, :zip_code, :city, :state, :country, :date_created, :date_updated)`
+        (:home_id, :tenant_id, :user_id, :type, :address_1, :address_2, :zip_code, :city, :state, :country, :version, :date_created, :date_updated)`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBHome(hme)); err != nil {
 		return fmt.Errorf("namedexeccontext: %w", err)
@@ -62,19 +75,84 @@ func (s *Store) Create(ctx context.Context, hme home.Home) error {
 	return nil
 }
 
-// Delete removes a home from the database.
+// CreateMany inserts the homes into the database in a single statement.
+func (s *Store) CreateMany(ctx context.Context, hmes []home.Home) error {
+	if len(hmes) == 0 {
+		return nil
+	}
+
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, hme := range hmes {
+		if !scope.Allows(hme.TenantID) {
+			return tenant.ErrForbidden
+		}
+	}
+
+	const q = `
+    INSERT INTO homes
+        (home_id, tenant_id, user_id, type, address_1, address_2, zip_code, city, state, country, version, date_created, date_updated)
+    VALUES
+        (:home_id, :tenant_id, :user_id, :type, :address_1, :address_2, :zip_code, :city, :state, :country, :version, :date_created, :date_updated)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBHomes(hmes)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete marks a home as deleted in the database.
 func (s *Store) Delete(ctx context.Context, hme home.Home) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	const q = `
+    UPDATE
+        homes
+    SET
+        "date_deleted" = :date_deleted
+    WHERE
+        home_id = :home_id AND ` + tenant.Clause
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, scoped{toDBHome(hme), scope}); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByUserID marks all the homes owned by the specified user as deleted.
+// Homes that are already deleted keep their original date.
+func (s *Store) DeleteByUserID(ctx context.Context, userID uuid.UUID, dateDeleted time.Time) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
 	data := struct {
-		ID string `db:"home_id"`
+		UserID      string    `db:"user_id"`
+		DateDeleted time.Time `db:"date_deleted"`
+		tenant.Scope
 	}{
-		ID: hme.ID.String(),
+		UserID:      userID.String(),
+		DateDeleted: dateDeleted.UTC(),
+		Scope:       scope,
 	}
 
 	const q = `
-    DELETE FROM
-	    homes
-	WHERE
-	  	home_id = :home_id`
+    UPDATE
+        homes
+    SET
+        "date_deleted" = :date_deleted
+    WHERE
+        user_id = :user_id AND
+        date_deleted IS NULL AND ` + tenant.Clause
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
 		return fmt.Errorf("namedexeccontext: %w", err)
@@ -83,8 +161,38 @@ func (s *Store) Delete(ctx context.Context, hme home.Home) error {
 	return nil
 }
 
-// Update replaces a home document in the database.
+// Restore clears the deleted mark of a home in the database.
+func (s *Store) Restore(ctx context.Context, hme home.Home) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	const q = `
+    UPDATE
+        homes
+    SET
+        "date_deleted" = NULL,
+        "version" = version + 1,
+        "date_updated" = :date_updated
+    WHERE
+        home_id = :home_id AND ` + tenant.Clause
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, scoped{toDBHome(hme), scope}); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a home document in the database. The home is only updated
+// if the version in the database matches the version of the specified home.
 func (s *Store) Update(ctx context.Context, hme home.Home) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
 	const q = `
     UPDATE
         homes
@@ -96,12 +204,19 @@ func (s *Store) Update(ctx context.Context, hme home.Home) error {
         "state"         = :state,
         "country"       = :country,
         "type"          = :type,
+        "version"       = :version + 1,
         "date_updated"  = :date_updated
     WHERE
-        home_id = :home_id`
+        home_id = :home_id AND
+        version = :version AND ` + tenant.Clause
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBHome(hme)); err != nil {
-		return fmt.Errorf("namedexeccontext: %w", err)
+	affected, err := sqldb.NamedExecContextAffected(ctx, s.log, s.db, q, scoped{toDBHome(hme), scope})
+	if err != nil {
+		return fmt.Errorf("namedexeccontextaffected: %w", err)
+	}
+
+	if affected == 0 {
+		return home.ErrVersionConflict
 	}
 
 	return nil
@@ -109,19 +224,25 @@ func (s *Store) Update(ctx context.Context, hme home.Home) error {
 
 // Query retrieves a list of existing homes from the database.
 func (s *Store) Query(ctx context.Context, filter home.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]home.Home, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	data := map[string]interface{}{
 		"offset":        (pageNumber - 1) * rowsPerPage,
 		"rows_per_page": rowsPerPage,
 	}
+	scope.Bind(data)
 
 	const q = `
     SELECT
-	    home_id, user_id, type, address_1, address_2, zip_code, city, state, country, date_created, date_updated
+	    home_id, tenant_id, user_id, type, address_1, address_2, zip_code, city, state, country, version, date_created, date_updated, date_deleted
 	FROM
 	  	homes`
 
 	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
+	s.applyFilter(filter, data, buf, tenant.Clause)
 
 	orderByClause, err := orderByClause(orderBy)
 	if err != nil {
@@ -144,9 +265,67 @@ func (s *Store) Query(ctx context.Context, filter home.QueryFilter, orderBy orde
 	return hmes, nil
 }
 
+// QueryByCursor retrieves a list of existing homes from the database positioned
+// relative to the specified cursor.
+func (s *Store) QueryByCursor(ctx context.Context, filter home.QueryFilter, orderBy order.By, cursor page.Cursor, limit int) ([]home.Home, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"rows_per_page": limit,
+	}
+	scope.Bind(data)
+
+	const q = `
+    SELECT
+	    home_id, tenant_id, user_id, type, address_1, address_2, zip_code, city, state, country, version, date_created, date_updated, date_deleted
+	FROM
+	  	homes`
+
+	where, orderByClause, err := cursorClauses(orderBy, cursor, data)
+	if err != nil {
+		return nil, err
+	}
+
+	wc := []string{tenant.Clause}
+	if where != "" {
+		wc = append(wc, where)
+	}
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf, wc...)
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" FETCH FIRST :rows_per_page ROWS ONLY")
+
+	var dbHmes []dbHome
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbHmes); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	if cursor.Backward {
+		slices.Reverse(dbHmes)
+	}
+
+	hmes, err := toCoreHomeSlice(dbHmes)
+	if err != nil {
+		return nil, err
+	}
+
+	return hmes, nil
+}
+
 // Count returns the total number of homes in the DB.
 func (s *Store) Count(ctx context.Context, filter home.QueryFilter) (int, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return 0, err
+	}
+
 	data := map[string]interface{}{}
+	scope.Bind(data)
 
 	const q = `
     SELECT
@@ -155,7 +334,7 @@ func (s *Store) Count(ctx context.Context, filter home.QueryFilter) (int, error)
         homes`
 
 	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
+	s.applyFilter(filter, data, buf, tenant.Clause)
 
 	var count struct {
 		Count int `db:"count"`
@@ -169,19 +348,27 @@ func (s *Store) Count(ctx context.Context, filter home.QueryFilter) (int, error)
 
 // QueryByID gets the specified home from the database.
 func (s *Store) QueryByID(ctx context.Context, homeID uuid.UUID) (home.Home, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return home.Home{}, err
+	}
+
 	data := struct {
 		ID string `db:"home_id"`
+		tenant.Scope
 	}{
-		ID: homeID.String(),
+		ID:    homeID.String(),
+		Scope: scope,
 	}
 
 	const q = `
     SELECT
-	  	home_id, user_id, type, address_1, address_2, zip_code, city, state, country, date_created, date_updated
+	  	home_id, tenant_id, user_id, type, address_1, address_2, zip_code, city, state, country, version, date_created, date_updated, date_deleted
     FROM
         homes
     WHERE
-        home_id = :home_id`
+        home_id = :home_id AND
+        date_deleted IS NULL AND ` + tenant.Clause
 
 	var dbHme dbHome
 	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbHme); err != nil {
@@ -196,19 +383,27 @@ func (s *Store) QueryByID(ctx context.Context, homeID uuid.UUID) (home.Home, err
 
 // QueryByUserID gets the specified home from the database by user id.
 func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]home.Home, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	data := struct {
 		ID string `db:"user_id"`
+		tenant.Scope
 	}{
-		ID: userID.String(),
+		ID:    userID.String(),
+		Scope: scope,
 	}
 
 	const q = `
 	SELECT
-	    home_id, user_id, type, address_1, address_2, zip_code, city, state, country, date_created, date_updated
+	    home_id, tenant_id, user_id, type, address_1, address_2, zip_code, city, state, country, version, date_created, date_updated, date_deleted
 	FROM
 		homes
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND
+		date_deleted IS NULL AND ` + tenant.Clause
 
 	var dbHmes []dbHome
 	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbHmes); err != nil {
@@ -217,3 +412,9 @@ func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]home.Hom
 
 	return toCoreHomeSlice(dbHmes)
 }
+
+// scoped binds the tenant scope of the request along with the home.
+type scoped struct {
+	dbHome
+	tenant.Scope
+}
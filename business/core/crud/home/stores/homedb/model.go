@@ -1,6 +1,7 @@
 package homedb
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -10,22 +11,26 @@ import (
 )
 
 type dbHome struct {
-	ID          uuid.UUID `db:"home_id"`
-	UserID      uuid.UUID `db:"user_id"`
-	Type        string    `db:"type"`
-	Address1    string    `db:"address_1"`
-	Address2    string    `db:"address_2"`
-	ZipCode     string    `db:"zip_code"`
-	City        string    `db:"city"`
-	Country     string    `db:"country"`
-	State       string    `db:"state"`
-	DateCreated time.Time `db:"date_created"`
-	DateUpdated time.Time `db:"date_updated"`
+	ID          uuid.UUID    `db:"home_id"`
+	TenantID    uuid.UUID    `db:"tenant_id"`
+	UserID      uuid.UUID    `db:"user_id"`
+	Type        string       `db:"type"`
+	Address1    string       `db:"address_1"`
+	Address2    string       `db:"address_2"`
+	ZipCode     string       `db:"zip_code"`
+	City        string       `db:"city"`
+	Country     string       `db:"country"`
+	State       string       `db:"state"`
+	Version     int          `db:"version"`
+	DateCreated time.Time    `db:"date_created"`
+	DateUpdated time.Time    `db:"date_updated"`
+	DateDeleted sql.NullTime `db:"date_deleted"`
 }
 
 func toDBHome(hme home.Home) dbHome {
 	hmeDB := dbHome{
 		ID:          hme.ID,
+		TenantID:    hme.TenantID,
 		UserID:      hme.UserID,
 		Type:        hme.Type.Name(),
 		Address1:    hme.Address.Address1,
@@ -34,13 +39,28 @@ func toDBHome(hme home.Home) dbHome {
 		City:        hme.Address.City,
 		Country:     hme.Address.Country,
 		State:       hme.Address.State,
+		Version:     hme.Version,
 		DateCreated: hme.DateCreated.UTC(),
 		DateUpdated: hme.DateUpdated.UTC(),
+		DateDeleted: sql.NullTime{
+			Time:  hme.DateDeleted.UTC(),
+			Valid: !hme.DateDeleted.IsZero(),
+		},
 	}
 
 	return hmeDB
 }
 
+func toDBHomes(hmes []home.Home) []dbHome {
+	dbHmes := make([]dbHome, len(hmes))
+
+	for i, hme := range hmes {
+		dbHmes[i] = toDBHome(hme)
+	}
+
+	return dbHmes
+}
+
 func toCoreHome(dbHme dbHome) (home.Home, error) {
 	typ, err := home.ParseType(dbHme.Type)
 	if err != nil {
@@ -48,9 +68,10 @@ func toCoreHome(dbHme dbHome) (home.Home, error) {
 	}
 
 	hme := home.Home{
-		ID:     dbHme.ID,
-		UserID: dbHme.UserID,
-		Type:   typ,
+		ID:       dbHme.ID,
+		TenantID: dbHme.TenantID,
+		UserID:   dbHme.UserID,
+		Type:     typ,
 		Address: home.Address{
 			Address1: dbHme.Address1,
 			Address2: dbHme.Address2,
@@ -59,10 +80,15 @@ func toCoreHome(dbHme dbHome) (home.Home, error) {
 			Country:  dbHme.Country,
 			State:    dbHme.State,
 		},
+		Version:     dbHme.Version,
 		DateCreated: dbHme.DateCreated.In(time.Local),
 		DateUpdated: dbHme.DateUpdated.In(time.Local),
 	}
 
+	if dbHme.DateDeleted.Valid {
+		hme.DateDeleted = dbHme.DateDeleted.Time.In(time.Local)
+	}
+
 	return hme, nil
 }
 
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"math/rand"
 
+	"github.com/testvergecloud/testApi/business/data/tenant"
+
 	"github.com/google/uuid"
 )
 
@@ -46,7 +48,7 @@ func TestGenerateSeedHomes(n int, api *Core, userID uuid.UUID) ([]Home, error) {
 
 	hmes := make([]Home, len(newHmes))
 	for i, nh := range newHmes {
-		hme, err := api.Create(context.Background(), nh)
+		hme, err := api.Create(tenant.Set(context.Background(), tenant.DefaultID), nh)
 		if err != nil {
 			return nil, fmt.Errorf("seeding home: idx: %d : %w", i, err)
 		}
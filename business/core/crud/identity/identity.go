@@ -0,0 +1,133 @@
+// Package identity provides support for mapping the accounts users have with
+// external identity providers to their user. A user logging in for the first
+// time is provisioned just in time.
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/foundation/logger"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound         = errors.New("identity not found")
+	ErrEmailNotVerified = errors.New("identity email not verified")
+)
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, idn Identity) error
+	QueryByExternal(ctx context.Context, issuer string, subject string) (Identity, error)
+}
+
+// Core manages the set of APIs for identity access.
+type Core struct {
+	log     *logger.Logger
+	usrCore *user.Core
+	storer  Storer
+	prov    Provisioning
+}
+
+// NewCore constructs an identity core API for use. Users are provisioned as
+// described by prov.
+func NewCore(log *logger.Logger, usrCore *user.Core, storer Storer, prov Provisioning) *Core {
+	return &Core{
+		log:     log,
+		usrCore: usrCore,
+		storer:  storer,
+		prov:    prov,
+	}
+}
+
+// Resolve returns the user the external account belongs to. An account seen
+// for the first time is linked to the user with the same email, or to a new
+// user if there's none. Either way the provider has to have verified the
+// email, otherwise anyone could claim the user of an email.
+func (c *Core) Resolve(ctx context.Context, ext External) (user.User, error) {
+	// The tenant isn't known until the account is found.
+	allCtx := tenant.SetAll(ctx)
+
+	idn, err := c.storer.QueryByExternal(allCtx, ext.Issuer, ext.Subject)
+	switch {
+	case err == nil:
+		usr, err := c.usrCore.QueryByID(tenant.Set(ctx, idn.TenantID), idn.UserID)
+		if err != nil {
+			return user.User{}, fmt.Errorf("querybyid: userID[%s]: %w", idn.UserID, err)
+		}
+		return usr, nil
+
+	case !errors.Is(err, ErrNotFound):
+		return user.User{}, fmt.Errorf("querybyexternal: %w", err)
+	}
+
+	if !ext.EmailVerified {
+		return user.User{}, ErrEmailNotVerified
+	}
+
+	usr, err := c.usrCore.QueryByEmail(allCtx, ext.Email)
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		if usr, err = c.provision(ctx, ext); err != nil {
+			return user.User{}, err
+		}
+
+	case err != nil:
+		return user.User{}, fmt.Errorf("querybyemail: %w", err)
+	}
+
+	// A user created without the link being stored is linked by its email on
+	// the next login.
+	idn = Identity{
+		Issuer:      ext.Issuer,
+		Subject:     ext.Subject,
+		UserID:      usr.ID,
+		TenantID:    usr.TenantID,
+		DateCreated: time.Now(),
+	}
+
+	if err := c.storer.Create(tenant.Set(ctx, usr.TenantID), idn); err != nil {
+		return user.User{}, fmt.Errorf("create: %w", err)
+	}
+
+	return usr, nil
+}
+
+// provision creates the user of an account seen for the first time. The user
+// gets a random password so they can only log in with the provider until
+// they set one.
+func (c *Core) provision(ctx context.Context, ext External) (user.User, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return user.User{}, fmt.Errorf("generating password: %w", err)
+	}
+	password := base64.RawURLEncoding.EncodeToString(b)
+
+	name := ext.Name
+	if name == "" {
+		name = ext.Email.Address
+	}
+
+	nu := user.NewUser{
+		Name:            name,
+		Email:           ext.Email,
+		Roles:           c.prov.Roles,
+		Password:        password,
+		PasswordConfirm: password,
+	}
+
+	usr, err := c.usrCore.Create(tenant.Set(ctx, c.prov.TenantID), nu)
+	if err != nil {
+		return user.User{}, fmt.Errorf("create user: %w", err)
+	}
+
+	return usr, nil
+}
@@ -0,0 +1,120 @@
+package identity_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/identity"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	code, err := run(m)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	os.Exit(code)
+}
+
+func run(m *testing.M) (int, error) {
+	var err error
+
+	c, err = dbtest.StartDB()
+	if err != nil {
+		return 1, err
+	}
+	defer dbtest.StopDB(c)
+
+	return m.Run(), nil
+}
+
+func Test_Identity(t *testing.T) {
+	t.Run("resolve", resolve)
+}
+
+func resolve(t *testing.T) {
+	test := dbtest.NewTest(t, c, "Test_Identity/resolve")
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ext := identity.External{
+		Issuer:        "https://idp.example.com",
+		Subject:       "external-1",
+		Email:         mail.Address{Address: "oidc@example.com"},
+		EmailVerified: true,
+		Name:          "OIDC Gopher",
+	}
+
+	usr, err := api.Identity.Resolve(ctx, ext)
+	if err != nil {
+		t.Fatalf("Should be able to provision a user for a new account : %s", err)
+	}
+
+	if usr.TenantID != tenant.DefaultID || len(usr.Roles) != 1 || usr.Roles[0] != user.RoleUser {
+		t.Fatalf("Should provision the user as configured : tenant %s roles %v", usr.TenantID, usr.Roles)
+	}
+
+	// The email can change at the provider, the account stays linked.
+	ext.Email = mail.Address{Address: "renamed@example.com"}
+
+	again, err := api.Identity.Resolve(ctx, ext)
+	if err != nil {
+		t.Fatalf("Should be able to resolve a known account : %s", err)
+	}
+
+	if again.ID != usr.ID {
+		t.Fatalf("Should get back the linked user : got %s exp %s", again.ID, usr.ID)
+	}
+
+	// -------------------------------------------------------------------------
+
+	existing := identity.External{
+		Issuer:        "https://idp.example.com",
+		Subject:       "external-2",
+		Email:         mail.Address{Address: "admin@example.com"},
+		EmailVerified: true,
+	}
+
+	admin, err := api.Identity.Resolve(ctx, existing)
+	if err != nil {
+		t.Fatalf("Should be able to link an account to an existing user : %s", err)
+	}
+
+	if admin.Email.Address != "admin@example.com" || len(admin.Roles) != 1 || admin.Roles[0] != user.RoleAdmin {
+		t.Fatalf("Should link the account to the user with the same email : %s %v", admin.Email.Address, admin.Roles)
+	}
+
+	// -------------------------------------------------------------------------
+
+	unverified := identity.External{
+		Issuer:  "https://idp.example.com",
+		Subject: "external-3",
+		Email:   mail.Address{Address: "user@example.com"},
+	}
+
+	if _, err := api.Identity.Resolve(ctx, unverified); !errors.Is(err, identity.ErrEmailNotVerified) {
+		t.Fatalf("Should NOT be able to claim a user with an unverified email : %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package identity
+
+import (
+	"net/mail"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+
+	"github.com/google/uuid"
+)
+
+// Identity links a user to the account they have with an external identity
+// provider. The issuer and subject identify the account.
+type Identity struct {
+	Issuer      string
+	Subject     string
+	UserID      uuid.UUID
+	TenantID    uuid.UUID
+	DateCreated time.Time
+}
+
+// External represents an account as asserted by an identity provider.
+type External struct {
+	Issuer        string
+	Subject       string
+	Email         mail.Address
+	EmailVerified bool
+	Name          string
+}
+
+// Provisioning describes the users created for accounts seen for the first
+// time.
+type Provisioning struct {
+	TenantID uuid.UUID
+	Roles    []user.Role
+}
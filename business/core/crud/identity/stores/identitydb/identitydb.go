@@ -0,0 +1,93 @@
+// Package identitydb contains identity related CRUD functionality.
+package identitydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/testvergecloud/testApi/business/core/crud/identity"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for identity database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new identity into the database. Linking an account that
+// is already linked keeps the existing link.
+func (s *Store) Create(ctx context.Context, idn identity.Identity) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !scope.Allows(idn.TenantID) {
+		return tenant.ErrForbidden
+	}
+
+	const q = `
+	INSERT INTO user_identities
+		(issuer, subject, user_id, tenant_id, date_created)
+	VALUES
+		(:issuer, :subject, :user_id, :tenant_id, :date_created)
+	ON CONFLICT DO NOTHING`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBIdentity(idn)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByExternal gets the identity of the specified account from the
+// database.
+func (s *Store) QueryByExternal(ctx context.Context, issuer string, subject string) (identity.Identity, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return identity.Identity{}, err
+	}
+
+	data := struct {
+		Issuer  string `db:"issuer"`
+		Subject string `db:"subject"`
+		tenant.Scope
+	}{
+		Issuer:  issuer,
+		Subject: subject,
+		Scope:   scope,
+	}
+
+	const q = `
+	SELECT
+		issuer, subject, user_id, tenant_id, date_created
+	FROM
+		user_identities
+	WHERE
+		issuer = :issuer AND
+		subject = :subject AND ` + tenant.Clause
+
+	var dbIdn dbIdentity
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbIdn); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return identity.Identity{}, fmt.Errorf("namedquerystruct: %w", identity.ErrNotFound)
+		}
+		return identity.Identity{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreIdentity(dbIdn), nil
+}
@@ -0,0 +1,37 @@
+package identitydb
+
+import (
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/identity"
+
+	"github.com/google/uuid"
+)
+
+type dbIdentity struct {
+	Issuer      string    `db:"issuer"`
+	Subject     string    `db:"subject"`
+	UserID      uuid.UUID `db:"user_id"`
+	TenantID    uuid.UUID `db:"tenant_id"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+func toDBIdentity(idn identity.Identity) dbIdentity {
+	return dbIdentity{
+		Issuer:      idn.Issuer,
+		Subject:     idn.Subject,
+		UserID:      idn.UserID,
+		TenantID:    idn.TenantID,
+		DateCreated: idn.DateCreated.UTC(),
+	}
+}
+
+func toCoreIdentity(dbIdn dbIdentity) identity.Identity {
+	return identity.Identity{
+		Issuer:      dbIdn.Issuer,
+		Subject:     dbIdn.Subject,
+		UserID:      dbIdn.UserID,
+		TenantID:    dbIdn.TenantID,
+		DateCreated: dbIdn.DateCreated.In(time.Local),
+	}
+}
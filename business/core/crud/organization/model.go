@@ -0,0 +1,27 @@
+package organization
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization represents a customer organization, the tenant users,
+// products and homes belong to.
+type Organization struct {
+	ID          uuid.UUID
+	Name        string
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewOrganization contains information needed to create a new organization.
+type NewOrganization struct {
+	Name string
+}
+
+// UpdateOrganization contains information needed to update an organization.
+// Fields that are not set are left unchanged.
+type UpdateOrganization struct {
+	Name *string
+}
@@ -0,0 +1,99 @@
+// Package organization provides support for the customer organizations served
+// by the service. Every user, product and home belongs to an organization,
+// which is the tenant the stores scope their queries to.
+package organization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound   = errors.New("organization not found")
+	ErrUniqueName = errors.New("organization name is not unique")
+)
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, org Organization) error
+	Update(ctx context.Context, org Organization) error
+	QueryAll(ctx context.Context) ([]Organization, error)
+	QueryByID(ctx context.Context, orgID uuid.UUID) (Organization, error)
+}
+
+// Core manages the set of APIs for organization access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewCore constructs an organization core API for use.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+	}
+}
+
+// Create adds a new organization to the system. Only a context that can
+// access every tenant can create one.
+func (c *Core) Create(ctx context.Context, no NewOrganization) (Organization, error) {
+	now := time.Now()
+
+	org := Organization{
+		ID:          uuid.New(),
+		Name:        no.Name,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := c.storer.Create(ctx, org); err != nil {
+		return Organization{}, fmt.Errorf("create: %w", err)
+	}
+
+	return org, nil
+}
+
+// Update modifies information about an organization.
+func (c *Core) Update(ctx context.Context, org Organization, uo UpdateOrganization) (Organization, error) {
+	if uo.Name != nil {
+		org.Name = *uo.Name
+	}
+
+	org.DateUpdated = time.Now()
+
+	if err := c.storer.Update(ctx, org); err != nil {
+		return Organization{}, fmt.Errorf("update: %w", err)
+	}
+
+	return org, nil
+}
+
+// QueryAll retrieves the organizations the context can access ordered by
+// name.
+func (c *Core) QueryAll(ctx context.Context) ([]Organization, error) {
+	orgs, err := c.storer.QueryAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("queryall: %w", err)
+	}
+
+	return orgs, nil
+}
+
+// QueryByID finds the organization by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, orgID uuid.UUID) (Organization, error) {
+	org, err := c.storer.QueryByID(ctx, orgID)
+	if err != nil {
+		return Organization{}, fmt.Errorf("query: orgID[%s]: %w", orgID, err)
+	}
+
+	return org, nil
+}
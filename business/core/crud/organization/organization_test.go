@@ -0,0 +1,268 @@
+package organization_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/organization"
+	"github.com/testvergecloud/testApi/business/core/crud/product"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	code, err := run(m)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	os.Exit(code)
+}
+
+func run(m *testing.M) (int, error) {
+	var err error
+
+	c, err = dbtest.StartDB()
+	if err != nil {
+		return 1, err
+	}
+	defer dbtest.StopDB(c)
+
+	return m.Run(), nil
+}
+
+func Test_Organization(t *testing.T) {
+	t.Run("crud", crud)
+	t.Run("isolation", isolation)
+}
+
+func crud(t *testing.T) {
+	test := dbtest.NewTest(t, c, "Test_Organization/crud")
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := api.Organization.Create(tenant.Set(ctx, tenant.DefaultID), organization.NewOrganization{Name: "Acme"}); !errors.Is(err, tenant.ErrForbidden) {
+		t.Fatalf("Should not be able to create an organization from a tenant: %v", err)
+	}
+
+	allCtx := tenant.SetAll(ctx)
+
+	org, err := api.Organization.Create(allCtx, organization.NewOrganization{Name: "Acme"})
+	if err != nil {
+		t.Fatalf("Should be able to create an organization: %s", err)
+	}
+
+	if _, err := api.Organization.Create(allCtx, organization.NewOrganization{Name: "Acme"}); !errors.Is(err, organization.ErrUniqueName) {
+		t.Fatalf("Should not be able to create an organization with the same name: %v", err)
+	}
+
+	name := "Acme Corp"
+	upd, err := api.Organization.Update(tenant.Set(ctx, org.ID), org, organization.UpdateOrganization{Name: &name})
+	if err != nil {
+		t.Fatalf("Should be able to update the organization from its own tenant: %s", err)
+	}
+
+	saved, err := api.Organization.QueryByID(tenant.Set(ctx, org.ID), org.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the organization by ID: %s", err)
+	}
+
+	if saved.Name != upd.Name {
+		t.Fatalf("Should get back the updated name: got %q, exp %q", saved.Name, upd.Name)
+	}
+
+	orgs, err := api.Organization.QueryAll(allCtx)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve every organization: %s", err)
+	}
+
+	if len(orgs) != 2 {
+		t.Fatalf("Should get back the default organization and the new one: got %d", len(orgs))
+	}
+}
+
+func isolation(t *testing.T) {
+	test := dbtest.NewTest(t, c, "Test_Organization/isolation")
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding ...")
+
+	allCtx := tenant.SetAll(ctx)
+
+	orgA, err := api.Organization.Create(allCtx, organization.NewOrganization{Name: "Tenant A"})
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	orgB, err := api.Organization.Create(allCtx, organization.NewOrganization{Name: "Tenant B"})
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	ctxA := tenant.Set(ctx, orgA.ID)
+	ctxB := tenant.Set(ctx, orgB.ID)
+
+	seed := func(ctx context.Context, email string) (user.User, product.Product, error) {
+		nu := user.NewUser{
+			Name:            "Tenant User",
+			Email:           mail.Address{Address: email},
+			Roles:           []user.Role{user.RoleAdmin},
+			Password:        "12345",
+			PasswordConfirm: "12345",
+		}
+
+		usr, err := api.User.Create(ctx, nu)
+		if err != nil {
+			return user.User{}, product.Product{}, fmt.Errorf("seeding user: %w", err)
+		}
+
+		np := product.NewProduct{
+			UserID:   usr.ID,
+			Name:     "Guitar",
+			Cost:     10,
+			Quantity: 1,
+		}
+
+		prd, err := api.Product.Create(ctx, np)
+		if err != nil {
+			return user.User{}, product.Product{}, fmt.Errorf("seeding product: %w", err)
+		}
+
+		return usr, prd, nil
+	}
+
+	usrA, prdA, err := seed(ctxA, "a@example.com")
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	usrB, prdB, err := seed(ctxB, "b@example.com")
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if usrA.TenantID != orgA.ID || prdA.TenantID != orgA.ID {
+		t.Fatalf("Should create the user and product in the tenant of the context: user %s product %s", usrA.TenantID, prdA.TenantID)
+	}
+
+	if _, err := api.User.QueryByID(ctx, usrA.ID); !errors.Is(err, tenant.ErrMissing) {
+		t.Fatalf("Should not be able to query without a tenant: %v", err)
+	}
+
+	if _, err := api.User.QueryByID(ctxA, usrB.ID); !errors.Is(err, user.ErrNotFound) {
+		t.Fatalf("Should not be able to retrieve a user of another tenant: %v", err)
+	}
+
+	if _, err := api.Product.QueryByID(ctxA, prdB.ID); !errors.Is(err, product.ErrNotFound) {
+		t.Fatalf("Should not be able to retrieve a product of another tenant: %v", err)
+	}
+
+	if _, err := api.Organization.QueryByID(ctxA, orgB.ID); !errors.Is(err, organization.ErrNotFound) {
+		t.Fatalf("Should not be able to retrieve another organization: %v", err)
+	}
+
+	usrs, err := api.User.Query(ctxA, user.QueryFilter{}, user.DefaultOrderBy, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to query the users of the tenant: %s", err)
+	}
+
+	if len(usrs) != 1 || usrs[0].ID != usrA.ID {
+		t.Fatalf("Should only get back the users of the tenant: got %d", len(usrs))
+	}
+
+	n, err := api.Product.Count(ctxA, product.QueryFilter{})
+	if err != nil {
+		t.Fatalf("Should be able to count the products of the tenant: %s", err)
+	}
+
+	if n != 1 {
+		t.Fatalf("Should only count the products of the tenant: got %d", n)
+	}
+
+	nu := user.NewUser{
+		TenantID:        orgB.ID,
+		Name:            "Intruder",
+		Email:           mail.Address{Address: "intruder@example.com"},
+		Roles:           []user.Role{user.RoleUser},
+		Password:        "12345",
+		PasswordConfirm: "12345",
+	}
+
+	if _, err := api.User.Create(ctxA, nu); !errors.Is(err, tenant.ErrForbidden) {
+		t.Fatalf("Should not be able to create a user in another tenant: %v", err)
+	}
+
+	name := "Stolen"
+	if _, err := api.User.Update(ctxA, usrB, user.UpdateUser{Name: &name}); err == nil {
+		t.Fatal("Should not be able to update a user of another tenant")
+	}
+
+	if err := api.User.Delete(ctxA, usrB); err == nil {
+		t.Fatal("Should not be able to delete a user of another tenant")
+	}
+
+	if _, err := api.User.QueryByID(ctxB, usrB.ID); err != nil {
+		t.Fatalf("Should not delete a user of another tenant: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	usrs, err = api.User.Query(allCtx, user.QueryFilter{}, user.DefaultOrderBy, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to query the users of every tenant: %s", err)
+	}
+
+	var found int
+	for _, usr := range usrs {
+		if usr.ID == usrA.ID || usr.ID == usrB.ID {
+			found++
+		}
+	}
+
+	if found != 2 {
+		t.Fatalf("Should get back the users of every tenant: got %d of 2", found)
+	}
+
+	nu.Email = mail.Address{Address: "placed@example.com"}
+	placed, err := api.User.Create(tenant.SetAll(ctxA), nu)
+	if err != nil {
+		t.Fatalf("Should be able to create a user in another tenant across tenants: %s", err)
+	}
+
+	if placed.TenantID != orgB.ID {
+		t.Fatalf("Should create the user in the requested tenant: got %s", placed.TenantID)
+	}
+}
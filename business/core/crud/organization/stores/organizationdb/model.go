@@ -0,0 +1,42 @@
+package organizationdb
+
+import (
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/organization"
+
+	"github.com/google/uuid"
+)
+
+type dbOrganization struct {
+	ID          uuid.UUID `db:"organization_id"`
+	Name        string    `db:"name"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+func toDBOrganization(org organization.Organization) dbOrganization {
+	return dbOrganization{
+		ID:          org.ID,
+		Name:        org.Name,
+		DateCreated: org.DateCreated.UTC(),
+		DateUpdated: org.DateUpdated.UTC(),
+	}
+}
+
+func toCoreOrganization(dbOrg dbOrganization) organization.Organization {
+	return organization.Organization{
+		ID:          dbOrg.ID,
+		Name:        dbOrg.Name,
+		DateCreated: dbOrg.DateCreated.In(time.Local),
+		DateUpdated: dbOrg.DateUpdated.In(time.Local),
+	}
+}
+
+func toCoreOrganizationSlice(dbOrgs []dbOrganization) []organization.Organization {
+	orgs := make([]organization.Organization, len(dbOrgs))
+	for i, dbOrg := range dbOrgs {
+		orgs[i] = toCoreOrganization(dbOrg)
+	}
+	return orgs
+}
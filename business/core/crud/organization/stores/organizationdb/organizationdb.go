@@ -0,0 +1,158 @@
+// Package organizationdb contains organization related CRUD functionality.
+package organizationdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/testvergecloud/testApi/business/core/crud/organization"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// scopeClause restricts a query to the organizations of the scope. An
+// organization is the tenant itself so it's matched on its own ID.
+const scopeClause = "(:scope_all OR organization_id = :scope_tenant_id)"
+
+// Store manages the set of APIs for organization database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new organization into the database. Only a scope with
+// access to every tenant can create one.
+func (s *Store) Create(ctx context.Context, org organization.Organization) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !scope.All {
+		return tenant.ErrForbidden
+	}
+
+	const q = `
+	INSERT INTO organizations
+		(organization_id, name, date_created, date_updated)
+	VALUES
+		(:organization_id, :name, :date_created, :date_updated)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBOrganization(org)); err != nil {
+		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", organization.ErrUniqueName)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces an organization document in the database.
+func (s *Store) Update(ctx context.Context, org organization.Organization) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	const q = `
+	UPDATE
+		organizations
+	SET
+		"name" = :name,
+		"date_updated" = :date_updated
+	WHERE
+		organization_id = :organization_id AND ` + scopeClause
+
+	affected, err := sqldb.NamedExecContextAffected(ctx, s.log, s.db, q, scoped{toDBOrganization(org), scope})
+	if err != nil {
+		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontextaffected: %w", organization.ErrUniqueName)
+		}
+		return fmt.Errorf("namedexeccontextaffected: %w", err)
+	}
+
+	if affected == 0 {
+		return organization.ErrNotFound
+	}
+
+	return nil
+}
+
+// QueryAll retrieves the organizations of the scope from the database ordered
+// by name.
+func (s *Store) QueryAll(ctx context.Context) ([]organization.Organization, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	const q = `
+	SELECT
+		organization_id, name, date_created, date_updated
+	FROM
+		organizations
+	WHERE ` + scopeClause + `
+	ORDER BY
+		name`
+
+	var dbOrgs []dbOrganization
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, scope, &dbOrgs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreOrganizationSlice(dbOrgs), nil
+}
+
+// QueryByID gets the specified organization from the database.
+func (s *Store) QueryByID(ctx context.Context, orgID uuid.UUID) (organization.Organization, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return organization.Organization{}, err
+	}
+
+	data := struct {
+		ID string `db:"organization_id"`
+		tenant.Scope
+	}{
+		ID:    orgID.String(),
+		Scope: scope,
+	}
+
+	const q = `
+	SELECT
+		organization_id, name, date_created, date_updated
+	FROM
+		organizations
+	WHERE
+		organization_id = :organization_id AND ` + scopeClause
+
+	var dbOrg dbOrganization
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbOrg); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return organization.Organization{}, fmt.Errorf("namedquerystruct: %w", organization.ErrNotFound)
+		}
+		return organization.Organization{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreOrganization(dbOrg), nil
+}
+
+// scoped binds the tenant scope of the request along with the organization.
+type scoped struct {
+	dbOrganization
+	tenant.Scope
+}
@@ -10,12 +10,15 @@ import (
 	"github.com/go-json-experiment/json"
 )
 
+// Domain represents the name of this domain.
+const Domain = "product"
+
 // registerDelegateFunctions will register action functions with the delegate
 // system. If the core was constructed for query only, there won't be a
 // delegate provided.
 func (c *Core) registerDelegateFunctions() {
 	if c.delegate != nil {
-		c.delegate.Register(user.Domain, user.ActionUpdated, c.actionUserUpdated)
+		c.delegate.Register(user.Domain, user.ActionUpdated, c.actionUserUpdated, delegate.WithName(Domain+".userupdated"))
 	}
 }
 
@@ -29,9 +32,16 @@ func (c *Core) actionUserUpdated(ctx context.Context, data delegate.Data) error
 
 	c.log.Info(ctx, "action-userupdate", "user_id", params.UserID, "enabled", params.Enabled)
 
-	// Now we can see if this user has been disabled. If they have been, we will
-	// want to disable to mark all these products as deleted. Right now we don't
-	// have support for this, but you can see how we can process the event.
+	// If the user has been disabled, all of their products are marked as
+	// deleted. The event can be delivered more than once, which is safe since
+	// products that are already deleted are left alone.
+	if params.Enabled == nil || *params.Enabled {
+		return nil
+	}
+
+	if err := c.DeleteByUserID(ctx, params.UserID); err != nil {
+		return fmt.Errorf("deletebyuserid: %w", err)
+	}
 
 	return nil
 }
@@ -1,7 +1,9 @@
 package product
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/testvergecloud/testApi/foundation/validate"
 
@@ -11,10 +13,19 @@ import (
 // QueryFilter holds the available fields a query can be filtered on.
 // We are using pointer semantics because the With API mutates the value.
 type QueryFilter struct {
-	ID       *uuid.UUID
-	Name     *string `validate:"omitempty,min=3"`
-	Cost     *float64
-	Quantity *int
+	ID               *uuid.UUID
+	IDs              []uuid.UUID `validate:"omitempty,max=100"`
+	NotIDs           []uuid.UUID `validate:"omitempty,max=100"`
+	Name             *string     `validate:"omitempty,min=3"`
+	NamePrefix       *string     `validate:"omitempty,min=1"`
+	NotName          *string     `validate:"omitempty,min=3"`
+	Cost             *float64
+	StartCost        *float64 `validate:"omitempty,gte=0"`
+	EndCost          *float64 `validate:"omitempty,gte=0"`
+	Quantity         *int
+	StartCreatedDate *time.Time
+	EndCreatedDate   *time.Time
+	IncludeDeleted   *bool
 }
 
 // Validate can perform a check of the data against the validate tags.
@@ -23,6 +34,14 @@ func (qf *QueryFilter) Validate() error {
 		return fmt.Errorf("validate: %w", err)
 	}
 
+	if qf.StartCost != nil && qf.EndCost != nil && *qf.EndCost < *qf.StartCost {
+		return fmt.Errorf("validate: %w", validate.NewFieldsError("end_cost", errors.New("end_cost is before start_cost")))
+	}
+
+	if qf.StartCreatedDate != nil && qf.EndCreatedDate != nil && qf.EndCreatedDate.Before(*qf.StartCreatedDate) {
+		return fmt.Errorf("validate: %w", validate.NewFieldsError("end_created_date", errors.New("end_created_date is before start_created_date")))
+	}
+
 	return nil
 }
 
@@ -31,17 +50,69 @@ func (qf *QueryFilter) WithProductID(productID uuid.UUID) {
 	qf.ID = &productID
 }
 
+// WithProductIDs sets the IDs field of the QueryFilter value. Products
+// matching any of the IDs are returned.
+func (qf *QueryFilter) WithProductIDs(productIDs []uuid.UUID) {
+	qf.IDs = productIDs
+}
+
+// WithoutProductIDs sets the NotIDs field of the QueryFilter value. Products
+// matching any of the IDs are excluded.
+func (qf *QueryFilter) WithoutProductIDs(productIDs []uuid.UUID) {
+	qf.NotIDs = productIDs
+}
+
 // WithName sets the Name field of the QueryFilter value.
 func (qf *QueryFilter) WithName(name string) {
 	qf.Name = &name
 }
 
+// WithNamePrefix sets the NamePrefix field of the QueryFilter value. The
+// prefix is matched case insensitively.
+func (qf *QueryFilter) WithNamePrefix(prefix string) {
+	qf.NamePrefix = &prefix
+}
+
+// WithoutName sets the NotName field of the QueryFilter value. Products whose
+// name contains the value are excluded.
+func (qf *QueryFilter) WithoutName(name string) {
+	qf.NotName = &name
+}
+
 // WithCost sets the Cost field of the QueryFilter value.
 func (qf *QueryFilter) WithCost(cost float64) {
 	qf.Cost = &cost
 }
 
+// WithStartCost sets the StartCost field of the QueryFilter value.
+func (qf *QueryFilter) WithStartCost(cost float64) {
+	qf.StartCost = &cost
+}
+
+// WithEndCost sets the EndCost field of the QueryFilter value.
+func (qf *QueryFilter) WithEndCost(cost float64) {
+	qf.EndCost = &cost
+}
+
 // WithQuantity sets the Quantity field of the QueryFilter value.
 func (qf *QueryFilter) WithQuantity(quantity int) {
 	qf.Quantity = &quantity
 }
+
+// WithStartDateCreated sets the DateCreated field of the QueryFilter value.
+func (qf *QueryFilter) WithStartDateCreated(startDate time.Time) {
+	d := startDate.UTC()
+	qf.StartCreatedDate = &d
+}
+
+// WithEndCreatedDate sets the DateCreated field of the QueryFilter value.
+func (qf *QueryFilter) WithEndCreatedDate(endDate time.Time) {
+	d := endDate.UTC()
+	qf.EndCreatedDate = &d
+}
+
+// WithIncludeDeleted sets the IncludeDeleted field of the QueryFilter value.
+// Deleted rows are excluded from queries unless this is set to true.
+func (qf *QueryFilter) WithIncludeDeleted(includeDeleted bool) {
+	qf.IncludeDeleted = &includeDeleted
+}
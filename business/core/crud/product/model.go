@@ -9,12 +9,15 @@ import (
 // Product represents an individual product.
 type Product struct {
 	ID          uuid.UUID
+	TenantID    uuid.UUID
 	UserID      uuid.UUID
 	Name        string
 	Cost        float64
 	Quantity    int
+	Version     int
 	DateCreated time.Time
 	DateUpdated time.Time
+	DateDeleted time.Time
 }
 
 // NewProduct is what we require from clients when adding a Product.
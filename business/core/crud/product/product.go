@@ -1,6 +1,5 @@
-// Package product provides an example of a core business API. Right now these
-// calls are just wrapping the data/store layer. But at some point you will
-// want auditing or something that isn't specific to the data/store layer.
+// Package product provides an example of a core business API. These calls wrap
+// the data/store layer and record every change in the audit log.
 package product
 
 import (
@@ -9,10 +8,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
 	"github.com/testvergecloud/testApi/business/core/crud/delegate"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/data/transaction"
 	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/business/web/page"
 	"github.com/testvergecloud/testApi/foundation/logger"
 
 	"github.com/google/uuid"
@@ -20,9 +21,11 @@ import (
 
 // Set of error variables for CRUD operations.
 var (
-	ErrNotFound     = errors.New("product not found")
-	ErrUserDisabled = errors.New("user disabled")
-	ErrInvalidCost  = errors.New("cost not valid")
+	ErrNotFound        = errors.New("product not found")
+	ErrUserDisabled    = errors.New("user disabled")
+	ErrInvalidCost     = errors.New("cost not valid")
+	ErrNotDeleted      = errors.New("product not deleted")
+	ErrVersionConflict = errors.New("product version conflict")
 )
 
 // Storer interface declares the behavior this package needs to perists and
@@ -30,9 +33,13 @@ var (
 type Storer interface {
 	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
 	Create(ctx context.Context, prd Product) error
+	CreateMany(ctx context.Context, prds []Product) error
 	Update(ctx context.Context, prd Product) error
 	Delete(ctx context.Context, prd Product) error
+	DeleteByUserID(ctx context.Context, userID uuid.UUID, dateDeleted time.Time) error
+	Restore(ctx context.Context, prd Product) error
 	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Product, error)
+	QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, limit int) ([]Product, error)
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 	QueryByID(ctx context.Context, productID uuid.UUID) (Product, error)
 	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Product, error)
@@ -43,15 +50,18 @@ type Core struct {
 	log      *logger.Logger
 	usrCore  *user.Core
 	delegate *delegate.Delegate
+	audit    *audit.Core
 	storer   Storer
 }
 
-// NewCore constructs a product core API for use.
-func NewCore(log *logger.Logger, usrCore *user.Core, delegate *delegate.Delegate, storer Storer) *Core {
+// NewCore constructs a product core API for use. The audit core can be nil when
+// the core is only used for queries.
+func NewCore(log *logger.Logger, usrCore *user.Core, delegate *delegate.Delegate, audCore *audit.Core, storer Storer) *Core {
 	c := Core{
 		log:      log,
 		usrCore:  usrCore,
 		delegate: delegate,
+		audit:    audCore,
 		storer:   storer,
 	}
 
@@ -73,17 +83,35 @@ func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error
 		return nil, err
 	}
 
+	dlg := c.delegate
+	if dlg != nil {
+		dlg, err = dlg.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	audCore := c.audit
+	if audCore != nil {
+		audCore, err = audCore.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	core := Core{
 		log:      c.log,
 		usrCore:  usrCore,
-		delegate: c.delegate,
+		delegate: dlg,
+		audit:    audCore,
 		storer:   storer,
 	}
 
 	return &core, nil
 }
 
-// Create adds a new product to the system.
+// Create adds a new product to the system. The product belongs to the tenant
+// of the user it's created for.
 func (c *Core) Create(ctx context.Context, np NewProduct) (Product, error) {
 	usr, err := c.usrCore.QueryByID(ctx, np.UserID)
 	if err != nil {
@@ -102,10 +130,12 @@ func (c *Core) Create(ctx context.Context, np NewProduct) (Product, error) {
 
 	prd := Product{
 		ID:          uuid.New(),
+		TenantID:    usr.TenantID,
 		Name:        np.Name,
 		Cost:        np.Cost,
 		Quantity:    np.Quantity,
 		UserID:      np.UserID,
+		Version:     1,
 		DateCreated: now,
 		DateUpdated: now,
 	}
@@ -114,11 +144,74 @@ func (c *Core) Create(ctx context.Context, np NewProduct) (Product, error) {
 		return Product{}, fmt.Errorf("create: %w", err)
 	}
 
+	if err := c.audit.Record(ctx, Domain, audit.ActionCreated, prd.TenantID, prd.ID, nil, prd); err != nil {
+		return Product{}, fmt.Errorf("audit: %w", err)
+	}
+
 	return prd, nil
 }
 
-// Update modifies information about a product.
+// CreateMany adds the new products to the system with a single insert. Either
+// all of them are added or none are.
+func (c *Core) CreateMany(ctx context.Context, nps []NewProduct) ([]Product, error) {
+	usrs := make(map[uuid.UUID]user.User)
+	for _, np := range nps {
+		if _, exists := usrs[np.UserID]; exists {
+			continue
+		}
+
+		usr, err := c.usrCore.QueryByID(ctx, np.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("user.querybyid: %s: %w", np.UserID, err)
+		}
+		usrs[np.UserID] = usr
+	}
+
+	now := time.Now()
+
+	prds := make([]Product, len(nps))
+	muts := make([]audit.Mutation, len(nps))
+	for i, np := range nps {
+		if np.Cost < 0 {
+			return nil, ErrInvalidCost
+		}
+
+		usr := usrs[np.UserID]
+		if !usr.Enabled {
+			return nil, ErrUserDisabled
+		}
+
+		prds[i] = Product{
+			ID:          uuid.New(),
+			TenantID:    usr.TenantID,
+			Name:        np.Name,
+			Cost:        np.Cost,
+			Quantity:    np.Quantity,
+			UserID:      np.UserID,
+			Version:     1,
+			DateCreated: now,
+			DateUpdated: now,
+		}
+		muts[i] = audit.Mutation{TenantID: prds[i].TenantID, EntityID: prds[i].ID, After: prds[i]}
+	}
+
+	if err := c.storer.CreateMany(ctx, prds); err != nil {
+		return nil, fmt.Errorf("createmany: %w", err)
+	}
+
+	if err := c.audit.RecordMany(ctx, Domain, audit.ActionCreated, muts); err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+
+	return prds, nil
+}
+
+// Update modifies information about a product. The update only succeeds if
+// the product still has the version it was read with, otherwise
+// ErrVersionConflict is returned.
 func (c *Core) Update(ctx context.Context, prd Product, up UpdateProduct) (Product, error) {
+	before := prd
+
 	if up.Name != nil {
 		prd.Name = *up.Name
 	}
@@ -136,19 +229,77 @@ func (c *Core) Update(ctx context.Context, prd Product, up UpdateProduct) (Produ
 	if err := c.storer.Update(ctx, prd); err != nil {
 		return Product{}, fmt.Errorf("update: %w", err)
 	}
+	prd.Version++
+
+	if err := c.audit.Record(ctx, Domain, audit.ActionUpdated, prd.TenantID, prd.ID, before, prd); err != nil {
+		return Product{}, fmt.Errorf("audit: %w", err)
+	}
 
 	return prd, nil
 }
 
-// Delete removes the specified product.
+// Delete marks the specified product as deleted. The product is no longer
+// returned by the query apis unless deleted products are asked for.
 func (c *Core) Delete(ctx context.Context, prd Product) error {
+	before := prd
+	prd.DateDeleted = time.Now()
+
 	if err := c.storer.Delete(ctx, prd); err != nil {
 		return fmt.Errorf("delete: %w", err)
 	}
 
+	if err := c.audit.Record(ctx, Domain, audit.ActionDeleted, prd.TenantID, prd.ID, before, prd); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
 	return nil
 }
 
+// DeleteByUserID marks all the products owned by the specified user as deleted.
+func (c *Core) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	if err := c.storer.DeleteByUserID(ctx, userID, time.Now()); err != nil {
+		return fmt.Errorf("deletebyuserid: userID[%s]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// Restore brings back the specified product that was previously deleted.
+func (c *Core) Restore(ctx context.Context, productID uuid.UUID) (Product, error) {
+	var filter QueryFilter
+	filter.WithProductID(productID)
+	filter.WithIncludeDeleted(true)
+
+	prds, err := c.storer.Query(ctx, filter, DefaultOrderBy, 1, 1)
+	if err != nil {
+		return Product{}, fmt.Errorf("query: productID[%s]: %w", productID, err)
+	}
+
+	if len(prds) == 0 {
+		return Product{}, fmt.Errorf("query: productID[%s]: %w", productID, ErrNotFound)
+	}
+
+	prd := prds[0]
+	if prd.DateDeleted.IsZero() {
+		return Product{}, ErrNotDeleted
+	}
+
+	before := prd
+	prd.DateDeleted = time.Time{}
+	prd.DateUpdated = time.Now()
+
+	if err := c.storer.Restore(ctx, prd); err != nil {
+		return Product{}, fmt.Errorf("restore: %w", err)
+	}
+	prd.Version++
+
+	if err := c.audit.Record(ctx, Domain, audit.ActionRestored, prd.TenantID, prd.ID, before, prd); err != nil {
+		return Product{}, fmt.Errorf("audit: %w", err)
+	}
+
+	return prd, nil
+}
+
 // Query retrieves a list of existing products.
 func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Product, error) {
 	if err := filter.Validate(); err != nil {
@@ -163,6 +314,23 @@ func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By,
 	return prds, nil
 }
 
+// QueryByCursor retrieves a list of existing products positioned after, or
+// before for a backward cursor, the specified cursor.
+func (c *Core) QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, rowsPerPage int) ([]Product, page.Cursors, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, page.Cursors{}, err
+	}
+
+	prds, err := c.storer.QueryByCursor(ctx, filter, orderBy, cursor, rowsPerPage+1)
+	if err != nil {
+		return nil, page.Cursors{}, fmt.Errorf("querybycursor: %w", err)
+	}
+
+	prds, cursors := page.Window(prds, orderBy, cursor, rowsPerPage, cursorPosition(orderBy))
+
+	return prds, cursors, nil
+}
+
 // Count returns the total number of products.
 func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
 	if err := filter.Validate(); err != nil {
@@ -7,17 +7,23 @@ import (
 	"net/mail"
 	"os"
 	"runtime/debug"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
 	"github.com/testvergecloud/testApi/business/core/crud/product"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/data/dbtest"
 	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/data/transaction"
+	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/business/web/page"
 	"github.com/testvergecloud/testApi/foundation/docker"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
 )
 
 var c *docker.Container
@@ -80,7 +86,7 @@ func crud(t *testing.T) {
 
 	api := test.CoreAPIs
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(tenant.Set(context.Background(), tenant.DefaultID), 10*time.Second)
 	defer cancel()
 
 	t.Log("Go seeding ...")
@@ -132,11 +138,19 @@ func crud(t *testing.T) {
 		t.Errorf("Should be able to update product : %s", err)
 	}
 
+	if _, err := api.Product.Update(ctx, saved, upd); !errors.Is(err, product.ErrVersionConflict) {
+		t.Errorf("Should get a version conflict updating a stale product : %v", err)
+	}
+
 	saved, err = api.Product.QueryByID(ctx, prds[0].ID)
 	if err != nil {
 		t.Fatalf("Should be able to retrieve updated product : %s", err)
 	}
 
+	if saved.Version != 2 {
+		t.Errorf("Should have bumped the version : got %d, exp %d", saved.Version, 2)
+	}
+
 	diff := prds[0].DateUpdated.Sub(saved.DateUpdated)
 	if diff > 0 {
 		t.Fatalf("Should have a larger DateUpdated : sav %v, prd %v, dif %v", saved.DateUpdated, saved.DateUpdated, diff)
@@ -198,6 +212,41 @@ func crud(t *testing.T) {
 	if !errors.Is(err, product.ErrNotFound) {
 		t.Fatalf("Should NOT be able to retrieve deleted product : %s", err)
 	}
+
+	if _, err := api.Product.Restore(ctx, prds[0].ID); err != nil {
+		t.Fatalf("Should be able to restore deleted product : %s", err)
+	}
+
+	if _, err := api.Product.QueryByID(ctx, prds[0].ID); err != nil {
+		t.Fatalf("Should be able to retrieve restored product : %s", err)
+	}
+
+	if _, err := api.Product.Restore(ctx, prds[0].ID); !errors.Is(err, product.ErrNotDeleted) {
+		t.Fatalf("Should NOT be able to restore product that is not deleted : %s", err)
+	}
+	// -------------------------------------------------------------------------
+
+	var audFilter audit.QueryFilter
+	audFilter.WithEntityID(prds[0].ID)
+
+	auds, err := api.Audit.Query(ctx, audFilter, order.NewBy(audit.OrderByTimestamp, order.ASC), 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to query the audit log : %s", err)
+	}
+
+	actions := make([]string, len(auds))
+	for i, aud := range auds {
+		actions[i] = aud.Action
+	}
+
+	exp := []string{audit.ActionCreated, audit.ActionUpdated, audit.ActionUpdated, audit.ActionDeleted, audit.ActionRestored}
+	if diff := cmp.Diff(exp, actions); diff != "" {
+		t.Fatalf("Should record every change in the audit log, dif:\n%s", diff)
+	}
+
+	if !strings.Contains(string(auds[2].Diff), "Graphic Novels") || strings.Contains(string(auds[2].Diff), "Quantity") {
+		t.Errorf("Should only record the changed fields : %s", auds[2].Diff)
+	}
 }
 
 func paging(t *testing.T) {
@@ -231,7 +280,7 @@ func paging(t *testing.T) {
 
 	api := test.CoreAPIs
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(tenant.Set(context.Background(), tenant.DefaultID), 10*time.Second)
 	defer cancel()
 
 	t.Log("Go seeding ...")
@@ -298,6 +347,74 @@ func paging(t *testing.T) {
 		t.Logf("product2: %v", prd3[1].ID)
 		t.Fatalf("Should have different product")
 	}
+
+	// -------------------------------------------------------------------------
+
+	var filter product.QueryFilter
+	filter.WithProductIDs([]uuid.UUID{prds[0].ID, prds[1].ID})
+	filter.WithoutProductIDs([]uuid.UUID{prds[1].ID})
+
+	in, err := api.Product.Query(ctx, filter, product.DefaultOrderBy, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve products by IDs : %s", err)
+	}
+
+	if len(in) != 1 || in[0].ID != prds[0].ID {
+		t.Fatalf("Should only get the product that was not excluded : %d", len(in))
+	}
+
+	filter = product.QueryFilter{}
+	filter.WithNamePrefix(strings.ToUpper(prds[0].Name[:2]))
+	filter.WithStartCost(prds[0].Cost)
+	filter.WithEndCost(prds[0].Cost)
+
+	rng, err := api.Product.Query(ctx, filter, product.DefaultOrderBy, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve products by prefix and cost range : %s", err)
+	}
+
+	if len(rng) == 0 || rng[0].Cost != prds[0].Cost {
+		t.Fatalf("Should get products matching the prefix and cost range : %d", len(rng))
+	}
+
+	filter = product.QueryFilter{}
+	filter.WithStartCost(10)
+	filter.WithEndCost(5)
+
+	if err := filter.Validate(); err == nil {
+		t.Fatalf("Should not accept a cost range that ends before it starts")
+	}
+
+	// -------------------------------------------------------------------------
+
+	orderBy := order.NewBy(product.OrderByName, order.ASC)
+
+	first, cursors, err := api.Product.QueryByCursor(ctx, product.QueryFilter{}, orderBy, page.Cursor{}, 1)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the first keyset page : %s", err)
+	}
+
+	if len(first) != 1 || cursors.Next == nil || cursors.Prev != nil {
+		t.Fatalf("Should have a single product and only a next cursor : %d %v", len(first), cursors)
+	}
+
+	second, cursors, err := api.Product.QueryByCursor(ctx, product.QueryFilter{}, orderBy, *cursors.Next, 1)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the second keyset page : %s", err)
+	}
+
+	if len(second) != 1 || second[0].ID == first[0].ID || cursors.Prev == nil {
+		t.Fatalf("Should have a different product and a prev cursor : %d %v", len(second), cursors)
+	}
+
+	back, _, err := api.Product.QueryByCursor(ctx, product.QueryFilter{}, orderBy, *cursors.Prev, 1)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the previous keyset page : %s", err)
+	}
+
+	if len(back) != 1 || back[0].ID != first[0].ID {
+		t.Fatalf("Should get back the first product walking backward")
+	}
 }
 
 func tran(t *testing.T) {
@@ -312,7 +429,7 @@ func tran(t *testing.T) {
 
 	api := test.CoreAPIs
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(tenant.Set(context.Background(), tenant.DefaultID), 10*time.Second)
 	defer cancel()
 
 	// -------------------------------------------------------------------------
@@ -1,6 +1,7 @@
 package productdb
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/testvergecloud/testApi/business/core/crud/product"
@@ -9,40 +10,65 @@ import (
 )
 
 type dbProduct struct {
-	ID          uuid.UUID `db:"product_id"`
-	UserID      uuid.UUID `db:"user_id"`
-	Name        string    `db:"name"`
-	Cost        float64   `db:"cost"`
-	Quantity    int       `db:"quantity"`
-	DateCreated time.Time `db:"date_created"`
-	DateUpdated time.Time `db:"date_updated"`
+	ID          uuid.UUID    `db:"product_id"`
+	TenantID    uuid.UUID    `db:"tenant_id"`
+	UserID      uuid.UUID    `db:"user_id"`
+	Name        string       `db:"name"`
+	Cost        float64      `db:"cost"`
+	Quantity    int          `db:"quantity"`
+	Version     int          `db:"version"`
+	DateCreated time.Time    `db:"date_created"`
+	DateUpdated time.Time    `db:"date_updated"`
+	DateDeleted sql.NullTime `db:"date_deleted"`
 }
 
 func toDBProduct(prd product.Product) dbProduct {
 	prdDB := dbProduct{
 		ID:          prd.ID,
+		TenantID:    prd.TenantID,
 		UserID:      prd.UserID,
 		Name:        prd.Name,
 		Cost:        prd.Cost,
 		Quantity:    prd.Quantity,
+		Version:     prd.Version,
 		DateCreated: prd.DateCreated.UTC(),
 		DateUpdated: prd.DateUpdated.UTC(),
+		DateDeleted: sql.NullTime{
+			Time:  prd.DateDeleted.UTC(),
+			Valid: !prd.DateDeleted.IsZero(),
+		},
 	}
 
 	return prdDB
 }
 
+func toDBProducts(prds []product.Product) []dbProduct {
+	dbPrds := make([]dbProduct, len(prds))
+
+	for i, prd := range prds {
+		dbPrds[i] = toDBProduct(prd)
+	}
+
+	return dbPrds
+}
+
 func toCoreProduct(dbPrd dbProduct) product.Product {
 	prd := product.Product{
 		ID:          dbPrd.ID,
+		TenantID:    dbPrd.TenantID,
 		UserID:      dbPrd.UserID,
 		Name:        dbPrd.Name,
 		Cost:        dbPrd.Cost,
 		Quantity:    dbPrd.Quantity,
+		Version:     dbPrd.Version,
 		DateCreated: dbPrd.DateCreated.In(time.Local),
 		DateUpdated: dbPrd.DateUpdated.In(time.Local),
 	}
 
+	if dbPrd.DateDeleted.Valid {
+		prd.DateDeleted = dbPrd.DateDeleted.Time.In(time.Local)
+	}
+
 	return prd
 }
 
@@ -2,9 +2,11 @@ package productdb
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/testvergecloud/testApi/business/core/crud/product"
 	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/business/web/page"
 )
 
 var orderByFields = map[string]string{
@@ -15,11 +17,75 @@ var orderByFields = map[string]string{
 	product.OrderByQuantity:  "quantity",
 }
 
+// orderByColumns maps the order by fields to their columns. The primary key
+// is added as a tie breaker, unless it's already ordered on, so the order is
+// stable.
+func orderByColumns(orderBy order.By) ([]string, []string, error) {
+	var columns []string
+	var directions []string
+	var hasKey bool
+
+	for _, by := range orderBy.Fields() {
+		column, exists := orderByFields[by.Field]
+		if !exists {
+			return nil, nil, fmt.Errorf("field %q does not exist", by.Field)
+		}
+
+		columns = append(columns, column)
+		directions = append(directions, by.Direction)
+
+		if column == "product_id" {
+			hasKey = true
+		}
+	}
+
+	if !hasKey {
+		columns = append(columns, "product_id")
+		directions = append(directions, order.ASC)
+	}
+
+	return columns, directions, nil
+}
+
 func orderByClause(orderBy order.By) (string, error) {
-	by, exists := orderByFields[orderBy.Field]
-	if !exists {
-		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	columns, directions, err := orderByColumns(orderBy)
+	if err != nil {
+		return "", err
+	}
+
+	clauses := make([]string, len(columns))
+	for i, column := range columns {
+		clauses[i] = column + " " + directions[i]
+	}
+
+	return " ORDER BY " + strings.Join(clauses, ", "), nil
+}
+
+// cursorClauses returns the condition that positions the query relative to
+// the cursor and the order by clause keyset paging requires.
+func cursorClauses(orderBy order.By, cursor page.Cursor, data map[string]interface{}) (string, string, error) {
+	columns, directions, err := orderByColumns(orderBy)
+	if err != nil {
+		return "", "", err
+	}
+
+	clauses := make([]string, len(columns))
+	for i, column := range columns {
+		clauses[i] = column + " " + cursor.Direction(directions[i])
+	}
+	orderByClause := " ORDER BY " + strings.Join(clauses, ", ")
+
+	if cursor.IsZero() {
+		return "", orderByClause, nil
+	}
+
+	// The cursor holds a value for every order by field and the ID for the
+	// primary key that is always compared last.
+	fields := len(orderBy.Fields())
+	where, err := cursor.Condition(append(columns[:fields:fields], "product_id"), append(directions[:fields:fields], order.ASC), data)
+	if err != nil {
+		return "", "", err
 	}
 
-	return " ORDER BY " + by + " " + orderBy.Direction, nil
+	return where, orderByClause, nil
 }
@@ -6,11 +6,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/testvergecloud/testApi/business/core/crud/product"
 	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/data/transaction"
 	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/business/web/page"
 	"github.com/testvergecloud/testApi/foundation/logger"
 
 	"github.com/google/uuid"
@@ -50,11 +54,20 @@ func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (product.Sto
 // Create adds a Product to the sqldb. It returns the created Product with
 // fields like ID and DateCreated populated.
 func (s *Store) Create(ctx context.Context, prd product.Product) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !scope.Allows(prd.TenantID) {
+		return tenant.ErrForbidden
+	}
+
 	const q = `
 	INSERT INTO products
-		(product_id, user_id, name, cost, quantity, date_created, date_updated)
+		(product_id, tenant_id, user_id, name, cost, quantity, version, date_created, date_updated)
 	VALUES
-		(:product_id, :user_id, :name, :cost, :quantity, :date_created, :date_updated)`
+		(:product_id, :tenant_id, :user_id, :name, :cost, :quantity, :version, :date_created, :date_updated)`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBProduct(prd)); err != nil {
 		return fmt.Errorf("namedexeccontext: %w", err)
@@ -63,9 +76,45 @@ func (s *Store) Create(ctx context.Context, prd product.Product) error {
 	return nil
 }
 
+// CreateMany adds the Products to the sqldb in a single statement.
+func (s *Store) CreateMany(ctx context.Context, prds []product.Product) error {
+	if len(prds) == 0 {
+		return nil
+	}
+
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, prd := range prds {
+		if !scope.Allows(prd.TenantID) {
+			return tenant.ErrForbidden
+		}
+	}
+
+	const q = `
+	INSERT INTO products
+		(product_id, tenant_id, user_id, name, cost, quantity, version, date_created, date_updated)
+	VALUES
+		(:product_id, :tenant_id, :user_id, :name, :cost, :quantity, :version, :date_created, :date_updated)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBProducts(prds)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
 // Update modifies data about a Product. It will error if the specified ID is
-// invalid or does not reference an existing Product.
+// invalid or does not reference an existing Product. The Product is only
+// updated if the version in the database matches the specified version.
 func (s *Store) Update(ctx context.Context, prd product.Product) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
 	const q = `
 	UPDATE
 		products
@@ -73,30 +122,72 @@ func (s *Store) Update(ctx context.Context, prd product.Product) error {
 		"name" = :name,
 		"cost" = :cost,
 		"quantity" = :quantity,
+		"version" = :version + 1,
 		"date_updated" = :date_updated
 	WHERE
-		product_id = :product_id`
+		product_id = :product_id AND
+		version = :version AND ` + tenant.Clause
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBProduct(prd)); err != nil {
-		return fmt.Errorf("namedexeccontext: %w", err)
+	affected, err := sqldb.NamedExecContextAffected(ctx, s.log, s.db, q, scoped{toDBProduct(prd), scope})
+	if err != nil {
+		return fmt.Errorf("namedexeccontextaffected: %w", err)
+	}
+
+	if affected == 0 {
+		return product.ErrVersionConflict
 	}
 
 	return nil
 }
 
-// Delete removes the product identified by a given ID.
+// Delete marks the product identified by a given ID as deleted.
 func (s *Store) Delete(ctx context.Context, prd product.Product) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	const q = `
+	UPDATE
+		products
+	SET
+		"date_deleted" = :date_deleted
+	WHERE
+		product_id = :product_id AND ` + tenant.Clause
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, scoped{toDBProduct(prd), scope}); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByUserID marks all the products owned by the given User ID as
+// deleted. Products that are already deleted keep their original date.
+func (s *Store) DeleteByUserID(ctx context.Context, userID uuid.UUID, dateDeleted time.Time) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
 	data := struct {
-		ID string `db:"product_id"`
+		UserID      string    `db:"user_id"`
+		DateDeleted time.Time `db:"date_deleted"`
+		tenant.Scope
 	}{
-		ID: prd.ID.String(),
+		UserID:      userID.String(),
+		DateDeleted: dateDeleted.UTC(),
+		Scope:       scope,
 	}
 
 	const q = `
-	DELETE FROM
+	UPDATE
 		products
+	SET
+		"date_deleted" = :date_deleted
 	WHERE
-		product_id = :product_id`
+		user_id = :user_id AND
+		date_deleted IS NULL AND ` + tenant.Clause
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
 		return fmt.Errorf("namedexeccontext: %w", err)
@@ -105,21 +196,51 @@ func (s *Store) Delete(ctx context.Context, prd product.Product) error {
 	return nil
 }
 
+// Restore clears the deleted mark of the product identified by a given ID.
+func (s *Store) Restore(ctx context.Context, prd product.Product) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	const q = `
+	UPDATE
+		products
+	SET
+		"date_deleted" = NULL,
+		"version" = version + 1,
+		"date_updated" = :date_updated
+	WHERE
+		product_id = :product_id AND ` + tenant.Clause
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, scoped{toDBProduct(prd), scope}); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
 // Query gets all Products from the database.
 func (s *Store) Query(ctx context.Context, filter product.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]product.Product, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	data := map[string]interface{}{
 		"offset":        (pageNumber - 1) * rowsPerPage,
 		"rows_per_page": rowsPerPage,
 	}
+	scope.Bind(data)
 
 	const q = `
 	SELECT
-	    product_id, user_id, name, cost, quantity, date_created, date_updated
+	    product_id, tenant_id, user_id, name, cost, quantity, version, date_created, date_updated, date_deleted
 	FROM
 		products`
 
 	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
+	s.applyFilter(filter, data, buf, tenant.Clause)
 
 	orderByClause, err := orderByClause(orderBy)
 	if err != nil {
@@ -137,9 +258,62 @@ func (s *Store) Query(ctx context.Context, filter product.QueryFilter, orderBy o
 	return toCoreProducts(dbPrds), nil
 }
 
+// QueryByCursor retrieves a list of existing products from the database positioned
+// relative to the specified cursor.
+func (s *Store) QueryByCursor(ctx context.Context, filter product.QueryFilter, orderBy order.By, cursor page.Cursor, limit int) ([]product.Product, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"rows_per_page": limit,
+	}
+	scope.Bind(data)
+
+	const q = `
+	SELECT
+	    product_id, tenant_id, user_id, name, cost, quantity, version, date_created, date_updated, date_deleted
+	FROM
+		products`
+
+	where, orderByClause, err := cursorClauses(orderBy, cursor, data)
+	if err != nil {
+		return nil, err
+	}
+
+	wc := []string{tenant.Clause}
+	if where != "" {
+		wc = append(wc, where)
+	}
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf, wc...)
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" FETCH FIRST :rows_per_page ROWS ONLY")
+
+	var dbPrds []dbProduct
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbPrds); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	if cursor.Backward {
+		slices.Reverse(dbPrds)
+	}
+
+	return toCoreProducts(dbPrds), nil
+}
+
 // Count returns the total number of users in the DB.
 func (s *Store) Count(ctx context.Context, filter product.QueryFilter) (int, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return 0, err
+	}
+
 	data := map[string]interface{}{}
+	scope.Bind(data)
 
 	const q = `
 	SELECT
@@ -148,7 +322,7 @@ func (s *Store) Count(ctx context.Context, filter product.QueryFilter) (int, err
 		products`
 
 	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
+	s.applyFilter(filter, data, buf, tenant.Clause)
 
 	var count struct {
 		Count   int `db:"count"`
@@ -164,19 +338,27 @@ func (s *Store) Count(ctx context.Context, filter product.QueryFilter) (int, err
 
 // QueryByID finds the product identified by a given ID.
 func (s *Store) QueryByID(ctx context.Context, productID uuid.UUID) (product.Product, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return product.Product{}, err
+	}
+
 	data := struct {
 		ID string `db:"product_id"`
+		tenant.Scope
 	}{
-		ID: productID.String(),
+		ID:    productID.String(),
+		Scope: scope,
 	}
 
 	const q = `
 	SELECT
-	    product_id, user_id, name, cost, quantity, date_created, date_updated
+	    product_id, tenant_id, user_id, name, cost, quantity, version, date_created, date_updated, date_deleted
 	FROM
 		products
 	WHERE
-		product_id = :product_id`
+		product_id = :product_id AND
+		date_deleted IS NULL AND ` + tenant.Clause
 
 	var dbPrd dbProduct
 	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbPrd); err != nil {
@@ -191,19 +373,27 @@ func (s *Store) QueryByID(ctx context.Context, productID uuid.UUID) (product.Pro
 
 // QueryByUserID finds the product identified by a given User ID.
 func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]product.Product, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	data := struct {
 		ID string `db:"user_id"`
+		tenant.Scope
 	}{
-		ID: userID.String(),
+		ID:    userID.String(),
+		Scope: scope,
 	}
 
 	const q = `
 	SELECT
-	    product_id, user_id, name, cost, quantity, date_created, date_updated
+	    product_id, tenant_id, user_id, name, cost, quantity, version, date_created, date_updated, date_deleted
 	FROM
 		products
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND
+		date_deleted IS NULL AND ` + tenant.Clause
 
 	var dbPrds []dbProduct
 	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbPrds); err != nil {
@@ -212,3 +402,9 @@ func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]product.
 
 	return toCoreProducts(dbPrds), nil
 }
+
+// scoped binds the tenant scope of the request along with the product.
+type scoped struct {
+	dbProduct
+	tenant.Scope
+}
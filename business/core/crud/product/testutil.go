@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"math/rand"
 
+	"github.com/testvergecloud/testApi/business/data/tenant"
+
 	"github.com/google/uuid"
 )
 
@@ -35,7 +37,7 @@ func TestGenerateSeedProducts(n int, api *Core, userID uuid.UUID) ([]Product, er
 
 	prds := make([]Product, len(newPrds))
 	for i, np := range newPrds {
-		prd, err := api.Create(context.Background(), np)
+		prd, err := api.Create(tenant.Set(context.Background(), tenant.DefaultID), np)
 		if err != nil {
 			return nil, fmt.Errorf("seeding product: idx: %d : %w", i, err)
 		}
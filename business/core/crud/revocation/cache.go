@@ -0,0 +1,65 @@
+package revocation
+
+import (
+	"sync"
+	"time"
+)
+
+// cache holds the IDs of the revoked tokens that haven't expired yet so
+// checking a token doesn't need the database.
+type cache struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+}
+
+func newCache() *cache {
+	return &cache{
+		entries: make(map[string]time.Time),
+	}
+}
+
+// add stores the revocation until the token it is for expires.
+func (c *cache) add(rev Revocation, now time.Time) {
+	if rev.expired(now) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[rev.TokenID] = rev.DateExpires
+}
+
+// contains reports whether the token ID has been revoked.
+func (c *cache) contains(tokenID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, exists := c.entries[tokenID]
+	return exists
+}
+
+// evict removes the revocations of the tokens that expired. It returns the
+// number of entries removed.
+func (c *cache) evict(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var removed int
+	for tokenID, expires := range c.entries {
+		if !expires.IsZero() && !now.Before(expires) {
+			delete(c.entries, tokenID)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+// len returns the number of revocations held.
+func (c *cache) len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.entries)
+}
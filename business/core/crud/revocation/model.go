@@ -0,0 +1,20 @@
+package revocation
+
+import (
+	"time"
+)
+
+// Revocation represents an access token that must no longer be accepted. A
+// zero DateExpires is used for tokens that never expire.
+type Revocation struct {
+	TokenID     string
+	Subject     string
+	DateExpires time.Time
+	DateRevoked time.Time
+}
+
+// expired reports whether the token the revocation is for has expired, at
+// which point the token is rejected anyway and the revocation can be dropped.
+func (r Revocation) expired(now time.Time) bool {
+	return !r.DateExpires.IsZero() && !now.Before(r.DateExpires)
+}
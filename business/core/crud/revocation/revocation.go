@@ -0,0 +1,231 @@
+// Package revocation provides support for revoking access tokens before they
+// expire. Revoked token IDs are kept in memory so every request can be
+// checked without the database. Every instance of the service listens for the
+// revocations made by the others so they all reject the same tokens.
+package revocation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/testvergecloud/testApi/foundation/logger"
+)
+
+// Set of default values used by the core.
+const (
+	defaultEvictInterval = time.Minute
+	defaultRetryInterval = 5 * time.Second
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound  = errors.New("revocation not found")
+	ErrMissingID = errors.New("token has no ID")
+)
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, rev Revocation) error
+	QueryByID(ctx context.Context, tokenID string) (Revocation, error)
+	QueryActive(ctx context.Context, now time.Time) ([]Revocation, error)
+	DeleteExpired(ctx context.Context, now time.Time) error
+	Listen(ctx context.Context, ready func(), fn func(rev Revocation)) error
+}
+
+// Core manages the set of APIs for token revocation.
+type Core struct {
+	log           *logger.Logger
+	storer        Storer
+	cache         *cache
+	loaded        atomic.Bool
+	EvictInterval time.Duration
+	RetryInterval time.Duration
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+// NewCore constructs a revocation core API for use. The revocations made by
+// other instances are only seen after Start is called.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:           log,
+		storer:        storer,
+		cache:         newCache(),
+		EvictInterval: defaultEvictInterval,
+		RetryInterval: defaultRetryInterval,
+	}
+}
+
+// Start launches the goroutines that keep the cache in sync with the
+// database until Shutdown is called. One listens for the revocations made by
+// any instance, the other evicts the revocations of expired tokens.
+func (c *Core) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+
+	c.wg.Add(2)
+
+	go func() {
+		defer c.wg.Done()
+		c.listen(ctx)
+	}()
+
+	go func() {
+		defer c.wg.Done()
+		c.evict(ctx)
+	}()
+}
+
+// Shutdown stops the goroutines launched by Start.
+func (c *Core) Shutdown(ctx context.Context) error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	ch := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(ch)
+	}()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Revoke records the revocation so the token is rejected by every instance
+// until it expires. Revoking a token twice keeps the first revocation.
+func (c *Core) Revoke(ctx context.Context, rev Revocation) error {
+	if rev.TokenID == "" {
+		return ErrMissingID
+	}
+
+	now := time.Now()
+	rev.DateRevoked = now
+
+	if err := c.storer.Create(ctx, rev); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	// The notification only arrives once the change is committed, the local
+	// cache is updated right away.
+	c.cache.add(rev, now)
+
+	return nil
+}
+
+// IsRevoked reports whether the token with the specified ID has been revoked.
+// Until the cache is loaded, and while the revocations of other instances
+// can't be received, the database is asked instead so a revoked token isn't
+// accepted in the meantime. A nil Core has no revocations so services without
+// a database can use it.
+func (c *Core) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	if c == nil || tokenID == "" {
+		return false, nil
+	}
+
+	if c.cache.contains(tokenID) {
+		return true, nil
+	}
+
+	if c.loaded.Load() {
+		return false, nil
+	}
+
+	rev, err := c.storer.QueryByID(ctx, tokenID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("querybyid: %w", err)
+	}
+
+	return !rev.expired(time.Now()), nil
+}
+
+// =============================================================================
+
+// listen receives the revocations made by every instance. The cache is loaded
+// once listening starts so no revocation is missed in between, and loaded
+// again after the connection is lost. The cache isn't trusted on its own
+// until it is loaded.
+func (c *Core) listen(ctx context.Context) {
+	ready := func() {
+		if err := c.load(ctx); err != nil {
+			c.log.Error(ctx, "revocation", "status", "load failed", "msg", err)
+		}
+	}
+
+	notify := func(rev Revocation) {
+		c.cache.add(rev, time.Now())
+	}
+
+	for {
+		err := c.storer.Listen(ctx, ready, notify)
+
+		// Revocations made while the connection is down are missed until
+		// the cache is loaded again.
+		c.loaded.Store(false)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.log.Error(ctx, "revocation", "status", "listen failed", "msg", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.RetryInterval):
+		}
+	}
+}
+
+// load adds the revocations of the tokens that haven't expired to the cache.
+func (c *Core) load(ctx context.Context) error {
+	now := time.Now()
+
+	revs, err := c.storer.QueryActive(ctx, now)
+	if err != nil {
+		return fmt.Errorf("queryactive: %w", err)
+	}
+
+	for _, rev := range revs {
+		c.cache.add(rev, now)
+	}
+
+	c.loaded.Store(true)
+
+	c.log.Info(ctx, "revocation", "status", "cache loaded", "revocations", c.cache.len())
+
+	return nil
+}
+
+// evict periodically drops the revocations of the tokens that expired, from
+// the cache and from the database.
+func (c *Core) evict(ctx context.Context) {
+	ticker := time.NewTicker(c.EvictInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		c.cache.evict(now)
+
+		if err := c.storer.DeleteExpired(ctx, now); err != nil {
+			c.log.Error(ctx, "revocation", "status", "delete expired failed", "msg", err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package revocation_test
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/revocation"
+	"github.com/testvergecloud/testApi/foundation/logger"
+)
+
+func Test_Revoke(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	store := newStore()
+	store.active = []revocation.Revocation{
+		{TokenID: "loaded", DateExpires: time.Now().Add(time.Hour)},
+	}
+
+	core := revocation.NewCore(log, store)
+	core.EvictInterval = 10 * time.Millisecond
+
+	ctx := context.Background()
+
+	var nilCore *revocation.Core
+	if isRevoked(t, nilCore, "loaded") {
+		t.Fatal("Should not have revocations without a core.")
+	}
+
+	if !isRevoked(t, core, "loaded") || isRevoked(t, core, "unknown") {
+		t.Fatal("Should check the database until the cache is loaded.")
+	}
+
+	if n := store.queries(); n != 2 {
+		t.Fatalf("Should have checked the database for both tokens: queries[%d]", n)
+	}
+
+	core.Start(ctx)
+	defer core.Shutdown(ctx)
+
+	<-store.listening
+
+	if !waitFor(func() bool { return isRevoked(t, core, "loaded") && store.queries() == 2 }) {
+		t.Fatal("Should load the revocations once listening.")
+	}
+
+	if err := core.Revoke(ctx, revocation.Revocation{}); err == nil {
+		t.Fatal("Should not be able to revoke a token without an ID.")
+	}
+
+	if err := core.Revoke(ctx, revocation.Revocation{TokenID: "local", DateExpires: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Should be able to revoke a token: %s", err)
+	}
+
+	if !isRevoked(t, core, "local") || len(store.created) != 1 {
+		t.Fatalf("Should revoke the token locally and store it: %v", store.created)
+	}
+
+	store.notify(revocation.Revocation{TokenID: "remote", DateExpires: time.Now().Add(50 * time.Millisecond)})
+
+	if !waitFor(func() bool { return isRevoked(t, core, "remote") }) {
+		t.Fatal("Should revoke the tokens revoked by other instances.")
+	}
+
+	if !waitFor(func() bool { return !isRevoked(t, core, "remote") }) {
+		t.Fatal("Should evict the revocation once the token expires.")
+	}
+
+	if isRevoked(t, core, "unknown") || store.queries() != 2 {
+		t.Fatalf("Should not revoke other tokens, using only the cache once loaded: queries[%d]", store.queries())
+	}
+}
+
+// =============================================================================
+
+func isRevoked(t *testing.T, core *revocation.Core, tokenID string) bool {
+	revoked, err := core.IsRevoked(context.Background(), tokenID)
+	if err != nil {
+		t.Fatalf("Should be able to check the token: %s", err)
+	}
+	return revoked
+}
+
+func waitFor(fn func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if fn() {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+type store struct {
+	mu        sync.Mutex
+	active    []revocation.Revocation
+	created   []revocation.Revocation
+	queried   int
+	listening chan struct{}
+	events    chan revocation.Revocation
+}
+
+func newStore() *store {
+	return &store{
+		listening: make(chan struct{}),
+		events:    make(chan revocation.Revocation),
+	}
+}
+
+func (s *store) notify(rev revocation.Revocation) {
+	s.events <- rev
+}
+
+func (s *store) Create(ctx context.Context, rev revocation.Revocation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.created = append(s.created, rev)
+	return nil
+}
+
+func (s *store) QueryByID(ctx context.Context, tokenID string) (revocation.Revocation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queried++
+	for _, rev := range s.active {
+		if rev.TokenID == tokenID {
+			return rev, nil
+		}
+	}
+	return revocation.Revocation{}, revocation.ErrNotFound
+}
+
+func (s *store) queries() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.queried
+}
+
+func (s *store) QueryActive(ctx context.Context, now time.Time) ([]revocation.Revocation, error) {
+	return s.active, nil
+}
+
+func (s *store) DeleteExpired(ctx context.Context, now time.Time) error {
+	return nil
+}
+
+func (s *store) Listen(ctx context.Context, ready func(), fn func(rev revocation.Revocation)) error {
+	ready()
+	close(s.listening)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case rev := <-s.events:
+			fn(rev)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package revocationdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/revocation"
+)
+
+type dbRevocation struct {
+	TokenID     string       `db:"token_id"`
+	Subject     string       `db:"user_id"`
+	DateExpires sql.NullTime `db:"date_expires"`
+	DateRevoked time.Time    `db:"date_revoked"`
+}
+
+func toDBRevocation(rev revocation.Revocation) dbRevocation {
+	return dbRevocation{
+		TokenID: rev.TokenID,
+		Subject: rev.Subject,
+		DateExpires: sql.NullTime{
+			Time:  rev.DateExpires.UTC(),
+			Valid: !rev.DateExpires.IsZero(),
+		},
+		DateRevoked: rev.DateRevoked.UTC(),
+	}
+}
+
+func toCoreRevocation(dbRev dbRevocation) revocation.Revocation {
+	rev := revocation.Revocation{
+		TokenID:     dbRev.TokenID,
+		Subject:     dbRev.Subject,
+		DateRevoked: dbRev.DateRevoked.In(time.Local),
+	}
+
+	if dbRev.DateExpires.Valid {
+		rev.DateExpires = dbRev.DateExpires.Time.In(time.Local)
+	}
+
+	return rev
+}
+
+func toCoreRevocationSlice(dbRevs []dbRevocation) []revocation.Revocation {
+	revs := make([]revocation.Revocation, len(dbRevs))
+	for i, dbRev := range dbRevs {
+		revs[i] = toCoreRevocation(dbRev)
+	}
+	return revs
+}
+
+// notification is the payload sent to the other instances when a token is
+// revoked. It only carries what the cache needs.
+type notification struct {
+	TokenID     string `json:"token_id"`
+	DateExpires int64  `json:"date_expires,omitempty"`
+}
+
+func toNotification(rev revocation.Revocation) notification {
+	n := notification{
+		TokenID: rev.TokenID,
+	}
+
+	if !rev.DateExpires.IsZero() {
+		n.DateExpires = rev.DateExpires.Unix()
+	}
+
+	return n
+}
+
+func toCoreRevocationFromNotification(n notification) revocation.Revocation {
+	rev := revocation.Revocation{
+		TokenID: n.TokenID,
+	}
+
+	if n.DateExpires != 0 {
+		rev.DateExpires = time.Unix(n.DateExpires, 0)
+	}
+
+	return rev
+}
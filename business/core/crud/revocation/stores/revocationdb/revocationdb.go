@@ -0,0 +1,193 @@
+// Package revocationdb contains revoked token related CRUD functionality.
+package revocationdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/revocation"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// channel is the postgres notification channel the revocations are sent on.
+const channel = "token_revocations"
+
+// Store manages the set of APIs for revoked token database access.
+type Store struct {
+	log *logger.Logger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new revocation into the database and notifies the
+// listeners once it is committed. Nothing happens if the token was already
+// revoked.
+func (s *Store) Create(ctx context.Context, rev revocation.Revocation) error {
+	payload, err := json.Marshal(toNotification(rev))
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+
+	data := struct {
+		dbRevocation
+		Channel string `db:"channel"`
+		Payload string `db:"payload"`
+	}{
+		dbRevocation: toDBRevocation(rev),
+		Channel:      channel,
+		Payload:      string(payload),
+	}
+
+	const q = `
+	WITH inserted AS (
+		INSERT INTO revoked_tokens
+			(token_id, user_id, date_expires, date_revoked)
+		VALUES
+			(:token_id, :user_id, :date_expires, :date_revoked)
+		ON CONFLICT (token_id) DO NOTHING
+		RETURNING token_id
+	)
+	SELECT pg_notify(:channel, :payload) FROM inserted`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByID retrieves the revocation of the token with the specified ID.
+func (s *Store) QueryByID(ctx context.Context, tokenID string) (revocation.Revocation, error) {
+	data := struct {
+		TokenID string `db:"token_id"`
+	}{
+		TokenID: tokenID,
+	}
+
+	const q = `
+	SELECT
+		token_id, user_id, date_expires, date_revoked
+	FROM
+		revoked_tokens
+	WHERE
+		token_id = :token_id`
+
+	var dbRev dbRevocation
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbRev); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return revocation.Revocation{}, fmt.Errorf("namedquerystruct: %w", revocation.ErrNotFound)
+		}
+		return revocation.Revocation{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreRevocation(dbRev), nil
+}
+
+// QueryActive retrieves the revocations of the tokens that haven't expired.
+func (s *Store) QueryActive(ctx context.Context, now time.Time) ([]revocation.Revocation, error) {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now.UTC(),
+	}
+
+	const q = `
+	SELECT
+		token_id, user_id, date_expires, date_revoked
+	FROM
+		revoked_tokens
+	WHERE
+		date_expires IS NULL OR
+		date_expires > :now`
+
+	var dbRevs []dbRevocation
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbRevs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreRevocationSlice(dbRevs), nil
+}
+
+// DeleteExpired removes the revocations of the tokens that expired.
+func (s *Store) DeleteExpired(ctx context.Context, now time.Time) error {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		revoked_tokens
+	WHERE
+		date_expires <= :now`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Listen holds a connection listening for the revocations made by any
+// instance and calls fn for each one. Ready is called once listening has
+// started. Listen blocks until the context is cancelled or the connection
+// fails.
+func (s *Store) Listen(ctx context.Context, ready func(), fn func(rev revocation.Revocation)) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("conn: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("listen requires the pgx driver")
+		}
+		pgConn := stdConn.Conn()
+
+		if _, err := pgConn.Exec(ctx, "LISTEN "+channel); err != nil {
+			return fmt.Errorf("listen: %w", err)
+		}
+
+		// The connection goes back to the pool, it must not keep listening.
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			pgConn.Exec(ctx, "UNLISTEN "+channel)
+		}()
+
+		ready()
+
+		for {
+			n, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return fmt.Errorf("wait: %w", err)
+			}
+
+			var msg notification
+			if err := json.Unmarshal([]byte(n.Payload), &msg); err != nil {
+				s.log.Error(ctx, "revocationdb", "status", "bad notification", "payload", n.Payload, "msg", err)
+				continue
+			}
+
+			fn(toCoreRevocationFromNotification(msg))
+		}
+	})
+}
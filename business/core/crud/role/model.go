@@ -0,0 +1,30 @@
+package role
+
+import (
+	"time"
+)
+
+// Role represents a role users can be assigned and the named permissions it
+// grants. Built-in roles can't be deleted.
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+	BuiltIn     bool
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewRole contains information needed to create a new role.
+type NewRole struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// UpdateRole contains information needed to update a role. Fields that are
+// not set are left unchanged.
+type UpdateRole struct {
+	Description *string
+	Permissions []string
+}
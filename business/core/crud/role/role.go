@@ -0,0 +1,308 @@
+// Package role provides support for the roles users are assigned and the
+// permissions they grant. Roles are stored in the database and kept in memory
+// so the permissions of a request can be resolved without the database. The
+// roles are loaded again periodically to pick up the changes made by other
+// instances of the service.
+package role
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/foundation/logger"
+)
+
+// defaultReloadInterval is how often the roles are loaded again by default.
+const defaultReloadInterval = 30 * time.Second
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound          = errors.New("role not found")
+	ErrUniqueName        = errors.New("role name is not unique")
+	ErrBuiltIn           = errors.New("built-in roles can't be deleted")
+	ErrInvalidName       = errors.New("role name must be upper case letters, digits or underscores")
+	ErrInvalidPermission = errors.New("permission must be lower case words separated by colons")
+)
+
+var permissionRegEx = regexp.MustCompile(`^[a-z][a-z0-9_]*(:[a-z0-9_*]+)*$`)
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, r Role) error
+	Update(ctx context.Context, r Role) error
+	Delete(ctx context.Context, r Role) error
+	QueryAll(ctx context.Context) ([]Role, error)
+	QueryByName(ctx context.Context, name string) (Role, error)
+}
+
+// Core manages the set of APIs for role access.
+type Core struct {
+	log            *logger.Logger
+	storer         Storer
+	roles          atomic.Pointer[map[string]Role]
+	ReloadInterval time.Duration
+	cancel         context.CancelFunc
+	wg             sync.WaitGroup
+}
+
+// NewCore constructs a role core API for use. The stored roles are only known
+// after Start or Load is called.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:            log,
+		storer:         storer,
+		ReloadInterval: defaultReloadInterval,
+	}
+}
+
+// Start loads the roles and launches the goroutine that loads them again
+// periodically until Shutdown is called.
+func (c *Core) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+
+	if err := c.Load(ctx); err != nil {
+		c.log.Error(ctx, "role", "status", "load failed", "msg", err)
+	}
+
+	c.wg.Add(1)
+
+	go func() {
+		defer c.wg.Done()
+		c.reload(ctx)
+	}()
+}
+
+// Shutdown stops the goroutine launched by Start.
+func (c *Core) Shutdown(ctx context.Context) error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	ch := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(ch)
+	}()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Create adds a new role to the system.
+func (c *Core) Create(ctx context.Context, nr NewRole) (Role, error) {
+	if _, err := user.ParseRole(nr.Name); err != nil {
+		return Role{}, ErrInvalidName
+	}
+
+	perms, err := checkPermissions(nr.Permissions)
+	if err != nil {
+		return Role{}, err
+	}
+
+	now := time.Now()
+
+	r := Role{
+		Name:        nr.Name,
+		Description: nr.Description,
+		Permissions: perms,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := c.storer.Create(ctx, r); err != nil {
+		return Role{}, fmt.Errorf("create: %w", err)
+	}
+
+	c.refresh(ctx)
+
+	return r, nil
+}
+
+// Update modifies information about a role.
+func (c *Core) Update(ctx context.Context, r Role, ur UpdateRole) (Role, error) {
+	if ur.Description != nil {
+		r.Description = *ur.Description
+	}
+
+	if ur.Permissions != nil {
+		perms, err := checkPermissions(ur.Permissions)
+		if err != nil {
+			return Role{}, err
+		}
+		r.Permissions = perms
+	}
+
+	r.DateUpdated = time.Now()
+
+	if err := c.storer.Update(ctx, r); err != nil {
+		return Role{}, fmt.Errorf("update: %w", err)
+	}
+
+	c.refresh(ctx)
+
+	return r, nil
+}
+
+// Delete removes the role from the system and from the users assigned to it.
+func (c *Core) Delete(ctx context.Context, r Role) error {
+	if r.BuiltIn || user.IsBuiltInRole(r.Name) {
+		return ErrBuiltIn
+	}
+
+	if err := c.storer.Delete(ctx, r); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	c.refresh(ctx)
+
+	return nil
+}
+
+// QueryAll retrieves all the roles ordered by name.
+func (c *Core) QueryAll(ctx context.Context) ([]Role, error) {
+	roles, err := c.storer.QueryAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("queryall: %w", err)
+	}
+
+	return roles, nil
+}
+
+// QueryByName finds the role by the specified name.
+func (c *Core) QueryByName(ctx context.Context, name string) (Role, error) {
+	r, err := c.storer.QueryByName(ctx, name)
+	if err != nil {
+		return Role{}, fmt.Errorf("query: name[%s]: %w", name, err)
+	}
+
+	return r, nil
+}
+
+// Permissions resolves the permissions granted by the specified roles. Roles
+// that aren't known grant nothing. A nil Core grants nothing so services
+// without a database can use it.
+func (c *Core) Permissions(roles []user.Role) []string {
+	if c == nil {
+		return nil
+	}
+
+	known := c.roles.Load()
+	if known == nil {
+		return nil
+	}
+
+	set := make(map[string]struct{})
+	for _, ur := range roles {
+		for _, perm := range (*known)[ur.Name()].Permissions {
+			set[perm] = struct{}{}
+		}
+	}
+
+	perms := make([]string, 0, len(set))
+	for perm := range set {
+		perms = append(perms, perm)
+	}
+	sort.Strings(perms)
+
+	return perms
+}
+
+// Exists reports whether the role is stored in the database, which makes the
+// core the role set of the user cores. The built-in roles always exist, even
+// before the roles are loaded.
+func (c *Core) Exists(r user.Role) bool {
+	if user.IsBuiltInRole(r.Name()) {
+		return true
+	}
+
+	if c == nil {
+		return false
+	}
+
+	known := c.roles.Load()
+	if known == nil {
+		return false
+	}
+
+	_, exists := (*known)[r.Name()]
+	return exists
+}
+
+// Load replaces the roles held in memory with the roles stored in the
+// database. Start calls it, tools that don't start the core call it before
+// checking the roles of users.
+func (c *Core) Load(ctx context.Context) error {
+	roles, err := c.storer.QueryAll(ctx)
+	if err != nil {
+		return fmt.Errorf("queryall: %w", err)
+	}
+
+	known := make(map[string]Role, len(roles))
+	for _, r := range roles {
+		known[r.Name] = r
+	}
+
+	c.roles.Store(&known)
+
+	return nil
+}
+
+// =============================================================================
+
+// reload periodically loads the roles so the changes made by other instances
+// are picked up.
+func (c *Core) reload(ctx context.Context) {
+	ticker := time.NewTicker(c.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.Load(ctx); err != nil {
+			c.log.Error(ctx, "role", "status", "reload failed", "msg", err)
+		}
+	}
+}
+
+// refresh loads the roles after a change so this instance sees it right away.
+func (c *Core) refresh(ctx context.Context) {
+	if err := c.Load(ctx); err != nil {
+		c.log.Error(ctx, "role", "status", "refresh failed", "msg", err)
+	}
+}
+
+// checkPermissions validates the permissions and returns them sorted without
+// duplicates.
+func checkPermissions(perms []string) ([]string, error) {
+	set := make(map[string]struct{}, len(perms))
+	for _, perm := range perms {
+		if !permissionRegEx.MatchString(perm) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPermission, perm)
+		}
+		set[perm] = struct{}{}
+	}
+
+	unique := make([]string, 0, len(set))
+	for perm := range set {
+		unique = append(unique, perm)
+	}
+	sort.Strings(unique)
+
+	return unique, nil
+}
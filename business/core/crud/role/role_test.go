@@ -0,0 +1,156 @@
+package role_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/testvergecloud/testApi/business/core/crud/role"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/foundation/logger"
+)
+
+func Test_Role(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	store := newStore(
+		role.Role{Name: "ADMIN", Permissions: []string{"roles:read", "roles:write"}, BuiltIn: true},
+		role.Role{Name: "USER", BuiltIn: true},
+	)
+
+	core := role.NewCore(log, store)
+	ctx := context.Background()
+
+	var nilCore *role.Core
+	if perms := nilCore.Permissions([]user.Role{user.RoleAdmin}); len(perms) != 0 {
+		t.Fatalf("Should not grant permissions without a core: %v", perms)
+	}
+
+	if core.Exists(user.MustParseRole("AUDITOR")) {
+		t.Fatal("Should not know a role that was never created.")
+	}
+
+	if _, err := core.Create(ctx, role.NewRole{Name: "auditor"}); !errors.Is(err, role.ErrInvalidName) {
+		t.Fatalf("Should not be able to create a role with a bad name: %v", err)
+	}
+
+	if _, err := core.Create(ctx, role.NewRole{Name: "AUDITOR", Permissions: []string{"Audits Read"}}); !errors.Is(err, role.ErrInvalidPermission) {
+		t.Fatalf("Should not be able to create a role with a bad permission: %v", err)
+	}
+
+	r, err := core.Create(ctx, role.NewRole{Name: "AUDITOR", Permissions: []string{"audits:read", "roles:read", "audits:read"}})
+	if err != nil {
+		t.Fatalf("Should be able to create a role: %s", err)
+	}
+
+	if len(r.Permissions) != 2 {
+		t.Fatalf("Should drop duplicated permissions: %v", r.Permissions)
+	}
+
+	auditor := user.MustParseRole("AUDITOR")
+	if !core.Exists(auditor) {
+		t.Fatal("Should know the created role.")
+	}
+
+	perms := core.Permissions([]user.Role{auditor, user.RoleAdmin})
+	if exp := []string{"audits:read", "roles:read", "roles:write"}; !equal(perms, exp) {
+		t.Fatalf("Should resolve the permissions of the roles: got %v, exp %v", perms, exp)
+	}
+
+	if err := core.Delete(ctx, role.Role{Name: "ADMIN", BuiltIn: true}); !errors.Is(err, role.ErrBuiltIn) {
+		t.Fatalf("Should not be able to delete a built-in role: %v", err)
+	}
+
+	if err := core.Delete(ctx, r); err != nil {
+		t.Fatalf("Should be able to delete a role: %s", err)
+	}
+
+	if core.Exists(auditor) {
+		t.Fatal("Should not know a deleted role.")
+	}
+
+	if !core.Exists(user.RoleAdmin) {
+		t.Fatal("Should always know the built-in roles.")
+	}
+}
+
+// =============================================================================
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type store struct {
+	mu    sync.Mutex
+	roles map[string]role.Role
+}
+
+func newStore(roles ...role.Role) *store {
+	s := store{
+		roles: make(map[string]role.Role),
+	}
+	for _, r := range roles {
+		s.roles[r.Name] = r
+	}
+	return &s
+}
+
+func (s *store) Create(ctx context.Context, r role.Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.roles[r.Name]; exists {
+		return role.ErrUniqueName
+	}
+	s.roles[r.Name] = r
+	return nil
+}
+
+func (s *store) Update(ctx context.Context, r role.Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.roles[r.Name] = r
+	return nil
+}
+
+func (s *store) Delete(ctx context.Context, r role.Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.roles, r.Name)
+	return nil
+}
+
+func (s *store) QueryAll(ctx context.Context) ([]role.Role, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles := make([]role.Role, 0, len(s.roles))
+	for _, r := range s.roles {
+		roles = append(roles, r)
+	}
+	return roles, nil
+}
+
+func (s *store) QueryByName(ctx context.Context, name string) (role.Role, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exists := s.roles[name]
+	if !exists {
+		return role.Role{}, role.ErrNotFound
+	}
+	return r, nil
+}
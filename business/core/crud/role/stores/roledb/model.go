@@ -0,0 +1,52 @@
+package roledb
+
+import (
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/role"
+	"github.com/testvergecloud/testApi/business/data/sqldb/dbarray"
+)
+
+type dbRole struct {
+	Name        string         `db:"name"`
+	Description string         `db:"description"`
+	Permissions dbarray.String `db:"permissions"`
+	BuiltIn     bool           `db:"built_in"`
+	DateCreated time.Time      `db:"date_created"`
+	DateUpdated time.Time      `db:"date_updated"`
+}
+
+func toDBRole(r role.Role) dbRole {
+	perms := r.Permissions
+	if perms == nil {
+		perms = []string{}
+	}
+
+	return dbRole{
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: perms,
+		BuiltIn:     r.BuiltIn,
+		DateCreated: r.DateCreated.UTC(),
+		DateUpdated: r.DateUpdated.UTC(),
+	}
+}
+
+func toCoreRole(dbR dbRole) role.Role {
+	return role.Role{
+		Name:        dbR.Name,
+		Description: dbR.Description,
+		Permissions: dbR.Permissions,
+		BuiltIn:     dbR.BuiltIn,
+		DateCreated: dbR.DateCreated.In(time.Local),
+		DateUpdated: dbR.DateUpdated.In(time.Local),
+	}
+}
+
+func toCoreRoleSlice(dbRoles []dbRole) []role.Role {
+	roles := make([]role.Role, len(dbRoles))
+	for i, dbR := range dbRoles {
+		roles[i] = toCoreRole(dbR)
+	}
+	return roles
+}
@@ -0,0 +1,142 @@
+// Package roledb contains role related CRUD functionality.
+package roledb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/testvergecloud/testApi/business/core/crud/role"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for role database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new role into the database.
+func (s *Store) Create(ctx context.Context, r role.Role) error {
+	const q = `
+	INSERT INTO roles
+		(name, description, permissions, built_in, date_created, date_updated)
+	VALUES
+		(:name, :description, :permissions, :built_in, :date_created, :date_updated)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRole(r)); err != nil {
+		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", role.ErrUniqueName)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a role document in the database.
+func (s *Store) Update(ctx context.Context, r role.Role) error {
+	const q = `
+	UPDATE
+		roles
+	SET
+		"description" = :description,
+		"permissions" = :permissions,
+		"date_updated" = :date_updated
+	WHERE
+		name = :name`
+
+	affected, err := sqldb.NamedExecContextAffected(ctx, s.log, s.db, q, toDBRole(r))
+	if err != nil {
+		return fmt.Errorf("namedexeccontextaffected: %w", err)
+	}
+
+	if affected == 0 {
+		return role.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a role from the database and from the users assigned to it.
+// Built-in roles are never removed.
+func (s *Store) Delete(ctx context.Context, r role.Role) error {
+	const q = `
+	WITH deleted AS (
+		DELETE FROM
+			roles
+		WHERE
+			name = :name AND
+			NOT built_in
+		RETURNING name
+	)
+	UPDATE
+		users
+	SET
+		"roles" = array_remove(roles, :name)
+	WHERE
+		:name = ANY(roles) AND
+		EXISTS (SELECT 1 FROM deleted)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBRole(r)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryAll retrieves all the roles from the database ordered by name.
+func (s *Store) QueryAll(ctx context.Context) ([]role.Role, error) {
+	const q = `
+	SELECT
+		name, description, permissions, built_in, date_created, date_updated
+	FROM
+		roles
+	ORDER BY
+		name`
+
+	var dbRoles []dbRole
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, struct{}{}, &dbRoles); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreRoleSlice(dbRoles), nil
+}
+
+// QueryByName gets the specified role from the database.
+func (s *Store) QueryByName(ctx context.Context, name string) (role.Role, error) {
+	data := struct {
+		Name string `db:"name"`
+	}{
+		Name: name,
+	}
+
+	const q = `
+	SELECT
+		name, description, permissions, built_in, date_created, date_updated
+	FROM
+		roles
+	WHERE
+		name = :name`
+
+	var dbR dbRole
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbR); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return role.Role{}, fmt.Errorf("namedquerystruct: %w", role.ErrNotFound)
+		}
+		return role.Role{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreRole(dbR), nil
+}
@@ -0,0 +1,20 @@
+package session
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token represents a refresh token issued to a user. The token itself is
+// never stored, only its hash.
+type Token struct {
+	ID          uuid.UUID
+	FamilyID    uuid.UUID
+	UserID      uuid.UUID
+	Hash        []byte
+	DateCreated time.Time
+	DateExpires time.Time
+	DateUsed    time.Time
+	DateRevoked time.Time
+}
@@ -0,0 +1,180 @@
+// Package session provides support for refresh tokens. A login starts a
+// session, which is a family of refresh tokens where each token can be used
+// once to get the next one. Presenting a token that was already used means it
+// leaked, so the whole family is revoked.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/google/uuid"
+)
+
+// Set of error variables for refresh token operations.
+var (
+	ErrNotFound     = errors.New("refresh token not found")
+	ErrExpired      = errors.New("refresh token expired")
+	ErrRevoked      = errors.New("refresh token revoked")
+	ErrReused       = errors.New("refresh token reused")
+	ErrInvalidToken = errors.New("refresh token not valid")
+)
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+	Create(ctx context.Context, tkn Token) error
+	MarkUsed(ctx context.Context, tkn Token) (bool, error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID, dateRevoked time.Time) error
+	QueryByHash(ctx context.Context, hash []byte) (Token, error)
+}
+
+// Core manages the set of APIs for refresh token access.
+type Core struct {
+	log    *logger.Logger
+	storer Storer
+}
+
+// NewCore constructs a session core API for use.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	storer, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	core := Core{
+		log:    c.log,
+		storer: storer,
+	}
+
+	return &core, nil
+}
+
+// Create starts a new session for the specified user and returns the first
+// refresh token of the family. Only the hash of the token is stored.
+func (c *Core) Create(ctx context.Context, userID uuid.UUID, ttl time.Duration) (string, Token, error) {
+	return c.issue(ctx, uuid.New(), userID, ttl)
+}
+
+// Rotate exchanges the refresh token for the next token of the family. A
+// token can only be rotated once, presenting it again revokes the family and
+// returns ErrReused.
+func (c *Core) Rotate(ctx context.Context, refreshToken string, ttl time.Duration) (string, Token, error) {
+	tkn, err := c.queryByToken(ctx, refreshToken)
+	if err != nil {
+		return "", Token{}, err
+	}
+
+	now := time.Now()
+
+	switch {
+	case !tkn.DateRevoked.IsZero():
+		return "", Token{}, ErrRevoked
+
+	case !tkn.DateUsed.IsZero():
+		if err := c.storer.RevokeFamily(ctx, tkn.FamilyID, now); err != nil {
+			return "", Token{}, fmt.Errorf("revokefamily: familyID[%s]: %w", tkn.FamilyID, err)
+		}
+		return "", Token{}, ErrReused
+
+	case now.After(tkn.DateExpires):
+		return "", Token{}, ErrExpired
+	}
+
+	// Marking the token as used only succeeds once, so two requests racing
+	// with the same token can't both get a new token.
+	tkn.DateUsed = now
+
+	used, err := c.storer.MarkUsed(ctx, tkn)
+	if err != nil {
+		return "", Token{}, fmt.Errorf("markused: tokenID[%s]: %w", tkn.ID, err)
+	}
+
+	if !used {
+		if err := c.storer.RevokeFamily(ctx, tkn.FamilyID, now); err != nil {
+			return "", Token{}, fmt.Errorf("revokefamily: familyID[%s]: %w", tkn.FamilyID, err)
+		}
+		return "", Token{}, ErrReused
+	}
+
+	return c.issue(ctx, tkn.FamilyID, tkn.UserID, ttl)
+}
+
+// Revoke revokes every token of the family the refresh token belongs to.
+func (c *Core) Revoke(ctx context.Context, refreshToken string) (Token, error) {
+	tkn, err := c.queryByToken(ctx, refreshToken)
+	if err != nil {
+		return Token{}, err
+	}
+
+	if err := c.storer.RevokeFamily(ctx, tkn.FamilyID, time.Now()); err != nil {
+		return Token{}, fmt.Errorf("revokefamily: familyID[%s]: %w", tkn.FamilyID, err)
+	}
+
+	return tkn, nil
+}
+
+// =============================================================================
+
+func (c *Core) issue(ctx context.Context, familyID uuid.UUID, userID uuid.UUID, ttl time.Duration) (string, Token, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", Token{}, fmt.Errorf("generating token: %w", err)
+	}
+	refreshToken := base64.RawURLEncoding.EncodeToString(secret)
+
+	now := time.Now()
+
+	tkn := Token{
+		ID:          uuid.New(),
+		FamilyID:    familyID,
+		UserID:      userID,
+		Hash:        hash(refreshToken),
+		DateCreated: now,
+		DateExpires: now.Add(ttl),
+	}
+
+	if err := c.storer.Create(ctx, tkn); err != nil {
+		return "", Token{}, fmt.Errorf("create: %w", err)
+	}
+
+	return refreshToken, tkn, nil
+}
+
+func (c *Core) queryByToken(ctx context.Context, refreshToken string) (Token, error) {
+	if refreshToken == "" {
+		return Token{}, ErrInvalidToken
+	}
+
+	tkn, err := c.storer.QueryByHash(ctx, hash(refreshToken))
+	if err != nil {
+		return Token{}, fmt.Errorf("querybyhash: %w", err)
+	}
+
+	return tkn, nil
+}
+
+// hash returns the value stored for a refresh token. The tokens are random
+// so a fast hash is enough to keep them useless if the table leaks.
+func hash(refreshToken string) []byte {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return sum[:]
+}
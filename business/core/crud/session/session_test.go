@@ -0,0 +1,124 @@
+package session_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/session"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/tenant"
+	"github.com/testvergecloud/testApi/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	code, err := run(m)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	os.Exit(code)
+}
+
+func run(m *testing.M) (int, error) {
+	var err error
+
+	c, err = dbtest.StartDB()
+	if err != nil {
+		return 1, err
+	}
+	defer dbtest.StopDB(c)
+
+	return m.Run(), nil
+}
+
+func Test_Session(t *testing.T) {
+	t.Run("rotate", rotate)
+}
+
+func rotate(t *testing.T) {
+	test := dbtest.NewTest(t, c, "Test_Session/rotate")
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.Set(context.Background(), tenant.DefaultID), 10*time.Second)
+	defer cancel()
+
+	usrs, err := user.TestGenerateSeedUsers(1, user.RoleUser, api.User)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	first, tkn, err := api.Session.Create(ctx, usrs[0].ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Should be able to start a session : %s", err)
+	}
+
+	second, rotated, err := api.Session.Rotate(ctx, first, time.Hour)
+	if err != nil {
+		t.Fatalf("Should be able to rotate the refresh token : %s", err)
+	}
+
+	if second == first || rotated.FamilyID != tkn.FamilyID || rotated.UserID != usrs[0].ID {
+		t.Fatalf("Should get a new token of the same family : %+v %+v", tkn, rotated)
+	}
+
+	if _, _, err := api.Session.Rotate(ctx, first, time.Hour); !errors.Is(err, session.ErrReused) {
+		t.Fatalf("Should detect the reuse of a rotated token : %v", err)
+	}
+
+	if _, _, err := api.Session.Rotate(ctx, second, time.Hour); !errors.Is(err, session.ErrRevoked) {
+		t.Fatalf("Should revoke the whole family when a token is reused : %v", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	first, _, err = api.Session.Create(ctx, usrs[0].ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Should be able to start another session : %s", err)
+	}
+
+	second, _, err = api.Session.Rotate(ctx, first, time.Hour)
+	if err != nil {
+		t.Fatalf("Should be able to rotate the refresh token : %s", err)
+	}
+
+	if _, err := api.Session.Revoke(ctx, second); err != nil {
+		t.Fatalf("Should be able to log out : %s", err)
+	}
+
+	if _, _, err := api.Session.Rotate(ctx, second, time.Hour); !errors.Is(err, session.ErrRevoked) {
+		t.Fatalf("Should NOT be able to use a token after logging out : %v", err)
+	}
+
+	if _, _, err := api.Session.Rotate(ctx, "unknown", time.Hour); !errors.Is(err, session.ErrNotFound) {
+		t.Fatalf("Should NOT be able to use an unknown token : %v", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	expired, _, err := api.Session.Create(ctx, usrs[0].ID, -time.Minute)
+	if err != nil {
+		t.Fatalf("Should be able to start a session : %s", err)
+	}
+
+	if _, _, err := api.Session.Rotate(ctx, expired, time.Hour); !errors.Is(err, session.ErrExpired) {
+		t.Fatalf("Should NOT be able to use an expired token : %v", err)
+	}
+}
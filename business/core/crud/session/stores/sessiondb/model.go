@@ -0,0 +1,61 @@
+package sessiondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/session"
+
+	"github.com/google/uuid"
+)
+
+type dbToken struct {
+	ID          uuid.UUID    `db:"token_id"`
+	FamilyID    uuid.UUID    `db:"family_id"`
+	UserID      uuid.UUID    `db:"user_id"`
+	Hash        []byte       `db:"token_hash"`
+	DateCreated time.Time    `db:"date_created"`
+	DateExpires time.Time    `db:"date_expires"`
+	DateUsed    sql.NullTime `db:"date_used"`
+	DateRevoked sql.NullTime `db:"date_revoked"`
+}
+
+func toDBToken(tkn session.Token) dbToken {
+	return dbToken{
+		ID:          tkn.ID,
+		FamilyID:    tkn.FamilyID,
+		UserID:      tkn.UserID,
+		Hash:        tkn.Hash,
+		DateCreated: tkn.DateCreated.UTC(),
+		DateExpires: tkn.DateExpires.UTC(),
+		DateUsed: sql.NullTime{
+			Time:  tkn.DateUsed.UTC(),
+			Valid: !tkn.DateUsed.IsZero(),
+		},
+		DateRevoked: sql.NullTime{
+			Time:  tkn.DateRevoked.UTC(),
+			Valid: !tkn.DateRevoked.IsZero(),
+		},
+	}
+}
+
+func toCoreToken(dbTkn dbToken) session.Token {
+	tkn := session.Token{
+		ID:          dbTkn.ID,
+		FamilyID:    dbTkn.FamilyID,
+		UserID:      dbTkn.UserID,
+		Hash:        dbTkn.Hash,
+		DateCreated: dbTkn.DateCreated.In(time.Local),
+		DateExpires: dbTkn.DateExpires.In(time.Local),
+	}
+
+	if dbTkn.DateUsed.Valid {
+		tkn.DateUsed = dbTkn.DateUsed.Time.In(time.Local)
+	}
+
+	if dbTkn.DateRevoked.Valid {
+		tkn.DateRevoked = dbTkn.DateRevoked.Time.In(time.Local)
+	}
+
+	return tkn
+}
@@ -0,0 +1,137 @@
+// Package sessiondb contains refresh token related CRUD functionality.
+package sessiondb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/session"
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for refresh token database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (session.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Create inserts a new refresh token into the database.
+func (s *Store) Create(ctx context.Context, tkn session.Token) error {
+	const q = `
+	INSERT INTO refresh_tokens
+		(token_id, family_id, user_id, token_hash, date_created, date_expires)
+	VALUES
+		(:token_id, :family_id, :user_id, :token_hash, :date_created, :date_expires)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBToken(tkn)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// MarkUsed records the token as used. It reports false if the token was
+// already used or revoked.
+func (s *Store) MarkUsed(ctx context.Context, tkn session.Token) (bool, error) {
+	const q = `
+	UPDATE
+		refresh_tokens
+	SET
+		date_used = :date_used
+	WHERE
+		token_id = :token_id AND
+		date_used IS NULL AND
+		date_revoked IS NULL`
+
+	affected, err := sqldb.NamedExecContextAffected(ctx, s.log, s.db, q, toDBToken(tkn))
+	if err != nil {
+		return false, fmt.Errorf("namedexeccontextaffected: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// RevokeFamily revokes every token of the specified family. Tokens that are
+// already revoked keep their original date.
+func (s *Store) RevokeFamily(ctx context.Context, familyID uuid.UUID, dateRevoked time.Time) error {
+	data := struct {
+		FamilyID    string    `db:"family_id"`
+		DateRevoked time.Time `db:"date_revoked"`
+	}{
+		FamilyID:    familyID.String(),
+		DateRevoked: dateRevoked.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		refresh_tokens
+	SET
+		date_revoked = :date_revoked
+	WHERE
+		family_id = :family_id AND
+		date_revoked IS NULL`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByHash finds the refresh token with the specified hash.
+func (s *Store) QueryByHash(ctx context.Context, hash []byte) (session.Token, error) {
+	data := struct {
+		Hash []byte `db:"token_hash"`
+	}{
+		Hash: hash,
+	}
+
+	const q = `
+	SELECT
+		token_id, family_id, user_id, token_hash, date_created, date_expires, date_used, date_revoked
+	FROM
+		refresh_tokens
+	WHERE
+		token_hash = :token_hash`
+
+	var dbTkn dbToken
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbTkn); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return session.Token{}, fmt.Errorf("namedquerystruct: %w", session.ErrNotFound)
+		}
+		return session.Token{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreToken(dbTkn), nil
+}
@@ -2,6 +2,7 @@ package user
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/testvergecloud/testApi/business/core/crud/delegate"
 
@@ -14,7 +15,8 @@ const Domain = "user"
 
 // Set of delegate actions.
 const (
-	ActionUpdated = "updated"
+	ActionUpdated   = "updated"
+	ActionLockedOut = "lockedout"
 )
 
 // ActionUpdatedParms represents the parameters for the updated action.
@@ -53,3 +55,40 @@ func ActionUpdatedData(uu UpdateUser, userID uuid.UUID) delegate.Data {
 		RawParams: rawParams,
 	}
 }
+
+// =============================================================================
+
+// ActionLockedOutParms represents the parameters for the lockedout action.
+type ActionLockedOutParms struct {
+	UserID      uuid.UUID
+	LockedUntil time.Time
+}
+
+// String returns a string representation of the action parameters.
+func (al *ActionLockedOutParms) String() string {
+	return fmt.Sprintf("&EventParamsLockedOut{UserID:%v, LockedUntil:%v}", al.UserID, al.LockedUntil)
+}
+
+// Marshal returns the event parameters encoded as JSON.
+func (al *ActionLockedOutParms) Marshal() ([]byte, error) {
+	return json.Marshal(al)
+}
+
+// ActionLockedOutData constructs the data for the lockedout action.
+func ActionLockedOutData(userID uuid.UUID, lockedUntil time.Time) delegate.Data {
+	params := ActionLockedOutParms{
+		UserID:      userID,
+		LockedUntil: lockedUntil,
+	}
+
+	rawParams, err := params.Marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return delegate.Data{
+		Domain:    Domain,
+		Action:    ActionLockedOut,
+		RawParams: rawParams,
+	}
+}
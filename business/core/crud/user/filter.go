@@ -1,6 +1,7 @@
 package user
 
 import (
+	"errors"
 	"fmt"
 	"net/mail"
 	"time"
@@ -14,10 +15,15 @@ import (
 // We are using pointer semantics because the With API mutates the value.
 type QueryFilter struct {
 	ID               *uuid.UUID
-	Name             *string `validate:"omitempty,min=3"`
+	IDs              []uuid.UUID `validate:"omitempty,max=100"`
+	NotIDs           []uuid.UUID `validate:"omitempty,max=100"`
+	Name             *string     `validate:"omitempty,min=3"`
+	NamePrefix       *string     `validate:"omitempty,min=1"`
+	NotName          *string     `validate:"omitempty,min=3"`
 	Email            *mail.Address
 	StartCreatedDate *time.Time
 	EndCreatedDate   *time.Time
+	IncludeDeleted   *bool
 }
 
 // Validate can perform a check of the data against the validate tags.
@@ -26,6 +32,10 @@ func (qf *QueryFilter) Validate() error {
 		return fmt.Errorf("validate: %w", err)
 	}
 
+	if qf.StartCreatedDate != nil && qf.EndCreatedDate != nil && qf.EndCreatedDate.Before(*qf.StartCreatedDate) {
+		return fmt.Errorf("validate: %w", validate.NewFieldsError("end_created_date", errors.New("end_created_date is before start_created_date")))
+	}
+
 	return nil
 }
 
@@ -34,11 +44,35 @@ func (qf *QueryFilter) WithUserID(userID uuid.UUID) {
 	qf.ID = &userID
 }
 
+// WithUserIDs sets the IDs field of the QueryFilter value. Users matching any
+// of the IDs are returned.
+func (qf *QueryFilter) WithUserIDs(userIDs []uuid.UUID) {
+	qf.IDs = userIDs
+}
+
+// WithoutUserIDs sets the NotIDs field of the QueryFilter value. Users
+// matching any of the IDs are excluded.
+func (qf *QueryFilter) WithoutUserIDs(userIDs []uuid.UUID) {
+	qf.NotIDs = userIDs
+}
+
 // WithName sets the Name field of the QueryFilter value.
 func (qf *QueryFilter) WithName(name string) {
 	qf.Name = &name
 }
 
+// WithNamePrefix sets the NamePrefix field of the QueryFilter value. The
+// prefix is matched case insensitively.
+func (qf *QueryFilter) WithNamePrefix(prefix string) {
+	qf.NamePrefix = &prefix
+}
+
+// WithoutName sets the NotName field of the QueryFilter value. Users whose
+// name contains the value are excluded.
+func (qf *QueryFilter) WithoutName(name string) {
+	qf.NotName = &name
+}
+
 // WithEmail sets the Email field of the QueryFilter value.
 func (qf *QueryFilter) WithEmail(email mail.Address) {
 	qf.Email = &email
@@ -55,3 +89,9 @@ func (qf *QueryFilter) WithEndCreatedDate(endDate time.Time) {
 	d := endDate.UTC()
 	qf.EndCreatedDate = &d
 }
+
+// WithIncludeDeleted sets the IncludeDeleted field of the QueryFilter value.
+// Deleted rows are excluded from queries unless this is set to true.
+func (qf *QueryFilter) WithIncludeDeleted(includeDeleted bool) {
+	qf.IncludeDeleted = &includeDeleted
+}
@@ -0,0 +1,171 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+)
+
+// ErrLocked is returned when a user or source is locked out of logging in.
+var ErrLocked = errors.New("locked out")
+
+// LockedError is returned when a login is refused because of the failed
+// logins before it. The login can be tried again once Until has passed.
+type LockedError struct {
+	Until time.Time
+}
+
+// Error implements the error interface.
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s until %s", ErrLocked, e.Until.Format(time.RFC3339))
+}
+
+// Is makes the error match ErrLocked.
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+// Lockout represents the failed logins of a user or source and until when
+// they can't log in.
+type Lockout struct {
+	FailedLogins int
+	LockedUntil  time.Time
+}
+
+// LockoutPolicy describes how failed logins are slowed down. Every failed
+// login of a user delays the next one, twice as long each time, until the
+// threshold locks the user out for the lockout duration. A source is only
+// locked out once it reaches its threshold, since the users behind it may
+// share it. Failed logins of a source are forgotten once it stopped failing
+// for the lockout duration.
+type LockoutPolicy struct {
+	Threshold       int
+	SourceThreshold int
+	Delay           time.Duration
+	Duration        time.Duration
+}
+
+// DefaultLockoutPolicy is the policy cores are constructed with.
+var DefaultLockoutPolicy = LockoutPolicy{
+	Threshold:       5,
+	SourceThreshold: 20,
+	Delay:           time.Second,
+	Duration:        15 * time.Minute,
+}
+
+// lockedUntil returns until when a user with the specified failed logins
+// can't log in.
+func (p LockoutPolicy) lockedUntil(failedLogins int, now time.Time) time.Time {
+	switch {
+	case failedLogins <= 0:
+		return time.Time{}
+	case failedLogins >= p.Threshold:
+		return now.Add(p.Duration)
+	}
+
+	delay := p.Delay << (failedLogins - 1)
+	if delay <= 0 || delay > p.Duration {
+		delay = p.Duration
+	}
+
+	return now.Add(delay)
+}
+
+// sourceLockedUntil returns until when a source with the specified failed
+// logins can't log in.
+func (p LockoutPolicy) sourceLockedUntil(failedLogins int, now time.Time) time.Time {
+	if failedLogins < p.SourceThreshold {
+		return time.Time{}
+	}
+
+	return now.Add(p.Duration)
+}
+
+// Unlock clears the failed logins of the user so they can log in right away.
+func (c *Core) Unlock(ctx context.Context, usr User) (User, error) {
+	before := usr
+
+	if err := c.storer.UpdateLockout(ctx, usr.ID, Lockout{}); err != nil {
+		return User{}, fmt.Errorf("updatelockout: %w", err)
+	}
+	usr.FailedLogins = 0
+	usr.LockedUntil = time.Time{}
+
+	if err := c.audit.Record(ctx, Domain, audit.ActionUpdated, usr.TenantID, usr.ID, auditView(before), auditView(usr)); err != nil {
+		return User{}, fmt.Errorf("audit: %w", err)
+	}
+
+	return usr, nil
+}
+
+// failLogin records a failed login of the user. Other domains are told when
+// the user gets locked out.
+func (c *Core) failLogin(ctx context.Context, usr User, now time.Time) error {
+	lck, err := c.storer.AddFailedLogin(ctx, usr.ID)
+	if err != nil {
+		return fmt.Errorf("addfailedlogin: %w", err)
+	}
+
+	lck.LockedUntil = c.Lockout.lockedUntil(lck.FailedLogins, now)
+
+	if err := c.storer.UpdateLockout(ctx, usr.ID, lck); err != nil {
+		return fmt.Errorf("updatelockout: %w", err)
+	}
+
+	if lck.FailedLogins < c.Lockout.Threshold || c.delegate == nil {
+		return nil
+	}
+
+	c.log.Info(ctx, "user locked out", "userID", usr.ID, "failedLogins", lck.FailedLogins, "until", lck.LockedUntil)
+
+	if err := c.delegate.Call(ctx, ActionLockedOutData(usr.ID, lck.LockedUntil)); err != nil {
+		return fmt.Errorf("failed to execute `%s` action: %w", ActionLockedOut, err)
+	}
+
+	return nil
+}
+
+// failSource records a failed login from the source. Logins without a source
+// aren't tracked.
+func (c *Core) failSource(ctx context.Context, source string, now time.Time) error {
+	if source == "" {
+		return nil
+	}
+
+	lck, err := c.storer.AddSourceFailedLogin(ctx, source, now, now.Add(-c.Lockout.Duration))
+	if err != nil {
+		return fmt.Errorf("addsourcefailedlogin: %w", err)
+	}
+
+	lck.LockedUntil = c.Lockout.sourceLockedUntil(lck.FailedLogins, now)
+	if lck.LockedUntil.IsZero() {
+		return nil
+	}
+
+	if err := c.storer.UpdateSourceLockout(ctx, source, lck); err != nil {
+		return fmt.Errorf("updatesourcelockout: %w", err)
+	}
+
+	return nil
+}
+
+// checkSource returns an error when the source is locked out.
+func (c *Core) checkSource(ctx context.Context, source string, now time.Time) error {
+	if source == "" {
+		return nil
+	}
+
+	lck, err := c.storer.QuerySourceLockout(ctx, source)
+	if err != nil {
+		return fmt.Errorf("querysourcelockout: %w", err)
+	}
+
+	if lck.LockedUntil.After(now) {
+		return &LockedError{Until: lck.LockedUntil}
+	}
+
+	return nil
+}
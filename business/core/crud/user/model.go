@@ -7,21 +7,35 @@ import (
 	"github.com/google/uuid"
 )
 
-// User represents information about an individual user.
+// User represents information about an individual user. The failed logins
+// count the logins that failed since the last one that succeeded, the user
+// can't log in until LockedUntil has passed.
 type User struct {
 	ID           uuid.UUID
+	TenantID     uuid.UUID
 	Name         string
 	Email        mail.Address
 	Roles        []Role
 	PasswordHash []byte
 	Department   string
 	Enabled      bool
+	FailedLogins int
+	LockedUntil  time.Time
+	Version      int
 	DateCreated  time.Time
 	DateUpdated  time.Time
+	DateDeleted  time.Time
+}
+
+// IsLocked reports whether the user is locked out of logging in at the
+// specified time.
+func (u User) IsLocked(now time.Time) bool {
+	return u.LockedUntil.After(now)
 }
 
 // NewUser contains information needed to create a new user.
 type NewUser struct {
+	TenantID        uuid.UUID
 	Name            string
 	Email           mail.Address
 	Roles           []Role
@@ -40,3 +54,10 @@ type UpdateUser struct {
 	PasswordConfirm *string
 	Enabled         *bool
 }
+
+// auditView returns the user without the password hash so the hash is never
+// written to the audit log.
+func auditView(usr User) User {
+	usr.PasswordHash = nil
+	return usr
+}
@@ -1,6 +1,11 @@
 package user
 
-import "github.com/testvergecloud/testApi/business/web/order"
+import (
+	"strconv"
+	"strings"
+
+	"github.com/testvergecloud/testApi/business/web/order"
+)
 
 // DefaultOrderBy represents the default way we sort.
 var DefaultOrderBy = order.NewBy(OrderByID, order.ASC)
@@ -13,3 +18,40 @@ const (
 	OrderByRoles   = "roles"
 	OrderByEnabled = "enabled"
 )
+
+// cursorPosition returns the values of the order by fields and the ID of the
+// user for use in a keyset paging cursor.
+func cursorPosition(orderBy order.By) func(usr User) ([]string, string) {
+	return func(usr User) ([]string, string) {
+		fields := orderBy.Fields()
+
+		values := make([]string, len(fields))
+		for i, by := range fields {
+			values[i] = cursorValue(usr, by.Field)
+		}
+
+		return values, usr.ID.String()
+	}
+}
+
+// cursorValue returns the value of the specified order by field for the user.
+// Roles are rendered as a database array literal so they compare the same way
+// the column does.
+func cursorValue(usr User, field string) string {
+	switch field {
+	case OrderByName:
+		return usr.Name
+	case OrderByEmail:
+		return usr.Email.Address
+	case OrderByRoles:
+		roles := make([]string, len(usr.Roles))
+		for i, role := range usr.Roles {
+			roles[i] = role.Name()
+		}
+		return "{" + strings.Join(roles, ",") + "}"
+	case OrderByEnabled:
+		return strconv.FormatBool(usr.Enabled)
+	default:
+		return usr.ID.String()
+	}
+}
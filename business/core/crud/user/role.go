@@ -1,17 +1,36 @@
 package user
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+)
 
-// Set of possible roles for a user.
+// Set of built-in roles, they always exist.
 var (
-	RoleAdmin = Role{"ADMIN"}
-	RoleUser  = Role{"USER"}
+	RoleAdmin      = Role{"ADMIN"}
+	RoleUser       = Role{"USER"}
+	RoleManager    = Role{"MANAGER"}
+	RoleSuperAdmin = Role{"SUPER_ADMIN"}
 )
 
-// Set of known roles.
-var roles = map[string]Role{
-	RoleAdmin.name: RoleAdmin,
-	RoleUser.name:  RoleUser,
+// roleRegEx matches the names roles can have.
+var roleRegEx = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,31}$`)
+
+// RoleSet tells which roles exist, users can only be assigned those. The role
+// core implements it with the roles stored in the database.
+type RoleSet interface {
+	Exists(role Role) bool
+}
+
+// BuiltInRoles is the set of the built-in roles, it's the set cores are
+// constructed with.
+var BuiltInRoles RoleSet = builtInRoles{}
+
+type builtInRoles struct{}
+
+// Exists reports whether the role is one of the built-in roles.
+func (builtInRoles) Exists(role Role) bool {
+	return IsBuiltInRole(role.name)
 }
 
 // Role represents a role in the system.
@@ -19,18 +38,28 @@ type Role struct {
 	name string
 }
 
-// ParseRole parses the string value and returns a role if one exists.
+// IsBuiltInRole reports whether the role is one of the built-in roles.
+func IsBuiltInRole(name string) bool {
+	switch name {
+	case RoleAdmin.name, RoleUser.name, RoleManager.name, RoleSuperAdmin.name:
+		return true
+	}
+
+	return false
+}
+
+// ParseRole parses the string value and returns a role if it's a valid role
+// name. Whether the role exists is checked by the core against its role set.
 func ParseRole(value string) (Role, error) {
-	role, exists := roles[value]
-	if !exists {
+	if !roleRegEx.MatchString(value) {
 		return Role{}, fmt.Errorf("invalid role %q", value)
 	}
 
-	return role, nil
+	return Role{value}, nil
 }
 
-// MustParseRole parses the string value and returns a role if one exists. If
-// an error occurs the function panics.
+// MustParseRole parses the string value and returns a role if it's a valid
+// role name. If an error occurs the function panics.
 func MustParseRole(value string) Role {
 	role, err := ParseRole(value)
 	if err != nil {
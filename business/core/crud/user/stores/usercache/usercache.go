@@ -5,10 +5,13 @@ import (
 	"context"
 	"net/mail"
 	"sync"
+	"time"
 
 	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/data/transaction"
 	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/business/web/page"
 	"github.com/testvergecloud/testApi/foundation/logger"
 
 	"github.com/google/uuid"
@@ -48,13 +51,27 @@ func (s *Store) Create(ctx context.Context, usr user.User) error {
 	return nil
 }
 
-// Update replaces a user document in the database.
+// CreateMany inserts the users into the database and caches them.
+func (s *Store) CreateMany(ctx context.Context, usrs []user.User) error {
+	if err := s.storer.CreateMany(ctx, usrs); err != nil {
+		return err
+	}
+
+	for _, usr := range usrs {
+		s.writeCache(usr)
+	}
+
+	return nil
+}
+
+// Update replaces a user document in the database. The database assigns the
+// user a new version, so the cached copy is dropped instead of replaced.
 func (s *Store) Update(ctx context.Context, usr user.User) error {
 	if err := s.storer.Update(ctx, usr); err != nil {
 		return err
 	}
 
-	s.writeCache(usr)
+	s.deleteCache(usr)
 
 	return nil
 }
@@ -70,11 +87,28 @@ func (s *Store) Delete(ctx context.Context, usr user.User) error {
 	return nil
 }
 
+// Restore clears the deleted mark of a user in the database.
+func (s *Store) Restore(ctx context.Context, usr user.User) error {
+	if err := s.storer.Restore(ctx, usr); err != nil {
+		return err
+	}
+
+	s.deleteCache(usr)
+
+	return nil
+}
+
 // Query retrieves a list of existing users from the database.
 func (s *Store) Query(ctx context.Context, filter user.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]user.User, error) {
 	return s.storer.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
 }
 
+// QueryByCursor retrieves a list of existing users from the database
+// positioned relative to the specified cursor.
+func (s *Store) QueryByCursor(ctx context.Context, filter user.QueryFilter, orderBy order.By, cursor page.Cursor, limit int) ([]user.User, error) {
+	return s.storer.QueryByCursor(ctx, filter, orderBy, cursor, limit)
+}
+
 // Count returns the total number of cards in the DB.
 func (s *Store) Count(ctx context.Context, filter user.QueryFilter) (int, error) {
 	return s.storer.Count(ctx, filter)
@@ -82,7 +116,7 @@ func (s *Store) Count(ctx context.Context, filter user.QueryFilter) (int, error)
 
 // QueryByID gets the specified user from the database.
 func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, error) {
-	cachedUsr, ok := s.readCache(userID.String())
+	cachedUsr, ok := s.readCache(ctx, userID.String())
 	if ok {
 		return cachedUsr, nil
 	}
@@ -109,7 +143,7 @@ func (s *Store) QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]user.Use
 
 // QueryByEmail gets the specified user from the database by email.
 func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (user.User, error) {
-	cachedUsr, ok := s.readCache(email.Address)
+	cachedUsr, ok := s.readCache(ctx, email.Address)
 	if ok {
 		return cachedUsr, nil
 	}
@@ -124,8 +158,54 @@ func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (user.User
 	return usr, nil
 }
 
-// readCache performs a safe search in the cache for the specified key.
-func (s *Store) readCache(key string) (user.User, bool) {
+// QueryLockout gets the failed logins of the specified user from the
+// database. They change with every login so they're never cached.
+func (s *Store) QueryLockout(ctx context.Context, userID uuid.UUID) (user.Lockout, error) {
+	return s.storer.QueryLockout(ctx, userID)
+}
+
+// AddFailedLogin counts a failed login of the specified user.
+func (s *Store) AddFailedLogin(ctx context.Context, userID uuid.UUID) (user.Lockout, error) {
+	lck, err := s.storer.AddFailedLogin(ctx, userID)
+	if err != nil {
+		return user.Lockout{}, err
+	}
+
+	s.deleteCacheByID(userID)
+
+	return lck, nil
+}
+
+// UpdateLockout replaces the failed logins of the specified user.
+func (s *Store) UpdateLockout(ctx context.Context, userID uuid.UUID, lck user.Lockout) error {
+	if err := s.storer.UpdateLockout(ctx, userID, lck); err != nil {
+		return err
+	}
+
+	s.deleteCacheByID(userID)
+
+	return nil
+}
+
+// QuerySourceLockout gets the failed logins of the specified source.
+func (s *Store) QuerySourceLockout(ctx context.Context, source string) (user.Lockout, error) {
+	return s.storer.QuerySourceLockout(ctx, source)
+}
+
+// AddSourceFailedLogin counts a failed login of the specified source.
+func (s *Store) AddSourceFailedLogin(ctx context.Context, source string, now time.Time, resetBefore time.Time) (user.Lockout, error) {
+	return s.storer.AddSourceFailedLogin(ctx, source, now, resetBefore)
+}
+
+// UpdateSourceLockout replaces the failed logins of the specified source.
+func (s *Store) UpdateSourceLockout(ctx context.Context, source string, lck user.Lockout) error {
+	return s.storer.UpdateSourceLockout(ctx, source, lck)
+}
+
+// readCache performs a safe search in the cache for the specified key. A user
+// of a tenant the context can't access is treated as not cached so the
+// database decides what is returned.
+func (s *Store) readCache(ctx context.Context, key string) (user.User, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -134,6 +214,11 @@ func (s *Store) readCache(key string) (user.User, bool) {
 		return user.User{}, false
 	}
 
+	scope, err := tenant.GetScope(ctx)
+	if err != nil || !scope.Allows(usr.TenantID) {
+		return user.User{}, false
+	}
+
 	return usr, true
 }
 
@@ -154,3 +239,18 @@ func (s *Store) deleteCache(usr user.User) {
 	delete(s.cache, usr.ID.String())
 	delete(s.cache, usr.Email.Address)
 }
+
+// deleteCacheByID performs a safe removal from the cache for the user with
+// the specified ID.
+func (s *Store) deleteCacheByID(userID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usr, exists := s.cache[userID.String()]
+	if !exists {
+		return
+	}
+
+	delete(s.cache, usr.ID.String())
+	delete(s.cache, usr.Email.Address)
+}
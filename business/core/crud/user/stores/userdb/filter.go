@@ -6,21 +6,45 @@ import (
 	"strings"
 
 	"github.com/testvergecloud/testApi/business/core/crud/user"
-)
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/sqldb/dbarray"
 
-func applyFilter(filter user.QueryFilter, data map[string]interface{}, buf *bytes.Buffer) {
-	var wc []string
+	"github.com/google/uuid"
+)
 
+// applyFilter writes the WHERE clause for the filter along with any
+// additional clauses provided by the caller.
+func applyFilter(filter user.QueryFilter, data map[string]interface{}, buf *bytes.Buffer, wc ...string) {
 	if filter.ID != nil {
 		data["user_id"] = *filter.ID
 		wc = append(wc, "user_id = :user_id")
 	}
 
+	if len(filter.IDs) > 0 {
+		data["user_ids"] = toDBIDs(filter.IDs)
+		wc = append(wc, "user_id = ANY(:user_ids)")
+	}
+
+	if len(filter.NotIDs) > 0 {
+		data["not_user_ids"] = toDBIDs(filter.NotIDs)
+		wc = append(wc, "user_id <> ALL(:not_user_ids)")
+	}
+
 	if filter.Name != nil {
 		data["name"] = fmt.Sprintf("%%%s%%", *filter.Name)
 		wc = append(wc, "name LIKE :name")
 	}
 
+	if filter.NamePrefix != nil {
+		data["name_prefix"] = sqldb.EscapeLike(*filter.NamePrefix) + "%"
+		wc = append(wc, "name ILIKE :name_prefix")
+	}
+
+	if filter.NotName != nil {
+		data["not_name"] = "%" + sqldb.EscapeLike(*filter.NotName) + "%"
+		wc = append(wc, "name NOT LIKE :not_name")
+	}
+
 	if filter.Email != nil {
 		data["email"] = (*filter.Email).String()
 		wc = append(wc, "email = :email")
@@ -36,8 +60,23 @@ func applyFilter(filter user.QueryFilter, data map[string]interface{}, buf *byte
 		wc = append(wc, "date_created <= :end_date_created")
 	}
 
+	if filter.IncludeDeleted == nil || !*filter.IncludeDeleted {
+		wc = append(wc, "date_deleted IS NULL")
+	}
+
 	if len(wc) > 0 {
 		buf.WriteString(" WHERE ")
 		buf.WriteString(strings.Join(wc, " AND "))
 	}
 }
+
+// toDBIDs converts the set of IDs into an array value the database can
+// compare a column against.
+func toDBIDs(ids []uuid.UUID) any {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+
+	return dbarray.Array(values)
+}
@@ -14,14 +14,19 @@ import (
 
 type dbUser struct {
 	ID           uuid.UUID      `db:"user_id"`
+	TenantID     uuid.UUID      `db:"tenant_id"`
 	Name         string         `db:"name"`
 	Email        string         `db:"email"`
 	Roles        dbarray.String `db:"roles"`
 	PasswordHash []byte         `db:"password_hash"`
 	Department   sql.NullString `db:"department"`
 	Enabled      bool           `db:"enabled"`
+	FailedLogins int            `db:"failed_logins"`
+	LockedUntil  sql.NullTime   `db:"date_locked_until"`
+	Version      int            `db:"version"`
 	DateCreated  time.Time      `db:"date_created"`
 	DateUpdated  time.Time      `db:"date_updated"`
+	DateDeleted  sql.NullTime   `db:"date_deleted"`
 }
 
 func toDBUser(usr user.User) dbUser {
@@ -32,6 +37,7 @@ func toDBUser(usr user.User) dbUser {
 
 	return dbUser{
 		ID:           usr.ID,
+		TenantID:     usr.TenantID,
 		Name:         usr.Name,
 		Email:        usr.Email.Address,
 		Roles:        roles,
@@ -40,10 +46,30 @@ func toDBUser(usr user.User) dbUser {
 			String: usr.Department,
 			Valid:  usr.Department != "",
 		},
-		Enabled:     usr.Enabled,
+		Enabled:      usr.Enabled,
+		FailedLogins: usr.FailedLogins,
+		LockedUntil: sql.NullTime{
+			Time:  usr.LockedUntil.UTC(),
+			Valid: !usr.LockedUntil.IsZero(),
+		},
+		Version:     usr.Version,
 		DateCreated: usr.DateCreated.UTC(),
 		DateUpdated: usr.DateUpdated.UTC(),
+		DateDeleted: sql.NullTime{
+			Time:  usr.DateDeleted.UTC(),
+			Valid: !usr.DateDeleted.IsZero(),
+		},
+	}
+}
+
+func toDBUsers(usrs []user.User) []dbUser {
+	dbUsrs := make([]dbUser, len(usrs))
+
+	for i, usr := range usrs {
+		dbUsrs[i] = toDBUser(usr)
 	}
+
+	return dbUsrs
 }
 
 func toCoreUser(dbUsr dbUser) (user.User, error) {
@@ -62,16 +88,27 @@ func toCoreUser(dbUsr dbUser) (user.User, error) {
 
 	usr := user.User{
 		ID:           dbUsr.ID,
+		TenantID:     dbUsr.TenantID,
 		Name:         dbUsr.Name,
 		Email:        addr,
 		Roles:        roles,
 		PasswordHash: dbUsr.PasswordHash,
 		Enabled:      dbUsr.Enabled,
+		FailedLogins: dbUsr.FailedLogins,
+		Version:      dbUsr.Version,
 		Department:   dbUsr.Department.String,
 		DateCreated:  dbUsr.DateCreated.In(time.Local),
 		DateUpdated:  dbUsr.DateUpdated.In(time.Local),
 	}
 
+	if dbUsr.LockedUntil.Valid {
+		usr.LockedUntil = dbUsr.LockedUntil.Time.In(time.Local)
+	}
+
+	if dbUsr.DateDeleted.Valid {
+		usr.DateDeleted = dbUsr.DateDeleted.Time.In(time.Local)
+	}
+
 	return usr, nil
 }
 
@@ -88,3 +125,32 @@ func toCoreUserSlice(dbUsers []dbUser) ([]user.User, error) {
 
 	return usrs, nil
 }
+
+// =============================================================================
+
+type dbLockout struct {
+	FailedLogins int          `db:"failed_logins"`
+	LockedUntil  sql.NullTime `db:"date_locked_until"`
+}
+
+func toDBLockout(lck user.Lockout) dbLockout {
+	return dbLockout{
+		FailedLogins: lck.FailedLogins,
+		LockedUntil: sql.NullTime{
+			Time:  lck.LockedUntil.UTC(),
+			Valid: !lck.LockedUntil.IsZero(),
+		},
+	}
+}
+
+func toCoreLockout(dbLck dbLockout) user.Lockout {
+	lck := user.Lockout{
+		FailedLogins: dbLck.FailedLogins,
+	}
+
+	if dbLck.LockedUntil.Valid {
+		lck.LockedUntil = dbLck.LockedUntil.Time.In(time.Local)
+	}
+
+	return lck
+}
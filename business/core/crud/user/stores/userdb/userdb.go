@@ -7,12 +7,16 @@ import (
 	"errors"
 	"fmt"
 	"net/mail"
+	"slices"
+	"time"
 
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/data/sqldb"
 	"github.com/testvergecloud/testApi/business/data/sqldb/dbarray"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/data/transaction"
 	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/business/web/page"
 	"github.com/testvergecloud/testApi/foundation/logger"
 
 	"github.com/google/uuid"
@@ -51,11 +55,20 @@ func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (user.Storer
 
 // Create inserts a new user into the database.
 func (s *Store) Create(ctx context.Context, usr user.User) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !scope.Allows(usr.TenantID) {
+		return tenant.ErrForbidden
+	}
+
 	const q = `
 	INSERT INTO users
-		(user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated)
+		(user_id, tenant_id, name, email, password_hash, roles, enabled, department, version, date_created, date_updated)
 	VALUES
-		(:user_id, :name, :email, :password_hash, :roles, :enabled, :department, :date_created, :date_updated)`
+		(:user_id, :tenant_id, :name, :email, :password_hash, :roles, :enabled, :department, :version, :date_created, :date_updated)`
 
 	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
 		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
@@ -67,8 +80,47 @@ func (s *Store) Create(ctx context.Context, usr user.User) error {
 	return nil
 }
 
-// Update replaces a user document in the database.
+// CreateMany inserts the users into the database in a single statement.
+func (s *Store) CreateMany(ctx context.Context, usrs []user.User) error {
+	if len(usrs) == 0 {
+		return nil
+	}
+
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, usr := range usrs {
+		if !scope.Allows(usr.TenantID) {
+			return tenant.ErrForbidden
+		}
+	}
+
+	const q = `
+	INSERT INTO users
+		(user_id, tenant_id, name, email, password_hash, roles, enabled, department, version, date_created, date_updated)
+	VALUES
+		(:user_id, :tenant_id, :name, :email, :password_hash, :roles, :enabled, :department, :version, :date_created, :date_updated)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBUsers(usrs)); err != nil {
+		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", user.ErrUniqueEmail)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a user document in the database. The user is only updated
+// if the version in the database matches the version of the specified user.
 func (s *Store) Update(ctx context.Context, usr user.User) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
 	const q = `
 	UPDATE
 		users
@@ -78,35 +130,71 @@ func (s *Store) Update(ctx context.Context, usr user.User) error {
 		"roles" = :roles,
 		"password_hash" = :password_hash,
 		"department" = :department,
+		"enabled" = :enabled,
+		"version" = :version + 1,
 		"date_updated" = :date_updated
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND
+		version = :version AND ` + tenant.Clause
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
+	affected, err := sqldb.NamedExecContextAffected(ctx, s.log, s.db, q, scoped{toDBUser(usr), scope})
+	if err != nil {
 		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
 			return user.ErrUniqueEmail
 		}
-		return fmt.Errorf("namedexeccontext: %w", err)
+		return fmt.Errorf("namedexeccontextaffected: %w", err)
+	}
+
+	if affected == 0 {
+		return user.ErrVersionConflict
 	}
 
 	return nil
 }
 
-// Delete removes a user from the database.
+// Delete marks a user as deleted in the database.
 func (s *Store) Delete(ctx context.Context, usr user.User) error {
-	data := struct {
-		ID string `db:"user_id"`
-	}{
-		ID: usr.ID.String(),
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
 	}
 
 	const q = `
-	DELETE FROM
+	UPDATE
 		users
+	SET
+		"date_deleted" = :date_deleted
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND ` + tenant.Clause
 
-	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, scoped{toDBUser(usr), scope}); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Restore clears the deleted mark of a user in the database.
+func (s *Store) Restore(ctx context.Context, usr user.User) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	const q = `
+	UPDATE
+		users
+	SET
+		"date_deleted" = NULL,
+		"version" = version + 1,
+		"date_updated" = :date_updated
+	WHERE
+		user_id = :user_id AND ` + tenant.Clause
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, scoped{toDBUser(usr), scope}); err != nil {
+		if errors.Is(err, sqldb.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", user.ErrUniqueEmail)
+		}
 		return fmt.Errorf("namedexeccontext: %w", err)
 	}
 
@@ -115,19 +203,25 @@ func (s *Store) Delete(ctx context.Context, usr user.User) error {
 
 // Query retrieves a list of existing users from the database.
 func (s *Store) Query(ctx context.Context, filter user.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]user.User, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	data := map[string]interface{}{
 		"offset":        (pageNumber - 1) * rowsPerPage,
 		"rows_per_page": rowsPerPage,
 	}
+	scope.Bind(data)
 
 	const q = `
 	SELECT
-		user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated
+		user_id, tenant_id, name, email, password_hash, roles, enabled, failed_logins, date_locked_until, department, version, date_created, date_updated, date_deleted
 	FROM
 		users`
 
 	buf := bytes.NewBufferString(q)
-	applyFilter(filter, data, buf)
+	applyFilter(filter, data, buf, tenant.Clause)
 
 	orderByClause, err := orderByClause(orderBy)
 	if err != nil {
@@ -145,9 +239,62 @@ func (s *Store) Query(ctx context.Context, filter user.QueryFilter, orderBy orde
 	return toCoreUserSlice(dbUsrs)
 }
 
+// QueryByCursor retrieves a list of existing users from the database positioned
+// relative to the specified cursor.
+func (s *Store) QueryByCursor(ctx context.Context, filter user.QueryFilter, orderBy order.By, cursor page.Cursor, limit int) ([]user.User, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"rows_per_page": limit,
+	}
+	scope.Bind(data)
+
+	const q = `
+	SELECT
+		user_id, tenant_id, name, email, password_hash, roles, enabled, failed_logins, date_locked_until, department, version, date_created, date_updated, date_deleted
+	FROM
+		users`
+
+	where, orderByClause, err := cursorClauses(orderBy, cursor, data)
+	if err != nil {
+		return nil, err
+	}
+
+	wc := []string{tenant.Clause}
+	if where != "" {
+		wc = append(wc, where)
+	}
+
+	buf := bytes.NewBufferString(q)
+	applyFilter(filter, data, buf, wc...)
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" FETCH FIRST :rows_per_page ROWS ONLY")
+
+	var dbUsrs []dbUser
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbUsrs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	if cursor.Backward {
+		slices.Reverse(dbUsrs)
+	}
+
+	return toCoreUserSlice(dbUsrs)
+}
+
 // Count returns the total number of users in the DB.
 func (s *Store) Count(ctx context.Context, filter user.QueryFilter) (int, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return 0, err
+	}
+
 	data := map[string]interface{}{}
+	scope.Bind(data)
 
 	const q = `
 	SELECT
@@ -156,7 +303,7 @@ func (s *Store) Count(ctx context.Context, filter user.QueryFilter) (int, error)
 		users`
 
 	buf := bytes.NewBufferString(q)
-	applyFilter(filter, data, buf)
+	applyFilter(filter, data, buf, tenant.Clause)
 
 	var count struct {
 		Count int `db:"count"`
@@ -170,19 +317,27 @@ func (s *Store) Count(ctx context.Context, filter user.QueryFilter) (int, error)
 
 // QueryByID gets the specified user from the database.
 func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return user.User{}, err
+	}
+
 	data := struct {
 		ID string `db:"user_id"`
+		tenant.Scope
 	}{
-		ID: userID.String(),
+		ID:    userID.String(),
+		Scope: scope,
 	}
 
 	const q = `
 	SELECT
-        user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated
+        user_id, tenant_id, name, email, password_hash, roles, enabled, failed_logins, date_locked_until, department, version, date_created, date_updated, date_deleted
 	FROM
 		users
 	WHERE 
-		user_id = :user_id`
+		user_id = :user_id AND
+		date_deleted IS NULL AND ` + tenant.Clause
 
 	var dbUsr dbUser
 	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbUsr); err != nil {
@@ -197,6 +352,11 @@ func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, err
 
 // QueryByIDs gets the specified users from the database.
 func (s *Store) QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]user.User, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	ids := make([]string, len(userIDs))
 	for i, userID := range userIDs {
 		ids[i] = userID.String()
@@ -204,17 +364,20 @@ func (s *Store) QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]user.Use
 
 	data := struct {
 		ID any `db:"user_id"`
+		tenant.Scope
 	}{
-		ID: dbarray.Array(ids),
+		ID:    dbarray.Array(ids),
+		Scope: scope,
 	}
 
 	const q = `
 	SELECT
-        user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated
+        user_id, tenant_id, name, email, password_hash, roles, enabled, failed_logins, date_locked_until, department, version, date_created, date_updated, date_deleted
 	FROM
 		users
 	WHERE
-		user_id = ANY(:user_id)`
+		user_id = ANY(:user_id) AND
+		date_deleted IS NULL AND ` + tenant.Clause
 
 	var dbUsrs []dbUser
 	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbUsrs); err != nil {
@@ -229,19 +392,27 @@ func (s *Store) QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]user.Use
 
 // QueryByEmail gets the specified user from the database by email.
 func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (user.User, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return user.User{}, err
+	}
+
 	data := struct {
 		Email string `db:"email"`
+		tenant.Scope
 	}{
 		Email: email.Address,
+		Scope: scope,
 	}
 
 	const q = `
 	SELECT
-        user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated
+        user_id, tenant_id, name, email, password_hash, roles, enabled, failed_logins, date_locked_until, department, version, date_created, date_updated, date_deleted
 	FROM
 		users
 	WHERE
-		email = :email`
+		email = :email AND
+		date_deleted IS NULL AND ` + tenant.Clause
 
 	var dbUsr dbUser
 	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbUsr); err != nil {
@@ -253,3 +424,202 @@ func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (user.User
 
 	return toCoreUser(dbUsr)
 }
+
+// QueryLockout gets the failed logins of the specified user from the
+// database.
+func (s *Store) QueryLockout(ctx context.Context, userID uuid.UUID) (user.Lockout, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return user.Lockout{}, err
+	}
+
+	data := struct {
+		ID string `db:"user_id"`
+		tenant.Scope
+	}{
+		ID:    userID.String(),
+		Scope: scope,
+	}
+
+	const q = `
+	SELECT
+		failed_logins, date_locked_until
+	FROM
+		users
+	WHERE
+		user_id = :user_id AND ` + tenant.Clause
+
+	var dbLck dbLockout
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbLck); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return user.Lockout{}, fmt.Errorf("namedquerystruct: %w", user.ErrNotFound)
+		}
+		return user.Lockout{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreLockout(dbLck), nil
+}
+
+// AddFailedLogin counts a failed login of the specified user. The count is
+// incremented by the database so concurrent failed logins are all counted.
+func (s *Store) AddFailedLogin(ctx context.Context, userID uuid.UUID) (user.Lockout, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return user.Lockout{}, err
+	}
+
+	data := struct {
+		ID string `db:"user_id"`
+		tenant.Scope
+	}{
+		ID:    userID.String(),
+		Scope: scope,
+	}
+
+	const q = `
+	UPDATE
+		users
+	SET
+		"failed_logins" = failed_logins + 1
+	WHERE
+		user_id = :user_id AND ` + tenant.Clause + `
+	RETURNING
+		failed_logins, date_locked_until`
+
+	var dbLck dbLockout
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbLck); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return user.Lockout{}, fmt.Errorf("namedquerystruct: %w", user.ErrNotFound)
+		}
+		return user.Lockout{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreLockout(dbLck), nil
+}
+
+// UpdateLockout replaces the failed logins of the specified user. The
+// version of the user is left alone, failed logins aren't a change made to
+// the user.
+func (s *Store) UpdateLockout(ctx context.Context, userID uuid.UUID, lck user.Lockout) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		ID string `db:"user_id"`
+		dbLockout
+		tenant.Scope
+	}{
+		ID:        userID.String(),
+		dbLockout: toDBLockout(lck),
+		Scope:     scope,
+	}
+
+	const q = `
+	UPDATE
+		users
+	SET
+		"failed_logins" = :failed_logins,
+		"date_locked_until" = :date_locked_until
+	WHERE
+		user_id = :user_id AND ` + tenant.Clause
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QuerySourceLockout gets the failed logins of the specified source from the
+// database. A source without failed logins has none.
+func (s *Store) QuerySourceLockout(ctx context.Context, source string) (user.Lockout, error) {
+	data := struct {
+		Source string `db:"source"`
+	}{
+		Source: source,
+	}
+
+	const q = `
+	SELECT
+		failed_logins, date_locked_until
+	FROM
+		login_sources
+	WHERE
+		source = :source`
+
+	var dbLck dbLockout
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbLck); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return user.Lockout{}, nil
+		}
+		return user.Lockout{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreLockout(dbLck), nil
+}
+
+// AddSourceFailedLogin counts a failed login of the specified source. The
+// count starts over when the source didn't fail since resetBefore.
+func (s *Store) AddSourceFailedLogin(ctx context.Context, source string, now time.Time, resetBefore time.Time) (user.Lockout, error) {
+	data := struct {
+		Source      string    `db:"source"`
+		Now         time.Time `db:"now"`
+		ResetBefore time.Time `db:"reset_before"`
+	}{
+		Source:      source,
+		Now:         now.UTC(),
+		ResetBefore: resetBefore.UTC(),
+	}
+
+	const q = `
+	INSERT INTO login_sources AS ls
+		(source, failed_logins, date_locked_until, date_updated)
+	VALUES
+		(:source, 1, NULL, :now)
+	ON CONFLICT (source) DO UPDATE SET
+		failed_logins = CASE WHEN ls.date_updated < :reset_before THEN 1 ELSE ls.failed_logins + 1 END,
+		date_updated = :now
+	RETURNING
+		failed_logins, date_locked_until`
+
+	var dbLck dbLockout
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbLck); err != nil {
+		return user.Lockout{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreLockout(dbLck), nil
+}
+
+// UpdateSourceLockout replaces the failed logins of the specified source.
+func (s *Store) UpdateSourceLockout(ctx context.Context, source string, lck user.Lockout) error {
+	data := struct {
+		Source string `db:"source"`
+		dbLockout
+	}{
+		Source:    source,
+		dbLockout: toDBLockout(lck),
+	}
+
+	const q = `
+	UPDATE
+		login_sources
+	SET
+		"failed_logins" = :failed_logins,
+		"date_locked_until" = :date_locked_until
+	WHERE
+		source = :source`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// scoped binds the tenant scope of the request along with the user.
+type scoped struct {
+	dbUser
+	tenant.Scope
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"math/rand"
 	"net/mail"
+
+	"github.com/testvergecloud/testApi/business/data/tenant"
 )
 
 // TestGenerateNewUsers is a helper method for testing.
@@ -36,7 +38,7 @@ func TestGenerateSeedUsers(n int, role Role, api *Core) ([]User, error) {
 
 	usrs := make([]User, len(newUsrs))
 	for i, nu := range newUsrs {
-		usr, err := api.Create(context.Background(), nu)
+		usr, err := api.Create(tenant.Set(context.Background(), tenant.DefaultID), nu)
 		if err != nil {
 			return nil, fmt.Errorf("seeding user: idx: %d : %w", i, err)
 		}
@@ -1,6 +1,5 @@
-// Package user provides an example of a core business API. Right now these
-// calls are just wrapping the data/data layer. But at some point you will
-// want auditing or something that isn't specific to the data/store layer.
+// Package user provides an example of a core business API. These calls wrap
+// the data/store layer and record every change in the audit log.
 package user
 
 import (
@@ -8,11 +7,15 @@ import (
 	"errors"
 	"fmt"
 	"net/mail"
+	"slices"
 	"time"
 
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
 	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/data/transaction"
 	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/business/web/page"
 	"github.com/testvergecloud/testApi/foundation/logger"
 
 	"github.com/google/uuid"
@@ -24,6 +27,10 @@ var (
 	ErrNotFound              = errors.New("user not found")
 	ErrUniqueEmail           = errors.New("email is not unique")
 	ErrAuthenticationFailure = errors.New("authentication failed")
+	ErrNotDeleted            = errors.New("user not deleted")
+	ErrVersionConflict       = errors.New("user version conflict")
+	ErrSuperAdminRole        = errors.New("super admin role can only be granted or removed by a super admin")
+	ErrUnknownRole           = errors.New("role doesn't exist")
 )
 
 // Storer interface declares the behavior this package needs to perists and
@@ -31,13 +38,22 @@ var (
 type Storer interface {
 	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
 	Create(ctx context.Context, usr User) error
+	CreateMany(ctx context.Context, usrs []User) error
 	Update(ctx context.Context, usr User) error
 	Delete(ctx context.Context, usr User) error
+	Restore(ctx context.Context, usr User) error
 	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]User, error)
+	QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, limit int) ([]User, error)
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 	QueryByID(ctx context.Context, userID uuid.UUID) (User, error)
 	QueryByIDs(ctx context.Context, userID []uuid.UUID) ([]User, error)
 	QueryByEmail(ctx context.Context, email mail.Address) (User, error)
+	QueryLockout(ctx context.Context, userID uuid.UUID) (Lockout, error)
+	AddFailedLogin(ctx context.Context, userID uuid.UUID) (Lockout, error)
+	UpdateLockout(ctx context.Context, userID uuid.UUID, lck Lockout) error
+	QuerySourceLockout(ctx context.Context, source string) (Lockout, error)
+	AddSourceFailedLogin(ctx context.Context, source string, now time.Time, resetBefore time.Time) (Lockout, error)
+	UpdateSourceLockout(ctx context.Context, source string, lck Lockout) error
 }
 
 // Core manages the set of APIs for user access.
@@ -45,14 +61,23 @@ type Core struct {
 	log      *logger.Logger
 	storer   Storer
 	delegate *delegate.Delegate
+	audit    *audit.Core
+	Lockout  LockoutPolicy
+	Roles    RoleSet
 }
 
-// NewCore constructs a user core API for use.
-func NewCore(log *logger.Logger, delegate *delegate.Delegate, storer Storer) *Core {
+// NewCore constructs a user core API for use. The audit core can be nil when
+// the core is only used for queries. Failed logins are slowed down following
+// the default lockout policy. Users can be assigned the built-in roles unless
+// another role set is given.
+func NewCore(log *logger.Logger, delegate *delegate.Delegate, audCore *audit.Core, storer Storer) *Core {
 	return &Core{
 		log:      log,
 		delegate: delegate,
+		audit:    audCore,
 		storer:   storer,
+		Lockout:  DefaultLockoutPolicy,
+		Roles:    BuiltInRoles,
 	}
 }
 
@@ -64,17 +89,50 @@ func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error
 		return nil, err
 	}
 
+	dlg := c.delegate
+	if dlg != nil {
+		dlg, err = dlg.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	audCore := c.audit
+	if audCore != nil {
+		audCore, err = audCore.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	core := Core{
 		log:      c.log,
-		delegate: c.delegate,
+		delegate: dlg,
+		audit:    audCore,
 		storer:   trS,
+		Lockout:  c.Lockout,
+		Roles:    c.Roles,
 	}
 
 	return &core, nil
 }
 
-// Create adds a new user to the system.
+// Create adds a new user to the system. The user belongs to the requested
+// tenant, or to the tenant of the context if none is requested.
 func (c *Core) Create(ctx context.Context, nu NewUser) (User, error) {
+	tenantID, err := tenant.Resolve(ctx, nu.TenantID)
+	if err != nil {
+		return User{}, fmt.Errorf("resolve: %w", err)
+	}
+
+	if err := c.checkRoles(nu.Roles); err != nil {
+		return User{}, err
+	}
+
+	if err := checkSuperAdmin(ctx, nil, nu.Roles); err != nil {
+		return User{}, err
+	}
+
 	hash, err := bcrypt.GenerateFromPassword([]byte(nu.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return User{}, fmt.Errorf("generatefrompassword: %w", err)
@@ -84,12 +142,14 @@ func (c *Core) Create(ctx context.Context, nu NewUser) (User, error) {
 
 	usr := User{
 		ID:           uuid.New(),
+		TenantID:     tenantID,
 		Name:         nu.Name,
 		Email:        nu.Email,
 		PasswordHash: hash,
 		Roles:        nu.Roles,
 		Department:   nu.Department,
 		Enabled:      true,
+		Version:      1,
 		DateCreated:  now,
 		DateUpdated:  now,
 	}
@@ -98,11 +158,72 @@ func (c *Core) Create(ctx context.Context, nu NewUser) (User, error) {
 		return User{}, fmt.Errorf("create: %w", err)
 	}
 
+	if err := c.audit.Record(ctx, Domain, audit.ActionCreated, usr.TenantID, usr.ID, nil, auditView(usr)); err != nil {
+		return User{}, fmt.Errorf("audit: %w", err)
+	}
+
 	return usr, nil
 }
 
-// Update modifies information about a user.
+// CreateMany adds the new users to the system with a single insert. Either all
+// of them are added or none are.
+func (c *Core) CreateMany(ctx context.Context, nus []NewUser) ([]User, error) {
+	now := time.Now()
+
+	usrs := make([]User, len(nus))
+	muts := make([]audit.Mutation, len(nus))
+	for i, nu := range nus {
+		tenantID, err := tenant.Resolve(ctx, nu.TenantID)
+		if err != nil {
+			return nil, fmt.Errorf("resolve: %w", err)
+		}
+
+		if err := c.checkRoles(nu.Roles); err != nil {
+			return nil, err
+		}
+
+		if err := checkSuperAdmin(ctx, nil, nu.Roles); err != nil {
+			return nil, err
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(nu.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("generatefrompassword: %w", err)
+		}
+
+		usrs[i] = User{
+			ID:           uuid.New(),
+			TenantID:     tenantID,
+			Name:         nu.Name,
+			Email:        nu.Email,
+			PasswordHash: hash,
+			Roles:        nu.Roles,
+			Department:   nu.Department,
+			Enabled:      true,
+			Version:      1,
+			DateCreated:  now,
+			DateUpdated:  now,
+		}
+		muts[i] = audit.Mutation{TenantID: tenantID, EntityID: usrs[i].ID, After: auditView(usrs[i])}
+	}
+
+	if err := c.storer.CreateMany(ctx, usrs); err != nil {
+		return nil, fmt.Errorf("createmany: %w", err)
+	}
+
+	if err := c.audit.RecordMany(ctx, Domain, audit.ActionCreated, muts); err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+
+	return usrs, nil
+}
+
+// Update modifies information about a user. The update only succeeds if the
+// user still has the version it was read with, otherwise ErrVersionConflict
+// is returned.
 func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error) {
+	before := usr
+
 	if uu.Name != nil {
 		usr.Name = *uu.Name
 	}
@@ -112,6 +233,12 @@ func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error
 	}
 
 	if uu.Roles != nil {
+		if err := c.checkRoles(uu.Roles); err != nil {
+			return User{}, err
+		}
+		if err := checkSuperAdmin(ctx, usr.Roles, uu.Roles); err != nil {
+			return User{}, err
+		}
 		usr.Roles = uu.Roles
 	}
 
@@ -135,6 +262,11 @@ func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error
 	if err := c.storer.Update(ctx, usr); err != nil {
 		return User{}, fmt.Errorf("update: %w", err)
 	}
+	usr.Version++
+
+	if err := c.audit.Record(ctx, Domain, audit.ActionUpdated, usr.TenantID, usr.ID, auditView(before), auditView(usr)); err != nil {
+		return User{}, fmt.Errorf("audit: %w", err)
+	}
 
 	// Other domains may need to know when a user is updated so business
 	// logic can be applied. This represents a delegate call to other domains.
@@ -145,15 +277,59 @@ func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error
 	return usr, nil
 }
 
-// Delete removes the specified user.
+// Delete marks the specified user as deleted. The user is no longer returned
+// by the query apis unless deleted users are asked for.
 func (c *Core) Delete(ctx context.Context, usr User) error {
+	before := usr
+	usr.DateDeleted = time.Now()
+
 	if err := c.storer.Delete(ctx, usr); err != nil {
 		return fmt.Errorf("delete: %w", err)
 	}
 
+	if err := c.audit.Record(ctx, Domain, audit.ActionDeleted, usr.TenantID, usr.ID, auditView(before), auditView(usr)); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
 	return nil
 }
 
+// Restore brings back the specified user that was previously deleted.
+func (c *Core) Restore(ctx context.Context, userID uuid.UUID) (User, error) {
+	var filter QueryFilter
+	filter.WithUserID(userID)
+	filter.WithIncludeDeleted(true)
+
+	usrs, err := c.storer.Query(ctx, filter, DefaultOrderBy, 1, 1)
+	if err != nil {
+		return User{}, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+
+	if len(usrs) == 0 {
+		return User{}, fmt.Errorf("query: userID[%s]: %w", userID, ErrNotFound)
+	}
+
+	usr := usrs[0]
+	if usr.DateDeleted.IsZero() {
+		return User{}, ErrNotDeleted
+	}
+
+	before := usr
+	usr.DateDeleted = time.Time{}
+	usr.DateUpdated = time.Now()
+
+	if err := c.storer.Restore(ctx, usr); err != nil {
+		return User{}, fmt.Errorf("restore: %w", err)
+	}
+	usr.Version++
+
+	if err := c.audit.Record(ctx, Domain, audit.ActionRestored, usr.TenantID, usr.ID, auditView(before), auditView(usr)); err != nil {
+		return User{}, fmt.Errorf("audit: %w", err)
+	}
+
+	return usr, nil
+}
+
 // Query retrieves a list of existing users.
 func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]User, error) {
 	if err := filter.Validate(); err != nil {
@@ -168,6 +344,23 @@ func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By,
 	return users, nil
 }
 
+// QueryByCursor retrieves a list of existing users positioned after, or
+// before for a backward cursor, the specified cursor.
+func (c *Core) QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, rowsPerPage int) ([]User, page.Cursors, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, page.Cursors{}, err
+	}
+
+	users, err := c.storer.QueryByCursor(ctx, filter, orderBy, cursor, rowsPerPage+1)
+	if err != nil {
+		return nil, page.Cursors{}, fmt.Errorf("querybycursor: %w", err)
+	}
+
+	users, cursors := page.Window(users, orderBy, cursor, rowsPerPage, cursorPosition(orderBy))
+
+	return users, cursors, nil
+}
+
 // Count returns the total number of users.
 func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
 	if err := filter.Validate(); err != nil {
@@ -209,16 +402,91 @@ func (c *Core) QueryByEmail(ctx context.Context, email mail.Address) (User, erro
 
 // Authenticate finds a user by their email and verifies their password. On
 // success it returns a Claims User representing this user. The claims can be
-// used to generate a token for future authentication.
-func (c *Core) Authenticate(ctx context.Context, email mail.Address, password string) (User, error) {
+// used to generate a token for future authentication. Failed logins are
+// tracked per user and per source, the address the login came from, and
+// lock them out for a while as described by the lockout policy.
+func (c *Core) Authenticate(ctx context.Context, email mail.Address, password string, source string) (User, error) {
+	now := time.Now()
+
+	// Emails are unique across tenants and the tenant of the caller isn't
+	// known until the user is found.
+	ctx = tenant.SetAll(ctx)
+
+	if err := c.checkSource(ctx, source, now); err != nil {
+		return User{}, err
+	}
+
 	usr, err := c.QueryByEmail(ctx, email)
 	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			if err := c.failSource(ctx, source, now); err != nil {
+				return User{}, err
+			}
+		}
 		return User{}, fmt.Errorf("query: email[%s]: %w", email, err)
 	}
 
+	// The user may come from a cache, the lockout is read from the store so
+	// every instance enforces the same one.
+	lck, err := c.storer.QueryLockout(ctx, usr.ID)
+	if err != nil {
+		return User{}, fmt.Errorf("querylockout: userID[%s]: %w", usr.ID, err)
+	}
+	usr.FailedLogins = lck.FailedLogins
+	usr.LockedUntil = lck.LockedUntil
+
+	if usr.IsLocked(now) {
+		return User{}, &LockedError{Until: usr.LockedUntil}
+	}
+
 	if err := bcrypt.CompareHashAndPassword(usr.PasswordHash, []byte(password)); err != nil {
+		if err := c.failLogin(ctx, usr, now); err != nil {
+			return User{}, err
+		}
+		if err := c.failSource(ctx, source, now); err != nil {
+			return User{}, err
+		}
 		return User{}, fmt.Errorf("comparehashandpassword: %w", ErrAuthenticationFailure)
 	}
 
+	if usr.FailedLogins > 0 {
+		if err := c.storer.UpdateLockout(ctx, usr.ID, Lockout{}); err != nil {
+			return User{}, fmt.Errorf("updatelockout: userID[%s]: %w", usr.ID, err)
+		}
+		usr.FailedLogins = 0
+		usr.LockedUntil = time.Time{}
+	}
+
 	return usr, nil
 }
+
+// checkRoles makes sure the roles are in the role set of the core.
+func (c *Core) checkRoles(roles []Role) error {
+	for _, role := range roles {
+		if !c.Roles.Exists(role) {
+			return fmt.Errorf("role[%s]: %w", role.Name(), ErrUnknownRole)
+		}
+	}
+
+	return nil
+}
+
+// checkSuperAdmin makes sure the super admin role is only granted or removed
+// under a context that can access every tenant, which only callers passing
+// the super admin rule are given.
+func checkSuperAdmin(ctx context.Context, before []Role, after []Role) error {
+	if slices.Contains(before, RoleSuperAdmin) == slices.Contains(after, RoleSuperAdmin) {
+		return nil
+	}
+
+	s, err := tenant.GetScope(ctx)
+	if err != nil {
+		return fmt.Errorf("scope: %w", err)
+	}
+
+	if !s.All {
+		return ErrSuperAdminRole
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/foundation/docker"
 
 	"github.com/google/go-cmp/cmp"
@@ -43,6 +44,8 @@ func run(m *testing.M) (int, error) {
 func Test_User(t *testing.T) {
 	t.Run("crud", crud)
 	t.Run("paging", paging)
+	t.Run("lockout", lockout)
+	t.Run("superAdmin", superAdmin)
 }
 
 func crud(t *testing.T) {
@@ -94,7 +97,7 @@ func crud(t *testing.T) {
 
 	api := test.CoreAPIs
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(tenant.Set(context.Background(), tenant.DefaultID), 10*time.Second)
 	defer cancel()
 
 	t.Log("Go seeding ...")
@@ -189,6 +192,48 @@ func crud(t *testing.T) {
 	if !errors.Is(err, user.ErrNotFound) {
 		t.Fatalf("Should NOT be able to retrieve user : %s.", err)
 	}
+
+	if _, err := api.User.Restore(ctx, saved.ID); err != nil {
+		t.Fatalf("Should be able to restore deleted user : %s.", err)
+	}
+
+	if _, err := api.User.QueryByID(ctx, saved.ID); err != nil {
+		t.Fatalf("Should be able to retrieve restored user : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := api.User.Delete(ctx, saved); err != nil {
+		t.Fatalf("Should be able to delete user : %s.", err)
+	}
+
+	nu := user.NewUser{
+		Name:            "Jacob Smith",
+		Email:           saved.Email,
+		Roles:           []user.Role{user.RoleUser},
+		Department:      "IT",
+		Password:        "12345",
+		PasswordConfirm: "12345",
+	}
+
+	reused, err := api.User.Create(ctx, nu)
+	if err != nil {
+		t.Fatalf("Should be able to reuse the email of a deleted user : %s.", err)
+	}
+
+	if _, err := api.User.Restore(ctx, saved.ID); !errors.Is(err, user.ErrUniqueEmail) {
+		t.Logf("got: %v", err)
+		t.Logf("exp: %v", user.ErrUniqueEmail)
+		t.Fatalf("Should NOT be able to restore a user whose email was reused.")
+	}
+
+	if err := api.User.Delete(ctx, reused); err != nil {
+		t.Fatalf("Should be able to delete user : %s.", err)
+	}
+
+	if _, err := api.User.Restore(ctx, saved.ID); err != nil {
+		t.Fatalf("Should be able to restore user once its email is free : %s.", err)
+	}
 }
 
 func paging(t *testing.T) {
@@ -203,7 +248,7 @@ func paging(t *testing.T) {
 
 	api := test.CoreAPIs
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(tenant.Set(context.Background(), tenant.DefaultID), 10*time.Second)
 	defer cancel()
 
 	// -------------------------------------------------------------------------
@@ -260,3 +305,162 @@ func paging(t *testing.T) {
 		t.Errorf("Should have different users")
 	}
 }
+
+func lockout(t *testing.T) {
+	test := dbtest.NewTest(t, c, "Test_User/lockout")
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+	api.User.Lockout = user.LockoutPolicy{
+		Threshold:       3,
+		SourceThreshold: 2,
+		Delay:           200 * time.Millisecond,
+		Duration:        time.Hour,
+	}
+
+	ctx, cancel := context.WithTimeout(tenant.Set(context.Background(), tenant.DefaultID), 10*time.Second)
+	defer cancel()
+
+	nu := user.NewUser{
+		Name:            "Jill Kennedy",
+		Email:           mail.Address{Address: "jill@ardanlabs.com"},
+		Roles:           []user.Role{user.RoleUser},
+		Department:      "IT",
+		Password:        "12345",
+		PasswordConfirm: "12345",
+	}
+
+	usr, err := api.User.Create(ctx, nu)
+	if err != nil {
+		t.Fatalf("Should be able to create user : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := api.User.Authenticate(ctx, nu.Email, "bad", ""); !errors.Is(err, user.ErrAuthenticationFailure) {
+		t.Fatalf("Should NOT be able to authenticate with a bad password : %v.", err)
+	}
+
+	if _, err := api.User.Authenticate(ctx, nu.Email, nu.Password, ""); !errors.Is(err, user.ErrLocked) {
+		t.Fatalf("Should be delayed right after a failed login : %v.", err)
+	}
+
+	for i := 2; i <= api.User.Lockout.Threshold; i++ {
+		time.Sleep(api.User.Lockout.Delay << (i - 2))
+
+		if _, err := api.User.Authenticate(ctx, nu.Email, "bad", ""); !errors.Is(err, user.ErrAuthenticationFailure) {
+			t.Fatalf("Should NOT be able to authenticate with a bad password : %v.", err)
+		}
+	}
+
+	_, err = api.User.Authenticate(ctx, nu.Email, nu.Password, "")
+
+	var lckErr *user.LockedError
+	if !errors.As(err, &lckErr) || time.Until(lckErr.Until) < 59*time.Minute {
+		t.Fatalf("Should be locked out once the threshold is reached : %v.", err)
+	}
+
+	saved, err := api.User.QueryByID(ctx, usr.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve user by ID: %s.", err)
+	}
+
+	if !saved.IsLocked(time.Now()) || saved.FailedLogins != api.User.Lockout.Threshold {
+		t.Fatalf("Should see the user locked out : %d %v.", saved.FailedLogins, saved.LockedUntil)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := api.User.Unlock(ctx, saved); err != nil {
+		t.Fatalf("Should be able to unlock user : %s.", err)
+	}
+
+	if _, err := api.User.Authenticate(ctx, nu.Email, nu.Password, ""); err != nil {
+		t.Fatalf("Should be able to authenticate once unlocked : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	const source = "10.0.0.1"
+
+	for range api.User.Lockout.SourceThreshold {
+		unknown := mail.Address{Address: "unknown@ardanlabs.com"}
+		if _, err := api.User.Authenticate(ctx, unknown, "bad", source); !errors.Is(err, user.ErrNotFound) {
+			t.Fatalf("Should NOT be able to authenticate an unknown user : %v.", err)
+		}
+	}
+
+	if _, err := api.User.Authenticate(ctx, nu.Email, nu.Password, source); !errors.Is(err, user.ErrLocked) {
+		t.Fatalf("Should lock out the source once its threshold is reached : %v.", err)
+	}
+
+	if _, err := api.User.Authenticate(ctx, nu.Email, nu.Password, "10.0.0.2"); err != nil {
+		t.Fatalf("Should be able to authenticate from another source : %s.", err)
+	}
+}
+
+func superAdmin(t *testing.T) {
+	test := dbtest.NewTest(t, c, "Test_User/superAdmin")
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.Set(context.Background(), tenant.DefaultID), 10*time.Second)
+	defer cancel()
+
+	nu := user.NewUser{
+		Name:            "Jill Kennedy",
+		Email:           mail.Address{Address: "jill@ardanlabs.com"},
+		Roles:           []user.Role{user.RoleAdmin, user.RoleSuperAdmin},
+		Department:      "IT",
+		Password:        "12345",
+		PasswordConfirm: "12345",
+	}
+
+	if _, err := api.User.Create(ctx, nu); !errors.Is(err, user.ErrSuperAdminRole) {
+		t.Fatalf("Should NOT be able to create a super admin for a single tenant : %v.", err)
+	}
+
+	if _, err := api.User.CreateMany(ctx, []user.NewUser{nu}); !errors.Is(err, user.ErrSuperAdminRole) {
+		t.Fatalf("Should NOT be able to create super admins for a single tenant : %v.", err)
+	}
+
+	usr, err := api.User.Create(tenant.SetAll(ctx), nu)
+	if err != nil {
+		t.Fatalf("Should be able to create a super admin across tenants : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	roles := []user.Role{user.RoleAdmin}
+
+	if _, err := api.User.Update(ctx, usr, user.UpdateUser{Roles: roles}); !errors.Is(err, user.ErrSuperAdminRole) {
+		t.Fatalf("Should NOT be able to remove the super admin role for a single tenant : %v.", err)
+	}
+
+	upd, err := api.User.Update(ctx, usr, user.UpdateUser{Roles: []user.Role{user.RoleSuperAdmin}})
+	if err != nil {
+		t.Fatalf("Should be able to change other roles of a super admin : %s.", err)
+	}
+
+	upd, err = api.User.Update(tenant.SetAll(ctx), upd, user.UpdateUser{Roles: roles})
+	if err != nil {
+		t.Fatalf("Should be able to remove the super admin role across tenants : %s.", err)
+	}
+
+	if _, err := api.User.Update(ctx, upd, user.UpdateUser{Roles: []user.Role{user.RoleSuperAdmin}}); !errors.Is(err, user.ErrSuperAdminRole) {
+		t.Fatalf("Should NOT be able to grant the super admin role for a single tenant : %v.", err)
+	}
+}
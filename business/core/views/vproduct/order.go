@@ -1,6 +1,10 @@
 package vproduct
 
-import "github.com/testvergecloud/testApi/business/web/order"
+import (
+	"strconv"
+
+	"github.com/testvergecloud/testApi/business/web/order"
+)
 
 // DefaultOrderBy represents the default way we sort.
 var DefaultOrderBy = order.NewBy(OrderByProductID, order.ASC)
@@ -14,3 +18,37 @@ const (
 	OrderByQuantity  = "quantity"
 	OrderByUserName  = "user_name"
 )
+
+// cursorPosition returns the values of the order by fields and the ID of the
+// product for use in a keyset paging cursor.
+func cursorPosition(orderBy order.By) func(prd Product) ([]string, string) {
+	return func(prd Product) ([]string, string) {
+		fields := orderBy.Fields()
+
+		values := make([]string, len(fields))
+		for i, by := range fields {
+			values[i] = cursorValue(prd, by.Field)
+		}
+
+		return values, prd.ID.String()
+	}
+}
+
+// cursorValue returns the value of the specified order by field for the
+// product.
+func cursorValue(prd Product, field string) string {
+	switch field {
+	case OrderByUserID:
+		return prd.UserID.String()
+	case OrderByName:
+		return prd.Name
+	case OrderByCost:
+		return strconv.FormatFloat(prd.Cost, 'f', -1, 64)
+	case OrderByQuantity:
+		return strconv.Itoa(prd.Quantity)
+	case OrderByUserName:
+		return prd.UserName
+	default:
+		return prd.ID.String()
+	}
+}
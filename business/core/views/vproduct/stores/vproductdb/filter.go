@@ -6,33 +6,77 @@ import (
 	"strings"
 
 	"github.com/testvergecloud/testApi/business/core/views/vproduct"
-)
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/sqldb/dbarray"
 
-func (s *Store) applyFilter(filter vproduct.QueryFilter, data map[string]interface{}, buf *bytes.Buffer) {
-	var wc []string
+	"github.com/google/uuid"
+)
 
+// applyFilter writes the WHERE clause for the filter along with any
+// additional clauses provided by the caller.
+func (s *Store) applyFilter(filter vproduct.QueryFilter, data map[string]interface{}, buf *bytes.Buffer, wc ...string) {
 	if filter.ID != nil {
 		data["product_id"] = *filter.ID
 		wc = append(wc, "product_id = :product_id")
 	}
 
+	if len(filter.IDs) > 0 {
+		data["product_ids"] = toDBIDs(filter.IDs)
+		wc = append(wc, "product_id = ANY(:product_ids)")
+	}
+
+	if len(filter.NotIDs) > 0 {
+		data["not_product_ids"] = toDBIDs(filter.NotIDs)
+		wc = append(wc, "product_id <> ALL(:not_product_ids)")
+	}
+
 	if filter.Name != nil {
 		data["name"] = fmt.Sprintf("%%%s%%", *filter.Name)
 		wc = append(wc, "name LIKE :name")
 	}
 
+	if filter.NamePrefix != nil {
+		data["name_prefix"] = sqldb.EscapeLike(*filter.NamePrefix) + "%"
+		wc = append(wc, "name ILIKE :name_prefix")
+	}
+
+	if filter.NotName != nil {
+		data["not_name"] = "%" + sqldb.EscapeLike(*filter.NotName) + "%"
+		wc = append(wc, "name NOT LIKE :not_name")
+	}
+
 	if filter.Cost != nil {
 		data["cost"] = *filter.Cost
 		wc = append(wc, "cost = :cost")
 	}
 
+	if filter.StartCost != nil {
+		data["start_cost"] = *filter.StartCost
+		wc = append(wc, "cost >= :start_cost")
+	}
+
+	if filter.EndCost != nil {
+		data["end_cost"] = *filter.EndCost
+		wc = append(wc, "cost <= :end_cost")
+	}
+
 	if filter.Quantity != nil {
 		data["quantity"] = *filter.Quantity
 		wc = append(wc, "quantity = :quantity")
 	}
 
+	if filter.StartCreatedDate != nil {
+		data["start_date_created"] = *filter.StartCreatedDate
+		wc = append(wc, "date_created >= :start_date_created")
+	}
+
+	if filter.EndCreatedDate != nil {
+		data["end_date_created"] = *filter.EndCreatedDate
+		wc = append(wc, "date_created <= :end_date_created")
+	}
+
 	if filter.UserName != nil {
-		data["user_name"] = fmt.Sprintf("%%%s%%", *filter.Name)
+		data["user_name"] = fmt.Sprintf("%%%s%%", *filter.UserName)
 		wc = append(wc, "user_name LIKE :user_name")
 	}
 
@@ -41,3 +85,14 @@ func (s *Store) applyFilter(filter vproduct.QueryFilter, data map[string]interfa
 		buf.WriteString(strings.Join(wc, " AND "))
 	}
 }
+
+// toDBIDs converts the set of IDs into an array value the database can
+// compare a column against.
+func toDBIDs(ids []uuid.UUID) any {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+
+	return dbarray.Array(values)
+}
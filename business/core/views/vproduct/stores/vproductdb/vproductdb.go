@@ -5,10 +5,13 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/testvergecloud/testApi/business/core/views/vproduct"
 	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/business/web/page"
 	"github.com/testvergecloud/testApi/foundation/logger"
 
 	"github.com/jmoiron/sqlx"
@@ -30,10 +33,16 @@ func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
 
 // Query retrieves a list of existing products from the database.
 func (s *Store) Query(ctx context.Context, filter vproduct.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]vproduct.Product, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	data := map[string]interface{}{
 		"offset":        (pageNumber - 1) * rowsPerPage,
 		"rows_per_page": rowsPerPage,
 	}
+	scope.Bind(data)
 
 	const q = `
 	SELECT
@@ -49,7 +58,7 @@ func (s *Store) Query(ctx context.Context, filter vproduct.QueryFilter, orderBy
 		view_products`
 
 	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
+	s.applyFilter(filter, data, buf, tenant.Clause)
 
 	orderByClause, err := orderByClause(orderBy)
 	if err != nil {
@@ -67,9 +76,114 @@ func (s *Store) Query(ctx context.Context, filter vproduct.QueryFilter, orderBy
 	return toCoreProducts(dnPrd), nil
 }
 
+// QueryByCursor retrieves a list of existing products from the database positioned
+// relative to the specified cursor.
+func (s *Store) QueryByCursor(ctx context.Context, filter vproduct.QueryFilter, orderBy order.By, cursor page.Cursor, limit int) ([]vproduct.Product, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"rows_per_page": limit,
+	}
+	scope.Bind(data)
+
+	const q = `
+	SELECT
+		product_id,
+		user_id,
+		name,
+		cost,
+		quantity,
+		date_created,
+		date_updated,
+		user_name
+	FROM
+		view_products`
+
+	where, orderByClause, err := cursorClauses(orderBy, cursor, data)
+	if err != nil {
+		return nil, err
+	}
+
+	wc := []string{tenant.Clause}
+	if where != "" {
+		wc = append(wc, where)
+	}
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf, wc...)
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" FETCH FIRST :rows_per_page ROWS ONLY")
+
+	var dnPrd []dbProduct
+	if err := sqldb.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dnPrd); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	if cursor.Backward {
+		slices.Reverse(dnPrd)
+	}
+
+	return toCoreProducts(dnPrd), nil
+}
+
+// QueryEach retrieves every product matching the filter from the database
+// through a server-side cursor, so they're never held in memory together.
+func (s *Store) QueryEach(ctx context.Context, filter vproduct.QueryFilter, orderBy order.By, fn func(vproduct.Product) error) error {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{}
+	scope.Bind(data)
+
+	const q = `
+	SELECT
+		product_id,
+		user_id,
+		name,
+		cost,
+		quantity,
+		date_created,
+		date_updated,
+		user_name
+	FROM
+		view_products`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(filter, data, buf, tenant.Clause)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteString(orderByClause)
+
+	each := func(dbPrd dbProduct) error {
+		return fn(toCoreProduct(dbPrd))
+	}
+
+	if err := sqldb.NamedQueryEach(ctx, s.log, s.db, buf.String(), data, each); err != nil {
+		return fmt.Errorf("namedqueryeach: %w", err)
+	}
+
+	return nil
+}
+
 // Count returns the total number of products in the DB.
 func (s *Store) Count(ctx context.Context, filter vproduct.QueryFilter) (int, error) {
+	scope, err := tenant.GetScope(ctx)
+	if err != nil {
+		return 0, err
+	}
+
 	data := map[string]interface{}{}
+	scope.Bind(data)
 
 	const q = `
 	SELECT
@@ -78,7 +192,7 @@ func (s *Store) Count(ctx context.Context, filter vproduct.QueryFilter) (int, er
 		view_products`
 
 	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
+	s.applyFilter(filter, data, buf, tenant.Clause)
 
 	var count struct {
 		Count int `db:"count"`
@@ -7,12 +7,15 @@ import (
 	"fmt"
 
 	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/business/web/page"
 )
 
 // Storer interface declares the behavior this package needs to perists and
 // retrieve data.
 type Storer interface {
 	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Product, error)
+	QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, limit int) ([]Product, error)
+	QueryEach(ctx context.Context, filter QueryFilter, orderBy order.By, fn func(Product) error) error
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 }
 
@@ -42,6 +45,38 @@ func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By,
 	return users, nil
 }
 
+// QueryByCursor retrieves a list of existing products positioned after, or
+// before for a backward cursor, the specified cursor.
+func (c *Core) QueryByCursor(ctx context.Context, filter QueryFilter, orderBy order.By, cursor page.Cursor, rowsPerPage int) ([]Product, page.Cursors, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, page.Cursors{}, err
+	}
+
+	prds, err := c.storer.QueryByCursor(ctx, filter, orderBy, cursor, rowsPerPage+1)
+	if err != nil {
+		return nil, page.Cursors{}, fmt.Errorf("querybycursor: %w", err)
+	}
+
+	prds, cursors := page.Window(prds, orderBy, cursor, rowsPerPage, cursorPosition(orderBy))
+
+	return prds, cursors, nil
+}
+
+// QueryEach retrieves every product matching the filter without paging and
+// passes them to the function one at a time. It stops at the first error
+// returned by the function.
+func (c *Core) QueryEach(ctx context.Context, filter QueryFilter, orderBy order.By, fn func(Product) error) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.storer.QueryEach(ctx, filter, orderBy, fn); err != nil {
+		return fmt.Errorf("queryeach: %w", err)
+	}
+
+	return nil
+}
+
 // Count returns the total number of products.
 func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
 	if err := filter.Validate(); err != nil {
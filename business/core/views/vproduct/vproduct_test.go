@@ -2,6 +2,7 @@ package vproduct_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"runtime/debug"
@@ -12,6 +13,7 @@ import (
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/core/views/vproduct"
 	"github.com/testvergecloud/testApi/business/data/dbtest"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/foundation/docker"
 )
 
@@ -73,7 +75,7 @@ func paging(t *testing.T) {
 
 	api := test.CoreAPIs
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(tenant.Set(context.Background(), tenant.DefaultID), 10*time.Second)
 	defer cancel()
 
 	t.Log("Go seeding ...")
@@ -158,4 +160,35 @@ func paging(t *testing.T) {
 		t.Log("exp:", usrs[0].Name)
 		t.Fatal("Should have the correct user name")
 	}
+
+	var all []vproduct.Product
+	err = api.VProduct.QueryEach(ctx, vproduct.QueryFilter{}, vproduct.DefaultOrderBy, func(prd vproduct.Product) error {
+		all = append(all, prd)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Should be able to retrieve every product : %s", err)
+	}
+
+	if len(all) != n {
+		t.Logf("got: %v", len(all))
+		t.Logf("exp: %v", n)
+		t.Fatalf("Should retrieve every product without paging")
+	}
+
+	if all[0].ID != prd3[0].ID || all[1].ID != prd3[1].ID {
+		t.Logf("got: %v %v", all[0].ID, all[1].ID)
+		t.Logf("exp: %v %v", prd3[0].ID, prd3[1].ID)
+		t.Fatalf("Should retrieve every product in order")
+	}
+
+	errStop := errors.New("stop")
+	var seen int
+	err = api.VProduct.QueryEach(ctx, vproduct.QueryFilter{}, vproduct.DefaultOrderBy, func(prd vproduct.Product) error {
+		seen++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || seen != 1 {
+		t.Fatalf("Should stop at the first error : %d : %s", seen, err)
+	}
 }
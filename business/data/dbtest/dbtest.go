@@ -8,21 +8,38 @@ import (
 	"math/rand"
 	"net/mail"
 	"testing"
+	"testing/fstest"
 	"time"
 
+	"github.com/testvergecloud/testApi/business/core/crud/apikey"
+	"github.com/testvergecloud/testApi/business/core/crud/apikey/stores/apikeydb"
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/business/core/crud/audit/stores/auditdb"
 	"github.com/testvergecloud/testApi/business/core/crud/delegate"
 	"github.com/testvergecloud/testApi/business/core/crud/home"
 	"github.com/testvergecloud/testApi/business/core/crud/home/stores/homedb"
+	"github.com/testvergecloud/testApi/business/core/crud/identity"
+	"github.com/testvergecloud/testApi/business/core/crud/identity/stores/identitydb"
+	"github.com/testvergecloud/testApi/business/core/crud/organization"
+	"github.com/testvergecloud/testApi/business/core/crud/organization/stores/organizationdb"
 	"github.com/testvergecloud/testApi/business/core/crud/product"
 	"github.com/testvergecloud/testApi/business/core/crud/product/stores/productdb"
+	"github.com/testvergecloud/testApi/business/core/crud/revocation"
+	"github.com/testvergecloud/testApi/business/core/crud/revocation/stores/revocationdb"
+	"github.com/testvergecloud/testApi/business/core/crud/role"
+	"github.com/testvergecloud/testApi/business/core/crud/role/stores/roledb"
+	"github.com/testvergecloud/testApi/business/core/crud/session"
+	"github.com/testvergecloud/testApi/business/core/crud/session/stores/sessiondb"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/core/crud/user/stores/userdb"
 	"github.com/testvergecloud/testApi/business/core/views/vproduct"
 	"github.com/testvergecloud/testApi/business/core/views/vproduct/stores/vproductdb"
 	"github.com/testvergecloud/testApi/business/data/migrate"
 	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/business/web/auth"
 	"github.com/testvergecloud/testApi/foundation/docker"
+	"github.com/testvergecloud/testApi/foundation/keystore"
 	"github.com/testvergecloud/testApi/foundation/logger"
 	"github.com/testvergecloud/testApi/foundation/web"
 
@@ -139,7 +156,12 @@ func NewTest(t *testing.T, c *docker.Container, testName string) *Test {
 	// 	DB:        db,
 	// 	KeyLookup: &keyStore{},
 	// }
-	a, err := auth.New(&config.Config{}, db, &keyStore{}, log)
+	ks := keystore.New()
+	if err := ks.LoadKeys(fstest.MapFS{kid + ".pem": {Data: []byte(privateKeyPEM)}}); err != nil {
+		t.Fatalf("Loading keys error: %s", err)
+	}
+
+	a, err := auth.New(&config.Config{Auth: &config.Auth{Issuer: "service project"}}, db, ks, log, coreAPIs.Revoke, coreAPIs.Role)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -178,7 +200,7 @@ func (test *Test) TokenV1(email string, pass string) string {
 	addr, _ := mail.ParseAddress(email)
 
 	store := userdb.NewStore(test.Log, test.DB)
-	dbUsr, err := store.QueryByEmail(context.Background(), *addr)
+	dbUsr, err := store.QueryByEmail(tenant.SetAll(context.Background()), *addr)
 	if err != nil {
 		return ""
 	}
@@ -190,10 +212,12 @@ func (test *Test) TokenV1(email string, pass string) string {
 			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 		},
-		Roles: dbUsr.Roles,
+		TenantID:   dbUsr.TenantID,
+		Roles:      dbUsr.Roles,
+		Department: dbUsr.Department,
 	}
 
-	token, err := test.V1.Auth.GenerateToken(kid, claims)
+	token, err := test.V1.Auth.GenerateToken(claims)
 	if err != nil {
 		test.t.Fatal(err)
 	}
@@ -222,41 +246,63 @@ func FloatPointer(f float64) *float64 {
 	return &f
 }
 
+// BoolPointer is a helper to get a *bool from a bool. It is in the tests
+// package because we normally don't want to deal with pointers to basic types
+// but it's useful in some tests.
+func BoolPointer(b bool) *bool {
+	return &b
+}
+
 // CoreAPIs represents all the core api's needed for testing.
 type CoreAPIs struct {
-	Delegate *delegate.Delegate
-	User     *user.Core
-	Product  *product.Core
-	Home     *home.Core
-	VProduct *vproduct.Core
+	Delegate     *delegate.Delegate
+	Audit        *audit.Core
+	Organization *organization.Core
+	User         *user.Core
+	Product      *product.Core
+	Home         *home.Core
+	VProduct     *vproduct.Core
+	Session      *session.Core
+	Revoke       *revocation.Core
+	Role         *role.Core
+	APIKey       *apikey.Core
+	Identity     *identity.Core
 }
 
 func newCoreAPIs(log *logger.Logger, db *sqlx.DB) CoreAPIs {
 	delegate := delegate.New(log)
-	usrCore := user.NewCore(log, delegate, userdb.NewStore(log, db))
-	prdCore := product.NewCore(log, usrCore, delegate, productdb.NewStore(log, db))
-	hmeCore := home.NewCore(log, usrCore, delegate, homedb.NewStore(log, db))
+	audCore := audit.NewCore(log, auditdb.NewStore(log, db))
+	orgCore := organization.NewCore(log, organizationdb.NewStore(log, db))
+	roleCore := role.NewCore(log, roledb.NewStore(log, db))
+	usrCore := user.NewCore(log, delegate, audCore, userdb.NewStore(log, db))
+	usrCore.Roles = roleCore
+	prdCore := product.NewCore(log, usrCore, delegate, audCore, productdb.NewStore(log, db))
+	hmeCore := home.NewCore(log, usrCore, delegate, audCore, homedb.NewStore(log, db))
 	vPrdCore := vproduct.NewCore(vproductdb.NewStore(log, db))
+	sesCore := session.NewCore(log, sessiondb.NewStore(log, db))
+	revCore := revocation.NewCore(log, revocationdb.NewStore(log, db))
+	keyCore := apikey.NewCore(log, usrCore, roleCore, apikeydb.NewStore(log, db), nil)
+	idnCore := identity.NewCore(log, usrCore, identitydb.NewStore(log, db), identity.Provisioning{
+		TenantID: tenant.DefaultID,
+		Roles:    []user.Role{user.RoleUser},
+	})
 
 	return CoreAPIs{
-		Delegate: delegate,
-		User:     usrCore,
-		Product:  prdCore,
-		Home:     hmeCore,
-		VProduct: vPrdCore,
+		Delegate:     delegate,
+		Audit:        audCore,
+		Organization: orgCore,
+		User:         usrCore,
+		Product:      prdCore,
+		Home:         hmeCore,
+		VProduct:     vPrdCore,
+		Session:      sesCore,
+		Revoke:       revCore,
+		Role:         roleCore,
+		APIKey:       keyCore,
+		Identity:     idnCore,
 	}
 }
 
-type keyStore struct{}
-
-func (ks *keyStore) PrivateKey(kid string) (string, error) {
-	return privateKeyPEM, nil
-}
-
-func (ks *keyStore) PublicKey(kid string) (string, error) {
-	return publicKeyPEM, nil
-}
-
 const (
 	kid = "s4sKIjD9kIRjxs2tulPqGLdxSfgPErRN1Mu3Hd9k9NQ"
 
@@ -288,13 +334,4 @@ xumKGh//G0AYsjqP02ItzOm2mWnbI3FrNlKmGFvR6VxIZMOyXvpLofHucjJ5SWli
 eYjPklKcXaMftt1FVO4n+EKj1k1+Tv14nytq/J5WN+r4FBlNEYj/6vg=
 -----END PRIVATE KEY-----
 `
-	publicKeyPEM = `-----BEGIN PUBLIC KEY-----
-MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEAvMAHb0IoLvoYuW2kA+LT
-mnk+hfnBq1eYIh4CT/rMPCxgtzjqU0guQOMnLg69ydyA5uu37v6rbS1+stuBTEiM
-Ql/bxAhgLkGrUhgpZ10Bt6GzSEgwQNloZoGaxe4p20wMPpT4kcMKNHkQds3uONNc
-LxPUmfjbbH64g+seg28pbgQPwKFKtF7bIsOBgz0g5Ptn5mrkdzqMPUSy9k9VCu+R
-42LH9c75JsRzz4FeN+VzwMAL6yQnZvOi7/zOgNyxeVia8XVKykrnhgcpiOn5oaLR
-BzQGN00Z7TuBRIfDJWU21qQN4Cq7keZmMP4gqCVWjYneK4bzrG/+H2w9BJ2TsmMG
-vwIDAQAB
------END PUBLIC KEY-----`
 )
@@ -64,3 +64,285 @@ CREATE TABLE homes (
     PRIMARY KEY (home_id),
     FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
 );
+
+-- Version: 1.05
+-- Description: Create table outbox
+CREATE TABLE outbox (
+    outbox_id       UUID       NOT NULL,
+    domain          TEXT       NOT NULL,
+    action          TEXT       NOT NULL,
+    params          JSONB      NOT NULL,
+    attempts        INT        NOT NULL,
+    last_error      TEXT       NULL,
+    date_created    TIMESTAMP  NOT NULL,
+    date_available  TIMESTAMP  NOT NULL,
+    date_delivered  TIMESTAMP  NULL,
+
+    PRIMARY KEY (outbox_id)
+);
+
+CREATE INDEX outbox_pending_idx ON outbox (date_available) WHERE date_delivered IS NULL;
+
+-- Version: 1.06
+-- Description: Create table dead_letters
+CREATE TABLE dead_letters (
+    dead_letter_id  UUID       NOT NULL,
+    domain          TEXT       NOT NULL,
+    action          TEXT       NOT NULL,
+    params          JSONB      NOT NULL,
+    func            TEXT       NOT NULL,
+    attempts        INT        NOT NULL,
+    error           TEXT       NOT NULL,
+    date_created    TIMESTAMP  NOT NULL,
+    date_replayed   TIMESTAMP  NULL,
+
+    PRIMARY KEY (dead_letter_id)
+);
+
+-- Version: 1.07
+-- Description: Add soft delete support to users, products and homes
+ALTER TABLE users ADD COLUMN date_deleted TIMESTAMP NULL;
+ALTER TABLE products ADD COLUMN date_deleted TIMESTAMP NULL;
+ALTER TABLE homes ADD COLUMN date_deleted TIMESTAMP NULL;
+
+CREATE OR REPLACE VIEW view_products AS
+SELECT
+    p.product_id,
+    p.user_id,
+    p.name,
+    p.cost,
+    p.quantity,
+    p.date_created,
+    p.date_updated,
+    u.name AS user_name
+FROM
+    products AS p
+JOIN
+    users AS u ON u.user_id = p.user_id
+WHERE
+    p.date_deleted IS NULL;
+
+-- Version: 1.08
+-- Description: Add version column for optimistic concurrency control
+ALTER TABLE users ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE products ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE homes ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- Version: 1.09
+-- Description: Create table audits
+CREATE TABLE audits (
+    audit_id    UUID       NOT NULL,
+    actor_id    UUID       NOT NULL,
+    domain      TEXT       NOT NULL,
+    action      TEXT       NOT NULL,
+    entity_id   UUID       NOT NULL,
+    diff        JSONB      NOT NULL,
+    trace_id    TEXT       NOT NULL,
+    timestamp   TIMESTAMP  NOT NULL,
+
+    PRIMARY KEY (audit_id)
+);
+
+CREATE INDEX audits_actor_id_idx ON audits (actor_id);
+CREATE INDEX audits_entity_id_idx ON audits (entity_id);
+CREATE INDEX audits_timestamp_idx ON audits (timestamp);
+
+-- Version: 1.10
+-- Description: Create table refresh_tokens
+CREATE TABLE refresh_tokens (
+    token_id      UUID       NOT NULL,
+    family_id     UUID       NOT NULL,
+    user_id       UUID       NOT NULL,
+    token_hash    BYTEA      NOT NULL,
+    date_created  TIMESTAMP  NOT NULL,
+    date_expires  TIMESTAMP  NOT NULL,
+    date_used     TIMESTAMP  NULL,
+    date_revoked  TIMESTAMP  NULL,
+
+    PRIMARY KEY (token_id),
+    UNIQUE (token_hash),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+-- Version: 1.11
+-- Description: Create table revoked_tokens
+CREATE TABLE revoked_tokens (
+    token_id      TEXT       NOT NULL,
+    user_id       TEXT       NOT NULL,
+    date_expires  TIMESTAMP  NULL,
+    date_revoked  TIMESTAMP  NOT NULL,
+
+    PRIMARY KEY (token_id)
+);
+
+CREATE INDEX revoked_tokens_date_expires_idx ON revoked_tokens (date_expires);
+
+-- Version: 1.12
+-- Description: Create table roles
+CREATE TABLE roles (
+    name          TEXT       NOT NULL,
+    description   TEXT       NOT NULL,
+    permissions   TEXT[]     NOT NULL,
+    built_in      BOOLEAN    NOT NULL,
+    date_created  TIMESTAMP  NOT NULL,
+    date_updated  TIMESTAMP  NOT NULL,
+
+    PRIMARY KEY (name)
+);
+
+INSERT INTO roles (name, description, permissions, built_in, date_created, date_updated) VALUES
+	('ADMIN', 'Administers the service', '{roles:read,roles:write}', true, NOW(), NOW()),
+	('USER', 'Manages their own data', '{}', true, NOW(), NOW()),
+	('MANAGER', 'Manages the users of their department', '{}', true, NOW(), NOW());
+
+-- Version: 1.13
+-- Description: Create table organizations and scope users, products, homes and audits to them
+CREATE TABLE organizations (
+    organization_id  UUID       NOT NULL,
+    name             TEXT       NOT NULL,
+    date_created     TIMESTAMP  NOT NULL,
+    date_updated     TIMESTAMP  NOT NULL,
+
+    PRIMARY KEY (organization_id),
+    UNIQUE (name)
+);
+
+INSERT INTO organizations (organization_id, name, date_created, date_updated) VALUES
+	('00000000-0000-0000-0000-000000000001', 'Default', NOW(), NOW());
+
+ALTER TABLE users ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(organization_id);
+ALTER TABLE users ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE products ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(organization_id);
+ALTER TABLE products ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE homes ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(organization_id);
+ALTER TABLE homes ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE audits ADD COLUMN tenant_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(organization_id);
+ALTER TABLE audits ALTER COLUMN tenant_id DROP DEFAULT;
+
+CREATE INDEX users_tenant_id_idx ON users (tenant_id);
+CREATE INDEX products_tenant_id_idx ON products (tenant_id);
+CREATE INDEX homes_tenant_id_idx ON homes (tenant_id);
+CREATE INDEX audits_tenant_id_idx ON audits (tenant_id);
+
+CREATE OR REPLACE VIEW view_products AS
+SELECT
+    p.product_id,
+    p.user_id,
+    p.name,
+    p.cost,
+    p.quantity,
+    p.date_created,
+    p.date_updated,
+    u.name AS user_name,
+    p.tenant_id
+FROM
+    products AS p
+JOIN
+    users AS u ON u.user_id = p.user_id
+WHERE
+    p.date_deleted IS NULL;
+
+INSERT INTO roles (name, description, permissions, built_in, date_created, date_updated) VALUES
+	('SUPER_ADMIN', 'Administers every organization', '{}', true, NOW(), NOW());
+
+-- Version: 1.14
+-- Description: Create table api_keys
+CREATE TABLE api_keys (
+    api_key_id      UUID       NOT NULL,
+    tenant_id       UUID       NOT NULL,
+    user_id         UUID       NOT NULL,
+    name            TEXT       NOT NULL,
+    prefix          TEXT       NOT NULL,
+    key_hash        BYTEA      NOT NULL,
+    scopes          TEXT[]     NOT NULL,
+    date_created    TIMESTAMP  NOT NULL,
+    date_expires    TIMESTAMP  NULL,
+    date_last_used  TIMESTAMP  NULL,
+    date_revoked    TIMESTAMP  NULL,
+
+    PRIMARY KEY (api_key_id),
+    UNIQUE (prefix),
+    FOREIGN KEY (tenant_id) REFERENCES organizations(organization_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX api_keys_tenant_id_idx ON api_keys (tenant_id);
+
+-- Version: 1.15
+-- Description: Create table user_identities
+CREATE TABLE user_identities (
+    issuer        TEXT       NOT NULL,
+    subject       TEXT       NOT NULL,
+    user_id       UUID       NOT NULL,
+    tenant_id     UUID       NOT NULL,
+    date_created  TIMESTAMP  NOT NULL,
+
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (tenant_id) REFERENCES organizations(organization_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX user_identities_user_id_idx ON user_identities (user_id);
+
+-- Version: 1.16
+-- Description: Create table rate_limits
+CREATE TABLE rate_limits (
+    key           TEXT              NOT NULL,
+    tokens        DOUBLE PRECISION  NOT NULL,
+    allowed       BOOLEAN           NOT NULL,
+    date_updated  TIMESTAMP         NOT NULL,
+
+    PRIMARY KEY (key)
+);
+
+CREATE INDEX rate_limits_date_updated_idx ON rate_limits (date_updated);
+
+-- Version: 1.17
+-- Description: Track failed logins of users and sources
+ALTER TABLE users ADD COLUMN failed_logins INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN date_locked_until TIMESTAMP NULL;
+
+CREATE TABLE login_sources (
+    source             TEXT       NOT NULL,
+    failed_logins      INT        NOT NULL,
+    date_locked_until  TIMESTAMP  NULL,
+    date_updated       TIMESTAMP  NOT NULL,
+
+    PRIMARY KEY (source)
+);
+
+-- Version: 1.18
+-- Description: Create table idempotency_keys
+CREATE TABLE idempotency_keys (
+    subject          TEXT       NOT NULL,
+    idempotency_key  TEXT       NOT NULL,
+    fingerprint      TEXT       NOT NULL,
+    status_code      INT        NOT NULL,
+    response_header  JSONB      NOT NULL,
+    response_body    BYTEA      NOT NULL,
+    date_created     TIMESTAMP  NOT NULL,
+    date_expires     TIMESTAMP  NOT NULL,
+
+    PRIMARY KEY (subject, idempotency_key)
+);
+
+CREATE INDEX idempotency_keys_date_expires_idx ON idempotency_keys (date_expires);
+
+-- Version: 1.19
+-- Description: Only require emails of users that aren't deleted to be unique
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+
+CREATE UNIQUE INDEX users_email_idx ON users (email) WHERE date_deleted IS NULL;
+
+-- Version: 1.20
+-- Description: Scope API keys to the permissions of the roles they were scoped to
+UPDATE api_keys AS k SET scopes = COALESCE(
+    (SELECT array_agg(DISTINCT p ORDER BY p) FROM roles AS r, unnest(r.permissions) AS p WHERE r.name = ANY(k.scopes)),
+    '{}'
+);
+
+-- Version: 1.21
+-- Description: Drop the roles:write permission nothing checks anymore
+UPDATE roles SET permissions = array_remove(permissions, 'roles:write') WHERE name = 'ADMIN';
@@ -151,6 +151,37 @@ func NamedExecContext(ctx context.Context, log *logger.Logger, db sqlx.ExtContex
 	return nil
 }
 
+// NamedExecContextAffected is a helper function to execute a CUD operation with
+// logging and tracing where field replacement is necessary. It returns the
+// number of rows affected by the operation.
+func NamedExecContextAffected(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any) (affected int64, err error) {
+	q := queryString(query, data)
+
+	defer func() {
+		if err != nil {
+			log.Infoc(ctx, 5, "database.NamedExecContextAffected", "query", q, "ERROR", err)
+		}
+	}()
+
+	ctx, span := web.AddSpan(ctx, "business.sys.database.exec", attribute.String("query", q))
+	defer span.End()
+
+	result, err := sqlx.NamedExecContext(ctx, db, query, data)
+	if err != nil {
+		if pqerr, ok := err.(*pgconn.PgError); ok {
+			switch pqerr.Code {
+			case undefinedTable:
+				return 0, ErrUndefinedTable
+			case uniqueViolation:
+				return 0, ErrDBDuplicatedEntry
+			}
+		}
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
 // QuerySlice is a helper function for executing queries that return a
 // collection of data to be unmarshalled into a slice.
 func QuerySlice[T any](ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, dest *[]T) error {
@@ -227,6 +258,100 @@ func namedQuerySlice[T any](ctx context.Context, log *logger.Logger, db sqlx.Ext
 	return nil
 }
 
+// cursorFetchSize is the number of rows fetched from a server-side cursor
+// at a time.
+const cursorFetchSize = 500
+
+// cursorName is the name of the server-side cursor NamedQueryEach declares.
+const cursorName = "query_each"
+
+// NamedQueryEach is a helper function for executing queries that return a
+// collection of data too large to be held in memory where field replacement
+// is necessary. The rows are read through a server-side cursor and passed to
+// the function one at a time. It stops at the first error returned by the
+// function or once the context is canceled.
+func NamedQueryEach[T any](ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, data any, fn func(T) error) (err error) {
+	q := queryString(query, data)
+
+	defer func() {
+		if err != nil && ctx.Err() == nil {
+			log.Infoc(ctx, 5, "database.NamedQueryEach", "query", q, "ERROR", err)
+		}
+	}()
+
+	ctx, span := web.AddSpan(ctx, "business.sys.database.queryeach", attribute.String("query", q))
+	defer span.End()
+
+	// A cursor only lives as long as the transaction it's declared in, a
+	// read only one is used when the query isn't executed under one.
+	tx, ok := db.(*sqlx.Tx)
+	if !ok {
+		bgn, ok := db.(interface {
+			BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+		})
+		if !ok {
+			return errors.New("database can't begin a transaction")
+		}
+
+		tx, err = bgn.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+	}
+
+	named, args, err := sqlx.Named(query, data)
+	if err != nil {
+		return err
+	}
+
+	declare := fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR %s", cursorName, tx.Rebind(named))
+	if _, err := tx.ExecContext(ctx, declare, args...); err != nil {
+		if pqerr, ok := err.(*pgconn.PgError); ok && pqerr.Code == undefinedTable {
+			return ErrUndefinedTable
+		}
+		return err
+	}
+	defer tx.ExecContext(context.WithoutCancel(ctx), "CLOSE "+cursorName)
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", cursorFetchSize, cursorName)
+
+	fetchNext := func() (int, error) {
+		rows, err := tx.QueryxContext(ctx, fetch)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+
+		var n int
+		for rows.Next() {
+			n++
+
+			var v T
+			if err := rows.StructScan(&v); err != nil {
+				return n, err
+			}
+
+			if err := fn(v); err != nil {
+				return n, err
+			}
+		}
+
+		return n, rows.Err()
+	}
+
+	for {
+		n, err := fetchNext()
+		if err != nil {
+			return err
+		}
+
+		if n < cursorFetchSize {
+			return nil
+		}
+	}
+}
+
 // QueryStruct is a helper function for executing queries that return a
 // single value to be unmarshalled into a struct type where field replacement is necessary.
 func QueryStruct(ctx context.Context, log *logger.Logger, db sqlx.ExtContext, query string, dest any) error {
@@ -300,6 +425,14 @@ func namedQueryStruct(ctx context.Context, log *logger.Logger, db sqlx.ExtContex
 	return nil
 }
 
+// EscapeLike escapes the characters that have a special meaning in a LIKE
+// pattern so the value is matched literally.
+func EscapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // queryString provides a pretty print version of the query and parameters.
 func queryString(query string, args any) string {
 	query, params, err := sqlx.Named(query, args)
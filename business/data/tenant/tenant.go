@@ -0,0 +1,98 @@
+// Package tenant provides support for scoping database access to the tenant,
+// the organization, a request acts for. The tenant is carried in the context
+// and every store binds it to its queries, so rows of other tenants are never
+// read or changed. Only a context marked for every tenant reaches across.
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// DefaultID is the ID of the organization created by the migration that added
+// tenants. The data that existed before belongs to it.
+var DefaultID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// Set of error variables for tenant scoping.
+var (
+	ErrMissing   = errors.New("tenant missing from context")
+	ErrForbidden = errors.New("tenant can't be accessed")
+)
+
+// Clause restricts a query to the rows of the scope bound to the query. The
+// table being queried has to have a tenant_id column.
+const Clause = "(:scope_all OR tenant_id = :scope_tenant_id)"
+
+type ctxKey int
+
+const scopeKey ctxKey = 1
+
+// Scope represents the tenants a context can access. It's bound to queries
+// for use by Clause.
+type Scope struct {
+	TenantID uuid.UUID `db:"scope_tenant_id"`
+	All      bool      `db:"scope_all"`
+}
+
+// Allows reports whether the scope can access the rows of the tenant.
+func (s Scope) Allows(tenantID uuid.UUID) bool {
+	return s.All || s.TenantID == tenantID
+}
+
+// Bind adds the scope to the named parameters of a query.
+func (s Scope) Bind(data map[string]any) {
+	data["scope_tenant_id"] = s.TenantID
+	data["scope_all"] = s.All
+}
+
+// Set stores the tenant the request acts for in the context.
+func Set(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, scopeKey, Scope{TenantID: tenantID})
+}
+
+// SetAll marks the context as able to access every tenant. The tenant already
+// stored in the context, if any, is kept as the tenant new rows belong to by
+// default.
+func SetAll(ctx context.Context) context.Context {
+	s, _ := ctx.Value(scopeKey).(Scope)
+	s.All = true
+
+	return context.WithValue(ctx, scopeKey, s)
+}
+
+// GetScope returns the scope stored in the context. ErrMissing is returned
+// for a context that was never scoped, so forgetting to scope a context never
+// grants access to anything.
+func GetScope(ctx context.Context) (Scope, error) {
+	s, ok := ctx.Value(scopeKey).(Scope)
+	if !ok {
+		return Scope{}, ErrMissing
+	}
+
+	return s, nil
+}
+
+// Resolve returns the tenant a new row belongs to. The requested tenant is
+// used if set, which has to be accessible by the scope of the context,
+// otherwise the tenant of the context is used.
+func Resolve(ctx context.Context, requested uuid.UUID) (uuid.UUID, error) {
+	s, err := GetScope(ctx)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	switch {
+	case requested != uuid.Nil:
+		if !s.Allows(requested) {
+			return uuid.UUID{}, ErrForbidden
+		}
+		return requested, nil
+
+	case s.TenantID == uuid.Nil:
+		return uuid.UUID{}, ErrMissing
+	}
+
+	return s.TenantID, nil
+}
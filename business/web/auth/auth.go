@@ -5,12 +5,20 @@ package auth
 
 import (
 	"context"
+	"crypto"
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/testvergecloud/testApi/business/core/crud/revocation"
+	"github.com/testvergecloud/testApi/business/core/crud/role"
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/core/crud/user/stores/userdb"
+	"github.com/testvergecloud/testApi/business/data/tenant"
 	"github.com/testvergecloud/testApi/foundation/config"
 	"github.com/testvergecloud/testApi/foundation/logger"
 
@@ -20,13 +28,21 @@ import (
 	"github.com/open-policy-agent/opa/rego"
 )
 
-// ErrForbidden is returned when a auth issue is identified.
-var ErrForbidden = errors.New("attempted action is not allowed")
+// Set of error variables for auth operations.
+var (
+	ErrForbidden = errors.New("attempted action is not allowed")
+	ErrRevoked   = errors.New("token has been revoked")
+)
 
-// Claims represents the authorization claims transmitted via a JWT.
+// Claims represents the authorization claims transmitted via a JWT. The
+// tenant is the organization the subject belongs to. Scopes are only set on
+// the claims of an API key, they replace the permissions of the roles.
 type Claims struct {
 	jwt.RegisteredClaims
-	Roles []user.Role `json:"roles"`
+	TenantID   uuid.UUID   `json:"tenant_id"`
+	Roles      []user.Role `json:"roles"`
+	Department string      `json:"department,omitempty"`
+	Scopes     []string    `json:"scopes,omitempty"`
 }
 
 // HasRole checks if the specified role exists.
@@ -40,11 +56,14 @@ func (c Claims) HasRole(r user.Role) bool {
 }
 
 // KeyLookup declares a method set of behavior for looking up
-// private and public keys for JWT use. The return could be a
-// PEM encoded string or a JWS based key.
+// private and public keys for JWT use. The keys are returned parsed, along
+// with the algorithm of the signatures they make or verify, so no PEM is
+// decoded per token. ActiveKID returns the kid of the key new tokens are
+// signed with.
 type KeyLookup interface {
-	PrivateKey(kid string) (key string, err error)
-	PublicKey(kid string) (key string, err error)
+	SigningKey(kid string) (key crypto.PrivateKey, alg string, err error)
+	VerifyingKey(kid string) (key crypto.PublicKey, alg string, err error)
+	ActiveKID() (kid string, err error)
 }
 
 // Config represents information required to initialize auth.
@@ -60,46 +79,105 @@ type KeyLookup interface {
 type Auth struct {
 	keyLookup KeyLookup
 	usrCore   *user.Core
-	method    jwt.SigningMethod
-	parser    *jwt.Parser
+	revCore   *revocation.Core
+	roleCore  *role.Core
+	log       *logger.Logger
+	policy    atomic.Pointer[policy]
 	issuer    string
+	accessTTL time.Duration
 }
 
-// New creates an Auth to support authentication/authorization.
-func New(cfg *config.Config, db *sqlx.DB, kl KeyLookup, log *logger.Logger) (*Auth, error) {
+// New creates an Auth to support authentication/authorization. Tokens are
+// checked against the revocations held by the revocation core, which can be
+// nil when revocation isn't supported. The permissions granted by the roles of
+// the claims are resolved by the role core, which can be nil when the roles
+// grant no permissions. The policies are compiled once here
+// and the prepared queries are shared by every request. The authorization
+// policy is loaded from the configured policy folder, if any, otherwise the
+// embedded policy is used.
+func New(cfg *config.Config, db *sqlx.DB, kl KeyLookup, log *logger.Logger, revCore *revocation.Core, roleCore *role.Core) (*Auth, error) {
 	// If a database connection is not provided, we won't perform the
 	// user enabled check.
 	var usrCore *user.Core
 	if db != nil {
-		usrCore = user.NewCore(log, nil, userdb.NewStore(log, db))
+		usrCore = user.NewCore(log, nil, nil, userdb.NewStore(log, db))
 	}
 
 	a := Auth{
 		keyLookup: kl,
 		usrCore:   usrCore,
-		method:    jwt.GetSigningMethod(jwt.SigningMethodRS256.Name),
-		parser:    jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Name})),
+		revCore:   revCore,
+		roleCore:  roleCore,
+		log:       log,
 		issuer:    cfg.Auth.Issuer,
+		accessTTL: cfg.Auth.AccessTokenTTL,
+	}
+
+	if a.accessTTL <= 0 {
+		a.accessTTL = 15 * time.Minute
+	}
+
+	b, source := embeddedBundle(), PolicySourceEmbedded
+	if cfg.Auth.PolicyFolder != "" {
+		var err error
+		if b, err = readBundle(os.DirFS(cfg.Auth.PolicyFolder)); err != nil {
+			return nil, fmt.Errorf("reading policy bundle: %w", err)
+		}
+		source = PolicySourceBundle
 	}
 
+	p, err := newPolicy(context.Background(), b, source)
+	if err != nil {
+		return nil, fmt.Errorf("preparing policies: %w", err)
+	}
+	a.policy.Store(p)
+
 	return &a, nil
 }
 
-// GenerateToken generates a signed JWT token string representing the user Claims.
-func (a *Auth) GenerateToken(kid string, claims Claims) (string, error) {
-	token := jwt.NewWithClaims(a.method, claims)
-	token.Header["kid"] = kid
+// AccessTokenTTL returns how long the access tokens generated by default are
+// valid for.
+func (a *Auth) AccessTokenTTL() time.Duration {
+	return a.accessTTL
+}
+
+// GenerateToken generates a signed JWT token string representing the user
+// Claims. Unless the claims say otherwise the token is a short lived access
+// token issued now, with a unique ID so it can be told apart from others. The
+// token is signed with the newest active key, using the signing method that
+// follows the type of the key.
+func (a *Auth) GenerateToken(claims Claims) (string, error) {
+	now := time.Now().UTC()
 
-	privateKeyPEM, err := a.keyLookup.PrivateKey(kid)
+	if claims.Issuer == "" {
+		claims.Issuer = a.issuer
+	}
+
+	if claims.IssuedAt == nil {
+		claims.IssuedAt = jwt.NewNumericDate(now)
+	}
+
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(a.accessTTL))
+	}
+
+	if claims.ID == "" {
+		claims.ID = uuid.NewString()
+	}
+
+	kid, err := a.keyLookup.ActiveKID()
 	if err != nil {
-		return "", fmt.Errorf("private key: %w", err)
+		return "", fmt.Errorf("active kid: %w", err)
 	}
 
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
+	privateKey, alg, err := a.keyLookup.SigningKey(kid)
 	if err != nil {
-		return "", fmt.Errorf("parsing private pem: %w", err)
+		return "", fmt.Errorf("private key: %w", err)
 	}
 
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(alg), claims)
+	token.Header["kid"] = kid
+
 	str, err := token.SignedString(privateKey)
 	if err != nil {
 		return "", fmt.Errorf("signing token: %w", err)
@@ -110,46 +188,84 @@ func (a *Auth) GenerateToken(kid string, claims Claims) (string, error) {
 
 // Authenticate processes the token to validate the sender's token is valid.
 func (a *Auth) Authenticate(ctx context.Context, bearerToken string) (Claims, error) {
+	claims, err := a.Identify(bearerToken)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	input := map[string]any{
+		"Claims": claims,
+		"ISS":    a.issuer,
+	}
+
+	if err := a.opaPolicyEvaluation(ctx, RuleAuthenticate, input); err != nil {
+		return Claims{}, fmt.Errorf("authentication failed : %w", err)
+	}
+
+	revoked, err := a.revCore.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return Claims{}, fmt.Errorf("checking revocation: %w", err)
+	}
+
+	if revoked {
+		return Claims{}, ErrRevoked
+	}
+
+	// Check the database for this user to verify they are still enabled.
+
+	if err := a.isUserEnabled(ctx, claims); err != nil {
+		return Claims{}, fmt.Errorf("user not enabled : %w", err)
+	}
+
+	return claims, nil
+}
+
+// Identify verifies the token was signed by this service and returns its
+// claims. Unlike Authenticate it doesn't check the token was revoked or its
+// user is still enabled, so it tells callers apart without hitting the
+// database but must not be used to let them in.
+func (a *Auth) Identify(bearerToken string) (Claims, error) {
 	parts := strings.Split(bearerToken, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
 		return Claims{}, errors.New("expected authorization header format: Bearer <token>")
 	}
 
 	var claims Claims
-	token, _, err := a.parser.ParseUnverified(parts[1], &claims)
-	if err != nil {
-		return Claims{}, fmt.Errorf("error parsing token: %w", err)
+	if err := a.verify(parts[1], &claims); err != nil {
+		return Claims{}, err
 	}
 
-	kidRaw, exists := token.Header["kid"]
-	if !exists {
-		return Claims{}, fmt.Errorf("kid missing from header: %w", err)
-	}
+	return claims, nil
+}
 
-	kid, ok := kidRaw.(string)
-	if !ok {
-		return Claims{}, fmt.Errorf("kid malformed: %w", err)
+// Revoke verifies the token was signed by this service and revokes it until
+// it expires. Tokens that already expired are left alone.
+func (a *Auth) Revoke(ctx context.Context, token string) (Claims, error) {
+	if a.revCore == nil {
+		return Claims{}, errors.New("token revocation is not supported")
 	}
 
-	pem, err := a.keyLookup.PublicKey(kid)
-	if err != nil {
-		return Claims{}, fmt.Errorf("failed to fetch public key: %w", err)
+	// The claims aren't validated since a token that isn't valid yet can be
+	// revoked too, all that matters is that it was signed by this service.
+	var claims Claims
+	if err := a.verify(token, &claims, jwt.WithoutClaimsValidation()); err != nil {
+		return Claims{}, err
 	}
 
-	input := map[string]any{
-		"Key":   pem,
-		"Token": parts[1],
-		"ISS":   a.issuer,
+	rev := revocation.Revocation{
+		TokenID: claims.ID,
+		Subject: claims.Subject,
 	}
 
-	if err := a.opaPolicyEvaluation(ctx, opaAuthentication, RuleAuthenticate, input); err != nil {
-		return Claims{}, fmt.Errorf("authentication failed : %w", err)
+	if claims.ExpiresAt != nil {
+		rev.DateExpires = claims.ExpiresAt.Time
+		if !time.Now().Before(rev.DateExpires) {
+			return claims, nil
+		}
 	}
 
-	// Check the database for this user to verify they are still enabled.
-
-	if err := a.isUserEnabled(ctx, claims); err != nil {
-		return Claims{}, fmt.Errorf("user not enabled : %w", err)
+	if err := a.revCore.Revoke(ctx, rev); err != nil {
+		return Claims{}, fmt.Errorf("revoke: %w", err)
 	}
 
 	return claims, nil
@@ -157,32 +273,121 @@ func (a *Auth) Authenticate(ctx context.Context, bearerToken string) (Claims, er
 
 // Authorize attempts to authorize the user with the provided input roles, if
 // none of the input roles are within the user's claims, we return an error
-// otherwise the user is authorized.
-func (a *Auth) Authorize(ctx context.Context, claims Claims, userID uuid.UUID, rule string) error {
-	input := map[string]any{
-		"Roles":   claims.Roles,
-		"Subject": claims.Subject,
-		"UserID":  userID,
+// otherwise the user is authorized. The rule can also reason about the
+// resource the request acts on and the request itself.
+func (a *Auth) Authorize(ctx context.Context, claims Claims, rule string, res Resource, req Request) error {
+	input := authorizeInput{
+		Roles:       claims.Roles,
+		Permissions: a.permissions(claims),
+		Subject:     claims.Subject,
+		Department:  claims.Department,
+		UserID:      res.OwnerID,
+		Resource:    res,
+		Request:     req,
 	}
 
-	if err := a.opaPolicyEvaluation(ctx, opaAuthorization, rule, input); err != nil {
+	if err := a.opaPolicyEvaluation(ctx, rule, input); err != nil {
 		return fmt.Errorf("rego evaluation failed : %w", err)
 	}
 
 	return nil
 }
 
-// opaPolicyEvaluation asks opa to evaluate the token against the specified token
-// policy and public key.
-func (a *Auth) opaPolicyEvaluation(ctx context.Context, opaPolicy string, rule string, input any) error {
-	query := fmt.Sprintf("x = data.%s.%s", opaPackage, rule)
+// Permissions returns the permissions granted by the roles.
+func (a *Auth) Permissions(roles []user.Role) []string {
+	return a.roleCore.Permissions(roles)
+}
+
+// permissions returns the permissions of the claims. Claims with scopes come
+// from an API key and are granted its scopes instead of those of the roles.
+func (a *Auth) permissions(claims Claims) []string {
+	if claims.Scopes != nil {
+		return claims.Scopes
+	}
+
+	return a.Permissions(claims.Roles)
+}
+
+// Scope returns the context scoped to the tenant of the claims. Claims let
+// through by the super admin rule can access every tenant.
+func (a *Auth) Scope(ctx context.Context, claims Claims, req Request) context.Context {
+	ctx = tenant.Set(ctx, claims.TenantID)
+
+	if err := a.Authorize(ctx, claims, RuleSuperAdmin, Resource{}, req); err == nil {
+		ctx = tenant.SetAll(ctx)
+	}
+
+	return ctx
+}
+
+// PolicyRevision returns the revision of the authorization policy in use.
+func (a *Auth) PolicyRevision() PolicyRevision {
+	return a.policy.Load().revision
+}
+
+// WatchPolicy reloads the authorization policy from the policy bundle rooted
+// in the directory whenever it changes, checking every interval until the
+// context is cancelled. A bundle that fails to compile is reported and the
+// last good policy stays in use. An interval of zero disables reloading.
+func (a *Auth) WatchPolicy(ctx context.Context, fsys fs.FS, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// The same broken bundle is only reported once.
+	var lastErr string
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := a.reloadPolicy(ctx, fsys)
+		switch {
+		case err == nil:
+			lastErr = ""
+
+		case err.Error() != lastErr:
+			lastErr = err.Error()
+			a.log.Error(ctx, "auth", "status", "policy reload failed", "revision", a.PolicyRevision().Revision, "msg", err)
+		}
+	}
+}
+
+// reloadPolicy replaces the authorization policy if the bundle changed and
+// compiles.
+func (a *Auth) reloadPolicy(ctx context.Context, fsys fs.FS) error {
+	b, err := readBundle(fsys)
+	if err != nil {
+		return fmt.Errorf("reading policy bundle: %w", err)
+	}
+
+	if b.digest == a.policy.Load().digest {
+		return nil
+	}
 
-	q, err := rego.New(
-		rego.Query(query),
-		rego.Module("policy.rego", opaPolicy),
-	).PrepareForEval(ctx)
+	p, err := newPolicy(ctx, b, PolicySourceBundle)
 	if err != nil {
-		return err
+		return fmt.Errorf("preparing policies: revision[%s]: %w", b.revision, err)
+	}
+	a.policy.Store(p)
+
+	a.log.Info(ctx, "auth", "status", "policy reloaded", "revision", p.revision.Revision, "rules", p.revision.Rules)
+
+	return nil
+}
+
+// opaPolicyEvaluation asks opa to evaluate the input against the prepared
+// query of the specified rule.
+func (a *Auth) opaPolicyEvaluation(ctx context.Context, rule string, input any) error {
+	q, exists := a.policy.Load().queries[rule]
+	if !exists {
+		return fmt.Errorf("unknown rule %q", rule)
 	}
 
 	results, err := q.Eval(ctx, rego.EvalInput(input))
@@ -202,6 +407,37 @@ func (a *Auth) opaPolicyEvaluation(ctx context.Context, opaPolicy string, rule s
 	return nil
 }
 
+// verify parses the token and checks it was signed with the key matching its
+// kid. The only signing method allowed is the one that follows the type of
+// that key, so a token can't pick another algorithm.
+func (a *Auth) verify(token string, claims *Claims, options ...jwt.ParserOption) error {
+	unverified, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	if err != nil {
+		return fmt.Errorf("error parsing token: %w", err)
+	}
+
+	kid, ok := unverified.Header["kid"].(string)
+	if !ok {
+		return fmt.Errorf("kid missing from header: %w", jwt.ErrTokenUnverifiable)
+	}
+
+	key, alg, err := a.keyLookup.VerifyingKey(kid)
+	if err != nil {
+		return fmt.Errorf("failed to fetch public key: %s: %w", err, jwt.ErrTokenUnverifiable)
+	}
+
+	options = append(options, jwt.WithValidMethods([]string{alg}))
+	keyFunc := func(*jwt.Token) (any, error) {
+		return key, nil
+	}
+
+	if _, err := jwt.NewParser(options...).ParseWithClaims(token, claims, keyFunc); err != nil {
+		return fmt.Errorf("error verifying token: %w", err)
+	}
+
+	return nil
+}
+
 // isUserEnabled hits the database and checks the user is not disabled. If the
 // no database connection was provided, this check is skipped.
 func (a *Auth) isUserEnabled(ctx context.Context, claims Claims) error {
@@ -214,6 +450,9 @@ func (a *Auth) isUserEnabled(ctx context.Context, claims Claims) error {
 		return fmt.Errorf("parse user: %w", err)
 	}
 
+	// The user has to belong to the tenant of the claims.
+	ctx = tenant.Set(ctx, claims.TenantID)
+
 	if _, err := a.usrCore.QueryByID(ctx, userID); err != nil {
 		return fmt.Errorf("query user: %w", err)
 	}
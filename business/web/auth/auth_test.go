@@ -3,14 +3,26 @@ package auth_test
 import (
 	"bytes"
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime/debug"
+	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/testvergecloud/testApi/business/core/crud/user"
 	"github.com/testvergecloud/testApi/business/web/auth"
 	"github.com/testvergecloud/testApi/foundation/config"
+	"github.com/testvergecloud/testApi/foundation/keystore"
 	"github.com/testvergecloud/testApi/foundation/logger"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -34,7 +46,7 @@ func Test_Auth(t *testing.T) {
 		},
 	}
 
-	a, err := auth.New(cfg, db, &keyStore{}, log)
+	a, err := auth.New(cfg, db, newKeyStore(t, kid, privateKeyPEM), log, nil, nil)
 	if err != nil {
 		t.Fatalf("Should be able to create an authenticator: %s", err)
 	}
@@ -50,7 +62,7 @@ func Test_Auth(t *testing.T) {
 	}
 	userID := uuid.MustParse(claims.Subject)
 
-	token, err := a.GenerateToken(kid, claims)
+	token, err := a.GenerateToken(claims)
 	if err != nil {
 		t.Fatalf("Should be able to generate a JWT : %s", err)
 	}
@@ -60,17 +72,17 @@ func Test_Auth(t *testing.T) {
 		t.Fatalf("Should be able to authenticate the claims : %s", err)
 	}
 
-	err = a.Authorize(context.Background(), parsedClaims, userID, auth.RuleAdminOnly)
+	err = a.Authorize(context.Background(), parsedClaims, auth.RuleAdminOnly, auth.Resource{OwnerID: userID}, auth.Request{})
 	if err != nil {
 		t.Errorf("Should be able to authorize the RoleAdmin claims : %s", err)
 	}
 
-	err = a.Authorize(context.Background(), parsedClaims, userID, auth.RuleUserOnly)
+	err = a.Authorize(context.Background(), parsedClaims, auth.RuleUserOnly, auth.Resource{OwnerID: userID}, auth.Request{})
 	if err == nil {
 		t.Error("Should NOT be able to authorize the RoleUser claim")
 	}
 
-	err = a.Authorize(context.Background(), parsedClaims, userID, auth.RuleAdminOrSubject)
+	err = a.Authorize(context.Background(), parsedClaims, auth.RuleAdminOrSubject, auth.Resource{OwnerID: userID}, auth.Request{})
 	if err != nil {
 		t.Errorf("Should be able to authorize the RuleAdminOrSubject claim with RoleAdmin only : %s", err)
 	}
@@ -88,7 +100,7 @@ func Test_Auth(t *testing.T) {
 	}
 	userID = uuid.MustParse(claims.Subject)
 
-	token, err = a.GenerateToken(kid, claims)
+	token, err = a.GenerateToken(claims)
 	if err != nil {
 		t.Fatalf("Should be able to generate a JWT : %v", err)
 	}
@@ -98,22 +110,22 @@ func Test_Auth(t *testing.T) {
 		t.Fatalf("Should be able to authenticate the claims : %s", err)
 	}
 
-	err = a.Authorize(context.Background(), parsedClaims, userID, auth.RuleUserOnly)
+	err = a.Authorize(context.Background(), parsedClaims, auth.RuleUserOnly, auth.Resource{OwnerID: userID}, auth.Request{})
 	if err != nil {
 		t.Errorf("Should be able to authorize the RuleUserOnly claim with RoleUser only : %s", err)
 	}
 
-	err = a.Authorize(context.Background(), parsedClaims, userID, auth.RuleAdminOnly)
+	err = a.Authorize(context.Background(), parsedClaims, auth.RuleAdminOnly, auth.Resource{OwnerID: userID}, auth.Request{})
 	if err == nil {
 		t.Error("Should NOT be able to authorize the RuleAdminOnly claim with RoleUser only")
 	}
 
-	err = a.Authorize(context.Background(), parsedClaims, userID, auth.RuleAdminOrSubject)
+	err = a.Authorize(context.Background(), parsedClaims, auth.RuleAdminOrSubject, auth.Resource{OwnerID: userID}, auth.Request{})
 	if err != nil {
 		t.Errorf("Should be able to authorize the RuleAdminOrSubject claim with RoleUser only : %s", err)
 	}
 
-	err = a.Authorize(context.Background(), parsedClaims, userID, auth.RuleAny)
+	err = a.Authorize(context.Background(), parsedClaims, auth.RuleAny, auth.Resource{OwnerID: userID}, auth.Request{})
 	if err != nil {
 		t.Errorf("Should be able to authorize the RuleAny any claim with RoleUser only : %s", err)
 	}
@@ -131,7 +143,7 @@ func Test_Auth(t *testing.T) {
 	}
 	userID = uuid.MustParse("9e979baa-61c9-4b50-81f2-f216d53f5c15")
 
-	token, err = a.GenerateToken(kid, claims)
+	token, err = a.GenerateToken(claims)
 	if err != nil {
 		t.Fatalf("Should be able to generate a JWT : %s", err)
 	}
@@ -141,7 +153,7 @@ func Test_Auth(t *testing.T) {
 		t.Fatalf("Should be able to authenticate the claims : %s", err)
 	}
 
-	err = a.Authorize(context.Background(), parsedClaims, userID, auth.RuleAdminOrSubject)
+	err = a.Authorize(context.Background(), parsedClaims, auth.RuleAdminOrSubject, auth.Resource{OwnerID: userID}, auth.Request{})
 	if err == nil {
 		t.Error("Should NOT be able to authorize the RuleAdminOrSubject claim with RoleUser only and different userID")
 	}
@@ -159,7 +171,7 @@ func Test_Auth(t *testing.T) {
 	}
 	userID = uuid.MustParse("9e979baa-61c9-4b50-81f2-f216d53f5c15")
 
-	token, err = a.GenerateToken(kid, claims)
+	token, err = a.GenerateToken(claims)
 	if err != nil {
 		t.Fatalf("Should be able to generate a JWT : %s", err)
 	}
@@ -169,7 +181,7 @@ func Test_Auth(t *testing.T) {
 		t.Fatalf("Should be able to authenticate the claims : %s", err)
 	}
 
-	err = a.Authorize(context.Background(), parsedClaims, userID, auth.RuleAny)
+	err = a.Authorize(context.Background(), parsedClaims, auth.RuleAny, auth.Resource{OwnerID: userID}, auth.Request{})
 	if err != nil {
 		t.Errorf("Should be able to authorize the RuleAny any claim with RoleUser and RoleAdmin : %s", err)
 	}
@@ -187,7 +199,7 @@ func Test_Auth(t *testing.T) {
 	}
 	userID = uuid.MustParse("9e979baa-61c9-4b50-81f2-f216d53f5c15")
 
-	token, err = a.GenerateToken(kid, claims)
+	token, err = a.GenerateToken(claims)
 	if err != nil {
 		t.Fatalf("Should be able to generate a JWT : %s", err)
 	}
@@ -197,7 +209,7 @@ func Test_Auth(t *testing.T) {
 		t.Fatalf("Should be able to authenticate the claims : %s", err)
 	}
 
-	err = a.Authorize(context.Background(), parsedClaims, userID, auth.RuleAny)
+	err = a.Authorize(context.Background(), parsedClaims, auth.RuleAny, auth.Resource{OwnerID: userID}, auth.Request{})
 	if err != nil {
 		t.Errorf("Should be able to authorize the RuleAny any claim with RoleUser only : %s", err)
 	}
@@ -215,7 +227,7 @@ func Test_Auth(t *testing.T) {
 	}
 	userID = uuid.MustParse("9e979baa-61c9-4b50-81f2-f216d53f5c15")
 
-	token, err = a.GenerateToken(kid, claims)
+	token, err = a.GenerateToken(claims)
 	if err != nil {
 		t.Fatalf("Should be able to generate a JWT : %s", err)
 	}
@@ -225,10 +237,319 @@ func Test_Auth(t *testing.T) {
 		t.Fatalf("Should be able to authenticate the claims : %s", err)
 	}
 
-	err = a.Authorize(context.Background(), parsedClaims, userID, auth.RuleAny)
+	err = a.Authorize(context.Background(), parsedClaims, auth.RuleAny, auth.Resource{OwnerID: userID}, auth.Request{})
 	if err != nil {
 		t.Errorf("Should be able to authorize the RuleAny any claim with RoleAdmin only : %s", err)
 	}
+
+	// -------------------------------------------------------------------------
+
+	claims = auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "5cf37266-3473-4006-984f-9325122678b7",
+		},
+		Roles: []user.Role{user.RoleUser},
+	}
+
+	token, err = a.GenerateToken(claims)
+	if err != nil {
+		t.Fatalf("Should be able to generate a JWT : %s", err)
+	}
+
+	parsedClaims, err = a.Authenticate(context.Background(), "Bearer "+token)
+	if err != nil {
+		t.Fatalf("Should be able to authenticate the default claims : %s", err)
+	}
+
+	if parsedClaims.ID == "" || parsedClaims.IssuedAt == nil {
+		t.Errorf("Should set the jti and iat claims : %+v", parsedClaims.RegisteredClaims)
+	}
+
+	if parsedClaims.ExpiresAt == nil || parsedClaims.ExpiresAt.Sub(parsedClaims.IssuedAt.Time) != a.AccessTokenTTL() {
+		t.Errorf("Should expire the token after the access token TTL : %+v", parsedClaims.RegisteredClaims)
+	}
+}
+
+func Test_SigningMethods(t *testing.T) {
+	log, _, teardown := newUnit(t)
+	defer teardown()
+
+	cfg := &config.Config{
+		Auth: &config.Auth{
+			Issuer: "service project",
+		},
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate an ECDSA key : %s", err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate an Ed25519 key : %s", err)
+	}
+
+	tests := []struct {
+		name string
+		key  any
+		alg  string
+	}{
+		{name: "ecdsa", key: ecKey, alg: "ES256"},
+		{name: "ed25519", key: edKey, alg: "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := newMemKeyStore(t, tt.key)
+
+			a, err := auth.New(cfg, nil, ks, log, nil, nil)
+			if err != nil {
+				t.Fatalf("Should be able to create an authenticator: %s", err)
+			}
+
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject: "5cf37266-3473-4006-984f-9325122678b7",
+				},
+				Roles: []user.Role{user.RoleUser},
+			}
+
+			token, err := a.GenerateToken(claims)
+			if err != nil {
+				t.Fatalf("Should be able to generate a JWT : %s", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &auth.Claims{})
+			if err != nil || parsed.Method.Alg() != tt.alg {
+				t.Fatalf("Should sign with the method of the key : %v", parsed.Header)
+			}
+
+			if _, err := a.Authenticate(context.Background(), "Bearer "+token); err != nil {
+				t.Fatalf("Should be able to authenticate the claims : %s", err)
+			}
+
+			// A token can't choose another method for the key of its kid.
+			forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+			forged.Header["kid"] = ks.kid
+			forgedToken, err := forged.SignedString([]byte(ks.publicPEM))
+			if err != nil {
+				t.Fatalf("Should be able to sign the forged token : %s", err)
+			}
+
+			if _, err := a.Authenticate(context.Background(), "Bearer "+forgedToken); err == nil {
+				t.Fatal("Should NOT be able to authenticate a token signed with another method")
+			}
+
+			claims.Issuer = "someone else"
+			token, err = a.GenerateToken(claims)
+			if err != nil {
+				t.Fatalf("Should be able to generate a JWT : %s", err)
+			}
+
+			if _, err := a.Authenticate(context.Background(), "Bearer "+token); err == nil {
+				t.Fatal("Should NOT be able to authenticate a token from another issuer")
+			}
+		})
+	}
+}
+
+func Test_AuthorizeResource(t *testing.T) {
+	log, _, teardown := newUnit(t)
+	defer teardown()
+
+	cfg := &config.Config{
+		Auth: &config.Auth{
+			Issuer: "service project",
+		},
+	}
+
+	a, err := auth.New(cfg, nil, newKeyStore(t, kid, privateKeyPEM), log, nil, nil)
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator: %s", err)
+	}
+
+	manager := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "5cf37266-3473-4006-984f-9325122678b7",
+		},
+		Roles:      []user.Role{user.RoleManager},
+		Department: "IT",
+	}
+
+	member := func(department string, roles ...string) auth.Resource {
+		return auth.Resource{
+			Type:    "user",
+			ID:      "45b5fbd3-755f-4379-8f07-a58d4a30fa2f",
+			OwnerID: uuid.MustParse("45b5fbd3-755f-4379-8f07-a58d4a30fa2f"),
+			Attributes: map[string]any{
+				"department": department,
+				"roles":      roles,
+			},
+		}
+	}
+
+	// The manager isn't granted any permission, granted gives the target
+	// the permissions of its roles.
+	granted := func(res auth.Resource, permissions ...string) auth.Resource {
+		res.Attributes["permissions"] = permissions
+		return res
+	}
+
+	req := auth.Request{
+		Method: "PUT",
+		Path:   "/v1/users/45b5fbd3-755f-4379-8f07-a58d4a30fa2f",
+		Time:   time.Now(),
+	}
+
+	tests := []struct {
+		name   string
+		claims auth.Claims
+		res    auth.Resource
+		allow  bool
+	}{
+		{name: "department", claims: manager, res: member("IT", "USER"), allow: true},
+		{name: "otherdepartment", claims: manager, res: member("Sales", "USER"), allow: false},
+		{name: "admin", claims: manager, res: member("IT", "ADMIN"), allow: false},
+		{name: "superadmin", claims: manager, res: member("IT", "SUPER_ADMIN"), allow: false},
+		{name: "morepermissions", claims: manager, res: granted(member("IT", "AUDITOR"), "audits:read"), allow: false},
+		{name: "nodepartment", claims: auth.Claims{RegisteredClaims: manager.RegisteredClaims, Roles: manager.Roles}, res: member("", "USER"), allow: false},
+		{name: "notmanager", claims: auth.Claims{RegisteredClaims: manager.RegisteredClaims, Roles: []user.Role{user.RoleUser}, Department: "IT"}, res: member("IT", "USER"), allow: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := a.Authorize(context.Background(), tt.claims, auth.RuleAdminSubjectOrManager, tt.res, req)
+			if tt.allow && err != nil {
+				t.Fatalf("Should be authorized : %s", err)
+			}
+			if !tt.allow && err == nil {
+				t.Fatal("Should NOT be authorized")
+			}
+		})
+	}
+}
+
+func Test_AuthorizeScopes(t *testing.T) {
+	log, _, teardown := newUnit(t)
+	defer teardown()
+
+	cfg := &config.Config{
+		Auth: &config.Auth{
+			Issuer: "service project",
+		},
+	}
+
+	a, err := auth.New(cfg, nil, newKeyStore(t, kid, privateKeyPEM), log, nil, nil)
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator: %s", err)
+	}
+
+	// The claims of an API key have no roles, only the scopes of the key.
+	key := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "5cf37266-3473-4006-984f-9325122678b7",
+		},
+		Scopes: []string{"roles:read"},
+	}
+
+	tests := []struct {
+		name       string
+		rule       string
+		permission string
+		allow      bool
+	}{
+		{name: "scope", rule: auth.RulePermission, permission: "roles:read", allow: true},
+		{name: "otherscope", rule: auth.RulePermission, permission: "roles:write", allow: false},
+		{name: "role", rule: auth.RuleAny, allow: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := a.Authorize(context.Background(), key, tt.rule, auth.Resource{}, auth.Request{Permission: tt.permission})
+			if tt.allow && err != nil {
+				t.Fatalf("Should be authorized : %s", err)
+			}
+			if !tt.allow && err == nil {
+				t.Fatal("Should NOT be authorized")
+			}
+		})
+	}
+}
+
+func Test_PolicyBundle(t *testing.T) {
+	log, _, teardown := newUnit(t)
+	defer teardown()
+
+	dir := t.TempDir()
+	writeBundle(t, dir, "r1", userOnlyDenied)
+
+	cfg := &config.Config{
+		Auth: &config.Auth{
+			Issuer:       "service project",
+			PolicyFolder: dir,
+		},
+	}
+
+	a, err := auth.New(cfg, nil, newKeyStore(t, kid, privateKeyPEM), log, nil, nil)
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator with a policy bundle : %s", err)
+	}
+
+	if rev := a.PolicyRevision(); rev.Revision != "r1" || rev.Source != auth.PolicySourceBundle {
+		t.Fatalf("Should use the policy bundle : %+v", rev)
+	}
+
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "5cf37266-3473-4006-984f-9325122678b7",
+		},
+		Roles: []user.Role{user.RoleUser},
+	}
+
+	if err := a.Authorize(context.Background(), claims, auth.RuleUserOnly, auth.Resource{}, auth.Request{}); err == nil {
+		t.Fatal("Should apply the rules of the policy bundle")
+	}
+
+	for name, module := range map[string]string{
+		"broken":        "package ardan.rego\n\nrule_any if {",
+		"missing rule":  "package ardan.rego\n\nrule_any := true",
+		"authenticates": userOnlyDenied + "\nauth := true\n",
+	} {
+		bad := t.TempDir()
+		writeBundle(t, bad, "", module)
+
+		cfg := &config.Config{Auth: &config.Auth{PolicyFolder: bad}}
+		if _, err := auth.New(cfg, nil, newKeyStore(t, kid, privateKeyPEM), log, nil, nil); err == nil {
+			t.Errorf("Should NOT be able to start with a %s policy bundle", name)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go a.WatchPolicy(ctx, os.DirFS(dir), 10*time.Millisecond)
+
+	writeBundle(t, dir, "r2", "package ardan.rego\n\nrule_any if {")
+	time.Sleep(100 * time.Millisecond)
+
+	if rev := a.PolicyRevision(); rev.Revision != "r1" {
+		t.Fatalf("Should keep the last good policy when a bundle fails to compile : %+v", rev)
+	}
+
+	writeBundle(t, dir, "r3", strings.Replace(userOnlyDenied, "default rule_user_only := false", "default rule_user_only := true", 1))
+
+	for i := 0; i < 100 && a.PolicyRevision().Revision != "r3"; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if rev := a.PolicyRevision(); rev.Revision != "r3" {
+		t.Fatalf("Should reload the policy bundle once it changes : %+v", rev)
+	}
+
+	if err := a.Authorize(context.Background(), claims, auth.RuleUserOnly, auth.Resource{}, auth.Request{}); err != nil {
+		t.Fatalf("Should apply the rules of the reloaded policy bundle : %s", err)
+	}
 }
 
 func newUnit(t *testing.T) (*logger.Logger, *sqlx.DB, func()) {
@@ -248,14 +569,72 @@ func newUnit(t *testing.T) (*logger.Logger, *sqlx.DB, func()) {
 	return log, nil, teardown
 }
 
-type keyStore struct{}
+// newKeyStore returns a key store holding the private key under the kid.
+func newKeyStore(t *testing.T, kid string, privatePEM string) *keystore.KeyStore {
+	ks := keystore.New()
+	if err := ks.LoadKeys(fstest.MapFS{kid + ".pem": {Data: []byte(privatePEM)}}); err != nil {
+		t.Fatalf("Should be able to load the keys : %s", err)
+	}
 
-func (ks *keyStore) PrivateKey(kid string) (string, error) {
-	return privateKeyPEM, nil
+	return ks
 }
 
-func (ks *keyStore) PublicKey(kid string) (string, error) {
-	return publicKeyPEM, nil
+type memKeyStore struct {
+	*keystore.KeyStore
+	kid       string
+	publicPEM string
+}
+
+func newMemKeyStore(t *testing.T, key any) *memKeyStore {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Should be able to marshal the private key : %s", err)
+	}
+
+	pub, err := x509.MarshalPKIXPublicKey(key.(crypto.Signer).Public())
+	if err != nil {
+		t.Fatalf("Should be able to marshal the public key : %s", err)
+	}
+
+	kid := uuid.NewString()
+
+	return &memKeyStore{
+		KeyStore:  newKeyStore(t, kid, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))),
+		kid:       kid,
+		publicPEM: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})),
+	}
+}
+
+// userOnlyDenied is an authorization policy that never lets users through
+// the user only rule.
+const userOnlyDenied = `package ardan.rego
+
+default rule_any := true
+
+default rule_admin_only := false
+
+default rule_user_only := false
+
+default rule_admin_or_subject := false
+
+default rule_admin_subject_or_manager := false
+
+default rule_permission := false
+
+default rule_super_admin := false
+`
+
+func writeBundle(t *testing.T, dir string, revision string, module string) {
+	if revision != "" {
+		manifest := fmt.Sprintf(`{"revision": %q}`, revision)
+		if err := os.WriteFile(filepath.Join(dir, ".manifest"), []byte(manifest), 0644); err != nil {
+			t.Fatalf("Should be able to write the manifest : %s", err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "authorization.rego"), []byte(module), 0644); err != nil {
+		t.Fatalf("Should be able to write the module : %s", err)
+	}
 }
 
 const (
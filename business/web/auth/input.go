@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+
+	"github.com/google/uuid"
+)
+
+// Resource describes the resource a request acts on so the authorization
+// policy can reason about it. It's available to the policy as input.Resource,
+// with the attributes of the resource under input.Resource.Attributes.
+type Resource struct {
+	Type       string
+	ID         string
+	OwnerID    uuid.UUID
+	Attributes map[string]any
+}
+
+// Request describes the request being authorized. It's available to the
+// policy as input.Request. The time is passed as an RFC 3339 string which can
+// be read with time.parse_rfc3339_ns. Permission is the permission the route
+// requires, if any.
+type Request struct {
+	Method     string
+	Path       string
+	Time       time.Time
+	Permission string
+}
+
+// authorizeInput represents the input document of the authorization policy.
+// UserID is the owner of the resource, kept from before resources were
+// passed to the policy. Permissions are the permissions granted by the roles.
+type authorizeInput struct {
+	Roles       []user.Role
+	Permissions []string
+	Subject     string
+	Department  string
+	UserID      uuid.UUID
+	Resource    Resource
+	Request     Request
+}
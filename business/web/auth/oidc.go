@@ -0,0 +1,345 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/testvergecloud/testApi/foundation/keystore"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Set of error variables for logging in with an OpenID provider.
+var (
+	ErrOIDCState = errors.New("oidc login state mismatch")
+	ErrOIDCGrant = errors.New("oidc authorization code not valid")
+	ErrOIDCToken = errors.New("oidc id token not valid")
+)
+
+// OIDCConfig describes the OpenID provider users can log in with and how
+// this service is registered with it.
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	Client       *http.Client
+}
+
+// ExternalIdentity represents a user as asserted by an OpenID provider. The
+// issuer and subject identify the user, the email can change over time.
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OIDCLogin holds the values a login was started with. They have to be kept
+// by the client until the provider redirects back, the URL is where the user
+// is sent to log in.
+type OIDCLogin struct {
+	URL      string
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// OIDC logs users in with an OpenID provider using the authorization code
+// flow with PKCE. The provider metadata is discovered on first use so the
+// service can start while the provider is unreachable.
+type OIDC struct {
+	cfg    OIDCConfig
+	client *http.Client
+
+	mu   sync.Mutex
+	meta *oidcMetadata
+	keys *keystore.Remote
+}
+
+// NewOIDC constructs an OIDC value for the provider. A default client is
+// used if the config has none.
+func NewOIDC(cfg OIDCConfig) *OIDC {
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid"}
+	}
+
+	return &OIDC{
+		cfg:    cfg,
+		client: client,
+	}
+}
+
+// Begin starts a login and returns the URL to send the user to along with
+// the values needed to complete it.
+func (o *OIDC) Begin(ctx context.Context) (OIDCLogin, error) {
+	meta, _, err := o.discover(ctx)
+	if err != nil {
+		return OIDCLogin{}, err
+	}
+
+	values := make([]string, 3)
+	for i := range values {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return OIDCLogin{}, fmt.Errorf("generating login values: %w", err)
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	login := OIDCLogin{
+		State:    values[0],
+		Nonce:    values[1],
+		Verifier: values[2],
+	}
+
+	challenge := sha256.Sum256([]byte(login.Verifier))
+
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {o.cfg.ClientID},
+		"redirect_uri":          {o.cfg.RedirectURL},
+		"scope":                 {strings.Join(o.cfg.Scopes, " ")},
+		"state":                 {login.State},
+		"nonce":                 {login.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	login.URL = meta.AuthorizationEndpoint + sep + q.Encode()
+
+	return login, nil
+}
+
+// Complete finishes the login the provider redirected back with. The state
+// has to match the one the login was started with, the code is exchanged for
+// an ID token which is verified before the identity it holds is returned.
+func (o *OIDC) Complete(ctx context.Context, login OIDCLogin, state string, code string) (ExternalIdentity, error) {
+	if login.State == "" || subtle.ConstantTimeCompare([]byte(login.State), []byte(state)) != 1 {
+		return ExternalIdentity{}, ErrOIDCState
+	}
+
+	meta, keys, err := o.discover(ctx)
+	if err != nil {
+		return ExternalIdentity{}, err
+	}
+
+	rawIDToken, err := o.exchange(ctx, meta, code, login.Verifier)
+	if err != nil {
+		return ExternalIdentity{}, fmt.Errorf("exchange: %w", err)
+	}
+
+	var claims oidcClaims
+	if err := o.verify(keys, meta.Issuer, rawIDToken, &claims); err != nil {
+		return ExternalIdentity{}, fmt.Errorf("%w: %s", ErrOIDCToken, err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(login.Nonce)) != 1 {
+		return ExternalIdentity{}, fmt.Errorf("%w: nonce mismatch", ErrOIDCToken)
+	}
+
+	idn := ExternalIdentity{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}
+
+	return idn, nil
+}
+
+// =============================================================================
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcError represents an error response of the provider.
+type oidcError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *oidcError) Error() string {
+	return fmt.Sprintf("provider error %s: %s", e.Code, e.Description)
+}
+
+type oidcClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+// discover fetches the provider metadata, which is kept once it was fetched.
+// The signing keys of the provider are fetched as tokens need them.
+func (o *OIDC) discover(ctx context.Context) (*oidcMetadata, *keystore.Remote, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.meta != nil {
+		return o.meta, o.keys, nil
+	}
+
+	endpoint := strings.TrimSuffix(o.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("discovery request: %w", err)
+	}
+
+	var meta oidcMetadata
+	if err := o.do(req, &meta); err != nil {
+		return nil, nil, fmt.Errorf("discovery: %w", err)
+	}
+
+	// The metadata is only trusted when it's for the configured issuer.
+	if meta.Issuer != o.cfg.Issuer {
+		return nil, nil, fmt.Errorf("discovery: issuer %q doesn't match %q", meta.Issuer, o.cfg.Issuer)
+	}
+
+	o.meta = &meta
+	o.keys = keystore.NewRemote(meta.JWKSURI, o.client)
+
+	return o.meta, o.keys, nil
+}
+
+// exchange trades the authorization code for the ID token of the user. The
+// verifier proves the code is redeemed by whoever started the login.
+func (o *OIDC) exchange(ctx context.Context, meta *oidcMetadata, code string, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {o.cfg.RedirectURL},
+		"client_id":     {o.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if o.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(o.cfg.ClientID), url.QueryEscape(o.cfg.ClientSecret))
+	}
+
+	var resp struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := o.do(req, &resp); err != nil {
+		var perr *oidcError
+		if errors.As(err, &perr) {
+			return "", fmt.Errorf("%w: %s", ErrOIDCGrant, perr)
+		}
+		return "", err
+	}
+
+	if resp.IDToken == "" {
+		return "", errors.New("id token missing from response")
+	}
+
+	return resp.IDToken, nil
+}
+
+// verify checks the ID token was signed by the provider for this service and
+// hasn't expired.
+func (o *OIDC) verify(keys *keystore.Remote, issuer string, rawIDToken string, claims *oidcClaims) error {
+	unverified, _, err := jwt.NewParser().ParseUnverified(rawIDToken, claims)
+	if err != nil {
+		return fmt.Errorf("parsing token: %w", err)
+	}
+
+	kid, _ := unverified.Header["kid"].(string)
+
+	key, alg, err := keys.VerifyingKey(kid)
+	if err != nil {
+		return fmt.Errorf("public key: %w", err)
+	}
+
+	keyFunc := func(*jwt.Token) (any, error) {
+		return key, nil
+	}
+
+	if _, err := jwt.NewParser(jwt.WithValidMethods([]string{alg})).ParseWithClaims(rawIDToken, claims, keyFunc); err != nil {
+		return fmt.Errorf("verifying token: %w", err)
+	}
+
+	switch {
+	case !claims.VerifyIssuer(issuer, true):
+		return errors.New("issuer mismatch")
+
+	case !claims.VerifyAudience(o.cfg.ClientID, true):
+		return errors.New("audience mismatch")
+
+	case claims.ExpiresAt == nil:
+		return errors.New("expiry missing")
+
+	case claims.Subject == "":
+		return errors.New("subject missing")
+	}
+
+	return nil
+}
+
+// do sends the request and decodes the JSON response into v. A response the
+// provider marks as an error is returned as one.
+func (o *OIDC) do(req *http.Request, v any) error {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Limit the document to 1 megabyte, provider responses are a few
+	// kilobytes.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var perr oidcError
+		if json.Unmarshal(body, &perr) == nil && perr.Code != "" {
+			return &perr
+		}
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/auth/oidctest"
+)
+
+func Test_OIDC(t *testing.T) {
+	provider, err := oidctest.NewProvider("cdn-api", "secret")
+	if err != nil {
+		t.Fatalf("Should be able to start the provider : %s", err)
+	}
+	defer provider.Close()
+
+	provider.SetIdentity(auth.ExternalIdentity{
+		Subject:       "external-1",
+		Email:         "oidc@example.com",
+		EmailVerified: true,
+		Name:          "OIDC Gopher",
+	})
+
+	o := auth.NewOIDC(auth.OIDCConfig{
+		Issuer:       provider.Issuer(),
+		ClientID:     "cdn-api",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/v1/auth/oidc/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	begin := func(t *testing.T) (auth.OIDCLogin, string, string) {
+		login, err := o.Begin(ctx)
+		if err != nil {
+			t.Fatalf("Should be able to begin a login : %s", err)
+		}
+
+		callback, err := provider.Authorize(login.URL)
+		if err != nil {
+			t.Fatalf("Should be redirected back by the provider : %s", err)
+		}
+
+		return login, callback.Query().Get("state"), callback.Query().Get("code")
+	}
+
+	t.Run("login", func(t *testing.T) {
+		login, state, code := begin(t)
+
+		idn, err := o.Complete(ctx, login, state, code)
+		if err != nil {
+			t.Fatalf("Should be able to complete the login : %s", err)
+		}
+
+		if idn.Issuer != provider.Issuer() || idn.Subject != "external-1" || idn.Email != "oidc@example.com" || !idn.EmailVerified {
+			t.Fatalf("Should get back the identity of the provider : %+v", idn)
+		}
+
+		if _, err := o.Complete(ctx, login, state, code); !errors.Is(err, auth.ErrOIDCGrant) {
+			t.Fatalf("Should NOT be able to redeem a code twice : %v", err)
+		}
+	})
+
+	t.Run("state", func(t *testing.T) {
+		login, _, code := begin(t)
+
+		if _, err := o.Complete(ctx, login, "forged", code); !errors.Is(err, auth.ErrOIDCState) {
+			t.Fatalf("Should NOT be able to complete a login with another state : %v", err)
+		}
+	})
+
+	t.Run("pkce", func(t *testing.T) {
+		login, state, code := begin(t)
+		login.Verifier = "forged"
+
+		if _, err := o.Complete(ctx, login, state, code); !errors.Is(err, auth.ErrOIDCGrant) {
+			t.Fatalf("Should NOT be able to redeem a code without the verifier : %v", err)
+		}
+	})
+
+	t.Run("nonce", func(t *testing.T) {
+		login, state, code := begin(t)
+		login.Nonce = "forged"
+
+		if _, err := o.Complete(ctx, login, state, code); !errors.Is(err, auth.ErrOIDCToken) {
+			t.Fatalf("Should NOT accept an ID token issued for another login : %v", err)
+		}
+	})
+
+	t.Run("client", func(t *testing.T) {
+		other := auth.NewOIDC(auth.OIDCConfig{
+			Issuer:       provider.Issuer(),
+			ClientID:     "cdn-api",
+			ClientSecret: "wrong",
+			RedirectURL:  "http://localhost/v1/auth/oidc/callback",
+		})
+
+		login, err := other.Begin(ctx)
+		if err != nil {
+			t.Fatalf("Should be able to begin a login : %s", err)
+		}
+
+		callback, err := provider.Authorize(login.URL)
+		if err != nil {
+			t.Fatalf("Should be redirected back by the provider : %s", err)
+		}
+
+		if _, err := other.Complete(ctx, login, callback.Query().Get("state"), callback.Query().Get("code")); !errors.Is(err, auth.ErrOIDCGrant) {
+			t.Fatalf("Should NOT be able to redeem a code with the wrong client secret : %v", err)
+		}
+	})
+}
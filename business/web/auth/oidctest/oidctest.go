@@ -0,0 +1,305 @@
+// Package oidctest provides an in-process OpenID provider for tests. It logs
+// in whoever it's told to without asking, but otherwise follows the
+// authorization code flow with PKCE the way a real provider does, so the
+// client is exercised end to end.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing/fstest"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/foundation/keystore"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const kid = "oidctest"
+
+// Provider represents a running OpenID provider. The identity is the user
+// that logs in on the next authorization request.
+type Provider struct {
+	ClientID     string
+	ClientSecret string
+
+	server *httptest.Server
+	ks     *keystore.KeyStore
+
+	mu       sync.Mutex
+	identity auth.ExternalIdentity
+	grants   map[string]grant
+}
+
+type grant struct {
+	clientID    string
+	redirectURI string
+	challenge   string
+	nonce       string
+	identity    auth.ExternalIdentity
+}
+
+// NewProvider starts a provider for the specified client. Close has to be
+// called once the provider isn't needed anymore.
+func NewProvider(clientID string, clientSecret string) (*Provider, error) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("generating key: %w", err)
+	}
+
+	block := pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(pk),
+	}
+
+	ks := keystore.New()
+	if err := ks.LoadKeys(fstest.MapFS{kid + ".pem": {Data: pem.EncodeToMemory(&block)}}); err != nil {
+		return nil, fmt.Errorf("loading key: %w", err)
+	}
+
+	p := Provider{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		ks:           ks,
+		grants:       make(map[string]grant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/keys", p.keys)
+
+	p.server = httptest.NewServer(mux)
+
+	return &p, nil
+}
+
+// Issuer returns the issuer of the provider to configure the client with.
+func (p *Provider) Issuer() string {
+	return p.server.URL
+}
+
+// Close stops the provider.
+func (p *Provider) Close() {
+	p.server.Close()
+}
+
+// SetIdentity sets the user that logs in on the next authorization request.
+// The issuer of the identity is always the provider.
+func (p *Provider) SetIdentity(idn auth.ExternalIdentity) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idn.Issuer = p.Issuer()
+	p.identity = idn
+}
+
+// Authorize follows the login URL the way the browser of the user would and
+// returns the callback URL the provider redirects back to.
+func (p *Provider) Authorize(loginURL string) (*url.URL, error) {
+	client := http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(loginURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.Location()
+}
+
+// =============================================================================
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.Issuer(),
+		"authorization_endpoint": p.Issuer() + "/authorize",
+		"token_endpoint":         p.Issuer() + "/token",
+		"jwks_uri":               p.Issuer() + "/keys",
+	})
+}
+
+func (p *Provider) keys(w http.ResponseWriter, r *http.Request) {
+	jwks, err := p.ks.JWKS()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, jwks)
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	redirectURI, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirectURI.Scheme == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "redirect_uri is not valid")
+		return
+	}
+
+	switch {
+	case q.Get("client_id") != p.ClientID:
+		writeError(w, http.StatusBadRequest, "unauthorized_client", "unknown client")
+		return
+
+	case q.Get("response_type") != "code":
+		writeError(w, http.StatusBadRequest, "unsupported_response_type", "only code is supported")
+		return
+
+	case q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256":
+		writeError(w, http.StatusBadRequest, "invalid_request", "an S256 code challenge is required")
+		return
+	}
+
+	code, err := randomString()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	p.mu.Lock()
+	p.grants[code] = grant{
+		clientID:    p.ClientID,
+		redirectURI: redirectURI.String(),
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		identity:    p.identity,
+	}
+	p.mu.Unlock()
+
+	callback := redirectURI.Query()
+	callback.Set("code", code)
+	callback.Set("state", q.Get("state"))
+	redirectURI.RawQuery = callback.Encode()
+
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != p.ClientID || clientSecret != p.ClientSecret {
+		writeError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+
+	// A code can only be redeemed once.
+	code := r.PostForm.Get("code")
+
+	p.mu.Lock()
+	g, found := p.grants[code]
+	delete(p.grants, code)
+	p.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+
+	switch {
+	case r.PostForm.Get("grant_type") != "authorization_code":
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type", "only authorization_code is supported")
+		return
+
+	case !found || g.redirectURI != r.PostForm.Get("redirect_uri"):
+		writeError(w, http.StatusBadRequest, "invalid_grant", "code is not valid")
+		return
+
+	case base64.RawURLEncoding.EncodeToString(verifier[:]) != g.challenge:
+		writeError(w, http.StatusBadRequest, "invalid_grant", "code verifier doesn't match the challenge")
+		return
+	}
+
+	idToken, err := p.idToken(g)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "oidctest",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (p *Provider) idToken(g grant) (string, error) {
+	privatePEM, err := p.ks.PrivateKey(kid)
+	if err != nil {
+		return "", err
+	}
+
+	pk, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privatePEM))
+	if err != nil {
+		return "", err
+	}
+
+	if g.identity.Subject == "" {
+		return "", errors.New("no identity set")
+	}
+
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"iss":            p.Issuer(),
+		"sub":            g.identity.Subject,
+		"aud":            g.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          g.nonce,
+		"email":          g.identity.Email,
+		"email_verified": g.identity.EmailVerified,
+		"name":           g.identity.Name,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	return token.SignedString(pk)
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string, description string) {
+	writeJSON(w, status, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
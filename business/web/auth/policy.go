@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// Set of sources a policy can be loaded from.
+const (
+	PolicySourceEmbedded = "embedded"
+	PolicySourceBundle   = "bundle"
+)
+
+// PolicyRevision describes the authorization policy in use.
+type PolicyRevision struct {
+	Revision   string
+	Source     string
+	Rules      []string
+	DateLoaded time.Time
+}
+
+// policy holds the prepared query of every rule of a revision of the
+// authorization policy.
+type policy struct {
+	revision PolicyRevision
+	digest   string
+	queries  map[string]rego.PreparedEvalQuery
+}
+
+// bundle represents the authorization modules read from a policy bundle.
+type bundle struct {
+	revision string
+	digest   string
+	modules  map[string]string
+}
+
+// manifest represents the optional .manifest file of a policy bundle.
+type manifest struct {
+	Revision string `json:"revision"`
+}
+
+// embeddedBundle returns the authorization policy built into the service.
+func embeddedBundle() bundle {
+	modules := map[string]string{
+		"authorization.rego": opaAuthorization,
+	}
+
+	digest := digestModules(modules)
+
+	return bundle{
+		revision: digest,
+		digest:   digest,
+		modules:  modules,
+	}
+}
+
+// readBundle reads the authorization modules of the policy bundle rooted in
+// the directory. A bundle is a set of .rego files of the ardan.rego package
+// and an optional .manifest file holding the revision of the bundle, like
+// an OPA bundle. Without a revision the digest of the modules is used.
+func readBundle(fsys fs.FS) (bundle, error) {
+	modules := make(map[string]string)
+
+	fn := func(fileName string, dirEntry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("walkdir failure: %w", err)
+		}
+
+		if dirEntry.IsDir() || path.Ext(fileName) != ".rego" {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, fileName)
+		if err != nil {
+			return fmt.Errorf("reading module: %w", err)
+		}
+
+		modules[fileName] = string(data)
+		return nil
+	}
+
+	if err := fs.WalkDir(fsys, ".", fn); err != nil {
+		return bundle{}, fmt.Errorf("walking directory: %w", err)
+	}
+
+	if len(modules) == 0 {
+		return bundle{}, errors.New("bundle has no rego modules")
+	}
+
+	b := bundle{
+		digest:  digestModules(modules),
+		modules: modules,
+	}
+	b.revision = b.digest
+
+	data, err := fs.ReadFile(fsys, ".manifest")
+	switch {
+	case err == nil:
+		var m manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return bundle{}, fmt.Errorf("parsing manifest: %w", err)
+		}
+
+		if m.Revision != "" {
+			b.revision = m.Revision
+		}
+
+		// A new manifest is a change even if the modules didn't change.
+		files := map[string]string{".manifest": string(data)}
+		for name, module := range modules {
+			files[name] = module
+		}
+		b.digest = digestModules(files)
+
+	case !errors.Is(err, fs.ErrNotExist):
+		return bundle{}, fmt.Errorf("reading manifest: %w", err)
+	}
+
+	return b, nil
+}
+
+// newPolicy compiles the authorization modules along with the authentication
+// policy and prepares the query of every rule. The modules must define the
+// rules the service uses and can't redefine the authentication rule. Any
+// other rule named rule_* is prepared too.
+func newPolicy(ctx context.Context, b bundle, source string) (*policy, error) {
+	modules := map[string]string{
+		"authentication.rego": opaAuthentication,
+	}
+
+	for name, module := range b.modules {
+		modules["bundle/"+name] = module
+	}
+
+	parsed := make(map[string]*ast.Module, len(modules))
+	for name, module := range modules {
+		m, err := ast.ParseModule(name, module)
+		if err != nil {
+			return nil, fmt.Errorf("parsing module: %w", err)
+		}
+		parsed[name] = m
+	}
+
+	compiler := ast.NewCompiler()
+	if compiler.Compile(parsed); compiler.Failed() {
+		return nil, fmt.Errorf("compiling policy: %w", compiler.Errors)
+	}
+
+	rules := make(map[string]struct{})
+	for name, m := range parsed {
+		if m.Package.Path.String() != "data."+opaPackage {
+			continue
+		}
+
+		for _, r := range m.Rules {
+			ruleName := r.Head.Ref().String()
+
+			if ruleName == RuleAuthenticate && name != "authentication.rego" {
+				return nil, fmt.Errorf("module %s can't define the %s rule", name, RuleAuthenticate)
+			}
+
+			if ruleName == RuleAuthenticate || strings.HasPrefix(ruleName, "rule_") {
+				rules[ruleName] = struct{}{}
+			}
+		}
+	}
+
+	for _, rule := range requiredRules {
+		if _, exists := rules[rule]; !exists {
+			return nil, fmt.Errorf("rule %s is not defined", rule)
+		}
+	}
+
+	p := policy{
+		revision: PolicyRevision{
+			Revision:   b.revision,
+			Source:     source,
+			DateLoaded: time.Now(),
+		},
+		digest:  b.digest,
+		queries: make(map[string]rego.PreparedEvalQuery, len(rules)),
+	}
+
+	for rule := range rules {
+		q, err := rego.New(
+			rego.Query(fmt.Sprintf("x = data.%s.%s", opaPackage, rule)),
+			rego.Compiler(compiler),
+		).PrepareForEval(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("rule[%s]: %w", rule, err)
+		}
+
+		p.queries[rule] = q
+
+		if rule != RuleAuthenticate {
+			p.revision.Rules = append(p.revision.Rules, rule)
+		}
+	}
+
+	sort.Strings(p.revision.Rules)
+
+	return &p, nil
+}
+
+// digestModules returns a digest of the modules that changes whenever any of
+// them changes.
+func digestModules(modules map[string]string) string {
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, modules[name])
+	}
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
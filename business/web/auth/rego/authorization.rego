@@ -11,16 +11,23 @@ default rule_user_only := false
 
 default rule_admin_or_subject := false
 
+default rule_admin_subject_or_manager := false
+
+default rule_permission := false
+
+default rule_super_admin := false
+
 role_user := "USER"
 
 role_admin := "ADMIN"
 
-role_all := {role_admin, role_user}
+role_manager := "MANAGER"
+
+role_super_admin := "SUPER_ADMIN"
 
+# Any role counts, including the roles created by admins.
 rule_any if {
-	claim_roles := {role | some role in input.Roles}
-	input_roles := role_all & claim_roles
-	count(input_roles) > 0
+	count(input.Roles) > 0
 }
 
 rule_admin_only if {
@@ -45,3 +52,40 @@ rule_admin_or_subject if {
 	count(input_user) > 0
 	input.UserID == input.Subject
 }
+
+# Department managers may act on the users in their own department, other
+# than the admins, the super admins and the users granted permissions the
+# manager doesn't have.
+rule_admin_subject_or_manager if {
+	rule_admin_or_subject
+} else if {
+	claim_roles := {role | some role in input.Roles}
+	input_manager := {role_manager} & claim_roles
+	count(input_manager) > 0
+	input.Resource.Type == "user"
+	input.Department != ""
+	input.Resource.Attributes.department == input.Department
+	not role_admin in input.Resource.Attributes.roles
+	not role_super_admin in input.Resource.Attributes.roles
+	resource_permissions := {perm | some perm in object.get(input.Resource.Attributes, "permissions", [])}
+	claim_permissions := {perm | some perm in input.Permissions}
+	count(resource_permissions - claim_permissions) == 0
+}
+
+# The roles of the user have to grant the permission the route requires.
+# Admins are always let through so they can't lock themselves out. API keys
+# have no roles, their permissions are the scopes of the key.
+rule_permission if {
+	rule_admin_only
+} else if {
+	input.Request.Permission != ""
+	input.Request.Permission in input.Permissions
+}
+
+# Super admins act across every organization, everyone else is confined to
+# the organization of their token.
+rule_super_admin if {
+	claim_roles := {role | some role in input.Roles}
+	input_super_admin := {role_super_admin} & claim_roles
+	count(input_super_admin) > 0
+}
@@ -6,11 +6,14 @@ import (
 
 // These the current set of rules we have for auth.
 const (
-	RuleAuthenticate   = "auth"
-	RuleAny            = "rule_any"
-	RuleAdminOnly      = "rule_admin_only"
-	RuleUserOnly       = "rule_user_only"
-	RuleAdminOrSubject = "rule_admin_or_subject"
+	RuleAuthenticate          = "auth"
+	RuleAny                   = "rule_any"
+	RuleAdminOnly             = "rule_admin_only"
+	RuleUserOnly              = "rule_user_only"
+	RuleAdminOrSubject        = "rule_admin_or_subject"
+	RuleAdminSubjectOrManager = "rule_admin_subject_or_manager"
+	RulePermission            = "rule_permission"
+	RuleSuperAdmin            = "rule_super_admin"
 )
 
 // Package name of our rego code.
@@ -26,3 +29,16 @@ var (
 	//go:embed rego/authorization.rego
 	opaAuthorization string
 )
+
+// requiredRules lists the rules the service uses, every authorization policy
+// has to define them.
+var requiredRules = []string{
+	RuleAuthenticate,
+	RuleAny,
+	RuleAdminOnly,
+	RuleUserOnly,
+	RuleAdminOrSubject,
+	RuleAdminSubjectOrManager,
+	RulePermission,
+	RuleSuperAdmin,
+}
@@ -0,0 +1,276 @@
+// Package batch provides support for executing a batch of operations on the
+// entities of a domain. An atomic batch executes under one transaction and
+// is rolled back as a whole when an operation fails, otherwise every
+// operation succeeds or fails on its own. Consecutive creates are inserted
+// together so a batch doesn't take a round trip per entity.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	wb "github.com/testvergecloud/testApi/business/web"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/validate"
+
+	"github.com/go-json-experiment/json"
+)
+
+// Set of operations a batch can hold.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// MaxOperations is the largest number of operations a batch can hold.
+const MaxOperations = 1000
+
+// Set of error variables for batches.
+var (
+	ErrEmpty      = errors.New("batch has no operations")
+	ErrTooLarge   = fmt.Errorf("batch has more than %d operations", MaxOperations)
+	ErrUnknownOp  = errors.New("operation is not known")
+	ErrRolledBack = errors.New("not applied, another operation of the batch failed")
+)
+
+// Item is an operation of a batch ready to be executed. A create carries the
+// value to create so it can be inserted along with the creates next to it,
+// the other operations are executed by Run. An item that couldn't be
+// prepared carries the error instead and isn't executed.
+type Item[N any] struct {
+	Op     string
+	New    N
+	Run    func(ctx context.Context, tx transaction.Transaction) (any, error)
+	Status int
+	Err    error
+}
+
+// Batch executes the items of a batch for a domain. CreateMany inserts the
+// new values and returns what's reported for each of them. Status maps the
+// errors of the domain to a status code, errors it doesn't know are
+// unexpected.
+type Batch[N any] struct {
+	Log        *logger.Logger
+	Beginner   transaction.Beginner
+	CreateMany func(ctx context.Context, tx transaction.Transaction, news []N) ([]any, error)
+	Status     func(err error) int
+}
+
+// Execute executes the items and returns the result of each of them.
+func (b Batch[N]) Execute(ctx context.Context, atomic bool, items []Item[N]) []Result {
+	results := make([]Result, len(items))
+
+	var prepared []int
+	for i, item := range items {
+		results[i].Index = i
+
+		if item.Err != nil {
+			results[i] = b.failed(ctx, i, item.Err)
+			continue
+		}
+		prepared = append(prepared, i)
+	}
+
+	if atomic {
+		if len(prepared) != len(items) {
+			rollBack(results)
+			return results
+		}
+
+		if err := b.inTransaction(ctx, items, prepared, results); err != nil {
+			rollBack(results)
+		}
+
+		return results
+	}
+
+	for _, group := range groups(items, prepared) {
+		err := b.inTransaction(ctx, items, group, results)
+
+		// The creates of a group fail together, so they're executed again
+		// one by one to tell which of them failed.
+		if err != nil && len(group) > 1 {
+			for _, i := range group {
+				b.inTransaction(ctx, items, []int{i}, results)
+			}
+		}
+	}
+
+	return results
+}
+
+// inTransaction executes the items of a group under its own transaction.
+// When the transaction fails to begin or commit, none of the items failed on
+// their own and all of them fail with it.
+func (b Batch[N]) inTransaction(ctx context.Context, items []Item[N], group []int, results []Result) error {
+	err := transaction.ExecuteUnderTransaction(ctx, b.Log, b.Beginner, func(tx transaction.Transaction) error {
+		return b.execute(ctx, tx, items, group, results)
+	})
+	if err == nil {
+		return nil
+	}
+
+	for _, idx := range group {
+		if results[idx].Failed() {
+			return err
+		}
+	}
+
+	for _, idx := range group {
+		results[idx] = b.failed(ctx, idx, err)
+	}
+
+	return err
+}
+
+// execute executes the items in order under the transaction and stops at the
+// first one failing.
+func (b Batch[N]) execute(ctx context.Context, tx transaction.Transaction, items []Item[N], indexes []int, results []Result) error {
+	var creates []int
+
+	flush := func() error {
+		if len(creates) == 0 {
+			return nil
+		}
+		defer func() { creates = creates[:0] }()
+
+		news := make([]N, len(creates))
+		for i, idx := range creates {
+			news[i] = items[idx].New
+		}
+
+		data, err := b.CreateMany(ctx, tx, news)
+		if err != nil {
+			for _, idx := range creates {
+				results[idx] = b.failed(ctx, idx, err)
+			}
+			return err
+		}
+
+		for i, idx := range creates {
+			results[idx] = Result{Index: idx, Status: items[idx].Status, Data: data[i]}
+		}
+
+		return nil
+	}
+
+	for _, idx := range indexes {
+		item := items[idx]
+
+		if item.Op == OpCreate {
+			creates = append(creates, idx)
+			continue
+		}
+
+		if err := flush(); err != nil {
+			return err
+		}
+
+		data, err := item.Run(ctx, tx)
+		if err != nil {
+			results[idx] = b.failed(ctx, idx, err)
+			return err
+		}
+		results[idx] = Result{Index: idx, Status: item.Status, Data: data}
+	}
+
+	return flush()
+}
+
+// failed describes the error of the item at the index.
+func (b Batch[N]) failed(ctx context.Context, idx int, err error) Result {
+	if fe := validate.GetFieldErrors(err); fe != nil {
+		return Result{Index: idx, Status: http.StatusBadRequest, Error: "data validation error", Fields: fe}
+	}
+
+	status := http.StatusInternalServerError
+	switch {
+	case wb.IsTrustedError(err):
+		status = wb.GetTrustedError(err).Status
+	case b.Status != nil:
+		status = b.Status(err)
+	}
+
+	if status >= http.StatusInternalServerError {
+		b.Log.Error(ctx, "batch", "index", idx, "msg", err)
+		return Result{Index: idx, Status: status, Error: http.StatusText(status)}
+	}
+
+	return Result{Index: idx, Status: status, Error: err.Error()}
+}
+
+// =============================================================================
+
+// Decode decodes the data of the operation into the value and validates it
+// when the value knows how to.
+func Decode(op Operation, val any) error {
+	if len(op.Data) == 0 {
+		return validate.NewFieldsError("data", errors.New("data is required"))
+	}
+
+	if err := json.Unmarshal(op.Data, val, json.RejectUnknownMembers(false)); err != nil {
+		return wb.NewTrustedError(fmt.Errorf("unable to decode data: %w", err), http.StatusBadRequest)
+	}
+
+	if v, ok := val.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StatusCode returns the status code to respond to the batch with. A batch
+// executing operations on their own succeeds even when some of them failed,
+// an atomic batch fails with the operation that rolled it back.
+func StatusCode(atomic bool, results []Result) int {
+	if !atomic {
+		return http.StatusOK
+	}
+
+	status := http.StatusOK
+	for _, res := range results {
+		switch {
+		case !res.Failed():
+		case res.Status != http.StatusFailedDependency:
+			return res.Status
+		default:
+			status = http.StatusFailedDependency
+		}
+	}
+
+	return status
+}
+
+// groups splits the prepared items into the groups executed on their own,
+// creates following each other form a single group.
+func groups[N any](items []Item[N], prepared []int) [][]int {
+	var groups [][]int
+
+	for i, idx := range prepared {
+		if items[idx].Op == OpCreate && i > 0 {
+			last := groups[len(groups)-1]
+			if items[last[0]].Op == OpCreate {
+				groups[len(groups)-1] = append(last, idx)
+				continue
+			}
+		}
+		groups = append(groups, []int{idx})
+	}
+
+	return groups
+}
+
+// rollBack marks the results of the operations that were undone.
+func rollBack(results []Result) {
+	for i, res := range results {
+		if !res.Failed() {
+			results[i] = Result{Index: i, Status: http.StatusFailedDependency, Error: ErrRolledBack.Error()}
+		}
+	}
+}
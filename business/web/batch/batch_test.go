@@ -0,0 +1,177 @@
+package batch_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	"github.com/testvergecloud/testApi/business/web/batch"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/validate"
+)
+
+var errNotFound = errors.New("not found")
+
+func Test_Batch(t *testing.T) {
+	t.Run("perItem", perItem)
+	t.Run("atomic", atomic)
+}
+
+func perItem(t *testing.T) {
+	st := store{}
+	b := newBatch(&st)
+
+	items := []batch.Item[string]{
+		st.create("a"),
+		st.create("bad"),
+		st.create("b"),
+		st.remove("missing"),
+		st.create("c"),
+		{Err: errors.New("unable to prepare")},
+	}
+
+	results := b.Execute(context.Background(), false, items)
+
+	statuses := []int{
+		http.StatusCreated,
+		http.StatusBadRequest,
+		http.StatusCreated,
+		http.StatusNotFound,
+		http.StatusCreated,
+		http.StatusInternalServerError,
+	}
+	for i, res := range results {
+		if res.Index != i || res.Status != statuses[i] {
+			t.Fatalf("Should get the result of every operation on its own: %d: %+v", i, res)
+		}
+	}
+
+	if !slices.Equal(st.committed, []string{"a", "b", "c"}) {
+		t.Fatalf("Should keep the operations that succeeded: %v", st.committed)
+	}
+
+	if st.createManyCalls != 5 {
+		t.Fatalf("Should insert consecutive creates together before retrying them one by one: %d", st.createManyCalls)
+	}
+
+	if code := batch.StatusCode(false, results); code != http.StatusOK {
+		t.Fatalf("Should succeed a batch executing operations on their own: %d", code)
+	}
+}
+
+func atomic(t *testing.T) {
+	st := store{}
+	b := newBatch(&st)
+
+	items := []batch.Item[string]{
+		st.create("a"),
+		st.remove("missing"),
+		st.create("b"),
+	}
+
+	results := b.Execute(context.Background(), true, items)
+
+	statuses := []int{http.StatusFailedDependency, http.StatusNotFound, http.StatusFailedDependency}
+	for i, res := range results {
+		if res.Status != statuses[i] {
+			t.Fatalf("Should roll back every operation along with the failed one: %d: %+v", i, res)
+		}
+	}
+
+	if len(st.committed) != 0 {
+		t.Fatalf("Should NOT keep any operation of a failed atomic batch: %v", st.committed)
+	}
+
+	if code := batch.StatusCode(true, results); code != http.StatusNotFound {
+		t.Fatalf("Should fail an atomic batch with the failed operation: %d", code)
+	}
+
+	results = b.Execute(context.Background(), true, []batch.Item[string]{st.create("a"), st.create("b")})
+	for _, res := range results {
+		if res.Status != http.StatusCreated {
+			t.Fatalf("Should execute an atomic batch: %+v", res)
+		}
+	}
+
+	if !slices.Equal(st.committed, []string{"a", "b"}) {
+		t.Fatalf("Should keep every operation of an atomic batch: %v", st.committed)
+	}
+}
+
+// =============================================================================
+
+func newBatch(st *store) batch.Batch[string] {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	return batch.Batch[string]{
+		Log:        log,
+		Beginner:   st,
+		CreateMany: st.createMany,
+		Status: func(err error) int {
+			if errors.Is(err, errNotFound) {
+				return http.StatusNotFound
+			}
+			return http.StatusInternalServerError
+		},
+	}
+}
+
+// store keeps names, a transaction only adds its names once committed.
+type store struct {
+	committed       []string
+	createManyCalls int
+}
+
+type tx struct {
+	st      *store
+	pending []string
+}
+
+func (st *store) Begin() (transaction.Transaction, error) {
+	return &tx{st: st}, nil
+}
+
+func (tx *tx) Commit() error {
+	tx.st.committed = append(tx.st.committed, tx.pending...)
+	return nil
+}
+
+func (tx *tx) Rollback() error {
+	return nil
+}
+
+func (st *store) createMany(ctx context.Context, trn transaction.Transaction, names []string) ([]any, error) {
+	st.createManyCalls++
+
+	if slices.Contains(names, "bad") {
+		return nil, validate.NewFieldsError("name", errors.New("name is not allowed"))
+	}
+
+	data := make([]any, len(names))
+	for i, name := range names {
+		data[i] = name
+	}
+	trn.(*tx).pending = append(trn.(*tx).pending, names...)
+
+	return data, nil
+}
+
+func (st *store) create(name string) batch.Item[string] {
+	return batch.Item[string]{Op: batch.OpCreate, New: name, Status: http.StatusCreated}
+}
+
+func (st *store) remove(name string) batch.Item[string] {
+	run := func(ctx context.Context, trn transaction.Transaction) (any, error) {
+		if !slices.Contains(st.committed, name) {
+			return nil, errNotFound
+		}
+		return nil, nil
+	}
+
+	return batch.Item[string]{Op: batch.OpDelete, Run: run, Status: http.StatusNoContent}
+}
@@ -0,0 +1,68 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/testvergecloud/testApi/foundation/validate"
+)
+
+// Request is the document a batch of operations is sent as. An atomic batch
+// executes all its operations under one transaction, otherwise every
+// operation is executed on its own.
+type Request struct {
+	Atomic     bool        `json:"atomic"`
+	Operations []Operation `json:"operations"`
+}
+
+// Validate checks the batch can be executed.
+func (r Request) Validate() error {
+	switch {
+	case len(r.Operations) == 0:
+		return ErrEmpty
+	case len(r.Operations) > MaxOperations:
+		return ErrTooLarge
+	}
+
+	return nil
+}
+
+// Operation is a single operation of a batch. Updates and deletes name the
+// entity they act on by ID, and can ask for the version they read so they
+// don't overwrite a newer change.
+type Operation struct {
+	Op      string          `json:"op"`
+	ID      string          `json:"id,omitempty"`
+	Version *int            `json:"version,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Result is the outcome of a single operation, reported at the index of the
+// operation in the batch.
+type Result struct {
+	Index  int                  `json:"index"`
+	Status int                  `json:"status"`
+	Data   any                  `json:"data,omitempty"`
+	Error  string               `json:"error,omitempty"`
+	Fields validate.FieldErrors `json:"fields,omitempty"`
+}
+
+// Failed reports whether the operation failed.
+func (r Result) Failed() bool {
+	return r.Status >= 400
+}
+
+// Response is the document the results of a batch are returned as.
+type Response struct {
+	Results []Result `json:"results"`
+}
+
+// CheckVersion returns a version conflict when the operation asked for a
+// version other than the current one.
+func (op Operation) CheckVersion(version int, errConflict error) error {
+	if op.Version != nil && *op.Version != version {
+		return fmt.Errorf("version[%d] current[%d]: %w", *op.Version, version, errConflict)
+	}
+
+	return nil
+}
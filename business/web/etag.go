@@ -0,0 +1,45 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Set of error variables for conditional requests.
+var (
+	ErrPreconditionFailed   = errors.New("resource has been modified, If-Match does not match")
+	ErrPreconditionRequired = errors.New("If-Match header is required")
+)
+
+// ETag returns the entity tag for the specified version of a resource.
+func ETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// CheckIfMatch compares the If-Match header of the request with the entity
+// tag of the specified version. It returns a trusted error with a 412 status
+// when none of the tags match, and with a 428 status when the header is
+// required but missing.
+func CheckIfMatch(r *http.Request, version int, required bool) error {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if required {
+			return NewTrustedError(ErrPreconditionRequired, http.StatusPreconditionRequired)
+		}
+		return nil
+	}
+
+	etag := ETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+
+		// Weak tags never match since If-Match uses the strong comparison.
+		if tag == "*" || tag == etag {
+			return nil
+		}
+	}
+
+	return NewTrustedError(ErrPreconditionFailed, http.StatusPreconditionFailed)
+}
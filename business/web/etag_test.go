@@ -0,0 +1,59 @@
+package web_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/testvergecloud/testApi/business/web"
+)
+
+func Test_CheckIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		required bool
+		status   int
+	}{
+		{name: "missing", header: "", required: false, status: 0},
+		{name: "missing-required", header: "", required: true, status: http.StatusPreconditionRequired},
+		{name: "match", header: `"3"`, required: true, status: 0},
+		{name: "match-list", header: `"1", "3"`, required: true, status: 0},
+		{name: "wildcard", header: "*", required: true, status: 0},
+		{name: "stale", header: `"2"`, required: true, status: http.StatusPreconditionFailed},
+		{name: "weak", header: `W/"3"`, required: true, status: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+
+			err := web.CheckIfMatch(r, 3, tt.required)
+
+			switch tt.status {
+			case 0:
+				if err != nil {
+					t.Fatalf("Should accept the request: %s", err)
+				}
+
+			default:
+				var te *web.TrustedError
+				if !errors.As(err, &te) {
+					t.Fatalf("Should get a trusted error: %v", err)
+				}
+
+				if te.Status != tt.status {
+					t.Fatalf("Should get the right status: got %d, exp %d", te.Status, tt.status)
+				}
+			}
+		})
+	}
+
+	if got, exp := web.ETag(3), `"3"`; got != exp {
+		t.Fatalf("Should get a quoted etag: got %s, exp %s", got, exp)
+	}
+}
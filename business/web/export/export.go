@@ -0,0 +1,225 @@
+// Package export provides support for streaming the result of a query as a
+// file instead of a page document.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/testvergecloud/testApi/foundation/validate"
+)
+
+// Format represents the format a query is exported in.
+type Format string
+
+// Set of formats a query can be exported in. FormatNone asks for the regular
+// page document.
+const (
+	FormatNone   Format = ""
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// flushEvery is the number of rows written between flushes to the client.
+const flushEvery = 100
+
+// ErrUnknownFormat is returned when the requested format isn't supported.
+var ErrUnknownFormat = errors.New("format is not supported")
+
+// ParseFormat parses the request for the format query string. A missing
+// format or json asks for the regular page document.
+func ParseFormat(r *http.Request) (Format, error) {
+	switch format := Format(r.URL.Query().Get("format")); format {
+	case FormatNone, "json":
+		return FormatNone, nil
+	case FormatCSV, FormatNDJSON:
+		return format, nil
+	}
+
+	return FormatNone, validate.NewFieldsError("format", ErrUnknownFormat)
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	}
+
+	return "application/json; charset=utf-8"
+}
+
+// Filename returns the name of the file the rows of the resource are
+// exported to.
+func (f Format) Filename(resource string) string {
+	return fmt.Sprintf("%s.%s", resource, f)
+}
+
+// =============================================================================
+
+// Writer writes rows in the format, flushing them to the client as they go
+// when the destination supports it. The columns of a CSV are the JSON names
+// of the fields of T, so they match the JSON API.
+type Writer[T any] struct {
+	dst     io.Writer
+	format  Format
+	csv     *csv.Writer
+	enc     *json.Encoder
+	columns []column
+	rows    int
+}
+
+// NewWriter constructs a writer of rows in the format. A CSV starts with the
+// header row, even when no row follows it.
+func NewWriter[T any](dst io.Writer, format Format) (*Writer[T], error) {
+	w := Writer[T]{
+		dst:    dst,
+		format: format,
+	}
+
+	switch format {
+	case FormatCSV:
+		w.csv = csv.NewWriter(dst)
+		w.columns = columns(reflect.TypeFor[T]())
+
+		header := make([]string, len(w.columns))
+		for i, col := range w.columns {
+			header[i] = col.name
+		}
+
+		if err := w.csv.Write(header); err != nil {
+			return nil, fmt.Errorf("write header: %w", err)
+		}
+
+	case FormatNDJSON:
+		w.enc = json.NewEncoder(dst)
+
+	default:
+		return nil, ErrUnknownFormat
+	}
+
+	return &w, nil
+}
+
+// Write writes the row.
+func (w *Writer[T]) Write(row T) error {
+	switch w.format {
+	case FormatCSV:
+		v := reflect.ValueOf(row)
+
+		record := make([]string, len(w.columns))
+		for i, col := range w.columns {
+			value, err := formatValue(v.FieldByIndex(col.index))
+			if err != nil {
+				return fmt.Errorf("format: %s: %w", col.name, err)
+			}
+			record[i] = value
+		}
+
+		if err := w.csv.Write(record); err != nil {
+			return err
+		}
+
+	case FormatNDJSON:
+		if err := w.enc.Encode(row); err != nil {
+			return err
+		}
+	}
+
+	w.rows++
+	if w.rows%flushEvery == 0 {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// Flush writes the buffered rows to the client.
+func (w *Writer[T]) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	if f, ok := w.dst.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// column is a field of a row written as a CSV column.
+type column struct {
+	name  string
+	index []int
+}
+
+// columns returns the columns of a row type, named and ordered as the JSON
+// encoding of its fields.
+func columns(typ reflect.Type) []column {
+	var cols []column
+
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+
+		cols = append(cols, column{name: name, index: field.Index})
+	}
+
+	return cols
+}
+
+// formatValue formats a field as a CSV value. Values without a plain text
+// form are written as their JSON encoding.
+func formatValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return "", nil
+		}
+		return formatValue(v.Elem())
+	case reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return "", nil
+		}
+	}
+
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,104 @@
+package export_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/testvergecloud/testApi/business/web/export"
+)
+
+type row struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Cost   float64  `json:"cost"`
+	Tags   []string `json:"tags,omitempty"`
+	Secret string   `json:"-"`
+}
+
+func Test_ParseFormat(t *testing.T) {
+	tests := []struct {
+		query string
+		exp   export.Format
+		fails bool
+	}{
+		{query: "", exp: export.FormatNone},
+		{query: "format=json", exp: export.FormatNone},
+		{query: "format=csv", exp: export.FormatCSV},
+		{query: "format=ndjson", exp: export.FormatNDJSON},
+		{query: "format=xml", fails: true},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/vproducts?"+tt.query, nil)
+
+		format, err := export.ParseFormat(r)
+		if (err != nil) != tt.fails || format != tt.exp {
+			t.Fatalf("Should parse %q : got %q : %v", tt.query, format, err)
+		}
+	}
+}
+
+func Test_Writer(t *testing.T) {
+	rows := []row{
+		{ID: "1", Name: "Comic, Books", Cost: 10.5, Secret: "x"},
+		{ID: "2", Name: `Big "Mac"`, Cost: 3, Tags: []string{"food"}},
+	}
+
+	tests := []struct {
+		format export.Format
+		exp    string
+	}{
+		{
+			format: export.FormatCSV,
+			exp:    "id,name,cost,tags\n1,\"Comic, Books\",10.5,\n2,\"Big \"\"Mac\"\"\",3,\"[\"\"food\"\"]\"\n",
+		},
+		{
+			format: export.FormatNDJSON,
+			exp:    "{\"id\":\"1\",\"name\":\"Comic, Books\",\"cost\":10.5}\n{\"id\":\"2\",\"name\":\"Big \\\"Mac\\\"\",\"cost\":3,\"tags\":[\"food\"]}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			w, err := export.NewWriter[row](rec, tt.format)
+			if err != nil {
+				t.Fatalf("Should be able to construct a writer : %s", err)
+			}
+
+			for _, r := range rows {
+				if err := w.Write(r); err != nil {
+					t.Fatalf("Should be able to write a row : %s", err)
+				}
+			}
+
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Should be able to flush the rows : %s", err)
+			}
+
+			if got := rec.Body.String(); got != tt.exp {
+				t.Logf("got: %s", got)
+				t.Logf("exp: %s", tt.exp)
+				t.Fatalf("Should write the rows in the format")
+			}
+
+			if !rec.Flushed {
+				t.Fatalf("Should flush the rows to the client")
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	w, err := export.NewWriter[row](&buf, export.FormatCSV)
+	if err != nil {
+		t.Fatalf("Should be able to construct a writer : %s", err)
+	}
+	w.Flush()
+
+	if buf.String() != "id,name,cost,tags\n" {
+		t.Fatalf("Should write the header of an empty CSV : %q", buf.String())
+	}
+}
@@ -0,0 +1,197 @@
+// Package idempotency provides support for executing a request only once per
+// idempotency key. The request and its final response are stored in the
+// transaction the request executes in, so a retry either replays the
+// committed response or executes the request as if it never happened.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	"github.com/testvergecloud/testApi/foundation/logger"
+)
+
+// Set of default values used by the core.
+const (
+	defaultTTL           = 24 * time.Hour
+	defaultEvictInterval = time.Hour
+)
+
+// Set of error variables for idempotency keys.
+var (
+	ErrNotFound   = errors.New("idempotency key not found")
+	ErrInProgress = errors.New("a request with the idempotency key is in progress")
+	ErrMismatch   = errors.New("idempotency key was used for a different request")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve idempotency records.
+type Storer interface {
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+	Lock(ctx context.Context, key Key) (bool, error)
+	Create(ctx context.Context, rec Record) error
+	Delete(ctx context.Context, key Key) error
+	DeleteExpired(ctx context.Context, now time.Time) error
+	QueryByKey(ctx context.Context, key Key) (Record, error)
+}
+
+// Core manages the set of APIs for idempotency keys.
+type Core struct {
+	log           *logger.Logger
+	storer        Storer
+	TTL           time.Duration
+	EvictInterval time.Duration
+	cancel        context.CancelFunc
+	wg            *sync.WaitGroup
+}
+
+// NewCore constructs a core for idempotency keys api access. Expired records
+// are only removed from the store after Start is called.
+func NewCore(log *logger.Logger, storer Storer) *Core {
+	return &Core{
+		log:           log,
+		storer:        storer,
+		TTL:           defaultTTL,
+		EvictInterval: defaultEvictInterval,
+		wg:            new(sync.WaitGroup),
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	storer, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	core := *c
+	core.storer = storer
+
+	return &core, nil
+}
+
+// Start launches the goroutine removing expired records until Shutdown is
+// called.
+func (c *Core) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+
+	c.wg.Add(1)
+
+	go func() {
+		defer c.wg.Done()
+		c.evict(ctx)
+	}()
+}
+
+// Shutdown stops the goroutine launched by Start.
+func (c *Core) Shutdown(ctx context.Context) error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	ch := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(ch)
+	}()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Begin claims the key for the request with the specified fingerprint until
+// the transaction of the core ends. The stored response is returned when the
+// request was executed before. ErrNotFound means the request is new and
+// should be executed, ErrInProgress that another transaction holds the key
+// and ErrMismatch that the key was used for a different request.
+func (c *Core) Begin(ctx context.Context, key Key, fingerprint string, now time.Time) (Response, error) {
+	locked, err := c.storer.Lock(ctx, key)
+	if err != nil {
+		return Response{}, fmt.Errorf("lock: %w", err)
+	}
+
+	if !locked {
+		return Response{}, ErrInProgress
+	}
+
+	rec, err := c.storer.QueryByKey(ctx, key)
+	if err != nil {
+		return Response{}, fmt.Errorf("query: %w", err)
+	}
+
+	// An expired record can still be around when the eviction didn't run
+	// yet, the key is free to be used again.
+	if !rec.DateExpires.After(now) {
+		if err := c.storer.Delete(ctx, key); err != nil {
+			return Response{}, fmt.Errorf("delete: %w", err)
+		}
+		return Response{}, ErrNotFound
+	}
+
+	if rec.Fingerprint != fingerprint {
+		return Response{}, ErrMismatch
+	}
+
+	return rec.Response, nil
+}
+
+// Complete stores the final response of the request claimed by Begin. It's
+// committed along with the changes the request made.
+func (c *Core) Complete(ctx context.Context, key Key, fingerprint string, resp Response, now time.Time) error {
+	rec := Record{
+		Key:         key,
+		Fingerprint: fingerprint,
+		Response:    resp,
+		DateCreated: now,
+		DateExpires: now.Add(c.TTL),
+	}
+
+	if err := c.storer.Create(ctx, rec); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Core) evict(ctx context.Context) {
+	ticker := time.NewTicker(c.EvictInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.storer.DeleteExpired(ctx, time.Now()); err != nil {
+			c.log.Error(ctx, "idempotency", "status", "delete expired failed", "msg", err)
+		}
+	}
+}
+
+// =============================================================================
+
+// Fingerprint identifies a request by its method, path and body, so a key
+// can't be reused for a different request.
+func Fingerprint(method string, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,30 @@
+package idempotency
+
+import (
+	"net/http"
+	"time"
+)
+
+// Key identifies a request by the subject making it and the idempotency key
+// it was sent with. Subjects can't see each other's keys.
+type Key struct {
+	Subject string
+	Key     string
+}
+
+// Response represents the final response of a request, replayed for the
+// requests retrying it.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Record represents a request that was executed under an idempotency key.
+type Record struct {
+	Key         Key
+	Fingerprint string
+	Response    Response
+	DateCreated time.Time
+	DateExpires time.Time
+}
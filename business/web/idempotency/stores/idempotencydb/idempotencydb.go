@@ -0,0 +1,147 @@
+// Package idempotencydb contains idempotency related CRUD functionality.
+package idempotencydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	"github.com/testvergecloud/testApi/business/web/idempotency"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Store manages the set of APIs for idempotency database access.
+type Store struct {
+	log *logger.Logger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (idempotency.Storer, error) {
+	ec, err := sqldb.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	store := Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return &store, nil
+}
+
+// Lock takes a transaction level advisory lock on the key without waiting
+// for it. It reports false when another transaction holds the lock. The lock
+// is released when the transaction ends.
+func (s *Store) Lock(ctx context.Context, key idempotency.Key) (bool, error) {
+	data := struct {
+		LockKey string `db:"lock_key"`
+	}{
+		LockKey: key.Subject + ":" + key.Key,
+	}
+
+	const q = `
+	SELECT
+		pg_try_advisory_xact_lock(hashtextextended(:lock_key, 0)) AS locked`
+
+	var dest struct {
+		Locked bool `db:"locked"`
+	}
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dest); err != nil {
+		return false, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return dest.Locked, nil
+}
+
+// Create inserts the record of a request executed under an idempotency key.
+func (s *Store) Create(ctx context.Context, rec idempotency.Record) error {
+	dbRec, err := toDBRecord(rec)
+	if err != nil {
+		return err
+	}
+
+	const q = `
+	INSERT INTO idempotency_keys
+		(subject, idempotency_key, fingerprint, status_code, response_header, response_body, date_created, date_expires)
+	VALUES
+		(:subject, :idempotency_key, :fingerprint, :status_code, :response_header, :response_body, :date_created, :date_expires)`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, dbRec); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the record of the key.
+func (s *Store) Delete(ctx context.Context, key idempotency.Key) error {
+	const q = `
+	DELETE FROM
+		idempotency_keys
+	WHERE
+		subject = :subject AND idempotency_key = :idempotency_key`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, toDBKey(key)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the records that expired before now.
+func (s *Store) DeleteExpired(ctx context.Context, now time.Time) error {
+	data := struct {
+		Now time.Time `db:"now"`
+	}{
+		Now: now.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		idempotency_keys
+	WHERE
+		date_expires <= :now`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByKey gets the record of the specified key from the database.
+func (s *Store) QueryByKey(ctx context.Context, key idempotency.Key) (idempotency.Record, error) {
+	const q = `
+	SELECT
+		subject, idempotency_key, fingerprint, status_code, response_header, response_body, date_created, date_expires
+	FROM
+		idempotency_keys
+	WHERE
+		subject = :subject AND idempotency_key = :idempotency_key`
+
+	var dbRec dbRecord
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, toDBKey(key), &dbRec); err != nil {
+		if errors.Is(err, sqldb.ErrDBNotFound) {
+			return idempotency.Record{}, fmt.Errorf("namedquerystruct: %w", idempotency.ErrNotFound)
+		}
+		return idempotency.Record{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreRecord(dbRec)
+}
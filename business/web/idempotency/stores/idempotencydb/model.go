@@ -0,0 +1,81 @@
+package idempotencydb
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/web/idempotency"
+
+	"github.com/go-json-experiment/json"
+)
+
+type dbKey struct {
+	Subject string `db:"subject"`
+	Key     string `db:"idempotency_key"`
+}
+
+func toDBKey(key idempotency.Key) dbKey {
+	return dbKey{
+		Subject: key.Subject,
+		Key:     key.Key,
+	}
+}
+
+type dbRecord struct {
+	Subject        string    `db:"subject"`
+	Key            string    `db:"idempotency_key"`
+	Fingerprint    string    `db:"fingerprint"`
+	StatusCode     int       `db:"status_code"`
+	ResponseHeader string    `db:"response_header"`
+	ResponseBody   []byte    `db:"response_body"`
+	DateCreated    time.Time `db:"date_created"`
+	DateExpires    time.Time `db:"date_expires"`
+}
+
+func toDBRecord(rec idempotency.Record) (dbRecord, error) {
+	header, err := json.Marshal(rec.Response.Header)
+	if err != nil {
+		return dbRecord{}, fmt.Errorf("marshal header: %w", err)
+	}
+
+	body := rec.Response.Body
+	if body == nil {
+		body = []byte{}
+	}
+
+	return dbRecord{
+		Subject:        rec.Key.Subject,
+		Key:            rec.Key.Key,
+		Fingerprint:    rec.Fingerprint,
+		StatusCode:     rec.Response.StatusCode,
+		ResponseHeader: string(header),
+		ResponseBody:   body,
+		DateCreated:    rec.DateCreated.UTC(),
+		DateExpires:    rec.DateExpires.UTC(),
+	}, nil
+}
+
+func toCoreRecord(dbRec dbRecord) (idempotency.Record, error) {
+	var header http.Header
+	if err := json.Unmarshal([]byte(dbRec.ResponseHeader), &header); err != nil {
+		return idempotency.Record{}, fmt.Errorf("unmarshal header: %w", err)
+	}
+
+	rec := idempotency.Record{
+		Key: idempotency.Key{
+			Subject: dbRec.Subject,
+			Key:     dbRec.Key,
+		},
+		Fingerprint: dbRec.Fingerprint,
+		Response: idempotency.Response{
+			StatusCode: dbRec.StatusCode,
+			Header:     header,
+			Body:       dbRec.ResponseBody,
+		},
+		DateCreated: dbRec.DateCreated.In(time.Local),
+		DateExpires: dbRec.DateExpires.In(time.Local),
+	}
+
+	return rec, nil
+}
@@ -0,0 +1,21 @@
+package web
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ParseIDs parses a comma separated list of IDs, as passed in a query string.
+func ParseIDs(values string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, value := range strings.Split(values, ",") {
+		id, err := uuid.Parse(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
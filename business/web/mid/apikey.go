@@ -0,0 +1,63 @@
+package mid
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/apikey"
+	"github.com/testvergecloud/testApi/business/core/crud/audit"
+	"github.com/testvergecloud/testApi/business/web/auth"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// APIKeyHeader is the header machine clients send their API key in.
+const APIKeyHeader = "X-API-Key"
+
+// AuthenticateAPIKey validates an API key from the `X-API-Key` header. The
+// key produces the claims of its owner without any role, only the scopes of
+// the key the owner is still granted, so the key is only let through by the
+// permissions of the routes. Requests without the header are passed on
+// untouched.
+func AuthenticateAPIKey(a *auth.Auth, keyCore *apikey.Core) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader(APIKeyHeader)
+		if rawKey == "" {
+			c.Next()
+			return
+		}
+
+		key, owner, err := keyCore.Authenticate(c.Request.Context(), rawKey)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("authenticate: failed: %s", err)})
+			c.Abort()
+			return
+		}
+
+		claims := auth.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:       key.ID.String(),
+				Subject:  owner.ID.String(),
+				IssuedAt: jwt.NewNumericDate(key.DateCreated),
+			},
+			TenantID:   key.TenantID,
+			Scopes:     key.Permissions(a.Permissions(owner.Roles)),
+			Department: owner.Department,
+		}
+
+		if !key.DateExpires.IsZero() {
+			claims.ExpiresAt = jwt.NewNumericDate(key.DateExpires)
+		}
+
+		c.Set("userID", owner.ID)
+		c.Set("claims", claims)
+
+		ctx := setClaims(c.Request.Context(), claims)
+		ctx = audit.SetActorID(ctx, owner.ID)
+		ctx = a.Scope(ctx, claims, authRequest(c))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -34,7 +34,7 @@ func setHome(c *gin.Context, hme home.Home) {
 // specified user id from the home.
 func AuthorizeHome(a *auth.Auth, rule string, hmeCore *home.Core) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var userID uuid.UUID
+		var res auth.Resource
 
 		if id := c.Param("home_id"); id != "" {
 			homeID, err := uuid.Parse(id)
@@ -44,7 +44,7 @@ func AuthorizeHome(a *auth.Auth, rule string, hmeCore *home.Core) gin.HandlerFun
 				return
 			}
 
-			hme, err := hmeCore.QueryByID(c, homeID)
+			hme, err := hmeCore.QueryByID(c.Request.Context(), homeID)
 			if err != nil {
 				switch {
 				case errors.Is(err, home.ErrNotFound):
@@ -56,12 +56,12 @@ func AuthorizeHome(a *auth.Auth, rule string, hmeCore *home.Core) gin.HandlerFun
 				return
 			}
 
-			userID = hme.UserID
+			res = homeResource(hme)
 			setHome(c, hme)
 		}
 
-		claims := getClaims(c)
-		if err := a.Authorize(c, claims, userID, rule); err != nil {
+		claims := getClaims(c.Request.Context())
+		if err := a.Authorize(c, claims, rule, res, authRequest(c)); err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": fmt.Sprintf("authorize: you are not authorized for that action, claims[%v] rule[%v]: %s", claims.Roles, rule, err)})
 			c.Abort()
 			return
@@ -70,3 +70,35 @@ func AuthorizeHome(a *auth.Auth, rule string, hmeCore *home.Core) gin.HandlerFun
 		c.Next()
 	}
 }
+
+// AuthorizeHomeItem executes the specified rule for a single home of a request
+// acting on several of them. A zero home stands for one that doesn't exist
+// yet.
+func AuthorizeHomeItem(c *gin.Context, a *auth.Auth, rule string, hme home.Home) error {
+	var res auth.Resource
+	if hme.ID != uuid.Nil {
+		res = homeResource(hme)
+	}
+
+	claims := getClaims(c.Request.Context())
+	if err := a.Authorize(c.Request.Context(), claims, rule, res, authRequest(c)); err != nil {
+		return fmt.Errorf("authorize: you are not authorized for that action, claims[%v] rule[%v]: %w", claims.Roles, rule, err)
+	}
+
+	return nil
+}
+
+// homeResource describes the home for the authorization policy.
+func homeResource(hme home.Home) auth.Resource {
+	return auth.Resource{
+		Type:    "home",
+		ID:      hme.ID.String(),
+		OwnerID: hme.UserID,
+		Attributes: map[string]any{
+			"type":    hme.Type.Name(),
+			"city":    hme.Address.City,
+			"state":   hme.Address.State,
+			"country": hme.Address.Country,
+		},
+	}
+}
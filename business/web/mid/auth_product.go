@@ -36,7 +36,7 @@ func setProduct(ctx context.Context, prd product.Product) context.Context {
 // specified user id from the product.
 func AuthorizeProduct(a *auth.Auth, rule string, prdCore *product.Core) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var userID uuid.UUID
+		var res auth.Resource
 
 		if id := c.Param("product_id"); id != "" {
 			var err error
@@ -47,7 +47,7 @@ func AuthorizeProduct(a *auth.Auth, rule string, prdCore *product.Core) gin.Hand
 				return
 			}
 
-			prd, err := prdCore.QueryByID(c, productID)
+			prd, err := prdCore.QueryByID(c.Request.Context(), productID)
 			if err != nil {
 				switch {
 				case errors.Is(err, product.ErrNotFound):
@@ -55,19 +55,19 @@ func AuthorizeProduct(a *auth.Auth, rule string, prdCore *product.Core) gin.Hand
 					c.Abort()
 					return
 				default:
-					c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("querybyid: productID[%s]: %w", productID, err)})
+					c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("querybyid: productID[%s]: %s", productID, err)})
 					c.Abort()
 					return
 				}
 			}
 
-			userID = prd.UserID
+			res = productResource(prd)
 			c.Request = c.Request.WithContext(setProduct(c.Request.Context(), prd))
 		}
 
 		claims := getClaims(c.Request.Context())
 
-		if err := a.Authorize(c.Request.Context(), claims, userID, rule); err != nil {
+		if err := a.Authorize(c.Request.Context(), claims, rule, res, authRequest(c)); err != nil {
 			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("authorize: you are not authorized for that action, claims[%v] rule[%v]: %s", claims.Roles, rule, err)})
 			c.Abort()
 			return
@@ -76,3 +76,34 @@ func AuthorizeProduct(a *auth.Auth, rule string, prdCore *product.Core) gin.Hand
 		c.Next()
 	}
 }
+
+// AuthorizeProductItem executes the specified rule for a single product of a request
+// acting on several of them. A zero product stands for one that doesn't exist
+// yet.
+func AuthorizeProductItem(c *gin.Context, a *auth.Auth, rule string, prd product.Product) error {
+	var res auth.Resource
+	if prd.ID != uuid.Nil {
+		res = productResource(prd)
+	}
+
+	claims := getClaims(c.Request.Context())
+	if err := a.Authorize(c.Request.Context(), claims, rule, res, authRequest(c)); err != nil {
+		return fmt.Errorf("authorize: you are not authorized for that action, claims[%v] rule[%v]: %w", claims.Roles, rule, err)
+	}
+
+	return nil
+}
+
+// productResource describes the product for the authorization policy.
+func productResource(prd product.Product) auth.Resource {
+	return auth.Resource{
+		Type:    "product",
+		ID:      prd.ID.String(),
+		OwnerID: prd.UserID,
+		Attributes: map[string]any{
+			"name":     prd.Name,
+			"cost":     prd.Cost,
+			"quantity": prd.Quantity,
+		},
+	}
+}
@@ -0,0 +1,132 @@
+package mid_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/product"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/foundation/config"
+	"github.com/testvergecloud/testApi/foundation/keystore"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+func Benchmark_Authenticate(b *testing.B) {
+	a, token := newBenchAuth(b)
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.GET("/check", mid.Authenticate(a), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	benchRequest(b, router, "/check", token)
+}
+
+func Benchmark_AuthorizeProduct(b *testing.B) {
+	a, token := newBenchAuth(b)
+
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	prd := product.Product{
+		ID:     uuid.New(),
+		UserID: uuid.MustParse(subject),
+	}
+	prdCore := product.NewCore(log, nil, nil, nil, &productStore{prd: prd})
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.GET("/products/:product_id", mid.Authenticate(a), mid.AuthorizeProduct(a, auth.RuleAdminOrSubject, prdCore), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	benchRequest(b, router, "/products/"+prd.ID.String(), token)
+}
+
+// =============================================================================
+
+const subject = "5cf37266-3473-4006-984f-9325122678b7"
+
+func benchRequest(b *testing.B, router http.Handler, target string, token string) {
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			b.Fatalf("Should get a 200 status: %d %s", w.Code, w.Body.String())
+		}
+	}
+}
+
+func newBenchAuth(b *testing.B) (*auth.Auth, string) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		b.Fatalf("Should be able to generate a key: %s", err)
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+
+	ks := keystore.New()
+	if err := ks.LoadKeys(fstest.MapFS{"bench.pem": {Data: privatePEM}}); err != nil {
+		b.Fatalf("Should be able to load the keys: %s", err)
+	}
+
+	cfg := config.Config{
+		Auth: &config.Auth{
+			Issuer: "service project",
+		},
+	}
+
+	a, err := auth.New(&cfg, nil, ks, log, nil, nil)
+	if err != nil {
+		b.Fatalf("Should be able to create an authenticator: %s", err)
+	}
+
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: subject,
+		},
+		Roles: []user.Role{user.RoleUser},
+	}
+
+	token, err := a.GenerateToken(claims)
+	if err != nil {
+		b.Fatalf("Should be able to generate a token: %s", err)
+	}
+
+	return a, token
+}
+
+// productStore only implements the lookup needed by the middleware.
+type productStore struct {
+	product.Storer
+	prd product.Product
+}
+
+func (s *productStore) QueryByID(ctx context.Context, productID uuid.UUID) (product.Product, error) {
+	return s.prd, nil
+}
@@ -9,11 +9,11 @@ import (
 	"github.com/testvergecloud/testApi/business/web/auth"
 )
 
-type ctxUserKey int
+type ctxUserKey string
 
 const (
-	userIDKey ctxUserKey = iota + 1
-	userKey
+	userIDKey ctxUserKey = "userID"
+	userKey   ctxUserKey = "user"
 )
 
 // GetUserID returns the claims from the context.
@@ -48,29 +48,29 @@ func setUser(c *gin.Context, usr user.User) {
 // user id.
 func AuthorizeUser(a *auth.Auth, rule string, usrCore *user.Core) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var userID uuid.UUID
+		var res auth.Resource
 
 		if id := c.Param("user_id"); id != "" {
-			var err error
-			userID, err = uuid.Parse(id)
+			userID, err := uuid.Parse(id)
 			if err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 				c.Abort()
 				return
 			}
 
-			usr, err := usrCore.QueryByID(c, userID)
+			usr, err := usrCore.QueryByID(c.Request.Context(), userID)
 			if err != nil {
 				c.JSON(http.StatusNoContent, gin.H{"error": "User not found"})
 				c.Abort()
 				return
 			}
 
+			res = userResource(a, usr)
 			setUser(c, usr)
 		}
 
-		claims := getClaims(c)
-		if err := a.Authorize(c, claims, userID, rule); err != nil {
+		claims := getClaims(c.Request.Context())
+		if err := a.Authorize(c, claims, rule, res, authRequest(c)); err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "You are not authorized for that action"})
 			c.Abort()
 			return
@@ -79,3 +79,24 @@ func AuthorizeUser(a *auth.Auth, rule string, usrCore *user.Core) gin.HandlerFun
 		c.Next()
 	}
 }
+
+// userResource describes the user for the authorization policy. A user owns
+// itself.
+func userResource(a *auth.Auth, usr user.User) auth.Resource {
+	roles := make([]string, len(usr.Roles))
+	for i, role := range usr.Roles {
+		roles[i] = role.Name()
+	}
+
+	return auth.Resource{
+		Type:    "user",
+		ID:      usr.ID.String(),
+		OwnerID: usr.ID,
+		Attributes: map[string]any{
+			"department":  usr.Department,
+			"roles":       roles,
+			"enabled":     usr.Enabled,
+			"permissions": a.Permissions(usr.Roles),
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package mid_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/testvergecloud/testApi/business/core/crud/user"
+	"github.com/testvergecloud/testApi/business/web/mid"
+)
+
+func Test_UserContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	if got := mid.GetUserID(c); got != (uuid.UUID{}) {
+		t.Fatalf("Should get a zero user id when none is set: got %s", got)
+	}
+
+	if got := mid.GetUser(c); got.ID != (uuid.UUID{}) {
+		t.Fatalf("Should get a zero user when none is set: got %s", got.ID)
+	}
+
+	userID := uuid.New()
+	usr := user.User{ID: userID, Name: "Bill Kennedy"}
+
+	c.Set("userID", userID)
+	c.Set("user", usr)
+
+	if got := mid.GetUserID(c); got != userID {
+		t.Fatalf("Should get the user id stored under \"userID\": got %s, exp %s", got, userID)
+	}
+
+	if got := mid.GetUser(c); got.ID != userID || got.Name != usr.Name {
+		t.Fatalf("Should get the user stored under \"user\": got %s, exp %s", got.ID, userID)
+	}
+}
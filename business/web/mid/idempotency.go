@@ -0,0 +1,157 @@
+package mid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	"github.com/testvergecloud/testApi/business/web/idempotency"
+)
+
+// IdempotencyKeyHeader is the header clients send their idempotency key in.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLen is the longest idempotency key accepted.
+const maxIdempotencyKeyLen = 255
+
+// Idempotency executes a POST request only once per idempotency key of the
+// subject. The final response is stored in the transaction of the request,
+// so it must run after ExecuteInTransaction. A retry gets the stored response
+// back, a request racing one with the same key gets a 409 and a different
+// request under a used key gets a 422. Requests without the header and a nil
+// core are passed on untouched.
+func Idempotency(idmCore *idempotency.Core) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader(IdempotencyKeyHeader)
+		if idmCore == nil || rawKey == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+
+		if len(rawKey) > maxIdempotencyKeyLen {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("idempotency: key longer than %d characters", maxIdempotencyKeyLen)})
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		tx, ok := transaction.Get(ctx)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "idempotency: request is not executed under a transaction"})
+			c.Abort()
+			return
+		}
+
+		idmCore, err := idmCore.ExecuteUnderTransaction(tx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("idempotency: %s", err)})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("idempotency: read body: %s", err)})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := idempotency.Key{
+			Subject: getClaims(ctx).Subject,
+			Key:     rawKey,
+		}
+		fingerprint := idempotency.Fingerprint(c.Request.Method, c.Request.URL.Path, body)
+
+		resp, err := idmCore.Begin(ctx, key, fingerprint, time.Now())
+		switch {
+		case err == nil:
+			replay(c, resp)
+			c.Abort()
+			return
+
+		case errors.Is(err, idempotency.ErrInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("idempotency: %s", err)})
+			c.Abort()
+			return
+
+		case errors.Is(err, idempotency.ErrMismatch):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("idempotency: %s", err)})
+			c.Abort()
+			return
+
+		case !errors.Is(err, idempotency.ErrNotFound):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("idempotency: %s", err)})
+			c.Abort()
+			return
+		}
+
+		// Only the headers set by the handler belong to the response, the
+		// ones set ahead of it describe this particular request.
+		before := c.Writer.Header().Clone()
+
+		rec := responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = &rec
+
+		c.Next()
+
+		c.Writer = rec.ResponseWriter
+
+		// A failed request is rolled back along with its key, so a retry
+		// executes it again.
+		if len(c.Errors) > 0 || rec.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		resp = idempotency.Response{
+			StatusCode: rec.Status(),
+			Header:     make(http.Header),
+			Body:       rec.body.Bytes(),
+		}
+		for k, v := range c.Writer.Header() {
+			if !slices.Equal(before[k], v) {
+				resp.Header[k] = v
+			}
+		}
+
+		if err := idmCore.Complete(ctx, key, fingerprint, resp, time.Now()); err != nil {
+			// Recording the error rolls back the transaction.
+			c.Error(fmt.Errorf("idempotency: complete: %w", err))
+		}
+	}
+}
+
+// replay writes the stored response of a request executed before.
+func replay(c *gin.Context, resp idempotency.Response) {
+	h := c.Writer.Header()
+	for k, v := range resp.Header {
+		h[k] = v
+	}
+	h.Set("Idempotent-Replayed", "true")
+
+	c.Writer.WriteHeader(resp.StatusCode)
+	c.Writer.Write(resp.Body)
+}
+
+// responseRecorder keeps a copy of the body written through it.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
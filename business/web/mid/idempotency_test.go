@@ -0,0 +1,163 @@
+package mid_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/data/transaction"
+	"github.com/testvergecloud/testApi/business/web/idempotency"
+	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/foundation/logger"
+)
+
+func Test_Idempotency(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	store := newIdempotencyStore()
+	idmCore := idempotency.NewCore(log, store)
+
+	var created int
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.POST("/products", inTransaction, mid.Idempotency(idmCore), func(c *gin.Context) {
+		created++
+		c.Header("ETag", fmt.Sprintf(`"%d"`, created))
+		c.JSON(http.StatusCreated, gin.H{"id": created})
+	})
+
+	request := func(key string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(mid.IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := request("k1", `{"name":"a"}`)
+	if w.Code != http.StatusCreated || created != 1 {
+		t.Fatalf("Should execute the first request: %d %d", w.Code, created)
+	}
+
+	replayed := request("k1", `{"name":"a"}`)
+	if replayed.Code != http.StatusCreated || created != 1 {
+		t.Fatalf("Should NOT execute a retried request: %d %d", replayed.Code, created)
+	}
+
+	if replayed.Body.String() != w.Body.String() || replayed.Header().Get("ETag") != w.Header().Get("ETag") {
+		t.Fatalf("Should replay the stored response: %s %v", replayed.Body, replayed.Header())
+	}
+
+	if replayed.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("Should tell the client the response was replayed: %v", replayed.Header())
+	}
+
+	if w := request("k1", `{"name":"b"}`); w.Code != http.StatusUnprocessableEntity || created != 1 {
+		t.Fatalf("Should NOT accept a different request under a used key: %d", w.Code)
+	}
+
+	store.lock(idempotency.Key{Key: "k2"})
+	if w := request("k2", `{"name":"a"}`); w.Code != http.StatusConflict || created != 1 {
+		t.Fatalf("Should NOT execute a request racing one with the same key: %d", w.Code)
+	}
+
+	for range 2 {
+		request("", `{"name":"a"}`)
+	}
+	if created != 3 {
+		t.Fatalf("Should execute every request without a key: %d", created)
+	}
+
+	// Expired keys are free to be used again.
+	idmCore.TTL = -time.Second
+	request("k3", `{"name":"a"}`)
+	request("k3", `{"name":"a"}`)
+	if created != 5 {
+		t.Fatalf("Should execute a request again once its key expired: %d", created)
+	}
+}
+
+// =============================================================================
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func inTransaction(c *gin.Context) {
+	c.Request = c.Request.WithContext(transaction.Set(c.Request.Context(), fakeTx{}))
+	c.Next()
+}
+
+type idempotencyStore struct {
+	mu      sync.Mutex
+	locked  map[idempotency.Key]bool
+	records map[idempotency.Key]idempotency.Record
+}
+
+func newIdempotencyStore() *idempotencyStore {
+	return &idempotencyStore{
+		locked:  make(map[idempotency.Key]bool),
+		records: make(map[idempotency.Key]idempotency.Record),
+	}
+}
+
+func (s *idempotencyStore) lock(key idempotency.Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locked[key] = true
+}
+
+func (s *idempotencyStore) ExecuteUnderTransaction(tx transaction.Transaction) (idempotency.Storer, error) {
+	return s, nil
+}
+
+func (s *idempotencyStore) Lock(ctx context.Context, key idempotency.Key) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.locked[key], nil
+}
+
+func (s *idempotencyStore) Create(ctx context.Context, rec idempotency.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[rec.Key] = rec
+	return nil
+}
+
+func (s *idempotencyStore) Delete(ctx context.Context, key idempotency.Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+func (s *idempotencyStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	return nil
+}
+
+func (s *idempotencyStore) QueryByKey(ctx context.Context, key idempotency.Key) (idempotency.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, exists := s.records[key]
+	if !exists {
+		return idempotency.Record{}, idempotency.ErrNotFound
+	}
+	return rec, nil
+}
@@ -0,0 +1,81 @@
+package mid
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/ratelimit"
+)
+
+// RateLimit limits the requests of each client to the quota with the
+// specified name. Clients are told apart by their API key, the subject of
+// their token or else their IP. The token is only verified to be signed by
+// this service, so the limit applies before the request is authenticated. A
+// nil limiter doesn't limit anything.
+func RateLimit(a *auth.Auth, rl *ratelimit.Limiter, quota string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rl == nil {
+			c.Next()
+			return
+		}
+
+		q := rl.Quota(quota)
+
+		key, limit := rateLimitKey(c, a, q)
+
+		res := rl.Allow(c.Request.Context(), q.Name+":"+key, limit)
+		if res.Limit.Unlimited() {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", res.Limit.Requests, seconds(res.Limit.Period)))
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit.Requests))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("ratelimit: too many requests, retry in %s", res.RetryAfter.Round(time.Second))})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey identifies the client of the request and returns the limit
+// that applies to it.
+func rateLimitKey(c *gin.Context, a *auth.Auth, q ratelimit.Quota) (string, ratelimit.Limit) {
+	claims := getClaims(c.Request.Context())
+
+	if claims.Subject == "" && a != nil {
+		if header := c.GetHeader("authorization"); header != "" {
+			if identified, err := a.Identify(header); err == nil {
+				claims = identified
+			}
+		}
+	}
+
+	switch {
+	case claims.Subject != "" && c.GetHeader(APIKeyHeader) != "":
+		return "key:" + claims.ID, q.User
+
+	case claims.Subject != "":
+		return "user:" + claims.Subject, q.User
+	}
+
+	return "ip:" + c.ClientIP(), q.IP
+}
+
+// seconds rounds the duration up to whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
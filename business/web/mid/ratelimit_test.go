@@ -0,0 +1,67 @@
+package mid_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/business/web/ratelimit"
+	"github.com/testvergecloud/testApi/business/web/ratelimit/stores/ratelimitmem"
+	"github.com/testvergecloud/testApi/foundation/logger"
+)
+
+func Test_RateLimit(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	quota := ratelimit.Quota{
+		Name: ratelimit.Token,
+		IP:   ratelimit.Limit{Requests: 1, Period: time.Minute},
+	}
+	rl := ratelimit.NewLimiter(log, ratelimitmem.NewStore(), quota)
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.GET("/limited", mid.RateLimit(nil, rl, ratelimit.Token), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/unlimited", mid.RateLimit(nil, nil, ratelimit.Token), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(target string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := request("/limited")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Should allow the first request: %d", w.Code)
+	}
+
+	if w.Header().Get("RateLimit-Limit") != "1" || w.Header().Get("RateLimit-Remaining") != "0" || w.Header().Get("RateLimit-Policy") != "1;w=60" {
+		t.Fatalf("Should describe the limit in the headers: %v", w.Header())
+	}
+
+	w = request("/limited")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Should NOT allow more requests than the limit: %d", w.Code)
+	}
+
+	if w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("Should tell the client when to retry: %v", w.Header())
+	}
+
+	for range 2 {
+		if w := request("/unlimited"); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("Should not limit requests without a limiter: %d", w.Code)
+		}
+	}
+}
@@ -3,12 +3,20 @@
 package mux
 
 import (
+	"context"
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/testvergecloud/testApi/business/core/crud/apikey"
 	"github.com/testvergecloud/testApi/business/core/crud/delegate"
+	"github.com/testvergecloud/testApi/business/core/crud/identity"
+	"github.com/testvergecloud/testApi/business/core/crud/role"
 	"github.com/testvergecloud/testApi/business/web/auth"
+	"github.com/testvergecloud/testApi/business/web/idempotency"
 	"github.com/testvergecloud/testApi/business/web/mid"
+	"github.com/testvergecloud/testApi/business/web/ratelimit"
+	"github.com/testvergecloud/testApi/foundation/keystore"
 	"github.com/testvergecloud/testApi/foundation/logger"
 	"github.com/testvergecloud/testApi/foundation/web"
 
@@ -18,7 +26,8 @@ import (
 
 // Options represent optional parameters.
 type Options struct {
-	corsOrigin []string
+	corsOrigin     []string
+	trustedProxies []string
 }
 
 // WithCORS provides configuration options for CORS.
@@ -28,15 +37,33 @@ func WithCORS(origins []string) func(opts *Options) {
 	}
 }
 
+// WithTrustedProxies provides the proxies in front of the service, only their
+// forwarding headers are used to find the IP address of the client.
+func WithTrustedProxies(proxies []string) func(opts *Options) {
+	return func(opts *Options) {
+		opts.trustedProxies = proxies
+	}
+}
+
 // Config contains all the mandatory systems required by handlers.
 type Config struct {
-	Build    string
-	Shutdown chan os.Signal
-	Log      *logger.Logger
-	Delegate *delegate.Delegate
-	Auth     *auth.Auth
-	DB       *sqlx.DB
-	Tracer   trace.Tracer
+	Build           string
+	Shutdown        chan os.Signal
+	Log             *logger.Logger
+	Delegate        *delegate.Delegate
+	Auth            *auth.Auth
+	DB              *sqlx.DB
+	Tracer          trace.Tracer
+	RequireIfMatch  bool
+	CursorKey       []byte
+	RefreshTokenTTL time.Duration
+	KeyStore        *keystore.KeyStore
+	Role            *role.Core
+	APIKey          *apikey.Core
+	OIDC            *auth.OIDC
+	Identity        *identity.Core
+	RateLimit       *ratelimit.Limiter
+	Idempotency     *idempotency.Core
 }
 
 // RouteAdder defines behavior that sets the routes to bind for an instance
@@ -61,10 +88,28 @@ func WebAPI(cfg Config, routeAdder RouteAdder, options ...func(opts *Options)) h
 		mid.Panics(),
 	)
 
+	if len(opts.trustedProxies) > 0 {
+		if err := app.TrustProxies(opts.trustedProxies); err != nil {
+			cfg.Log.Error(context.Background(), "trusted proxies", "msg", err)
+		}
+	}
+
 	if len(opts.corsOrigin) > 0 {
 		app.EnableCORS(mid.Cors(opts.corsOrigin))
 	}
 
+	// Every route gets the default quota so a single client can't saturate
+	// the service and its database. Route groups add tighter quotas. It comes
+	// before the API keys are checked, so guessing keys is limited by the
+	// quota of the IP.
+	app.Mux.Use(mid.RateLimit(cfg.Auth, cfg.RateLimit, ratelimit.Default))
+
+	// API keys are accepted on every route, they're checked ahead of the
+	// route groups so the token authentication of the groups can rely on it.
+	if cfg.APIKey != nil {
+		app.Mux.Use(mid.AuthenticateAPIKey(cfg.Auth, cfg.APIKey))
+	}
+
 	routeAdder.Add(app, cfg)
 
 	return app
@@ -0,0 +1,166 @@
+package mux_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/testvergecloud/testApi/business/core/crud/apikey"
+	"github.com/testvergecloud/testApi/business/web/mux"
+	"github.com/testvergecloud/testApi/business/web/ratelimit"
+	"github.com/testvergecloud/testApi/business/web/ratelimit/stores/ratelimitmem"
+	"github.com/testvergecloud/testApi/foundation/logger"
+	"github.com/testvergecloud/testApi/foundation/web"
+
+	"github.com/google/uuid"
+)
+
+func Test_APIKeyRateLimit(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	quota := ratelimit.Quota{
+		Name: ratelimit.Default,
+		IP:   ratelimit.Limit{Requests: 3, Period: time.Minute},
+	}
+
+	store := keyStore{}
+
+	gin.SetMode(gin.ReleaseMode)
+	handler := mux.WebAPI(mux.Config{
+		Shutdown:  make(chan os.Signal, 1),
+		Log:       log,
+		APIKey:    apikey.NewCore(log, nil, nil, &store, nil),
+		RateLimit: ratelimit.NewLimiter(log, ratelimitmem.NewStore(), quota),
+	}, routes{})
+
+	// The key looks like a key, so each attempt is looked up.
+	const badKey = "cdn_0123456789ab_0123456789abcdef0123456789abcdef"
+
+	for i := range quota.IP.Requests {
+		r := httptest.NewRequest(http.MethodGet, "/v1/check", nil)
+		r.Header.Set("X-API-Key", badKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("Should reject the bad key %d: %d", i, w.Code)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/check", nil)
+	r.Header.Set("X-API-Key", badKey)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Should limit the attempts with bad keys: %d", w.Code)
+	}
+
+	if n := store.lookups.Load(); n != int64(quota.IP.Requests) {
+		t.Errorf("Exp: %d", quota.IP.Requests)
+		t.Errorf("Got: %d", n)
+		t.Fatal("Should NOT look up keys once the quota is used up")
+	}
+}
+
+func Test_ForwardedFor(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	quota := ratelimit.Quota{
+		Name: ratelimit.Default,
+		IP:   ratelimit.Limit{Requests: 1, Period: time.Minute},
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+
+	newHandler := func(options ...func(opts *mux.Options)) http.Handler {
+		return mux.WebAPI(mux.Config{
+			Shutdown:  make(chan os.Signal, 1),
+			Log:       log,
+			RateLimit: ratelimit.NewLimiter(log, ratelimitmem.NewStore(), quota),
+		}, routes{}, options...)
+	}
+
+	send := func(handler http.Handler, forwardedFor string) int {
+		r := httptest.NewRequest(http.MethodGet, "/v1/check", nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	t.Run("untrusted", func(t *testing.T) {
+		handler := newHandler()
+
+		if code := send(handler, "198.51.100.1"); code != http.StatusOK {
+			t.Fatalf("Should allow the first request: %d", code)
+		}
+
+		if code := send(handler, "198.51.100.2"); code != http.StatusTooManyRequests {
+			t.Fatalf("Should NOT take the IP from a spoofed X-Forwarded-For: %d", code)
+		}
+	})
+
+	t.Run("trusted", func(t *testing.T) {
+		handler := newHandler(mux.WithTrustedProxies([]string{"192.0.2.0/24"}))
+
+		if code := send(handler, "198.51.100.1"); code != http.StatusOK {
+			t.Fatalf("Should allow the first client: %d", code)
+		}
+
+		if code := send(handler, "198.51.100.2"); code != http.StatusOK {
+			t.Fatalf("Should take the IP from the X-Forwarded-For of a trusted proxy: %d", code)
+		}
+
+		if code := send(handler, "198.51.100.1"); code != http.StatusTooManyRequests {
+			t.Fatalf("Should limit the first client: %d", code)
+		}
+	})
+}
+
+// =============================================================================
+
+type routes struct{}
+
+func (routes) Add(app *web.App, cfg mux.Config) {
+	app.Handle(http.MethodGet, app.Mux.Group("/v1"), "/check", func(c *gin.Context) error {
+		c.Status(http.StatusOK)
+		return nil
+	})
+}
+
+// keyStore doesn't know any key.
+type keyStore struct {
+	lookups atomic.Int64
+}
+
+func (s *keyStore) Create(ctx context.Context, key apikey.APIKey) error {
+	return nil
+}
+
+func (s *keyStore) Update(ctx context.Context, key apikey.APIKey) error {
+	return nil
+}
+
+func (s *keyStore) QueryAll(ctx context.Context) ([]apikey.APIKey, error) {
+	return nil, nil
+}
+
+func (s *keyStore) QueryByID(ctx context.Context, keyID uuid.UUID) (apikey.APIKey, error) {
+	return apikey.APIKey{}, apikey.ErrNotFound
+}
+
+func (s *keyStore) QueryByPrefix(ctx context.Context, prefix string) (apikey.APIKey, error) {
+	s.lookups.Add(1)
+	return apikey.APIKey{}, apikey.ErrNotFound
+}
@@ -21,10 +21,12 @@ var directions = map[string]string{
 	DESC: "DESC",
 }
 
-// By represents a field used to order by and direction.
+// By represents a field used to order by and direction. Then holds the
+// fields used to order rows that are equal on the fields before them.
 type By struct {
 	Field     string
 	Direction string
+	Then      []By
 }
 
 // NewBy constructs a new By value with no checks.
@@ -42,8 +44,44 @@ func NewBy(field string, direction string) By {
 	}
 }
 
-// Parse constructs a By value by parsing a string in the form
-// of "field,direction".
+// ThenBy returns a copy of the By value with an additional field to order by.
+func (b By) ThenBy(field string, direction string) By {
+	then := make([]By, len(b.Then), len(b.Then)+1)
+	copy(then, b.Then)
+	b.Then = append(then, NewBy(field, direction))
+
+	return b
+}
+
+// Fields returns the list of fields to order by, in order of precedence.
+func (b By) Fields() []By {
+	fields := make([]By, 0, len(b.Then)+1)
+	fields = append(fields, By{Field: b.Field, Direction: b.Direction})
+
+	return append(fields, b.Then...)
+}
+
+// Map returns a copy of the By value with every field renamed using the
+// specified mapping. Fields that are not part of the mapping are kept as is.
+func (b By) Map(fields map[string]string) By {
+	rename := func(field string) string {
+		if name, exists := fields[field]; exists {
+			return name
+		}
+		return field
+	}
+
+	by := NewBy(rename(b.Field), b.Direction)
+	for _, then := range b.Then {
+		by = by.ThenBy(rename(then.Field), then.Direction)
+	}
+
+	return by
+}
+
+// Parse constructs a By value by parsing a string in the form of
+// "field,direction". Multiple fields are separated by a semicolon, as in
+// "field,direction;field,direction".
 func Parse(r *http.Request, defaultOrder By) (By, error) {
 	v := r.URL.Query().Get("orderBy")
 
@@ -51,24 +89,47 @@ func Parse(r *http.Request, defaultOrder By) (By, error) {
 		return defaultOrder, nil
 	}
 
+	var by By
+	seen := make(map[string]bool)
+
+	for i, part := range strings.Split(v, ";") {
+		field, err := parseField(part)
+		if err != nil {
+			return By{}, validate.NewFieldsError(v, err)
+		}
+
+		if seen[field.Field] {
+			return By{}, validate.NewFieldsError(v, fmt.Errorf("duplicate order field: %s", field.Field))
+		}
+		seen[field.Field] = true
+
+		switch i {
+		case 0:
+			by = field
+		default:
+			by = by.ThenBy(field.Field, field.Direction)
+		}
+	}
+
+	return by, nil
+}
+
+func parseField(v string) (By, error) {
 	orderParts := strings.Split(v, ",")
 
-	var by By
 	switch len(orderParts) {
 	case 1:
-		by = NewBy(strings.TrimSpace(orderParts[0]), ASC)
+		return NewBy(strings.TrimSpace(orderParts[0]), ASC), nil
 
 	case 2:
 		direction := strings.Trim(orderParts[1], " ")
 		if _, exists := directions[direction]; !exists {
-			return By{}, validate.NewFieldsError(v, fmt.Errorf("unknown direction: %s", by.Direction))
+			return By{}, fmt.Errorf("unknown direction: %s", direction)
 		}
 
-		by = NewBy(strings.Trim(orderParts[0], " "), direction)
+		return NewBy(strings.Trim(orderParts[0], " "), direction), nil
 
 	default:
-		return By{}, validate.NewFieldsError(v, errors.New("unknown order field"))
+		return By{}, errors.New("unknown order field")
 	}
-
-	return by, nil
 }
@@ -0,0 +1,66 @@
+package order_test
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/testvergecloud/testApi/business/web/order"
+)
+
+func Test_Parse(t *testing.T) {
+	defaultOrder := order.NewBy("product_id", order.ASC)
+
+	tests := []struct {
+		name    string
+		orderBy string
+		exp     order.By
+		fails   bool
+	}{
+		{name: "default", orderBy: "", exp: defaultOrder},
+		{name: "field", orderBy: "name", exp: order.NewBy("name", order.ASC)},
+		{name: "direction", orderBy: "name,DESC", exp: order.NewBy("name", order.DESC)},
+		{name: "multiple", orderBy: "user_name,ASC;cost,DESC", exp: order.NewBy("user_name", order.ASC).ThenBy("cost", order.DESC)},
+		{name: "bad-direction", orderBy: "name,UP", fails: true},
+		{name: "duplicate", orderBy: "name;name,DESC", fails: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/?orderBy="+url.QueryEscape(tt.orderBy), nil)
+
+			by, err := order.Parse(r, defaultOrder)
+			switch {
+			case tt.fails:
+				if err == nil {
+					t.Fatalf("Should not be able to parse %q", tt.orderBy)
+				}
+
+			default:
+				if err != nil {
+					t.Fatalf("Should be able to parse %q: %s", tt.orderBy, err)
+				}
+
+				if !reflect.DeepEqual(by, tt.exp) {
+					t.Fatalf("Should get the expected order: got %+v, exp %+v", by, tt.exp)
+				}
+			}
+		})
+	}
+}
+
+func Test_Map(t *testing.T) {
+	by := order.NewBy("name", order.DESC).ThenBy("cost", order.ASC)
+
+	got := by.Map(map[string]string{"name": "user_name", "cost": "cost"})
+
+	fields := got.Fields()
+	if len(fields) != 2 || fields[0].Field != "user_name" || fields[0].Direction != order.DESC || fields[1].Field != "cost" {
+		t.Fatalf("Should rename every field keeping the directions: %+v", fields)
+	}
+
+	if by.Field != "name" {
+		t.Fatalf("Should not modify the original value: %+v", by)
+	}
+}
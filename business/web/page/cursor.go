@@ -0,0 +1,238 @@
+package page
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/foundation/validate"
+)
+
+// ErrInvalidCursor is returned when a cursor can't be decoded or its
+// signature doesn't match.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor represents a position in an ordered result set used for keyset
+// paging. Values holds the order by fields and ID holds the primary key of
+// the row the cursor points at. When Backward is true, the rows before the
+// position are requested instead of the rows after it.
+type Cursor struct {
+	OrderBy  order.By `json:"o"`
+	Values   []string `json:"v"`
+	ID       string   `json:"i"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// IsZero reports whether the cursor points at the start of the result set.
+func (c Cursor) IsZero() bool {
+	return c.ID == ""
+}
+
+// Comparison returns the operator that selects the rows past the cursor
+// position for the specified order direction.
+func (c Cursor) Comparison(direction string) string {
+	if (direction == order.DESC) != c.Backward {
+		return "<"
+	}
+	return ">"
+}
+
+// Direction returns the direction rows need to be fetched in to move away
+// from the cursor position for the specified order direction.
+func (c Cursor) Direction(direction string) string {
+	if !c.Backward {
+		return direction
+	}
+
+	if direction == order.DESC {
+		return order.ASC
+	}
+	return order.DESC
+}
+
+// Condition returns the condition that selects the rows past the cursor
+// position. The columns and directions line up with the cursor values
+// followed by the primary key column, which is compared with the cursor ID.
+// The values are added to data as named parameters.
+func (c Cursor) Condition(columns []string, directions []string, data map[string]interface{}) (string, error) {
+	if len(columns) != len(c.Values)+1 || len(directions) != len(columns) {
+		return "", ErrInvalidCursor
+	}
+
+	params := make([]string, len(columns))
+	for i := range columns {
+		params[i] = fmt.Sprintf("cursor_%d", i)
+		switch i {
+		case len(columns) - 1:
+			data[params[i]] = c.ID
+		default:
+			data[params[i]] = c.Values[i]
+		}
+	}
+
+	// Rows past the cursor are greater, or smaller for a descending column,
+	// on a column and equal on all the columns before it.
+	terms := make([]string, len(columns))
+	for i := range columns {
+		conds := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conds = append(conds, columns[j]+" = :"+params[j])
+		}
+		conds = append(conds, columns[i]+" "+c.Comparison(directions[i])+" :"+params[i])
+
+		terms[i] = "(" + strings.Join(conds, " AND ") + ")"
+	}
+
+	return "(" + strings.Join(terms, " OR ") + ")", nil
+}
+
+// Cursors holds the cursors pointing at the pages adjacent to a result set. A
+// nil cursor means there is no page in that direction.
+type Cursors struct {
+	Next *Cursor
+	Prev *Cursor
+}
+
+// Encode returns the signed string form of the cursors.
+func (c Cursors) Encode(key []byte) (next string, prev string) {
+	if c.Next != nil {
+		next = EncodeCursor(key, *c.Next)
+	}
+
+	if c.Prev != nil {
+		prev = EncodeCursor(key, *c.Prev)
+	}
+
+	return next, prev
+}
+
+// Window trims a result set that was fetched with one extra row beyond the
+// rows per page and returns the cursors for the adjacent pages. The position
+// function returns the order by values and ID of an item.
+func Window[T any](items []T, orderBy order.By, cursor Cursor, rowsPerPage int, position func(T) ([]string, string)) ([]T, Cursors) {
+	hasMore := len(items) > rowsPerPage
+
+	at := func(item T, backward bool) *Cursor {
+		values, id := position(item)
+		return &Cursor{
+			OrderBy:  orderBy,
+			Values:   values,
+			ID:       id,
+			Backward: backward,
+		}
+	}
+
+	var cursors Cursors
+
+	switch cursor.Backward {
+	case true:
+		if hasMore {
+			items = items[1:]
+		}
+
+		if len(items) == 0 {
+			return items, cursors
+		}
+
+		if hasMore {
+			cursors.Prev = at(items[0], true)
+		}
+		cursors.Next = at(items[len(items)-1], false)
+
+	default:
+		if hasMore {
+			items = items[:rowsPerPage]
+		}
+
+		if len(items) == 0 {
+			return items, cursors
+		}
+
+		if !cursor.IsZero() {
+			cursors.Prev = at(items[0], true)
+		}
+		if hasMore {
+			cursors.Next = at(items[len(items)-1], false)
+		}
+	}
+
+	return items, cursors
+}
+
+// EncodeCursor returns an opaque string for the cursor signed with the
+// specified key.
+func EncodeCursor(key []byte, cursor Cursor) string {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sign(key, payload))
+}
+
+// DecodeCursor validates the signature of the opaque cursor string and
+// returns the cursor it represents.
+func DecodeCursor(key []byte, token string) (Cursor, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	if !hmac.Equal(sig, sign(key, payload)) {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// ParseCursor parses the request for the cursor query string. The boolean
+// reports whether keyset paging was requested. An empty cursor requests the
+// first page of a keyset paged result set.
+func ParseCursor(r *http.Request, key []byte) (Cursor, bool, error) {
+	values := r.URL.Query()
+
+	if !values.Has("cursor") {
+		return Cursor{}, false, nil
+	}
+
+	token := values.Get("cursor")
+	if token == "" {
+		return Cursor{}, true, nil
+	}
+
+	cursor, err := DecodeCursor(key, token)
+	if err != nil {
+		return Cursor{}, false, validate.NewFieldsError("cursor", err)
+	}
+
+	return cursor, true, nil
+}
+
+func sign(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,112 @@
+package page_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/testvergecloud/testApi/business/web/order"
+	"github.com/testvergecloud/testApi/business/web/page"
+)
+
+func Test_CursorSigning(t *testing.T) {
+	key := []byte("cursor-signing-key")
+
+	exp := page.Cursor{
+		OrderBy: order.NewBy("name", order.DESC),
+		Values:  []string{"Comics"},
+		ID:      "45b5fbd3-755f-4379-8f07-a58d4a30fa2f",
+	}
+
+	token := page.EncodeCursor(key, exp)
+
+	got, err := page.DecodeCursor(key, token)
+	if err != nil {
+		t.Fatalf("Should be able to decode the cursor: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("Should get back the same cursor: got %v, exp %v", got, exp)
+	}
+
+	if _, err := page.DecodeCursor([]byte("other-key"), token); !errors.Is(err, page.ErrInvalidCursor) {
+		t.Fatalf("Should not accept a cursor signed with another key: %v", err)
+	}
+
+	tampered := page.EncodeCursor([]byte("other-key"), exp)
+	if _, err := page.DecodeCursor(key, tampered); !errors.Is(err, page.ErrInvalidCursor) {
+		t.Fatalf("Should not accept a forged cursor: %v", err)
+	}
+}
+
+func Test_CursorWindow(t *testing.T) {
+	orderBy := order.NewBy("id", order.ASC)
+	position := func(v int) ([]string, string) {
+		return []string{strconv.Itoa(v)}, strconv.Itoa(v)
+	}
+
+	// First page fetched with one extra row.
+	items, cursors := page.Window([]int{1, 2, 3}, orderBy, page.Cursor{}, 2, position)
+	if len(items) != 2 || items[1] != 2 {
+		t.Fatalf("Should trim the extra row: %v", items)
+	}
+
+	if cursors.Prev != nil || cursors.Next == nil || cursors.Next.ID != "2" {
+		t.Fatalf("Should only have a next cursor at the last row: %+v", cursors)
+	}
+
+	// Last page moving forward.
+	items, cursors = page.Window([]int{3, 4}, orderBy, *cursors.Next, 2, position)
+	if len(items) != 2 || cursors.Next != nil || cursors.Prev == nil || cursors.Prev.ID != "3" || !cursors.Prev.Backward {
+		t.Fatalf("Should only have a backward prev cursor: %v %+v", items, cursors)
+	}
+
+	// Moving backward with more rows before the page. The store returns the
+	// rows in presentation order so the extra row is the first one.
+	items, cursors = page.Window([]int{1, 2, 3}, orderBy, *cursors.Prev, 2, position)
+	if len(items) != 2 || items[0] != 2 {
+		t.Fatalf("Should trim the extra leading row: %v", items)
+	}
+
+	if cursors.Prev == nil || cursors.Prev.ID != "2" || cursors.Next == nil || cursors.Next.ID != "3" {
+		t.Fatalf("Should have both cursors: %+v", cursors)
+	}
+}
+
+func Test_CursorCondition(t *testing.T) {
+	cursor := page.Cursor{
+		Values: []string{"Bill", "10"},
+		ID:     "45b5fbd3-755f-4379-8f07-a58d4a30fa2f",
+	}
+
+	data := make(map[string]interface{})
+	where, err := cursor.Condition([]string{"user_name", "cost", "product_id"}, []string{order.ASC, order.DESC, order.ASC}, data)
+	if err != nil {
+		t.Fatalf("Should be able to build the condition: %s", err)
+	}
+
+	exp := "((user_name > :cursor_0) OR (user_name = :cursor_0 AND cost < :cursor_1) OR (user_name = :cursor_0 AND cost = :cursor_1 AND product_id > :cursor_2))"
+	if where != exp {
+		t.Fatalf("Should get the expected condition:\ngot %s\nexp %s", where, exp)
+	}
+
+	if data["cursor_2"] != cursor.ID {
+		t.Fatalf("Should compare the primary key with the cursor ID: %v", data["cursor_2"])
+	}
+
+	cursor.Backward = true
+	where, err = cursor.Condition([]string{"user_name", "cost", "product_id"}, []string{order.ASC, order.DESC, order.ASC}, data)
+	if err != nil {
+		t.Fatalf("Should be able to build the backward condition: %s", err)
+	}
+
+	exp = "((user_name < :cursor_0) OR (user_name = :cursor_0 AND cost > :cursor_1) OR (user_name = :cursor_0 AND cost = :cursor_1 AND product_id < :cursor_2))"
+	if where != exp {
+		t.Fatalf("Should get the expected backward condition:\ngot %s\nexp %s", where, exp)
+	}
+
+	if _, err := cursor.Condition([]string{"user_name", "product_id"}, []string{order.ASC, order.ASC}, data); !errors.Is(err, page.ErrInvalidCursor) {
+		t.Fatalf("Should not accept a cursor that doesn't match the order: %v", err)
+	}
+}
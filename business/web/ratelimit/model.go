@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limit represents how many requests are allowed per period. A client can
+// make all of them at once, after that they're allowed again as the period
+// goes by. The zero value is no limit.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Unlimited reports whether the limit doesn't limit anything.
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0 || l.Period <= 0
+}
+
+// Rate returns the tokens added to a bucket per second.
+func (l Limit) Rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// String returns the limit the way ParseLimit reads it.
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return ""
+	}
+
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// refill returns how long the bucket takes to get the specified tokens back.
+func (l Limit) refill(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+
+	return time.Duration(tokens / l.Rate() * float64(time.Second))
+}
+
+// Quota represents the limits the clients of a route group get. Clients that
+// authenticated are limited per user or API key, anonymous clients per IP.
+type Quota struct {
+	Name string
+	User Limit
+	IP   Limit
+}
+
+// Bucket represents the bucket of a client after a token was taken from it.
+// Allowed is false when there was no token left to take.
+type Bucket struct {
+	Tokens  float64
+	Allowed bool
+}
+
+// Result represents the outcome of a request against a limit.
+type Result struct {
+	Allowed    bool
+	Limit      Limit
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
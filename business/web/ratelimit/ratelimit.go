@@ -0,0 +1,190 @@
+// Package ratelimit provides support for limiting the rate of requests each
+// client makes. Every client gets a token bucket per quota, a request takes a
+// token and the bucket refills at the rate of the limit. The buckets are kept
+// by a store, which can be shared by the instances of the service so limits
+// hold across them.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/testvergecloud/testApi/foundation/logger"
+)
+
+// Set of quota names used by the route groups.
+const (
+	Default = "default"
+	Token   = "token"
+)
+
+// Set of default values used by the limiter.
+const (
+	defaultEvictInterval = time.Minute
+)
+
+// ErrInvalidLimit is returned when a limit can't be parsed.
+var ErrInvalidLimit = errors.New("limit is not valid")
+
+// Storer interface declares the behavior this package needs to keep the
+// buckets of the clients.
+type Storer interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Bucket, error)
+	DeleteIdle(ctx context.Context, before time.Time) error
+}
+
+// Limiter manages the set of APIs for rate limiting.
+type Limiter struct {
+	log           *logger.Logger
+	storer        Storer
+	quotas        map[string]Quota
+	idle          time.Duration
+	EvictInterval time.Duration
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+// NewLimiter constructs a limiter enforcing the specified quotas. Idle buckets
+// are only removed from the store after Start is called.
+func NewLimiter(log *logger.Logger, storer Storer, quotas ...Quota) *Limiter {
+	l := Limiter{
+		log:           log,
+		storer:        storer,
+		quotas:        make(map[string]Quota, len(quotas)),
+		EvictInterval: defaultEvictInterval,
+	}
+
+	// A bucket left alone for the longest period of any limit is full, so
+	// removing it changes nothing.
+	for _, q := range quotas {
+		l.quotas[q.Name] = q
+		l.idle = max(l.idle, q.User.Period, q.IP.Period)
+	}
+
+	return &l
+}
+
+// Start launches the goroutine removing idle buckets until Shutdown is called.
+func (l *Limiter) Start(ctx context.Context) {
+	ctx, l.cancel = context.WithCancel(ctx)
+
+	l.wg.Add(1)
+
+	go func() {
+		defer l.wg.Done()
+		l.evict(ctx)
+	}()
+}
+
+// Shutdown stops the goroutine launched by Start.
+func (l *Limiter) Shutdown(ctx context.Context) error {
+	if l.cancel != nil {
+		l.cancel()
+	}
+
+	ch := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(ch)
+	}()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Quota returns the quota with the specified name. A quota that wasn't
+// configured doesn't limit anything.
+func (l *Limiter) Quota(name string) Quota {
+	if q, exists := l.quotas[name]; exists {
+		return q
+	}
+
+	return Quota{Name: name}
+}
+
+// Allow takes a token from the bucket of the key. The limiter fails open, a
+// request is allowed when the store can't be reached so an outage of the
+// store doesn't take the service down with it.
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) Result {
+	if limit.Unlimited() {
+		return Result{Allowed: true}
+	}
+
+	bucket, err := l.storer.Take(ctx, key, limit, time.Now())
+	if err != nil {
+		l.log.Error(ctx, "ratelimit", "status", "take failed", "key", key, "msg", err)
+		return Result{Allowed: true}
+	}
+
+	return newResult(limit, bucket)
+}
+
+func (l *Limiter) evict(ctx context.Context) {
+	ticker := time.NewTicker(l.EvictInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := l.storer.DeleteIdle(ctx, time.Now().Add(-l.idle)); err != nil {
+			l.log.Error(ctx, "ratelimit", "status", "delete idle failed", "msg", err)
+		}
+	}
+}
+
+// =============================================================================
+
+// ParseLimit parses a limit written as requests per period, like "100/1m".
+// An empty string or zero requests is no limit.
+func ParseLimit(s string) (Limit, error) {
+	if s == "" {
+		return Limit{}, nil
+	}
+
+	requests, period, found := strings.Cut(s, "/")
+	if !found {
+		return Limit{}, fmt.Errorf("%w: %q", ErrInvalidLimit, s)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("%w: %q", ErrInvalidLimit, s)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("%w: %q", ErrInvalidLimit, s)
+	}
+
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// newResult describes the bucket after a token was taken from it.
+func newResult(limit Limit, bucket Bucket) Result {
+	res := Result{
+		Allowed:   bucket.Allowed,
+		Limit:     limit,
+		Remaining: int(math.Floor(bucket.Tokens)),
+		Reset:     limit.refill(float64(limit.Requests) - bucket.Tokens),
+	}
+
+	if !bucket.Allowed {
+		res.RetryAfter = limit.refill(1 - bucket.Tokens)
+	}
+
+	return res
+}
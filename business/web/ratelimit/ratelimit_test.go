@@ -0,0 +1,109 @@
+package ratelimit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/web/ratelimit"
+	"github.com/testvergecloud/testApi/business/web/ratelimit/stores/ratelimitmem"
+	"github.com/testvergecloud/testApi/foundation/logger"
+)
+
+func Test_ParseLimit(t *testing.T) {
+	limit, err := ratelimit.ParseLimit("10/1m")
+	if err != nil {
+		t.Fatalf("Should be able to parse a limit: %s", err)
+	}
+
+	if limit.Requests != 10 || limit.Period != time.Minute {
+		t.Fatalf("Should get back the requests and period: %+v", limit)
+	}
+
+	if limit, err := ratelimit.ParseLimit(""); err != nil || !limit.Unlimited() {
+		t.Fatalf("Should parse an empty limit as no limit: %+v %v", limit, err)
+	}
+
+	for _, s := range []string{"10", "x/1m", "10/x", "10/0s", "-1/1m"} {
+		if _, err := ratelimit.ParseLimit(s); !errors.Is(err, ratelimit.ErrInvalidLimit) {
+			t.Fatalf("Should NOT be able to parse %q: %v", s, err)
+		}
+	}
+}
+
+func Test_Limiter(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.LevelInfo, "TEST", func(context.Context) string { return "" })
+
+	limit := ratelimit.Limit{Requests: 2, Period: time.Hour}
+
+	l := ratelimit.NewLimiter(log, ratelimitmem.NewStore(), ratelimit.Quota{Name: ratelimit.Token, User: limit, IP: limit})
+
+	if q := l.Quota(ratelimit.Default); !q.User.Unlimited() || !q.IP.Unlimited() {
+		t.Fatalf("Should not limit a quota that wasn't configured: %+v", q)
+	}
+
+	ctx := context.Background()
+	q := l.Quota(ratelimit.Token)
+
+	for i := range limit.Requests {
+		res := l.Allow(ctx, "ip:1", q.IP)
+		if !res.Allowed || res.Remaining != limit.Requests-i-1 {
+			t.Fatalf("Should allow the burst of the limit: %d %+v", i, res)
+		}
+	}
+
+	res := l.Allow(ctx, "ip:1", q.IP)
+	if res.Allowed {
+		t.Fatalf("Should NOT allow more than the burst of the limit: %+v", res)
+	}
+
+	// A token is added every half hour.
+	if res.RetryAfter <= 29*time.Minute || res.RetryAfter > 30*time.Minute {
+		t.Fatalf("Should retry once a token was added: %s", res.RetryAfter)
+	}
+
+	if res.Reset <= 59*time.Minute || res.Reset > time.Hour {
+		t.Fatalf("Should reset once the bucket is full: %s", res.Reset)
+	}
+
+	if res := l.Allow(ctx, "ip:2", q.IP); !res.Allowed {
+		t.Fatalf("Should limit every key on its own: %+v", res)
+	}
+}
+
+func Test_Refill(t *testing.T) {
+	store := ratelimitmem.NewStore()
+
+	ctx := context.Background()
+	limit := ratelimit.Limit{Requests: 2, Period: 2 * time.Second}
+	now := time.Now()
+
+	for range limit.Requests {
+		store.Take(ctx, "user:1", limit, now)
+	}
+
+	if b, _ := store.Take(ctx, "user:1", limit, now); b.Allowed {
+		t.Fatalf("Should NOT allow a request from an empty bucket: %+v", b)
+	}
+
+	if b, _ := store.Take(ctx, "user:1", limit, now.Add(-time.Hour)); b.Allowed {
+		t.Fatalf("Should NOT add tokens when the clock goes backwards: %+v", b)
+	}
+
+	if b, _ := store.Take(ctx, "user:1", limit, now.Add(time.Second)); !b.Allowed || b.Tokens != 0 {
+		t.Fatalf("Should add a token per second: %+v", b)
+	}
+
+	if b, _ := store.Take(ctx, "user:1", limit, now.Add(time.Hour)); !b.Allowed || b.Tokens != 1 {
+		t.Fatalf("Should not fill the bucket beyond the burst: %+v", b)
+	}
+
+	store.DeleteIdle(ctx, now.Add(2*time.Hour))
+
+	if b, _ := store.Take(ctx, "user:1", limit, now.Add(time.Hour)); b.Tokens != 1 {
+		t.Fatalf("Should start a new bucket once the idle one was removed: %+v", b)
+	}
+}
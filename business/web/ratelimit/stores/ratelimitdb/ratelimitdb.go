@@ -0,0 +1,98 @@
+// Package ratelimitdb keeps the rate limit buckets in the database so the
+// limits hold across the instances of the service.
+package ratelimitdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/data/sqldb"
+	"github.com/testvergecloud/testApi/business/web/ratelimit"
+	"github.com/testvergecloud/testApi/foundation/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// refilled is the bucket refilled for the time passed since it was last
+// used, up to the burst of the limit. The clock going backwards doesn't add
+// tokens. Every SET expression of the update sees the bucket as it was before
+// the update.
+const refilled = `LEAST(CAST(:burst AS DOUBLE PRECISION), rl.tokens + GREATEST(0, EXTRACT(EPOCH FROM (CAST(:now AS TIMESTAMP) - rl.date_updated))) * CAST(:rate AS DOUBLE PRECISION))`
+
+// Store manages the set of APIs for rate limit bucket database access.
+type Store struct {
+	log *logger.Logger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *logger.Logger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Take refills the bucket of the key for the time passed since it was last
+// used and takes a token from it if there's one left. It's done in a single
+// statement so concurrent requests from any instance can't take the same
+// token.
+func (s *Store) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Bucket, error) {
+	data := struct {
+		Key   string    `db:"key"`
+		Burst float64   `db:"burst"`
+		Rate  float64   `db:"rate"`
+		Now   time.Time `db:"now"`
+	}{
+		Key:   key,
+		Burst: float64(limit.Requests),
+		Rate:  limit.Rate(),
+		Now:   now.UTC(),
+	}
+
+	const q = `
+	INSERT INTO rate_limits AS rl
+		(key, tokens, allowed, date_updated)
+	VALUES
+		(:key, CAST(:burst AS DOUBLE PRECISION) - 1, TRUE, :now)
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = CASE WHEN ` + refilled + ` >= 1 THEN ` + refilled + ` - 1 ELSE ` + refilled + ` END,
+		allowed = ` + refilled + ` >= 1,
+		date_updated = GREATEST(rl.date_updated, :now)
+	RETURNING
+		tokens, allowed`
+
+	var dbBkt dbBucket
+	if err := sqldb.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbBkt); err != nil {
+		return ratelimit.Bucket{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return ratelimit.Bucket{Tokens: dbBkt.Tokens, Allowed: dbBkt.Allowed}, nil
+}
+
+// DeleteIdle removes the buckets that weren't used since before.
+func (s *Store) DeleteIdle(ctx context.Context, before time.Time) error {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		rate_limits
+	WHERE
+		date_updated < :before`
+
+	if err := sqldb.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+type dbBucket struct {
+	Tokens  float64 `db:"tokens"`
+	Allowed bool    `db:"allowed"`
+}
@@ -0,0 +1,72 @@
+// Package ratelimitmem keeps the rate limit buckets in memory. The limits
+// only hold per instance of the service.
+package ratelimitmem
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/testvergecloud/testApi/business/web/ratelimit"
+)
+
+type bucket struct {
+	tokens      float64
+	dateUpdated time.Time
+}
+
+// Store manages the set of APIs for rate limit buckets kept in memory.
+type Store struct {
+	mu      sync.Mutex
+	buckets map[string]bucket
+}
+
+// NewStore constructs the api for data access.
+func NewStore() *Store {
+	return &Store{
+		buckets: make(map[string]bucket),
+	}
+}
+
+// Take refills the bucket of the key for the time passed since it was last
+// used and takes a token from it if there's one left.
+func (s *Store) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	burst := float64(limit.Requests)
+
+	b, exists := s.buckets[key]
+	if !exists {
+		b = bucket{tokens: burst, dateUpdated: now}
+	}
+
+	// The clock going backwards doesn't add tokens.
+	if now.After(b.dateUpdated) {
+		b.tokens = min(burst, b.tokens+now.Sub(b.dateUpdated).Seconds()*limit.Rate())
+		b.dateUpdated = now
+	}
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	s.buckets[key] = b
+
+	return ratelimit.Bucket{Tokens: b.tokens, Allowed: allowed}, nil
+}
+
+// DeleteIdle removes the buckets that weren't used since before.
+func (s *Store) DeleteIdle(ctx context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, b := range s.buckets {
+		if b.dateUpdated.Before(before) {
+			delete(s.buckets, key)
+		}
+	}
+
+	return nil
+}
@@ -45,10 +45,12 @@ func GetTrustedError(err error) *TrustedError {
 
 // PageDocument is the form used for API responses from query API calls.
 type PageDocument[T any] struct {
-	Items       []T `json:"items"`
-	Total       int `json:"total"`
-	Page        int `json:"page"`
-	RowsPerPage int `json:"rowsPerPage"`
+	Items       []T    `json:"items"`
+	Total       int    `json:"total"`
+	Page        int    `json:"page"`
+	RowsPerPage int    `json:"rowsPerPage"`
+	NextCursor  string `json:"nextCursor,omitempty"`
+	PrevCursor  string `json:"prevCursor,omitempty"`
 }
 
 // NewPageDocument constructs a response value for a web paging trusted.
@@ -60,3 +62,14 @@ func NewPageDocument[T any](items []T, total int, page int, rowsPerPage int) Pag
 		RowsPerPage: rowsPerPage,
 	}
 }
+
+// NewCursorDocument constructs a response value for a keyset paged query.
+func NewCursorDocument[T any](items []T, total int, rowsPerPage int, nextCursor string, prevCursor string) PageDocument[T] {
+	return PageDocument[T]{
+		Items:       items,
+		Total:       total,
+		RowsPerPage: rowsPerPage,
+		NextCursor:  nextCursor,
+		PrevCursor:  prevCursor,
+	}
+}
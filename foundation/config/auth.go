@@ -1,14 +1,20 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
 type Auth struct {
-	KeysFolder string `mapstructure:"CDN_AUTH_KEYS_FOLDER"`
-	ActiveKID  string `mapstructure:"CDN_AUTH_ACTIVE_KID"`
-	DefaultKID string `mapstructure:"CDN_AUTH_DEFAULT_KID"`
-	Issuer     string `mapstructure:"CDN_AUTH_ISSUER"`
+	KeysFolder           string        `mapstructure:"CDN_AUTH_KEYS_FOLDER"`
+	KeysReloadInterval   time.Duration `mapstructure:"CDN_AUTH_KEYS_RELOAD_INTERVAL"`
+	KeyGracePeriod       time.Duration `mapstructure:"CDN_AUTH_KEY_GRACE_PERIOD"`
+	PolicyFolder         string        `mapstructure:"CDN_AUTH_POLICY_FOLDER"`
+	PolicyReloadInterval time.Duration `mapstructure:"CDN_AUTH_POLICY_RELOAD_INTERVAL"`
+	Issuer               string        `mapstructure:"CDN_AUTH_ISSUER"`
+	AccessTokenTTL       time.Duration `mapstructure:"CDN_AUTH_ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL      time.Duration `mapstructure:"CDN_AUTH_REFRESH_TOKEN_TTL"`
 }
 
 func LoadAuthConfig(path string, name string, typeC string) (*Auth, error) {
@@ -29,7 +35,10 @@ func LoadAuthConfig(path string, name string, typeC string) (*Auth, error) {
 
 func (a *Auth) setDefault() {
 	a.KeysFolder = "zarf/keys/"
-	a.DefaultKID = "54bb2165-71e1-41a6-af3e-7da4a0e1e2c1"
-	a.ActiveKID = "54bb2165-71e1-41a6-af3e-7da4a0e1e2c1"
+	a.KeysReloadInterval = 10 * time.Second
+	a.KeyGracePeriod = 24 * time.Hour
+	a.PolicyReloadInterval = 10 * time.Second
 	a.Issuer = "service project"
+	a.AccessTokenTTL = 15 * time.Minute
+	a.RefreshTokenTTL = 30 * 24 * time.Hour
 }
@@ -1,5 +1,7 @@
 package config
 
+import "github.com/spf13/viper"
+
 // Config stores all configuration of the application.
 // The values are read by viper from a config file or environment variable.
 type Config struct {
@@ -14,6 +16,9 @@ type Config struct {
 	Desc   string
 	*Web
 	*Auth
+	*OIDC
+	*RateLimit
+	*Idempotency
 	*DB
 	*Tempo
 	*Expvar
@@ -40,6 +45,25 @@ func LoadConfig(path string, args ...string) (*Config, error) {
 				return nil, err
 			}
 			cfg.Auth = a
+			cfg.CDNApiKey = viper.GetString("CDN_API_KEY")
+		case "oidc":
+			o, err := LoadOIDCConfig(path, "oidc", "env")
+			if err != nil {
+				return nil, err
+			}
+			cfg.OIDC = o
+		case "ratelimit":
+			r, err := LoadRateLimitConfig(path, "ratelimit", "env")
+			if err != nil {
+				return nil, err
+			}
+			cfg.RateLimit = r
+		case "idempotency":
+			i, err := LoadIdempotencyConfig(path, "idempotency", "env")
+			if err != nil {
+				return nil, err
+			}
+			cfg.Idempotency = i
 		case "db":
 			d, err := LoadDBConfig(path, "db", "env")
 			if err != nil {
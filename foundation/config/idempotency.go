@@ -0,0 +1,33 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Idempotency configures how long the responses of requests sent with an
+// Idempotency-Key are kept for replay.
+type Idempotency struct {
+	IdempotencyTTL time.Duration `mapstructure:"CDN_IDEMPOTENCY_TTL"`
+}
+
+func LoadIdempotencyConfig(path string, name string, typeC string) (*Idempotency, error) {
+	viper.AddConfigPath(path)
+	viper.SetConfigName(name)
+	viper.SetConfigType(typeC)
+
+	viper.AutomaticEnv()
+
+	var i Idempotency
+	i.setDefault()
+	if err := viper.ReadInConfig(); err != nil {
+		return &i, err
+	}
+	viper.Unmarshal(&i)
+	return &i, nil
+}
+
+func (i *Idempotency) setDefault() {
+	i.IdempotencyTTL = 24 * time.Hour
+}
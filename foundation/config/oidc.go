@@ -0,0 +1,40 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// OIDC configures logging in with an OpenID Connect provider. Logging in
+// this way is disabled unless an issuer is set. Users seen for the first time
+// are created in the tenant with the default roles.
+type OIDC struct {
+	Issuer       string   `mapstructure:"CDN_OIDC_ISSUER"`
+	ClientID     string   `mapstructure:"CDN_OIDC_CLIENT_ID"`
+	ClientSecret string   `mapstructure:"CDN_OIDC_CLIENT_SECRET"`
+	RedirectURL  string   `mapstructure:"CDN_OIDC_REDIRECT_URL"`
+	Scopes       []string `mapstructure:"CDN_OIDC_SCOPES"`
+	DefaultRoles []string `mapstructure:"CDN_OIDC_DEFAULT_ROLES"`
+	TenantID     string   `mapstructure:"CDN_OIDC_TENANT_ID"`
+}
+
+func LoadOIDCConfig(path string, name string, typeC string) (*OIDC, error) {
+	viper.AddConfigPath(path)
+	viper.SetConfigName(name)
+	viper.SetConfigType(typeC)
+
+	viper.AutomaticEnv()
+
+	var o OIDC
+	o.setDefault()
+	if err := viper.ReadInConfig(); err != nil {
+		return &o, err
+	}
+	viper.Unmarshal(&o)
+	return &o, nil
+}
+
+func (o *OIDC) setDefault() {
+	o.Scopes = []string{"openid", "email", "profile"}
+	o.DefaultRoles = []string{"USER"}
+	o.TenantID = "00000000-0000-0000-0000-000000000001"
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// RateLimit configures limiting the rate of requests. Limits are written as
+// requests per period, like "100/1m", and an empty limit doesn't limit
+// anything. The backend keeps the limits in "memory" per instance or in
+// "postgres" across instances, rate limiting is disabled without one. The
+// token limit applies to the routes issuing tokens.
+type RateLimit struct {
+	RateLimitBackend string `mapstructure:"CDN_RATELIMIT_BACKEND"`
+	UserLimit        string `mapstructure:"CDN_RATELIMIT_USER"`
+	IPLimit          string `mapstructure:"CDN_RATELIMIT_IP"`
+	TokenLimit       string `mapstructure:"CDN_RATELIMIT_TOKEN"`
+}
+
+func LoadRateLimitConfig(path string, name string, typeC string) (*RateLimit, error) {
+	viper.AddConfigPath(path)
+	viper.SetConfigName(name)
+	viper.SetConfigType(typeC)
+
+	viper.AutomaticEnv()
+
+	var r RateLimit
+	r.setDefault()
+	if err := viper.ReadInConfig(); err != nil {
+		return &r, err
+	}
+	viper.Unmarshal(&r)
+	return &r, nil
+}
+
+func (r *RateLimit) setDefault() {
+	r.RateLimitBackend = "memory"
+	r.UserLimit = "1200/1m"
+	r.IPLimit = "300/1m"
+	r.TokenLimit = "10/1m"
+}
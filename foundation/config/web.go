@@ -14,6 +14,9 @@ type Web struct {
 	APIHost            string        `mapstructure:"CDN_WEB_API_HOST"`
 	DebugHost          string        `mapstructure:"CDN_WEB_DEBUG_HOST"`
 	CORSAllowedOrigins []string      `mapstructure:"CDN_WEB_CORS_ALLOWED_ORIGIN"`
+	RequireIfMatch     bool          `mapstructure:"CDN_WEB_REQUIRE_IF_MATCH"`
+	CursorKey          string        `mapstructure:"CDN_WEB_CURSOR_KEY"`
+	TrustedProxies     []string      `mapstructure:"CDN_WEB_TRUSTED_PROXIES"`
 }
 
 func LoadWebConfig(path string, name string, typeC string) (*Web, error) {
@@ -0,0 +1,251 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// JWK represents a public key as a JSON Web Key. RSA keys use N and E, the
+// elliptic curve keys use Crv and X, plus Y for ECDSA.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS represents a set of public keys as a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the key store that can verify tokens as a
+// JSON Web Key Set so other services can verify the tokens signed with the
+// private keys. The keys are ordered by kid.
+func (ks *KeyStore) JWKS() (JWKS, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := time.Now()
+
+	kids := make([]string, 0, len(ks.store))
+	for kid, key := range ks.store {
+		if key.metadata.status(now) != StatusRetired {
+			kids = append(kids, kid)
+		}
+	}
+	sort.Strings(kids)
+
+	jwks := JWKS{
+		Keys: make([]JWK, len(kids)),
+	}
+
+	for i, kid := range kids {
+		jwk, err := toJWK(kid, ks.store[kid].publicPEM)
+		if err != nil {
+			return JWKS{}, fmt.Errorf("kid[%s]: %w", kid, err)
+		}
+		jwks.Keys[i] = jwk
+	}
+
+	return jwks, nil
+}
+
+// toJWK converts a PEM encoded public key into a JWK.
+func toJWK(kid string, publicPEM string) (JWK, error) {
+	parsedKey, alg, err := parsePublicKey(publicPEM)
+	if err != nil {
+		return JWK{}, err
+	}
+
+	jwk := JWK{
+		Kid: kid,
+		Use: "sig",
+		Alg: alg,
+	}
+
+	switch pk := parsedKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pk.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pk.E)).Bytes())
+
+	case *ecdsa.PublicKey:
+		crv := curves[pk.Curve]
+
+		// The coordinates are padded to the size of the curve.
+		size := (pk.Curve.Params().BitSize + 7) / 8
+
+		jwk.Kty = "EC"
+		jwk.Crv = crv.name
+		jwk.X = base64.RawURLEncoding.EncodeToString(pk.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(pk.Y.FillBytes(make([]byte, size)))
+
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pk)
+
+	}
+
+	return jwk, nil
+}
+
+// parsePublicKey parses a PEM encoded public key and returns it along with
+// the algorithm of the signatures it verifies.
+func parsePublicKey(publicPEM string) (crypto.PublicKey, string, error) {
+	block, _ := pem.Decode([]byte(publicPEM))
+	if block == nil {
+		return nil, "", errors.New("invalid key: Key must be a PEM encoded public key")
+	}
+
+	parsedKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("parsing public key: %w", err)
+	}
+
+	switch pk := parsedKey.(type) {
+	case *rsa.PublicKey:
+		return pk, "RS256", nil
+
+	case *ecdsa.PublicKey:
+		crv, ok := curves[pk.Curve]
+		if !ok {
+			return nil, "", errors.New("unsupported curve")
+		}
+		return pk, crv.alg, nil
+
+	case ed25519.PublicKey:
+		return pk, "EdDSA", nil
+	}
+
+	return nil, "", errors.New("key is not a valid RSA, ECDSA or Ed25519 public key")
+}
+
+// toPublicPEM converts the JWK back into a PEM encoded public key.
+func (jwk JWK) toPublicPEM() (string, error) {
+	var publicKey any
+
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return "", fmt.Errorf("decoding modulus: %w", err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return "", fmt.Errorf("decoding exponent: %w", err)
+		}
+
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > int64(^uint32(0)>>1) {
+			return "", errors.New("exponent out of range")
+		}
+
+		publicKey = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(exponent.Int64()),
+		}
+
+	case "EC":
+		var curve elliptic.Curve
+		for c, crv := range curves {
+			if crv.name == jwk.Crv {
+				curve = c
+			}
+		}
+
+		if curve == nil {
+			return "", fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return "", fmt.Errorf("decoding x: %w", err)
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return "", fmt.Errorf("decoding y: %w", err)
+		}
+
+		pk := ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+
+		if !curve.IsOnCurve(pk.X, pk.Y) {
+			return "", errors.New("point is not on the curve")
+		}
+
+		publicKey = &pk
+
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return "", fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return "", fmt.Errorf("decoding x: %w", err)
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return "", errors.New("invalid Ed25519 key size")
+		}
+
+		publicKey = ed25519.PublicKey(x)
+
+	default:
+		return "", fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+
+	asn1Bytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("marshaling public key: %w", err)
+	}
+
+	publicBlock := pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: asn1Bytes,
+	}
+
+	var buf bytes.Buffer
+	if err := pem.Encode(&buf, &publicBlock); err != nil {
+		return "", fmt.Errorf("encoding to public PEM: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// curve describes how an elliptic curve is named in a JWK.
+type curve struct {
+	name string
+	alg  string
+}
+
+// curves holds the elliptic curves supported for ECDSA keys.
+var curves = map[elliptic.Curve]curve{
+	elliptic.P256(): {name: "P-256", alg: "ES256"},
+	elliptic.P384(): {name: "P-384", alg: "ES384"},
+	elliptic.P521(): {name: "P-521", alg: "ES512"},
+}
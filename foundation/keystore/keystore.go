@@ -1,11 +1,17 @@
 // Package keystore implements the auth.KeyLookup interface. This implements
-// an in-memory keystore for JWT support.
+// an in-memory keystore for JWT support, which can publish its public keys as
+// a JSON Web Key Set, and a remote keystore backed by such a set.
 package keystore
 
 import (
 	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -13,17 +19,49 @@ import (
 	"io/fs"
 	"path"
 	"strings"
+	"sync"
+	"time"
 )
 
-// key represents key information.
+// Set of statuses a key can have.
+const (
+	StatusActive  = "active"
+	StatusVerify  = "verify"
+	StatusRetired = "retired"
+)
+
+// Metadata describes how a key can be used. It's read from a JSON file next
+// to the PEM file with the same name, a key without one is active. A key
+// that is only used to verify tokens is retired once RetireAt has passed.
+type Metadata struct {
+	Status   string     `json:"status"`
+	Created  time.Time  `json:"created"`
+	RetireAt *time.Time `json:"retireAt,omitempty"`
+}
+
+// status returns the status of the key at the specified time.
+func (md Metadata) status(now time.Time) string {
+	if md.Status == StatusVerify && md.RetireAt != nil && !now.Before(*md.RetireAt) {
+		return StatusRetired
+	}
+	return md.Status
+}
+
+// key represents key information. The keys are kept parsed as well so tokens
+// can be signed and verified without decoding the PEM every time.
 type key struct {
 	privatePEM string
+	privateKey crypto.PrivateKey
 	publicPEM  string
+	publicKey  crypto.PublicKey
+	alg        string
+	metadata   Metadata
 }
 
 // KeyStore represents an in memory store implementation of the
 // KeyLookup interface for use with the auth package.
 type KeyStore struct {
+	mu    sync.RWMutex
 	store map[string]key
 }
 
@@ -34,11 +72,15 @@ func New() *KeyStore {
 	}
 }
 
-// LoadRSAKeys loads a set of RSA PEM files rooted inside of a directory. The
-// name of each PEM file will be used as the key id.
-// Example: ks.LoadRSAKeys(os.DirFS("/zarf/keys/"))
+// LoadKeys loads a set of RSA, ECDSA and Ed25519 PEM files rooted inside of a
+// directory. The name of each PEM file will be used as the key id. The loaded
+// keys replace the keys already in the store.
+// Example: ks.LoadKeys(os.DirFS("/zarf/keys/"))
 // Example: /zarf/keys/54bb2165-71e1-41a6-af3e-7da4a0e1e2c1.pem
-func (ks *KeyStore) LoadRSAKeys(fsys fs.FS) error {
+// Example: /zarf/keys/54bb2165-71e1-41a6-af3e-7da4a0e1e2c1.json
+func (ks *KeyStore) LoadKeys(fsys fs.FS) error {
+	store := make(map[string]key)
+
 	fn := func(fileName string, dirEntry fs.DirEntry, err error) error {
 		if err != nil {
 			return fmt.Errorf("walkdir failure: %w", err)
@@ -67,17 +109,36 @@ func (ks *KeyStore) LoadRSAKeys(fsys fs.FS) error {
 		}
 
 		privatePEM := string(pem)
-		publicPEM, err := toPublicPEM(privatePEM)
+		privateKey, err := parsePrivatePEM(privatePEM)
+		if err != nil {
+			return fmt.Errorf("parsing private key: %w", err)
+		}
+
+		publicPEM, err := toPublicPEM(privateKey)
 		if err != nil {
 			return fmt.Errorf("converting private PEM to public: %w", err)
 		}
 
+		publicKey, alg, err := parsePublicKey(publicPEM)
+		if err != nil {
+			return fmt.Errorf("parsing public key: %w", err)
+		}
+
+		md, err := readMetadata(fsys, fileName, dirEntry)
+		if err != nil {
+			return fmt.Errorf("reading metadata: %w", err)
+		}
+
 		key := key{
 			privatePEM: privatePEM,
+			privateKey: privateKey,
 			publicPEM:  publicPEM,
+			publicKey:  publicKey,
+			alg:        alg,
+			metadata:   md,
 		}
 
-		ks.store[strings.TrimSuffix(dirEntry.Name(), ".pem")] = key
+		store[strings.TrimSuffix(dirEntry.Name(), ".pem")] = key
 
 		return nil
 	}
@@ -86,50 +147,247 @@ func (ks *KeyStore) LoadRSAKeys(fsys fs.FS) error {
 		return fmt.Errorf("walking directory: %w", err)
 	}
 
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.store = store
+
 	return nil
 }
 
+// Watch reloads the keys from the directory whenever its files change,
+// checking every interval until the context is cancelled. The keys in use
+// are kept when a reload fails, the error is passed to errFn. An interval of
+// zero disables reloading.
+func (ks *KeyStore) Watch(ctx context.Context, fsys fs.FS, interval time.Duration, errFn func(err error)) {
+	if interval <= 0 {
+		return
+	}
+
+	last, err := fingerprint(fsys)
+	if err != nil {
+		errFn(err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := fingerprint(fsys)
+		if err != nil {
+			errFn(err)
+			continue
+		}
+
+		if current == last {
+			continue
+		}
+
+		if err := ks.LoadKeys(fsys); err != nil {
+			errFn(err)
+			continue
+		}
+
+		last = current
+	}
+}
+
 // PrivateKey searches the key store for a given kid and returns the private key.
+// Only active keys can be used to sign tokens.
 func (ks *KeyStore) PrivateKey(kid string) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
 	key, found := ks.store[kid]
-	if !found {
+	if !found || key.metadata.status(time.Now()) != StatusActive {
 		return "", errors.New("kid lookup failed")
 	}
 
 	return key.privatePEM, nil
 }
 
+// SigningKey searches the key store for a given kid and returns the parsed
+// private key along with the algorithm of the signatures it makes. Only
+// active keys can be used to sign tokens.
+func (ks *KeyStore) SigningKey(kid string) (crypto.PrivateKey, string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, found := ks.store[kid]
+	if !found || key.metadata.status(time.Now()) != StatusActive {
+		return nil, "", errors.New("kid lookup failed")
+	}
+
+	return key.privateKey, key.alg, nil
+}
+
 // PublicKey searches the key store for a given kid and returns the public key.
+// Retired keys can't be used to verify tokens anymore.
 func (ks *KeyStore) PublicKey(kid string) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
 	key, found := ks.store[kid]
-	if !found {
+	if !found || key.metadata.status(time.Now()) == StatusRetired {
 		return "", errors.New("kid lookup failed")
 	}
 
 	return key.publicPEM, nil
 }
 
-func toPublicPEM(privatePEM string) (string, error) {
-	block, _ := pem.Decode([]byte(privatePEM))
-	if block == nil {
-		return "", errors.New("invalid key: Key must be a PEM encoded PKCS1 or PKCS8 key")
+// VerifyingKey searches the key store for a given kid and returns the parsed
+// public key along with the algorithm of the signatures it verifies. Retired
+// keys can't be used to verify tokens anymore.
+func (ks *KeyStore) VerifyingKey(kid string) (crypto.PublicKey, string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, found := ks.store[kid]
+	if !found || key.metadata.status(time.Now()) == StatusRetired {
+		return nil, "", errors.New("kid lookup failed")
+	}
+
+	return key.publicKey, key.alg, nil
+}
+
+// ActiveKID returns the kid of the newest active key, which is the key used
+// to sign new tokens.
+func (ks *KeyStore) ActiveKID() (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	var activeKID string
+	var created time.Time
+
+	now := time.Now()
+	for kid, key := range ks.store {
+		if key.metadata.status(now) != StatusActive {
+			continue
+		}
+
+		// Ties are broken by kid so every instance picks the same key.
+		if activeKID == "" || key.metadata.Created.After(created) || (key.metadata.Created.Equal(created) && kid > activeKID) {
+			activeKID = kid
+			created = key.metadata.Created
+		}
+	}
+
+	if activeKID == "" {
+		return "", errors.New("no active key")
+	}
+
+	return activeKID, nil
+}
+
+// Metadata returns the metadata of every key in the store, keyed by kid.
+func (ks *KeyStore) Metadata() map[string]Metadata {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	mds := make(map[string]Metadata, len(ks.store))
+	for kid, key := range ks.store {
+		mds[kid] = key.metadata
 	}
 
-	var parsedKey any
-	parsedKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	return mds
+}
+
+// readMetadata reads the metadata of the key stored in the PEM file. A key
+// without metadata is active and created when the file was last modified.
+func readMetadata(fsys fs.FS, fileName string, dirEntry fs.DirEntry) (Metadata, error) {
+	data, err := fs.ReadFile(fsys, strings.TrimSuffix(fileName, ".pem")+".json")
 	if err != nil {
-		parsedKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return Metadata{}, err
+		}
+
+		info, err := dirEntry.Info()
 		if err != nil {
-			return "", err
+			return Metadata{}, err
 		}
+
+		md := Metadata{
+			Status:  StatusActive,
+			Created: info.ModTime(),
+		}
+
+		return md, nil
 	}
 
-	pk, ok := parsedKey.(*rsa.PrivateKey)
-	if !ok {
-		return "", errors.New("key is not a valid RSA private key")
+	var md Metadata
+	if err := json.Unmarshal(data, &md); err != nil {
+		return Metadata{}, err
 	}
 
-	asn1Bytes, err := x509.MarshalPKIXPublicKey(&pk.PublicKey)
+	switch md.Status {
+	case StatusActive, StatusVerify, StatusRetired:
+	default:
+		return Metadata{}, fmt.Errorf("unknown status %q", md.Status)
+	}
+
+	return md, nil
+}
+
+// fingerprint summarizes the name, size and modification time of the files
+// in the directory so changes can be detected without reading them.
+func fingerprint(fsys fs.FS) (string, error) {
+	var b strings.Builder
+
+	fn := func(fileName string, dirEntry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if dirEntry.IsDir() {
+			return nil
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&b, "%s:%d:%d;", fileName, info.Size(), info.ModTime().UnixNano())
+		return nil
+	}
+
+	if err := fs.WalkDir(fsys, ".", fn); err != nil {
+		return "", fmt.Errorf("walking directory: %w", err)
+	}
+
+	return b.String(), nil
+}
+
+// parsePrivatePEM parses the PEM encoded private key.
+func parsePrivatePEM(privatePEM string) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, errors.New("invalid key: Key must be a PEM encoded PKCS1, PKCS8 or SEC1 key")
+	}
+
+	return parsePrivateKey(block.Bytes)
+}
+
+func toPublicPEM(privateKey crypto.PrivateKey) (string, error) {
+	var publicKey any
+	switch pk := privateKey.(type) {
+	case *rsa.PrivateKey:
+		publicKey = &pk.PublicKey
+	case *ecdsa.PrivateKey:
+		publicKey = &pk.PublicKey
+	case ed25519.PrivateKey:
+		publicKey = pk.Public()
+	default:
+		return "", errors.New("key is not a valid RSA, ECDSA or Ed25519 private key")
+	}
+
+	asn1Bytes, err := x509.MarshalPKIXPublicKey(publicKey)
 	if err != nil {
 		return "", fmt.Errorf("marshaling public key: %w", err)
 	}
@@ -146,3 +404,17 @@ func toPublicPEM(privatePEM string) (string, error) {
 
 	return buf.String(), nil
 }
+
+// parsePrivateKey parses a private key in any of the encodings the key files
+// can use.
+func parsePrivateKey(der []byte) (any, error) {
+	if pk, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return pk, nil
+	}
+
+	if pk, err := x509.ParseECPrivateKey(der); err == nil {
+		return pk, nil
+	}
+
+	return x509.ParsePKCS8PrivateKey(der)
+}
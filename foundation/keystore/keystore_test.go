@@ -0,0 +1,423 @@
+package keystore_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/testvergecloud/testApi/foundation/keystore"
+)
+
+func Test_Remote(t *testing.T) {
+	fsys := fstest.MapFS{
+		"first.pem": &fstest.MapFile{Data: newKeyPEM(t)},
+	}
+
+	ks := keystore.New()
+	if err := ks.LoadKeys(fsys); err != nil {
+		t.Fatalf("Should be able to load the keys: %s", err)
+	}
+
+	var mu sync.Mutex
+	var fetches int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches++
+		mu.Unlock()
+
+		jwks, err := ks.JWKS()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	defer srv.Close()
+
+	remote := keystore.NewRemote(srv.URL, srv.Client())
+
+	exp, _ := ks.PublicKey("first")
+	got, err := remote.PublicKey("first")
+	if err != nil {
+		t.Fatalf("Should be able to get the public key: %s", err)
+	}
+
+	if got != exp {
+		t.Fatalf("Should get the published key:\ngot %s\nexp %s", got, exp)
+	}
+
+	if _, err := remote.PublicKey("first"); err != nil || fetches != 1 {
+		t.Fatalf("Should use the cached key: fetches[%d] err[%v]", fetches, err)
+	}
+
+	if _, err := remote.PublicKey("unknown"); err == nil || fetches != 1 {
+		t.Fatalf("Should not fetch again right after a fetch: fetches[%d] err[%v]", fetches, err)
+	}
+
+	if _, err := remote.PrivateKey("first"); err == nil {
+		t.Fatal("Should not have private keys.")
+	}
+
+	// Publish a new key, the unknown kid triggers a new fetch.
+	fsys["second.pem"] = &fstest.MapFile{Data: newKeyPEM(t)}
+	if err := ks.LoadKeys(fsys); err != nil {
+		t.Fatalf("Should be able to load the keys: %s", err)
+	}
+	remote.RefreshBackoff = 0
+
+	exp, _ = ks.PublicKey("second")
+	got, err = remote.PublicKey("second")
+	if err != nil {
+		t.Fatalf("Should be able to get the rotated key: %s", err)
+	}
+
+	if got != exp || fetches != 2 {
+		t.Fatalf("Should fetch the rotated key: fetches[%d]", fetches)
+	}
+}
+
+func Test_RemoteFetchInFlight(t *testing.T) {
+	fsys := fstest.MapFS{
+		"first.pem": &fstest.MapFile{Data: newKeyPEM(t)},
+	}
+
+	ks := keystore.New()
+	if err := ks.LoadKeys(fsys); err != nil {
+		t.Fatalf("Should be able to load the keys: %s", err)
+	}
+
+	var mu sync.Mutex
+	var fetches int
+	var block bool
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches++
+		wait := block
+		mu.Unlock()
+
+		if wait {
+			started <- struct{}{}
+			<-release
+		}
+
+		jwks, err := ks.JWKS()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	defer srv.Close()
+
+	remote := keystore.NewRemote(srv.URL, srv.Client())
+	remote.RefreshBackoff = 0
+
+	if _, err := remote.PublicKey("first"); err != nil {
+		t.Fatalf("Should be able to get the public key: %s", err)
+	}
+
+	// Publish a new key and hold the fetch it triggers in the server.
+	fsys["second.pem"] = &fstest.MapFile{Data: newKeyPEM(t)}
+	if err := ks.LoadKeys(fsys); err != nil {
+		t.Fatalf("Should be able to load the keys: %s", err)
+	}
+
+	mu.Lock()
+	block = true
+	mu.Unlock()
+
+	const callers = 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := remote.PublicKey("second")
+			errs <- err
+		}()
+	}
+
+	<-started
+
+	known := make(chan error, 1)
+	go func() {
+		_, err := remote.PublicKey("first")
+		known <- err
+	}()
+
+	select {
+	case err := <-known:
+		if err != nil {
+			t.Fatalf("Should be able to get the known key: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Should serve a known kid while a fetch is in flight")
+	}
+
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Should be able to get the rotated key: %s", err)
+		}
+	}
+
+	if fetches != 2 {
+		t.Fatalf("Should share a single fetch between the callers: fetches[%d]", fetches)
+	}
+}
+
+func Test_KeyTypes(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate a key: %s", err)
+	}
+
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("Should be able to marshal the key: %s", err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate a key: %s", err)
+	}
+
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("Should be able to marshal the key: %s", err)
+	}
+
+	fsys := fstest.MapFS{
+		"ec.pem":  &fstest.MapFile{Data: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER})},
+		"ed.pem":  &fstest.MapFile{Data: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER})},
+		"rsa.pem": &fstest.MapFile{Data: newKeyPEM(t)},
+	}
+
+	ks := keystore.New()
+	if err := ks.LoadKeys(fsys); err != nil {
+		t.Fatalf("Should be able to load the keys: %s", err)
+	}
+
+	jwks, err := ks.JWKS()
+	if err != nil {
+		t.Fatalf("Should be able to build the key set: %s", err)
+	}
+
+	exp := map[string]string{"ec": "ES384", "ed": "EdDSA", "rsa": "RS256"}
+	for _, jwk := range jwks.Keys {
+		if exp[jwk.Kid] != jwk.Alg {
+			t.Fatalf("Should use the algorithm of the key type: %+v", jwk)
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	defer srv.Close()
+
+	remote := keystore.NewRemote(srv.URL, srv.Client())
+
+	for kid, alg := range exp {
+		want, _ := ks.PublicKey(kid)
+		got, err := remote.PublicKey(kid)
+		if err != nil || got != want {
+			t.Fatalf("Should get back the same public key for kid[%s]: %v", kid, err)
+		}
+
+		key, gotAlg, err := ks.VerifyingKey(kid)
+		if err != nil || gotAlg != alg {
+			t.Fatalf("Should get the parsed public key for kid[%s]: alg[%s] err[%v]", kid, gotAlg, err)
+		}
+
+		signer, gotAlg, err := ks.SigningKey(kid)
+		if err != nil || gotAlg != alg || !signer.(crypto.Signer).Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(key) {
+			t.Fatalf("Should get the parsed private key for kid[%s]: alg[%s] err[%v]", kid, gotAlg, err)
+		}
+
+		remoteKey, gotAlg, err := remote.VerifyingKey(kid)
+		if err != nil || gotAlg != alg || !remoteKey.(interface{ Equal(crypto.PublicKey) bool }).Equal(key) {
+			t.Fatalf("Should get back the same parsed public key for kid[%s]: alg[%s] err[%v]", kid, gotAlg, err)
+		}
+	}
+}
+
+func Test_Reload(t *testing.T) {
+	ks := keystore.New()
+	if err := ks.LoadKeys(fstest.MapFS{"key.pem": &fstest.MapFile{Data: newKeyPEM(t)}}); err != nil {
+		t.Fatalf("Should be able to load the keys: %s", err)
+	}
+
+	before, _, err := ks.VerifyingKey("key")
+	if err != nil {
+		t.Fatalf("Should get the parsed public key: %s", err)
+	}
+
+	fsys := fstest.MapFS{
+		"key.pem":   &fstest.MapFile{Data: newKeyPEM(t)},
+		"other.pem": &fstest.MapFile{Data: newKeyPEM(t)},
+	}
+
+	if err := ks.LoadKeys(fsys); err != nil {
+		t.Fatalf("Should be able to reload the keys: %s", err)
+	}
+
+	after, _, err := ks.VerifyingKey("key")
+	if err != nil {
+		t.Fatalf("Should get the parsed public key: %s", err)
+	}
+
+	if after.(*rsa.PublicKey).Equal(before) {
+		t.Fatal("Should replace the parsed public key when the keys are reloaded.")
+	}
+
+	if _, _, err := ks.VerifyingKey("other"); err != nil {
+		t.Fatalf("Should get the parsed public key of a new key: %s", err)
+	}
+}
+
+func Test_KeyStatus(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	fsys := fstest.MapFS{
+		"old.pem":      &fstest.MapFile{Data: newKeyPEM(t)},
+		"old.json":     &fstest.MapFile{Data: newMetadata(t, keystore.StatusVerify, time.Now().Add(-time.Hour), &future)},
+		"expired.pem":  &fstest.MapFile{Data: newKeyPEM(t)},
+		"expired.json": &fstest.MapFile{Data: newMetadata(t, keystore.StatusVerify, time.Now().Add(-2*time.Hour), &past)},
+		"plain.pem":    &fstest.MapFile{Data: newKeyPEM(t), ModTime: time.Now().Add(-3 * time.Hour)},
+		"new.pem":      &fstest.MapFile{Data: newKeyPEM(t)},
+		"new.json":     &fstest.MapFile{Data: newMetadata(t, keystore.StatusActive, time.Now(), nil)},
+	}
+
+	ks := keystore.New()
+	if err := ks.LoadKeys(fsys); err != nil {
+		t.Fatalf("Should be able to load the keys: %s", err)
+	}
+
+	if kid, err := ks.ActiveKID(); err != nil || kid != "new" {
+		t.Fatalf("Should sign with the newest active key: kid[%s] err[%v]", kid, err)
+	}
+
+	if _, err := ks.PrivateKey("old"); err == nil {
+		t.Fatal("Should not sign with a verify only key.")
+	}
+
+	if _, _, err := ks.SigningKey("old"); err == nil {
+		t.Fatal("Should not sign with a verify only key.")
+	}
+
+	if _, err := ks.PublicKey("old"); err != nil {
+		t.Fatalf("Should verify with a verify only key: %s", err)
+	}
+
+	if _, err := ks.PublicKey("expired"); err == nil {
+		t.Fatal("Should not verify with a key past its grace period.")
+	}
+
+	if _, _, err := ks.VerifyingKey("expired"); err == nil {
+		t.Fatal("Should not verify with a key past its grace period.")
+	}
+
+	if _, err := ks.PrivateKey("plain"); err != nil {
+		t.Fatalf("Should treat a key without metadata as active: %s", err)
+	}
+
+	jwks, err := ks.JWKS()
+	if err != nil {
+		t.Fatalf("Should be able to build the key set: %s", err)
+	}
+
+	if len(jwks.Keys) != 3 {
+		t.Fatalf("Should not publish retired keys: %+v", jwks.Keys)
+	}
+}
+
+func Test_Watch(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "first.pem"), newKeyPEM(t), 0600); err != nil {
+		t.Fatalf("Should be able to write the key: %s", err)
+	}
+
+	ks := keystore.New()
+	if err := ks.LoadKeys(os.DirFS(dir)); err != nil {
+		t.Fatalf("Should be able to load the keys: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go ks.Watch(ctx, os.DirFS(dir), 10*time.Millisecond, func(err error) {
+		t.Errorf("Should be able to reload the keys: %s", err)
+	})
+
+	if err := os.WriteFile(filepath.Join(dir, "second.json"), newMetadata(t, keystore.StatusActive, time.Now().Add(time.Hour), nil), 0600); err != nil {
+		t.Fatalf("Should be able to write the metadata: %s", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "second.pem"), newKeyPEM(t), 0600); err != nil {
+		t.Fatalf("Should be able to write the key: %s", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		if kid, _ := ks.ActiveKID(); kid == "second" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("Should reload the keys when the folder changes.")
+}
+
+func newMetadata(t *testing.T, status string, created time.Time, retireAt *time.Time) []byte {
+	md := keystore.Metadata{
+		Status:   status,
+		Created:  created,
+		RetireAt: retireAt,
+	}
+
+	data, err := json.Marshal(md)
+	if err != nil {
+		t.Fatalf("Should be able to marshal the metadata: %s", err)
+	}
+
+	return data
+}
+
+func newKeyPEM(t *testing.T) []byte {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Should be able to generate a key: %s", err)
+	}
+
+	block := pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	}
+
+	return pem.EncodeToMemory(&block)
+}
@@ -0,0 +1,198 @@
+package keystore
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Set of default values used by the remote key store.
+const (
+	defaultFetchTimeout   = 10 * time.Second
+	defaultRefreshBackoff = 30 * time.Second
+)
+
+// Remote represents an implementation of the KeyLookup interface for use with
+// the auth package that verifies tokens using the public keys published by
+// another service as a JSON Web Key Set. The key set is fetched on first use
+// and fetched again when a token is signed with an unknown kid, so keys
+// rotated by the other service are picked up.
+type Remote struct {
+	url    string
+	client *http.Client
+
+	// RefreshBackoff is the minimum time between two fetches, it keeps
+	// tokens with made up kids from hammering the other service.
+	RefreshBackoff time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]remoteKey
+	lastFetch time.Time
+	fetches   singleflight.Group
+}
+
+// remoteKey is a public key of the key set, kept parsed as well so tokens
+// can be verified without decoding the PEM every time.
+type remoteKey struct {
+	publicPEM string
+	publicKey crypto.PublicKey
+	alg       string
+}
+
+// NewRemote constructs a Remote key store for the key set published at the
+// specified url. A default client is used if client is nil.
+func NewRemote(url string, client *http.Client) *Remote {
+	if client == nil {
+		client = &http.Client{Timeout: defaultFetchTimeout}
+	}
+
+	return &Remote{
+		url:            url,
+		client:         client,
+		RefreshBackoff: defaultRefreshBackoff,
+		keys:           make(map[string]remoteKey),
+	}
+}
+
+// PrivateKey always fails since a remote key set only has public keys.
+func (r *Remote) PrivateKey(kid string) (string, error) {
+	return "", errors.New("remote key store has no private keys")
+}
+
+// SigningKey always fails since a remote key set only has public keys.
+func (r *Remote) SigningKey(kid string) (crypto.PrivateKey, string, error) {
+	return nil, "", errors.New("remote key store has no private keys")
+}
+
+// ActiveKID always fails since a remote key set can't sign tokens.
+func (r *Remote) ActiveKID() (string, error) {
+	return "", errors.New("remote key store has no private keys")
+}
+
+// PublicKey searches the key set for a given kid and returns the public key.
+// The key set is fetched again if the kid is unknown.
+func (r *Remote) PublicKey(kid string) (string, error) {
+	key, err := r.lookup(kid)
+	if err != nil {
+		return "", err
+	}
+
+	return key.publicPEM, nil
+}
+
+// VerifyingKey searches the key set for a given kid and returns the parsed
+// public key along with the algorithm of the signatures it verifies. The key
+// set is fetched again if the kid is unknown.
+func (r *Remote) VerifyingKey(kid string) (crypto.PublicKey, string, error) {
+	key, err := r.lookup(kid)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key.publicKey, key.alg, nil
+}
+
+// lookup searches the key set for a given kid, fetching it again if the kid
+// is unknown. Known kids are served while a fetch is in flight, and callers
+// missing a kid at the same time share a single fetch.
+func (r *Remote) lookup(kid string) (remoteKey, error) {
+	if key, found := r.key(kid); found {
+		return key, nil
+	}
+
+	if _, err, _ := r.fetches.Do("fetch", func() (any, error) { return nil, r.refresh() }); err != nil {
+		return remoteKey{}, err
+	}
+
+	key, found := r.key(kid)
+	if !found {
+		return remoteKey{}, errors.New("kid lookup failed")
+	}
+
+	return key, nil
+}
+
+// key returns the cached key for the specified kid.
+func (r *Remote) key(kid string) (remoteKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, found := r.keys[kid]
+	return key, found
+}
+
+// refresh replaces the cached keys with the keys currently published, unless
+// the key set was fetched within the refresh backoff.
+func (r *Remote) refresh() error {
+	r.mu.Lock()
+	if !r.lastFetch.IsZero() && time.Since(r.lastFetch) < r.RefreshBackoff {
+		r.mu.Unlock()
+		return nil
+	}
+	r.lastFetch = time.Now()
+	r.mu.Unlock()
+
+	keys, err := r.fetch()
+	if err != nil {
+		return fmt.Errorf("fetching key set: %w", err)
+	}
+
+	r.mu.Lock()
+	r.keys = keys
+	r.mu.Unlock()
+
+	return nil
+}
+
+// fetch retrieves and parses the keys currently published.
+func (r *Remote) fetch() (map[string]remoteKey, error) {
+	resp, err := r.client.Get(r.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	// Limit the document to 1 megabyte, a key set is a few kilobytes.
+	var jwks JWKS
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1024*1024)).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("decoding key set: %w", err)
+	}
+
+	keys := make(map[string]remoteKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		// Keys that aren't meant for verifying signatures, or of a type
+		// this store can't use, are skipped.
+		if (jwk.Use != "" && jwk.Use != "sig") || (jwk.Kty != "RSA" && jwk.Kty != "EC" && jwk.Kty != "OKP") {
+			continue
+		}
+
+		pem, err := jwk.toPublicPEM()
+		if err != nil {
+			return nil, fmt.Errorf("kid[%s]: %w", jwk.Kid, err)
+		}
+
+		publicKey, alg, err := parsePublicKey(pem)
+		if err != nil {
+			return nil, fmt.Errorf("kid[%s]: %w", jwk.Kid, err)
+		}
+
+		keys[jwk.Kid] = remoteKey{
+			publicPEM: pem,
+			publicKey: publicKey,
+			alg:       alg,
+		}
+	}
+
+	return keys, nil
+}
@@ -3,6 +3,7 @@ package web
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"syscall"
@@ -45,6 +46,12 @@ func NewApp(shutdown chan os.Signal, tracer trace.Tracer, mw ...gin.HandlerFunc)
 	// https://w3c.github.io/trace-context/
 
 	mux := gin.New()
+
+	// Gin trusts every proxy by default, which lets any client pick the IP
+	// address it's seen with through the X-Forwarded-For header. No proxy is
+	// trusted until the application says which ones are in front of it.
+	mux.SetTrustedProxies(nil)
+
 	for _, v := range mw {
 		mux.Use(v)
 	}
@@ -57,6 +64,16 @@ func NewApp(shutdown chan os.Signal, tracer trace.Tracer, mw ...gin.HandlerFunc)
 	}
 }
 
+// TrustProxies sets the proxies, as IP addresses or CIDR ranges, whose
+// forwarding headers are used to find the IP address of the client.
+func (a *App) TrustProxies(proxies []string) error {
+	if err := a.Mux.SetTrustedProxies(proxies); err != nil {
+		return fmt.Errorf("settrustedproxies: %w", err)
+	}
+
+	return nil
+}
+
 // SignalShutdown is used to gracefully shut down the app when an integrity
 // issue is identified.
 func (a *App) SignalShutdown() {
@@ -150,6 +167,23 @@ func (a *App) Handle(method string, group *gin.RouterGroup, path string, handler
 	a.methodHandler(method, group, path, h)
 }
 
+// HandleCustomMethod sets a handler function for a custom method of a
+// resource, like POST /v1/products:batch. The router can't escape the colon,
+// so the custom method is matched as a parameter holding the rest of the path
+// segment and any other value of it isn't found.
+func (a *App) HandleCustomMethod(method string, group *gin.RouterGroup, path string, custom string, handler GinHandler) {
+	const param = "custom_method"
+
+	a.Handle(method, group, path+":"+param, func(c *gin.Context) error {
+		if c.Param(param) != ":"+custom {
+			c.JSON(http.StatusNotFound, gin.H{"error": http.StatusText(http.StatusNotFound)})
+			return nil
+		}
+
+		return handler(c)
+	})
+}
+
 func (a *App) Trace(c *gin.Context) {
 	span := a.startSpan(c)
 	defer span.End()
@@ -87,7 +87,8 @@ func (w *Worker) Shutdown(ctx context.Context) error {
 }
 
 // Start lookups a job by key and launches a goroutine to perform the work. A
-// work key is returned so the caller can cancel work early.
+// work key is returned so the caller can cancel work early. The work gets the
+// values of the context, but only its deadline and not its cancellation.
 func (w *Worker) Start(ctx context.Context, jobFn JobFn) (string, error) {
 
 	// We need to block here waiting to capture a semaphore, timeout or shutdown.
@@ -109,8 +110,9 @@ func (w *Worker) Start(ctx context.Context, jobFn JobFn) (string, error) {
 		deadline = time.Now().Add(time.Second)
 	}
 
-	// Create a cancel function and keep it for stop/shutdown purposes.
-	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	// Create a cancel function and keep it for stop/shutdown purposes. The
+	// work isn't cancelled with the caller's context but keeps its values.
+	ctx, cancel := context.WithDeadline(context.WithoutCancel(ctx), deadline)
 
 	// Register this new G as running.
 	w.trackWork(workKey, cancel)
@@ -149,3 +149,49 @@ func Test_StopWorker(t *testing.T) {
 		t.Fatalf("Should be able to shutdown work cleanly : %s", err)
 	}
 }
+
+func Test_ContextWorker(t *testing.T) {
+	type ctxKey int
+
+	// Define a work function that reports the value it was started with once
+	// the caller's context is cancelled.
+	result := make(chan any, 1)
+	release := make(chan struct{})
+	work := func(ctx context.Context) {
+		<-release
+		if ctx.Err() != nil {
+			result <- ctx.Err()
+			return
+		}
+		result <- ctx.Value(ctxKey(1))
+	}
+
+	w, err := worker.New(1)
+	if err != nil {
+		t.Fatalf("Should be able to create a worker with max 1 : %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey(1), "value"), 10*time.Second)
+	if _, err := w.Start(ctx, work); err != nil {
+		t.Fatalf("Should be able to execute work : %s", err)
+	}
+
+	// The caller is done with its context as soon as the work is started.
+	cancel()
+	close(release)
+
+	select {
+	case got := <-result:
+		if got != "value" {
+			t.Errorf("Exp: %v", "value")
+			t.Errorf("Got: %v", got)
+			t.Fatal("Should run the work with the values of the context, past its cancellation")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Should run the work")
+	}
+
+	if err := w.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Should be able to shutdown work cleanly : %s", err)
+	}
+}
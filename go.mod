@@ -26,6 +26,7 @@ require (
 	go.opentelemetry.io/otel/trace v1.23.1
 	go.uber.org/fx v1.20.1
 	golang.org/x/crypto v0.21.0
+	golang.org/x/sync v0.6.0
 )
 
 require (
@@ -95,7 +96,6 @@ require (
 	golang.org/x/arch v0.7.0 // indirect
 	golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
 	golang.org/x/net v0.22.0 // indirect
-	golang.org/x/sync v0.6.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20240221002015-b0ce06bbee7c // indirect